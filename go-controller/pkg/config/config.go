@@ -14,6 +14,7 @@ import (
 	"strings"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	knet "k8s.io/apimachinery/pkg/util/net"
 
 	"github.com/urfave/cli/v2"
 	gcfg "gopkg.in/gcfg.v1"
@@ -91,13 +92,21 @@ var (
 
 	// Kubernetes holds Kubernetes-related parsed config file parameters and command-line overrides
 	Kubernetes = KubernetesConfig{
-		APIServer:          DefaultAPIServer,
-		RawServiceCIDRs:    "172.16.1.0/24",
-		OVNConfigNamespace: "ovn-kubernetes",
+		APIServer:             DefaultAPIServer,
+		RawServiceCIDRs:       "172.16.1.0/24",
+		RawNodePortRange:      "30000-32767",
+		NodePortRange:         knet.PortRange{Base: 30000, Size: 2768},
+		OVNConfigNamespace:    "ovn-kubernetes",
+		ServiceSyncWorkers:    8,
+		ServiceResyncInterval: 600,
 	}
 
 	// OVNKubernetesFeatureConfig holds OVN-Kubernetes feature enhancement config file parameters and command-line overrides
-	OVNKubernetesFeature OVNKubernetesFeatureConfig
+	OVNKubernetesFeature = OVNKubernetesFeatureConfig{
+		RejectACLPriority: 1000,
+		RejectACLSeverity: "info",
+		RejectACLMeter:    "acl-logging",
+	}
 
 	// OvnNorth holds northbound OVN database client and server authentication and location details
 	OvnNorth OvnAuthConfig
@@ -221,26 +230,42 @@ type CNIConfig struct {
 
 // KubernetesConfig holds Kubernetes-related parsed config file parameters and command-line overrides
 type KubernetesConfig struct {
-	Kubeconfig            string `gcfg:"kubeconfig"`
-	CACert                string `gcfg:"cacert"`
-	APIServer             string `gcfg:"apiserver"`
-	Token                 string `gcfg:"token"`
-	CompatServiceCIDR     string `gcfg:"service-cidr"`
-	RawServiceCIDRs       string `gcfg:"service-cidrs"`
-	ServiceCIDRs          []*net.IPNet
-	OVNConfigNamespace    string `gcfg:"ovn-config-namespace"`
-	MetricsBindAddress    string `gcfg:"metrics-bind-address"`
-	OVNMetricsBindAddress string `gcfg:"ovn-metrics-bind-address"`
-	MetricsEnablePprof    bool   `gcfg:"metrics-enable-pprof"`
-	OVNEmptyLbEvents      bool   `gcfg:"ovn-empty-lb-events"`
-	PodIP                 string `gcfg:"pod-ip"` // UNUSED
-	RawNoHostSubnetNodes  string `gcfg:"no-hostsubnet-nodes"`
-	NoHostSubnetNodes     *metav1.LabelSelector
+	Kubeconfig               string `gcfg:"kubeconfig"`
+	CACert                   string `gcfg:"cacert"`
+	APIServer                string `gcfg:"apiserver"`
+	Token                    string `gcfg:"token"`
+	CompatServiceCIDR        string `gcfg:"service-cidr"`
+	RawServiceCIDRs          string `gcfg:"service-cidrs"`
+	ServiceCIDRs             []*net.IPNet
+	RawNodePortRange         string `gcfg:"service-node-port-range"`
+	NodePortRange            knet.PortRange
+	OVNConfigNamespace       string `gcfg:"ovn-config-namespace"`
+	MetricsBindAddress       string `gcfg:"metrics-bind-address"`
+	OVNMetricsBindAddress    string `gcfg:"ovn-metrics-bind-address"`
+	MetricsEnablePprof       bool   `gcfg:"metrics-enable-pprof"`
+	OVNEmptyLbEvents         bool   `gcfg:"ovn-empty-lb-events"`
+	DisableServiceRejectACLs bool   `gcfg:"disable-service-reject-acls"`
+	ServiceDryRun            bool   `gcfg:"service-dry-run"`
+	ServiceSyncWorkers       int    `gcfg:"service-sync-workers"`
+	ServiceResyncInterval    int    `gcfg:"service-resync-interval"`
+	RawLBSelectionFields     string `gcfg:"lb-selection-fields"`
+	LBSelectionFields        []string
+	PodIP                    string `gcfg:"pod-ip"` // UNUSED
+	RawNoHostSubnetNodes     string `gcfg:"no-hostsubnet-nodes"`
+	NoHostSubnetNodes        *metav1.LabelSelector
 }
 
 // OVNKubernetesFeatureConfig holds OVN-Kubernetes feature enhancement config file parameters and command-line overrides
 type OVNKubernetesFeatureConfig struct {
 	EnableEgressIP bool `gcfg:"enable-egress-ip"`
+	// RejectACLPriority is the priority of the ACLs rejecting traffic to services without endpoints
+	RejectACLPriority int `gcfg:"reject-acl-priority"`
+	// RejectACLSeverity is the log severity of the reject ACLs of services in namespaces that
+	// do not set their own ACL logging severity
+	RejectACLSeverity string `gcfg:"reject-acl-severity"`
+	// RejectACLMeter is the meter rate-limiting the logging of the reject ACLs of services in
+	// namespaces that do not set their own ACL logging rate, empty for no meter
+	RejectACLMeter string `gcfg:"reject-acl-meter"`
 }
 
 // GatewayMode holds the node gateway mode
@@ -273,6 +298,9 @@ type GatewayConfig struct {
 	V4JoinSubnet string `gcfg:"v4-join-subnet"`
 	// V6JoinSubnet to be used in the cluster
 	V6JoinSubnet string `gcfg:"v6-join-subnet"`
+	// LBNeighborResponder tells the gateway routers which VIPs of their load balancers to answer
+	// ARP and neighbor solicitations for: "none", "reachable" or "all". Empty keeps the OVN default.
+	LBNeighborResponder string `gcfg:"lb-neighbor-responder"`
 }
 
 // OvnAuthConfig holds client authentication and location details for
@@ -477,7 +505,7 @@ func overrideFields(dst, src, defaults interface{}) error {
 
 var cliConfig config
 
-//CommonFlags capture general options.
+// CommonFlags capture general options.
 var CommonFlags = []cli.Flag{
 	// Mode flags
 	&cli.StringFlag{
@@ -674,6 +702,24 @@ var OVNK8sFeatureFlags = []cli.Flag{
 		Destination: &cliConfig.OVNKubernetesFeature.EnableEgressIP,
 		Value:       OVNKubernetesFeature.EnableEgressIP,
 	},
+	&cli.IntFlag{
+		Name:        "reject-acl-priority",
+		Usage:       "The priority of the ACLs rejecting traffic to services without endpoints, between 0 and 32767 (default 1000)",
+		Destination: &cliConfig.OVNKubernetesFeature.RejectACLPriority,
+		Value:       OVNKubernetesFeature.RejectACLPriority,
+	},
+	&cli.StringFlag{
+		Name:        "reject-acl-severity",
+		Usage:       "The log severity of the ACLs rejecting traffic to services without endpoints: alert, warning, notice, info or debug (default info)",
+		Destination: &cliConfig.OVNKubernetesFeature.RejectACLSeverity,
+		Value:       OVNKubernetesFeature.RejectACLSeverity,
+	},
+	&cli.StringFlag{
+		Name:        "reject-acl-meter",
+		Usage:       "The meter rate-limiting the logging of the ACLs rejecting traffic to services without endpoints, or empty for no meter (default acl-logging)",
+		Destination: &cliConfig.OVNKubernetesFeature.RejectACLMeter,
+		Value:       OVNKubernetesFeature.RejectACLMeter,
+	},
 }
 
 // K8sFlags capture Kubernetes-related options
@@ -697,6 +743,14 @@ var K8sFlags = []cli.Flag{
 		Destination: &cliConfig.Kubernetes.RawServiceCIDRs,
 		Value:       Kubernetes.RawServiceCIDRs,
 	},
+	&cli.StringFlag{
+		Name: "service-node-port-range",
+		Usage: "The port range reserved for services with NodePort visibility. This should be the " +
+			"same as the value provided for kube-apiserver \"--service-node-port-range\" option. " +
+			"(default: 30000-32767)",
+		Destination: &cliConfig.Kubernetes.RawNodePortRange,
+		Value:       Kubernetes.RawNodePortRange,
+	},
 	&cli.StringFlag{
 		Name:        "k8s-kubeconfig",
 		Usage:       "absolute path to the Kubernetes kubeconfig file (not required if the --k8s-apiserver, --k8s-ca-cert, and --k8s-token are given)",
@@ -747,6 +801,38 @@ var K8sFlags = []cli.Flag{
 			"will spin up pods for the load balancer to send traffic to.",
 		Destination: &cliConfig.Kubernetes.OVNEmptyLbEvents,
 	},
+	&cli.BoolFlag{
+		Name: "disable-service-reject-acls",
+		Usage: "If set, then services without endpoints do not get reject ACLs, so connections " +
+			"to them are not refused by OVN. Reject ACLs created before are removed at startup.",
+		Destination: &cliConfig.Kubernetes.DisableServiceRejectACLs,
+	},
+	&cli.BoolFlag{
+		Name: "service-dry-run",
+		Usage: "If set, then the changes to the load balancers and reject ACLs of services are " +
+			"logged instead of made, while the OVN northbound database is still read.",
+		Destination: &cliConfig.Kubernetes.ServiceDryRun,
+	},
+	&cli.IntFlag{
+		Name:        "service-sync-workers",
+		Usage:       "The number of load balancer cleanups run in parallel while syncing services at startup (default 8)",
+		Destination: &cliConfig.Kubernetes.ServiceSyncWorkers,
+		Value:       Kubernetes.ServiceSyncWorkers,
+	},
+	&cli.IntFlag{
+		Name: "service-resync-interval",
+		Usage: "The number of seconds between full syncs of the services after the one at startup, " +
+			"jittered by up to 10%, or 0 to only sync them at startup (default 600)",
+		Destination: &cliConfig.Kubernetes.ServiceResyncInterval,
+		Value:       Kubernetes.ServiceResyncInterval,
+	},
+	&cli.StringFlag{
+		Name: "lb-selection-fields",
+		Usage: "A comma separated list of the packet fields the cluster and gateway router load " +
+			"balancers hash on to select the endpoint of a service, like ip_src,ip_dst, among " +
+			"eth_src, eth_dst, ip_src, ip_dst, tp_src and tp_dst. Leave empty to hash on the 5-tuple.",
+		Destination: &cliConfig.Kubernetes.RawLBSelectionFields,
+	},
 	&cli.StringFlag{
 		Name:  "pod-ip",
 		Usage: "UNUSED",
@@ -795,7 +881,7 @@ var OvnNBFlags = []cli.Flag{
 	},
 }
 
-//OvnSBFlags capture OVN southbound database options
+// OvnSBFlags capture OVN southbound database options
 var OvnSBFlags = []cli.Flag{
 	&cli.StringFlag{
 		Name: "sb-address",
@@ -832,7 +918,7 @@ var OvnSBFlags = []cli.Flag{
 	},
 }
 
-//OVNGatewayFlags capture L3 Gateway related flags
+// OVNGatewayFlags capture L3 Gateway related flags
 var OVNGatewayFlags = []cli.Flag{
 	&cli.StringFlag{
 		Name: "gateway-mode",
@@ -884,6 +970,12 @@ var OVNGatewayFlags = []cli.Flag{
 		Destination: &cliConfig.Gateway.V6JoinSubnet,
 		Value:       Gateway.V6JoinSubnet,
 	},
+	&cli.StringFlag{
+		Name: "gateway-lb-neighbor-responder",
+		Usage: "The VIPs of the load balancers of the gateway routers to answer ARP and neighbor " +
+			"solicitations for: none, reachable or all. Leave empty to keep the OVN default.",
+		Destination: &cliConfig.Gateway.LBNeighborResponder,
+	},
 	// Deprecated CLI options
 	&cli.BoolFlag{
 		Name:        "init-gateways",
@@ -1125,6 +1217,38 @@ func buildKubernetesConfig(exec kexec.Interface, cli, file *config, saPath strin
 		return fmt.Errorf("kubernetes service-cidrs must contain either a single CIDR or else an IPv4/IPv6 pair")
 	}
 
+	var nodePortRange knet.PortRange
+	if err := nodePortRange.Set(Kubernetes.RawNodePortRange); err != nil || nodePortRange.Size == 0 {
+		return fmt.Errorf("kubernetes service-node-port-range %q invalid: expect a range like 30000-32767",
+			Kubernetes.RawNodePortRange)
+	}
+	Kubernetes.NodePortRange = nodePortRange
+
+	if Kubernetes.ServiceResyncInterval < 0 {
+		return fmt.Errorf("kubernetes service-resync-interval %d invalid: must not be negative",
+			Kubernetes.ServiceResyncInterval)
+	}
+
+	validSelectionFields := []string{"eth_src", "eth_dst", "ip_src", "ip_dst", "tp_src", "tp_dst"}
+	Kubernetes.LBSelectionFields = nil
+	for _, field := range strings.Split(Kubernetes.RawLBSelectionFields, ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+		var found bool
+		for _, valid := range validSelectionFields {
+			if field == valid {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("kubernetes lb-selection-fields %q invalid: expect fields among %s",
+				Kubernetes.RawLBSelectionFields, strings.Join(validSelectionFields, ","))
+		}
+		Kubernetes.LBSelectionFields = append(Kubernetes.LBSelectionFields, field)
+	}
+
 	if Kubernetes.RawNoHostSubnetNodes != "" {
 		if nodeSelector, err := metav1.ParseToLabelSelector(Kubernetes.RawNoHostSubnetNodes); err == nil {
 			Kubernetes.NoHostSubnetNodes = nodeSelector
@@ -1184,6 +1308,13 @@ func buildGatewayConfig(ctx *cli.Context, cli, file *config, allSubnets *configS
 		return fmt.Errorf("gateway VLAN ID option: %d is supported only in shared gateway mode", Gateway.VLANID)
 	}
 
+	switch Gateway.LBNeighborResponder {
+	case "", "none", "reachable", "all":
+	default:
+		return fmt.Errorf("invalid gateway load balancer neighbor responder %q: expect one of none,reachable,all",
+			Gateway.LBNeighborResponder)
+	}
+
 	// Validate v4 and v6 join subnets
 	v4IP, v4JoinCIDR, err := net.ParseCIDR(Gateway.V4JoinSubnet)
 	if err != nil || utilnet.IsIPv6(v4IP) {
@@ -1210,6 +1341,23 @@ func buildOVNKubernetesFeatureConfig(ctx *cli.Context, cli, file *config) error
 	if err := overrideFields(&OVNKubernetesFeature, &cli.OVNKubernetesFeature, &savedOVNKubernetesFeature); err != nil {
 		return err
 	}
+
+	if OVNKubernetesFeature.RejectACLPriority < 0 || OVNKubernetesFeature.RejectACLPriority > 32767 {
+		return fmt.Errorf("invalid reject ACL priority %d: expect a value between 0 and 32767",
+			OVNKubernetesFeature.RejectACLPriority)
+	}
+	validSeverities := []string{"alert", "warning", "notice", "info", "debug"}
+	var found bool
+	for _, severity := range validSeverities {
+		if OVNKubernetesFeature.RejectACLSeverity == severity {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("invalid reject ACL severity %q: expect one of %s",
+			OVNKubernetesFeature.RejectACLSeverity, strings.Join(validSeverities, ","))
+	}
 	return nil
 }
 
@@ -143,6 +143,7 @@ token=TG9yZW0gaXBzdW0gZ
 cacert=/path/to/kubeca.crt
 service-cidrs=172.18.0.0/24
 no-hostsubnet-nodes=label=another-test-label
+lb-selection-fields=ip_src,ip_dst
 
 [logging]
 loglevel=5
@@ -179,6 +180,7 @@ vlan-id=10
 nodeport=false
 v4-join-subnet=100.65.0.0/16
 v6-join-subnet=fd90::/64
+lb-neighbor-responder=reachable
 
 [hybridoverlay]
 enabled=true
@@ -250,6 +252,15 @@ var _ = Describe("Config Operations", func() {
 			gomega.Expect(Kubernetes.APIServer).To(gomega.Equal(DefaultAPIServer))
 			gomega.Expect(Kubernetes.RawServiceCIDRs).To(gomega.Equal("172.16.1.0/24"))
 			gomega.Expect(Kubernetes.RawNoHostSubnetNodes).To(gomega.Equal(""))
+			gomega.Expect(Kubernetes.NodePortRange.String()).To(gomega.Equal("30000-32767"))
+			gomega.Expect(Kubernetes.ServiceSyncWorkers).To(gomega.Equal(8))
+			gomega.Expect(Kubernetes.ServiceResyncInterval).To(gomega.Equal(600))
+			gomega.Expect(Kubernetes.LBSelectionFields).To(gomega.BeEmpty())
+			gomega.Expect(Kubernetes.DisableServiceRejectACLs).To(gomega.BeFalse())
+			gomega.Expect(Kubernetes.ServiceDryRun).To(gomega.BeFalse())
+			gomega.Expect(OVNKubernetesFeature.RejectACLPriority).To(gomega.Equal(1000))
+			gomega.Expect(OVNKubernetesFeature.RejectACLSeverity).To(gomega.Equal("info"))
+			gomega.Expect(OVNKubernetesFeature.RejectACLMeter).To(gomega.Equal("acl-logging"))
 			gomega.Expect(Default.ClusterSubnets).To(gomega.Equal([]CIDRNetworkEntry{
 				{ovntest.MustParseIPNet("10.128.0.0/14"), 23},
 			}))
@@ -493,6 +504,7 @@ var _ = Describe("Config Operations", func() {
 			gomega.Expect(Kubernetes.Token).To(gomega.Equal("TG9yZW0gaXBzdW0gZ"))
 			gomega.Expect(Kubernetes.APIServer).To(gomega.Equal("https://1.2.3.4:6443"))
 			gomega.Expect(Kubernetes.RawServiceCIDRs).To(gomega.Equal("172.18.0.0/24"))
+			gomega.Expect(Kubernetes.LBSelectionFields).To(gomega.Equal([]string{"ip_src", "ip_dst"}))
 			gomega.Expect(Default.ClusterSubnets).To(gomega.Equal([]CIDRNetworkEntry{
 				{ovntest.MustParseIPNet("10.132.0.0/14"), 23},
 			}))
@@ -518,6 +530,7 @@ var _ = Describe("Config Operations", func() {
 			gomega.Expect(Gateway.NodeportEnable).To(gomega.BeFalse())
 			gomega.Expect(Gateway.V4JoinSubnet).To(gomega.Equal("100.65.0.0/16"))
 			gomega.Expect(Gateway.V6JoinSubnet).To(gomega.Equal("fd90::/64"))
+			gomega.Expect(Gateway.LBNeighborResponder).To(gomega.Equal("reachable"))
 
 			gomega.Expect(HybridOverlay.Enabled).To(gomega.BeTrue())
 			gomega.Expect(HybridOverlay.ClusterSubnets).To(gomega.Equal([]CIDRNetworkEntry{
@@ -561,6 +574,7 @@ var _ = Describe("Config Operations", func() {
 			gomega.Expect(Kubernetes.APIServer).To(gomega.Equal("https://4.4.3.2:8080"))
 			gomega.Expect(Kubernetes.RawServiceCIDRs).To(gomega.Equal("172.15.0.0/24"))
 			gomega.Expect(Kubernetes.RawNoHostSubnetNodes).To(gomega.Equal("test=pass"))
+			gomega.Expect(Kubernetes.LBSelectionFields).To(gomega.Equal([]string{"ip_src", "ip_dst", "tp_dst"}))
 			gomega.Expect(Default.ClusterSubnets).To(gomega.Equal([]CIDRNetworkEntry{
 				{ovntest.MustParseIPNet("10.130.0.0/15"), 24},
 			}))
@@ -583,6 +597,7 @@ var _ = Describe("Config Operations", func() {
 			gomega.Expect(Gateway.NodeportEnable).To(gomega.BeTrue())
 			gomega.Expect(Gateway.V4JoinSubnet).To(gomega.Equal("100.63.0.0/16"))
 			gomega.Expect(Gateway.V6JoinSubnet).To(gomega.Equal("fd99::/48"))
+			gomega.Expect(Gateway.LBNeighborResponder).To(gomega.Equal("all"))
 
 			gomega.Expect(HybridOverlay.Enabled).To(gomega.BeTrue())
 			gomega.Expect(HybridOverlay.ClusterSubnets).To(gomega.Equal([]CIDRNetworkEntry{
@@ -608,6 +623,7 @@ var _ = Describe("Config Operations", func() {
 			"-k8s-service-cidrs=172.15.0.0/24",
 			"-nb-address=ssl:6.5.4.3:6651",
 			"-no-hostsubnet-nodes=test=pass",
+			"-lb-selection-fields=ip_src, ip_dst, tp_dst",
 			"-nb-client-privkey=/client/privkey",
 			"-nb-client-cert=/client/cert",
 			"-nb-client-cacert=/client/cacert",
@@ -621,6 +637,7 @@ var _ = Describe("Config Operations", func() {
 			"-nodeport",
 			"-gateway-v4-join-subnet=100.63.0.0/16",
 			"-gateway-v6-join-subnet=fd99::/48",
+			"-gateway-lb-neighbor-responder=all",
 			"-enable-hybrid-overlay",
 			"-hybrid-overlay-cluster-subnets=11.132.0.0/14/23",
 		}
@@ -804,6 +821,80 @@ mode=shared
 		gomega.Expect(err).NotTo(gomega.HaveOccurred())
 	})
 
+	It("parses the service node port range", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(Kubernetes.NodePortRange.Contains(20000)).To(gomega.BeTrue())
+			gomega.Expect(Kubernetes.NodePortRange.Contains(20999)).To(gomega.BeTrue())
+			gomega.Expect(Kubernetes.NodePortRange.Contains(30000)).To(gomega.BeFalse())
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-service-node-port-range=20000-20999",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+
+	It("returns an error when the service node port range is invalid", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).To(gomega.MatchError("kubernetes service-node-port-range \"32767-30000\" invalid: expect a range like 30000-32767"))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-service-node-port-range=32767-30000",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+
+	It("returns an error when the load balancer selection fields are invalid", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).To(gomega.MatchError("kubernetes lb-selection-fields \"ip_src,port\" invalid: " +
+				"expect fields among eth_src,eth_dst,ip_src,ip_dst,tp_src,tp_dst"))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-lb-selection-fields=ip_src,port",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+
+	It("returns an error when the reject ACL priority is out of range", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).To(gomega.MatchError("invalid reject ACL priority 40000: expect a value between 0 and 32767"))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-reject-acl-priority=40000",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+
+	It("returns an error when the reject ACL severity is invalid", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).To(gomega.MatchError("invalid reject ACL severity \"verbose\": expect one of alert,warning,notice,info,debug"))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-reject-acl-severity=verbose",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+
 	It("returns an error when the vlan-id is specified for mode other than shared gateway mode", func() {
 		app.Action = func(ctx *cli.Context) error {
 			_, err := InitConfig(ctx, kexec.New(), nil)
@@ -818,6 +909,20 @@ mode=shared
 		err := app.Run(cliArgs)
 		gomega.Expect(err).NotTo(gomega.HaveOccurred())
 	})
+	It("returns an error when the gateway load balancer neighbor responder is invalid", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).To(gomega.MatchError("invalid gateway load balancer neighbor responder \"some\": " +
+				"expect one of none,reachable,all"))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-gateway-lb-neighbor-responder=some",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
 	It("returns an error when the v4 join subnet specified is invalid", func() {
 		app.Action = func(ctx *cli.Context) error {
 			_, err := InitConfig(ctx, kexec.New(), nil)
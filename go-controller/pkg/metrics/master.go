@@ -46,6 +46,28 @@ var metricOvnCliLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
 	[]string{"command"},
 )
 
+// metricNbctlCommandDuration is the latency of ovn-nbctl commands by verb (find, get,
+// set, remove, create or other) and by whether they succeeded
+var metricNbctlCommandDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Name:      "nbctl_command_duration_seconds",
+	Help:      "The latency of ovn-nbctl commands by verb and status",
+	Buckets:   prometheus.ExponentialBuckets(.001, 2, 15)},
+	// labels
+	[]string{"verb", "ok"},
+)
+
+// MetricNbctlOpDuration is the latency of the ovn-nbctl calls of the primitive load balancer
+// operations, by operation
+var MetricNbctlOpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Name:      "nbctl_op_duration_seconds",
+	Help:      "The latency of the ovn-nbctl calls of the load balancer operations by operation",
+	Buckets:   prometheus.ExponentialBuckets(.001, 2, 15)},
+	// labels
+	[]string{"operation"},
+)
+
 // MetricResourceUpdateCount is the number of times a particular resource's UpdateFunc has been called.
 var MetricResourceUpdateCount = prometheus.NewCounterVec(prometheus.CounterOpts{
 	Namespace: MetricOvnkubeNamespace,
@@ -100,6 +122,38 @@ var MetricSyncServiceLatency = prometheus.NewHistogramVec(prometheus.HistogramOp
 	[]string{"name"},
 )
 
+// MetricServiceSyncDuration is the time taken by the full sync of the services with OVN
+// done when the services are first watched.
+var MetricServiceSyncDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Name:      "service_sync_duration_seconds",
+	Help:      "The duration of the full sync of the services with the OVN load balancers and reject ACLs",
+	Buckets:   prometheus.ExponentialBuckets(.1, 2, 15)},
+)
+
+// Phases of the full sync of the services whose failures are counted in MetricServiceSyncErrors
+const (
+	ServiceSyncPhaseRejectACL    = "reject_acl"
+	ServiceSyncPhaseClusterVIP   = "cluster_vip"
+	ServiceSyncPhaseGatewayVIP   = "gateway_vip"
+	ServiceSyncPhaseVIPReconcile = "vip_reconcile"
+)
+
+// MetricServiceSyncErrors is the number of full syncs of the services that failed, by phase.
+var MetricServiceSyncErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Name:      "service_sync_errors_total",
+	Help:      "The number of full syncs of the services that failed in a given phase"},
+	[]string{"phase"},
+)
+
+// MetricRejectACLCount is the number of reject ACLs found in OVN by the last full sync of the services.
+var MetricRejectACLCount = prometheus.NewGauge(prometheus.GaugeOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Name:      "reject_acls",
+	Help:      "The number of reject ACLs found in OVN by the last full sync of the services",
+})
+
 var MetricMasterReadyDuration = prometheus.NewGauge(prometheus.GaugeOpts{
 	Namespace: MetricOvnkubeNamespace,
 	Subsystem: MetricOvnkubeSubsystemMaster,
@@ -188,11 +242,17 @@ func RegisterMasterMetrics(nbClient, sbClient goovn.Client) {
 		prometheus.MustRegister(metricOvnCliLatency)
 		// this is to not to create circular import between metrics and util package
 		util.MetricOvnCliLatency = metricOvnCliLatency
+		prometheus.MustRegister(metricNbctlCommandDuration)
+		util.MetricNbctlCommandDuration = metricNbctlCommandDuration
+		prometheus.MustRegister(MetricNbctlOpDuration)
 		prometheus.MustRegister(MetricResourceUpdateCount)
 		prometheus.MustRegister(MetricResourceUpdateLatency)
 		prometheus.MustRegister(MetricRequeueServiceCount)
 		prometheus.MustRegister(MetricSyncServiceCount)
 		prometheus.MustRegister(MetricSyncServiceLatency)
+		prometheus.MustRegister(MetricServiceSyncDuration)
+		prometheus.MustRegister(MetricServiceSyncErrors)
+		prometheus.MustRegister(MetricRejectACLCount)
 		prometheus.MustRegister(prometheus.NewGaugeFunc(
 			prometheus.GaugeOpts{
 				Namespace: MetricOvnkubeNamespace,
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 
@@ -71,7 +72,7 @@ func RemoveACLFromNodeSwitches(switches []string, aclUUID string) error {
 	}
 	args := []string{}
 	for _, ls := range switches {
-		args = append(args, "--", "--if-exists", "remove", "logical_switch", ls, "acl", aclUUID)
+		args = append(args, "--", "--if-exists", "remove", "logical_switch", ls, "acls", aclUUID)
 	}
 	_, _, err := util.RunOVNNbctl(args...)
 	if err != nil {
@@ -100,7 +101,8 @@ func AddRejectACLToPortGroup(clusterPortGroupUUID, aclName, sourceIP string, sou
 
 	aclMatch := fmt.Sprintf("match=\"%s.dst==%s && %s && %s.dst==%d\"", l3Prefix, sourceIP,
 		strings.ToLower(string(proto)), strings.ToLower(string(proto)), sourcePort)
-	cmd := []string{"--id=@reject-acl", "create", "acl", "direction=" + types.DirectionFromLPort, "priority=" + types.DefaultDenyPriority, aclMatch, "action=reject",
+	cmd := []string{"--id=@reject-acl", "create", "acl", "direction=" + types.DirectionFromLPort,
+		fmt.Sprintf("priority=%d", config.OVNKubernetesFeature.RejectACLPriority), aclMatch, "action=reject",
 		fmt.Sprintf("name=%s", aclName), "--", "add", "port_group", clusterPortGroupUUID, "acls", "@reject-acl"}
 	aclUUID, stderr, err := util.RunOVNNbctl(cmd...)
 	if err != nil {
@@ -118,7 +120,8 @@ func AddRejectACLToLogicalSwitch(logicalSwitch, aclName, sourceIP string, source
 
 	aclMatch := fmt.Sprintf("match=\"%s.dst==%s && %s && %s.dst==%d\"", l3Prefix, sourceIP,
 		strings.ToLower(string(proto)), strings.ToLower(string(proto)), sourcePort)
-	cmd := []string{"--id=@reject-acl", "create", "acl", "direction=" + types.DirectionFromLPort, "priority=" + types.DefaultDenyPriority, aclMatch, "action=reject",
+	cmd := []string{"--id=@reject-acl", "create", "acl", "direction=" + types.DirectionFromLPort,
+		fmt.Sprintf("priority=%d", config.OVNKubernetesFeature.RejectACLPriority), aclMatch, "action=reject",
 		fmt.Sprintf("name=%s", aclName), "--", "add", "logical_switch", logicalSwitch, "acls", "@reject-acl"}
 
 	aclUUID, stderr, err := util.RunOVNNbctl(cmd...)
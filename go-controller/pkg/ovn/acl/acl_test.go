@@ -74,7 +74,7 @@ func TestRemoveACLFromNodeSwitches(t *testing.T) {
 			switches: []string{"sw1", "sw2"},
 			aclUUID:  "a08ea426-2288-11eb-a30b-a8a1590cda29",
 			ovnCmd: ovntest.ExpectedCmd{
-				Cmd:    "ovn-nbctl --timeout=15 -- --if-exists remove logical_switch sw1 acl a08ea426-2288-11eb-a30b-a8a1590cda29 -- --if-exists remove logical_switch sw2 acl a08ea426-2288-11eb-a30b-a8a1590cda29",
+				Cmd:    "ovn-nbctl --timeout=15 -- --if-exists remove logical_switch sw1 acls a08ea426-2288-11eb-a30b-a8a1590cda29 -- --if-exists remove logical_switch sw2 acls a08ea426-2288-11eb-a30b-a8a1590cda29",
 				Output: "",
 			},
 			wantErr: false,
@@ -91,7 +91,7 @@ func TestRemoveACLFromNodeSwitches(t *testing.T) {
 			switches: []string{"sw1", "sw2"},
 			aclUUID:  "a08ea426-2288-11eb-a30b-a8a1590cda29",
 			ovnCmd: ovntest.ExpectedCmd{
-				Cmd:    "ovn-nbctl --timeout=15 -- --if-exists remove logical_switch sw1 acl a08ea426-2288-11eb-a30b-a8a1590cda29 -- --if-exists remove logical_switch sw2 acl a08ea426-2288-11eb-a30b-a8a1590cda29",
+				Cmd:    "ovn-nbctl --timeout=15 -- --if-exists remove logical_switch sw1 acls a08ea426-2288-11eb-a30b-a8a1590cda29 -- --if-exists remove logical_switch sw2 acls a08ea426-2288-11eb-a30b-a8a1590cda29",
 				Output: "",
 				Err:    fmt.Errorf("error while removing ACL: sw1, from switches"),
 			},
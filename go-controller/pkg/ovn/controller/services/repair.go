@@ -98,10 +98,13 @@ func (r *Repair) runOnce() error {
 	for _, p := range protocols {
 		for _, lb := range ovnLBCache[p] {
 			vips, err := loadbalancer.GetLoadBalancerVIPs(lb)
-			if err != nil {
+			if err != nil && vips == nil {
 				klog.V(4).Infof("Failed to get vips for %s load balancer %s, err: %v", p, lb, err)
 				continue
 			}
+			if err != nil {
+				klog.Errorf("Ignoring the malformed vips of %s load balancer %s, err: %v", p, lb, err)
+			}
 			for vip := range vips {
 				key := virtualIPKey(vip, p)
 				// Virtual IP and protocol doesn't belong to a Kubernetes service
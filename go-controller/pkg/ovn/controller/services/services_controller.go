@@ -286,7 +286,7 @@ func (c *Controller) syncServices(key string) error {
 			vip := util.JoinHostPortInt32(ip, svcPort.Port)
 			klog.V(4).Infof("Updating service %s/%s with VIP %s %s", name, namespace, vip, svcPort.Protocol)
 			// get the endpoints associated to the vip
-			eps := getLbEndpoints(endpointSlices, svcPort, family)
+			eps := getLbEndpoints(endpointSlices, svcPort, family, service.Spec.PublishNotReadyAddresses)
 			// Reconcile OVN, update the load balancer with current endpoints
 			if c.needsOVNLBUpdate(eps.IPs, service) {
 				// If any of the lbEps contain the a host IP we add to worker/GR LB separately, and not to cluster LB
@@ -334,13 +334,15 @@ func (c *Controller) syncServices(key string) error {
 			// if there is no ACL and there are no endpoints we add a new ACL
 			// if there is an ACL and we have endpoints we have to remove the ACL
 			// if there is no ACL and we have endpoints we don´t need to do anything
-			if len(eps.IPs) == 0 && len(aclID) == 0 {
+			// if reject ACLs are disabled we only remove the ACL
+			rejects := len(eps.IPs) == 0 && !config.Kubernetes.DisableServiceRejectACLs
+			if rejects && len(aclID) == 0 {
 				klog.V(4).Infof("Service %s/%s without endpoints", name, namespace)
 				_, err = acl.AddRejectACLToPortGroup(c.clusterPortGroupUUID, rejectACLName, ip, int(svcPort.Port), svcPort.Protocol)
 				if err != nil {
 					klog.Errorf("Error trying to add ACL for Service %s/%s: %v", name, namespace, err)
 				}
-			} else if len(eps.IPs) > 0 && len(aclID) > 0 {
+			} else if !rejects && len(aclID) > 0 {
 				// remove acl
 				err = acl.RemoveACLFromPortGroup(aclID, c.clusterPortGroupUUID)
 				if err != nil {
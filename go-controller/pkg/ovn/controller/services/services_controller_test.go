@@ -244,6 +244,88 @@ func TestSyncServices(t *testing.T) {
 					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
 					Output: loadbalancerTCP,
 				},
+				{
+					Cmd:    `ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer ` + loadbalancerTCP + ` vips`,
+					Output: "",
+				},
+				{
+					Cmd:    `ovn-nbctl --timeout=15 set load_balancer ` + loadbalancerTCP + ` vips:"192.168.1.1:80"="10.0.0.2:3456"`,
+					Output: "",
+				},
+				{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+					Output: FakeGRs,
+				},
+				{
+					Cmd:    `ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:TCP_lb_gateway_router=GR_1`,
+					Output: "load_balancer_1",
+				},
+				{
+					Cmd:    `ovn-nbctl --timeout=15 --if-exists remove load_balancer load_balancer_1 vips "192.168.1.1:80"`,
+					Output: "",
+				},
+				{
+					Cmd:    `ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:TCP_lb_gateway_router=GR_2`,
+					Output: "",
+				},
+				{
+					Cmd:    `ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=a08ea426-2288-11eb-a30b-a8a1590cda29-192.168.1.1\:80`,
+					Output: "",
+				},
+			},
+		},
+		{
+			name: "create OVN LoadBalancer from Single Stack Service with only not ready endpoints published",
+			slice: &discovery.EndpointSlice{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      serviceName + "ab23",
+					Namespace: ns,
+					Labels:    map[string]string{discovery.LabelServiceName: serviceName},
+				},
+				Ports: []discovery.EndpointPort{
+					{
+						Name:     utilpointer.StringPtr("tcp-example"),
+						Protocol: protoPtr(v1.ProtocolTCP),
+						Port:     utilpointer.Int32Ptr(int32(3456)),
+					},
+				},
+				AddressType: discovery.AddressTypeIPv4,
+				Endpoints: []discovery.Endpoint{
+					{
+						Conditions: discovery.EndpointConditions{
+							Ready: utilpointer.BoolPtr(false),
+						},
+						Addresses: []string{"10.0.0.2"},
+						Topology:  map[string]string{"kubernetes.io/hostname": "node-1"},
+					},
+				},
+			},
+			service: &v1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: serviceName, Namespace: ns},
+				Spec: v1.ServiceSpec{
+					Type:       v1.ServiceTypeClusterIP,
+					ClusterIP:  "192.168.1.1",
+					ClusterIPs: []string{"192.168.1.1"},
+					Selector:   map[string]string{"foo": "bar"},
+					// the not ready endpoints are load balanced to, so no reject ACL is added
+					PublishNotReadyAddresses: true,
+					Ports: []v1.ServicePort{{
+						Port:       80,
+						Protocol:   v1.ProtocolTCP,
+						TargetPort: intstr.FromInt(3456),
+					}},
+				},
+			},
+			updateTracker: false,
+			ovnCmd: []ovntest.ExpectedCmd{
+				{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
+					Output: loadbalancerTCP,
+				},
+				{
+					Cmd:    `ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer ` + loadbalancerTCP + ` vips`,
+					Output: "",
+				},
 				{
 					Cmd:    `ovn-nbctl --timeout=15 set load_balancer ` + loadbalancerTCP + ` vips:"192.168.1.1:80"="10.0.0.2:3456"`,
 					Output: "",
@@ -316,6 +398,10 @@ func TestSyncServices(t *testing.T) {
 					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
 					Output: loadbalancerTCP,
 				},
+				{
+					Cmd:    `ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer a08ea426-2288-11eb-a30b-a8a1590cda29 vips`,
+					Output: "",
+				},
 				{
 					Cmd:    `ovn-nbctl --timeout=15 set load_balancer a08ea426-2288-11eb-a30b-a8a1590cda29 vips:"192.168.1.1:80"="10.0.0.2:3456"`,
 					Output: "",
@@ -379,6 +465,10 @@ func TestUpdateServicePorts(t *testing.T) {
 		Output: loadbalancerTCP,
 	})
 	// Add a new loadbalancer with the Service Port 80
+	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+		Cmd:    `ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer a08ea426-2288-11eb-a30b-a8a1590cda29 vips`,
+		Output: "",
+	})
 	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
 		Cmd:    `ovn-nbctl --timeout=15 set load_balancer a08ea426-2288-11eb-a30b-a8a1590cda29 vips:"192.168.1.1:80"="10.0.0.2:3456"`,
 		Output: "",
@@ -408,6 +498,10 @@ func TestUpdateServicePorts(t *testing.T) {
 		Output: loadbalancerTCP,
 	})
 	// Add a new loadbalancer with the new Service Port 8888
+	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+		Cmd:    `ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer a08ea426-2288-11eb-a30b-a8a1590cda29 vips`,
+		Output: "",
+	})
 	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
 		Cmd:    `ovn-nbctl --timeout=15 set load_balancer a08ea426-2288-11eb-a30b-a8a1590cda29 vips:"192.168.1.1:8888"="10.0.0.2:3456"`,
 		Output: "",
@@ -541,6 +635,10 @@ func TestServiceCreateReject(t *testing.T) {
 		Output: loadbalancerTCP,
 	})
 	// Endpoints got added, create LB entry
+	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+		Cmd:    `ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer a08ea426-2288-11eb-a30b-a8a1590cda29 vips`,
+		Output: "",
+	})
 	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
 		Cmd:    `ovn-nbctl --timeout=15 set load_balancer a08ea426-2288-11eb-a30b-a8a1590cda29 vips:"192.168.1.1:80"="10.0.0.2:3456"`,
 		Output: loadbalancerTCP,
@@ -636,6 +734,10 @@ func TestUpdateServiceEndpointsToHost(t *testing.T) {
 		Output: loadbalancerTCP,
 	})
 	// Add a new loadbalancer with the Service Port 80
+	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+		Cmd:    `ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer a08ea426-2288-11eb-a30b-a8a1590cda29 vips`,
+		Output: "",
+	})
 	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
 		Cmd:    `ovn-nbctl --timeout=15 set load_balancer a08ea426-2288-11eb-a30b-a8a1590cda29 vips:"192.168.1.1:80"="10.128.0.2:3456"`,
 		Output: "",
@@ -698,6 +800,10 @@ func TestUpdateServiceEndpointsToHost(t *testing.T) {
 		Output: "2.2.2.2",
 	})
 	// endpoint is self node IP, so need to use special masquerade endpoint
+	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+		Cmd:    `ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer load_balancer_1 vips`,
+		Output: "",
+	})
 	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
 		Cmd:    `ovn-nbctl --timeout=15 set load_balancer load_balancer_1 vips:"192.168.1.1:80"="169.254.169.2:3456"`,
 		Output: "",
@@ -707,6 +813,10 @@ func TestUpdateServiceEndpointsToHost(t *testing.T) {
 		Output: "load_balancer_worker_1",
 	})
 	// use regular backend on the worker switch LB
+	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+		Cmd:    `ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer load_balancer_worker_1 vips`,
+		Output: "",
+	})
 	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
 		Cmd:    `ovn-nbctl --timeout=15 set load_balancer load_balancer_worker_1 vips:"192.168.1.1:80"="2.2.2.2:3456"`,
 		Output: "",
@@ -720,6 +830,10 @@ func TestUpdateServiceEndpointsToHost(t *testing.T) {
 		Output: "2.2.2.3",
 	})
 	// adding to second node will not use special masquerade
+	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+		Cmd:    `ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer load_balancer_2 vips`,
+		Output: "",
+	})
 	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
 		Cmd:    `ovn-nbctl --timeout=15 set load_balancer load_balancer_2 vips:"192.168.1.1:80"="2.2.2.2:3456"`,
 		Output: "",
@@ -729,6 +843,10 @@ func TestUpdateServiceEndpointsToHost(t *testing.T) {
 		Output: "load_balancer_worker_2",
 	})
 	// and regular endpoint IP on the 2nd worker switch
+	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+		Cmd:    `ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer load_balancer_worker_2 vips`,
+		Output: "",
+	})
 	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
 		Cmd:    `ovn-nbctl --timeout=15 set load_balancer load_balancer_worker_2 vips:"192.168.1.1:80"="2.2.2.2:3456"`,
 		Output: "",
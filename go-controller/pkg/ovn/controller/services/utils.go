@@ -26,8 +26,9 @@ type lbEndpoints struct {
 	Port int32
 }
 
-// return the endpoints that belong to the IPFamily as a slice of IPs
-func getLbEndpoints(slices []*discovery.EndpointSlice, svcPort v1.ServicePort, family v1.IPFamily) lbEndpoints {
+// return the endpoints that belong to the IPFamily as a slice of IPs, including the
+// not ready ones if includeNotReady is set
+func getLbEndpoints(slices []*discovery.EndpointSlice, svcPort v1.ServicePort, family v1.IPFamily, includeNotReady bool) lbEndpoints {
 	epsSet := sets.NewString()
 	lbEps := lbEndpoints{[]string{}, 0}
 	// return an empty object so the caller don't have to check for nil and can use it as an iterator
@@ -72,8 +73,8 @@ func getLbEndpoints(slices []*discovery.EndpointSlice, svcPort v1.ServicePort, f
 
 			lbEps.Port = *port.Port
 			for _, endpoint := range slice.Endpoints {
-				// Skip endpoints that are not ready
-				if endpoint.Conditions.Ready != nil && !*endpoint.Conditions.Ready {
+				// Skip endpoints that are not ready, unless the Service publishes them
+				if !includeNotReady && endpoint.Conditions.Ready != nil && !*endpoint.Conditions.Ready {
 					klog.V(4).Infof("Slice endpoints Not Ready")
 					continue
 				}
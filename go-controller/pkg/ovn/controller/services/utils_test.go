@@ -15,10 +15,47 @@ import (
 )
 
 func Test_getLbEndpoints(t *testing.T) {
+	notReadySlices := []*discovery.EndpointSlice{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "svc-ab23",
+				Namespace: "ns",
+				Labels:    map[string]string{discovery.LabelServiceName: "svc"},
+			},
+			Ports: []discovery.EndpointPort{
+				{
+					Name:     utilpointer.StringPtr("tcp-example"),
+					Protocol: protoPtr(v1.ProtocolTCP),
+					Port:     utilpointer.Int32Ptr(int32(80)),
+				},
+			},
+			AddressType: discovery.AddressTypeIPv4,
+			Endpoints: []discovery.Endpoint{
+				{
+					Conditions: discovery.EndpointConditions{
+						Ready: utilpointer.BoolPtr(true),
+					},
+					Addresses: []string{"10.0.0.2"},
+				},
+				{
+					Conditions: discovery.EndpointConditions{
+						Ready: utilpointer.BoolPtr(false),
+					},
+					Addresses: []string{"10.0.0.3"},
+				},
+			},
+		},
+	}
+	notReadySvcPort := v1.ServicePort{
+		Name:       "tcp-example",
+		TargetPort: intstr.FromInt(80),
+		Protocol:   v1.ProtocolTCP,
+	}
 	type args struct {
-		slices  []*discovery.EndpointSlice
-		svcPort v1.ServicePort
-		family  v1.IPFamily
+		slices          []*discovery.EndpointSlice
+		svcPort         v1.ServicePort
+		family          v1.IPFamily
+		includeNotReady bool
 	}
 	tests := []struct {
 		name string
@@ -209,10 +246,29 @@ func Test_getLbEndpoints(t *testing.T) {
 			},
 			want: lbEndpoints{[]string{"10.0.0.2", "10.1.1.2", "10.2.2.2"}, 80},
 		},
+		{
+			name: "slices with not ready endpoints",
+			args: args{
+				slices:  notReadySlices,
+				svcPort: notReadySvcPort,
+				family:  v1.IPv4Protocol,
+			},
+			want: lbEndpoints{[]string{"10.0.0.2"}, 80},
+		},
+		{
+			name: "slices with not ready endpoints published",
+			args: args{
+				slices:          notReadySlices,
+				svcPort:         notReadySvcPort,
+				family:          v1.IPv4Protocol,
+				includeNotReady: true,
+			},
+			want: lbEndpoints{[]string{"10.0.0.2", "10.0.0.3"}, 80},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := getLbEndpoints(tt.args.slices, tt.args.svcPort, tt.args.family); !reflect.DeepEqual(got, tt.want) {
+			if got := getLbEndpoints(tt.args.slices, tt.args.svcPort, tt.args.family, tt.args.includeNotReady); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("getLbEndpoints() = %v, want %v", got, tt.want)
 			}
 		})
@@ -21,15 +21,19 @@ import (
 type unidlingController struct {
 	eventRecorder record.EventRecorder
 	// Map of load balancers to service namespace
-	serviceVIPToName     map[ServiceVIPKey]types.NamespacedName
-	serviceVIPToNameLock sync.Mutex
+	serviceVIPToName map[ServiceVIPKey]types.NamespacedName
+	// Map of the NodePorts to service namespace, as the VIPs of a NodePort are on the
+	// physical IPs of every node
+	serviceNodePortToName map[ServiceNodePortKey]types.NamespacedName
+	serviceVIPToNameLock  sync.Mutex
 }
 
 // NewController creates a new unidling controller
 func NewController(recorder record.EventRecorder, serviceInformer cache.SharedIndexInformer) *unidlingController {
 	uc := &unidlingController{
-		eventRecorder:    recorder,
-		serviceVIPToName: map[ServiceVIPKey]types.NamespacedName{},
+		eventRecorder:         recorder,
+		serviceVIPToName:      map[ServiceVIPKey]types.NamespacedName{},
+		serviceNodePortToName: map[ServiceNodePortKey]types.NamespacedName{},
 	}
 
 	// we only process events on unidling, there is no reconcilation
@@ -48,11 +52,14 @@ func NewController(recorder record.EventRecorder, serviceInformer cache.SharedIn
 func (uc *unidlingController) onServiceAdd(obj interface{}) {
 	svc := obj.(*v1.Service)
 	if util.ServiceTypeHasClusterIP(svc) && util.IsClusterIPSet(svc) {
-		for _, ip := range util.GetClusterIPs(svc) {
-			for _, svcPort := range svc.Spec.Ports {
+		for _, svcPort := range svc.Spec.Ports {
+			for _, ip := range serviceVIPIPs(svc) {
 				vip := util.JoinHostPortInt32(ip, svcPort.Port)
 				uc.AddServiceVIPToName(vip, svcPort.Protocol, svc.Namespace, svc.Name)
 			}
+			if util.ServicePortHasNodePort(svc, &svcPort) {
+				uc.AddServiceNodePortToName(svcPort.NodePort, svcPort.Protocol, svc.Namespace, svc.Name)
+			}
 		}
 	}
 }
@@ -73,15 +80,31 @@ func (uc *unidlingController) onServiceDelete(obj interface{}) {
 	}
 
 	if util.ServiceTypeHasClusterIP(svc) && util.IsClusterIPSet(svc) {
-		for _, ip := range util.GetClusterIPs(svc) {
-			for _, svcPort := range svc.Spec.Ports {
+		for _, svcPort := range svc.Spec.Ports {
+			for _, ip := range serviceVIPIPs(svc) {
 				vip := util.JoinHostPortInt32(ip, svcPort.Port)
 				uc.DeleteServiceVIPToName(vip, svcPort.Protocol)
 			}
+			if util.ServicePortHasNodePort(svc, &svcPort) {
+				uc.DeleteServiceNodePortToName(svcPort.NodePort, svcPort.Protocol)
+			}
 		}
 	}
 }
 
+// serviceVIPIPs returns the IPs the VIPs of svc are on: its ClusterIPs, external IPs and load
+// balancer ingress IPs
+func serviceVIPIPs(svc *v1.Service) []string {
+	ips := append([]string{}, util.GetClusterIPs(svc)...)
+	ips = append(ips, svc.Spec.ExternalIPs...)
+	for _, ing := range svc.Status.LoadBalancer.Ingress {
+		if ing.IP != "" {
+			ips = append(ips, ing.IP)
+		}
+	}
+	return ips
+}
+
 // ServiceVIPKey is used for looking up service namespace information for a
 // particular load balancer
 type ServiceVIPKey struct {
@@ -91,6 +114,13 @@ type ServiceVIPKey struct {
 	protocol v1.Protocol
 }
 
+// ServiceNodePortKey is used for looking up service namespace information for
+// the load balancer VIPs of a NodePort
+type ServiceNodePortKey struct {
+	nodePort int32
+	protocol v1.Protocol
+}
+
 // AddServiceVIPToName associates a k8s service name with a load balancer VIP
 func (uc *unidlingController) AddServiceVIPToName(vip string, protocol v1.Protocol, namespace, name string) {
 	uc.serviceVIPToNameLock.Lock()
@@ -98,11 +128,18 @@ func (uc *unidlingController) AddServiceVIPToName(vip string, protocol v1.Protoc
 	uc.serviceVIPToName[ServiceVIPKey{vip, protocol}] = types.NamespacedName{Namespace: namespace, Name: name}
 }
 
-// GetServiceVIPToName retrieves the associated k8s service name for a load balancer VIP
+// GetServiceVIPToName retrieves the associated k8s service name for a load balancer VIP,
+// falling back to the service of the NodePort of the VIP
 func (uc *unidlingController) GetServiceVIPToName(vip string, protocol v1.Protocol) (types.NamespacedName, bool) {
 	uc.serviceVIPToNameLock.Lock()
 	defer uc.serviceVIPToNameLock.Unlock()
 	namespace, ok := uc.serviceVIPToName[ServiceVIPKey{vip, protocol}]
+	if ok {
+		return namespace, ok
+	}
+	if _, port, err := util.SplitHostPortInt32(vip); err == nil {
+		namespace, ok = uc.serviceNodePortToName[ServiceNodePortKey{port, protocol}]
+	}
 	return namespace, ok
 }
 
@@ -113,6 +150,39 @@ func (uc *unidlingController) DeleteServiceVIPToName(vip string, protocol v1.Pro
 	delete(uc.serviceVIPToName, ServiceVIPKey{vip, protocol})
 }
 
+// AddServiceNodePortToName associates a k8s service name with the load balancer VIPs of a NodePort
+func (uc *unidlingController) AddServiceNodePortToName(nodePort int32, protocol v1.Protocol, namespace, name string) {
+	uc.serviceVIPToNameLock.Lock()
+	defer uc.serviceVIPToNameLock.Unlock()
+	uc.serviceNodePortToName[ServiceNodePortKey{nodePort, protocol}] = types.NamespacedName{Namespace: namespace, Name: name}
+}
+
+// DeleteServiceNodePortToName removes the associated k8s service name for the load balancer VIPs of a NodePort
+func (uc *unidlingController) DeleteServiceNodePortToName(nodePort int32, protocol v1.Protocol) {
+	uc.serviceVIPToNameLock.Lock()
+	defer uc.serviceVIPToNameLock.Unlock()
+	delete(uc.serviceNodePortToName, ServiceNodePortKey{nodePort, protocol})
+}
+
+// handleEvent unidles the service the VIP of an empty load balancer backends event belongs to:
+// it sends the NeedPods event the idler scales the workload back up on. The idler removes the
+// idled-at annotation of the service once unidled, which brings the reject ACLs of its VIPs back
+// for as long as it has no endpoints.
+func (uc *unidlingController) handleEvent(event emptyLBBackendEvent) {
+	serviceName, ok := uc.GetServiceVIPToName(event.vip, event.protocol)
+	if !ok {
+		klog.V(5).Infof("No service found for the empty backends event of %s VIP %s", event.protocol, event.vip)
+		return
+	}
+	serviceRef := v1.ObjectReference{
+		Kind:      "Service",
+		Namespace: serviceName.Namespace,
+		Name:      serviceName.Name,
+	}
+	klog.V(5).Infof("Sending a NeedPods event for service %s in namespace %s.", serviceName.Name, serviceName.Namespace)
+	uc.eventRecorder.Eventf(&serviceRef, v1.EventTypeNormal, "NeedPods", "The service %s needs pods", serviceName.Name)
+}
+
 func (uc *unidlingController) Run(stopCh <-chan struct{}) {
 	ticker := time.NewTicker(5 * time.Second)
 
@@ -136,15 +206,7 @@ func (uc *unidlingController) Run(stopCh <-chan struct{}) {
 					klog.Errorf("Unable to remove controller event %s", event.uuid)
 					continue
 				}
-				if serviceName, ok := uc.GetServiceVIPToName(event.vip, event.protocol); ok {
-					serviceRef := v1.ObjectReference{
-						Kind:      "Service",
-						Namespace: serviceName.Namespace,
-						Name:      serviceName.Name,
-					}
-					klog.V(5).Infof("Sending a NeedPods event for service %s in namespace %s.", serviceName.Name, serviceName.Namespace)
-					uc.eventRecorder.Eventf(&serviceRef, v1.EventTypeNormal, "NeedPods", "The service %s needs pods", serviceName.Name)
-				}
+				uc.handleEvent(event)
 			}
 		case <-stopCh:
 			return
@@ -0,0 +1,77 @@
+package unidling
+
+import (
+	"testing"
+
+	kapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func Test_handleEvent(t *testing.T) {
+	service := &kapi.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "service1", Namespace: "namespace1"},
+		Spec: kapi.ServiceSpec{
+			Type:        kapi.ServiceTypeNodePort,
+			ClusterIP:   "172.30.0.10",
+			ClusterIPs:  []string{"172.30.0.10"},
+			ExternalIPs: []string{"192.0.2.10"},
+			Ports:       []kapi.ServicePort{{Port: 80, NodePort: 30080, Protocol: kapi.ProtocolTCP}},
+		},
+	}
+	tests := []struct {
+		name      string
+		event     emptyLBBackendEvent
+		wantFound bool
+	}{
+		{
+			name:      "ClusterIP VIP",
+			event:     emptyLBBackendEvent{vip: "172.30.0.10:80", protocol: kapi.ProtocolTCP},
+			wantFound: true,
+		},
+		{
+			name:      "external IP VIP",
+			event:     emptyLBBackendEvent{vip: "192.0.2.10:80", protocol: kapi.ProtocolTCP},
+			wantFound: true,
+		},
+		{
+			name:      "NodePort VIP on a physical IP",
+			event:     emptyLBBackendEvent{vip: "192.168.0.1:30080", protocol: kapi.ProtocolTCP},
+			wantFound: true,
+		},
+		{
+			name:      "VIP of another protocol",
+			event:     emptyLBBackendEvent{vip: "172.30.0.10:80", protocol: kapi.ProtocolUDP},
+			wantFound: false,
+		},
+		{
+			name:      "unknown VIP",
+			event:     emptyLBBackendEvent{vip: "172.30.0.20:80", protocol: kapi.ProtocolTCP},
+			wantFound: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(service)
+			recorder := record.NewFakeRecorder(10)
+			informer := informers.NewSharedInformerFactory(client, 0).Core().V1().Services().Informer()
+			uc := NewController(recorder, informer)
+			uc.onServiceAdd(service)
+
+			uc.handleEvent(tt.event)
+
+			if len(recorder.Events) != 0 != tt.wantFound {
+				t.Fatalf("NeedPods event sent = %v, want %v", len(recorder.Events) != 0, tt.wantFound)
+			}
+			if !tt.wantFound {
+				return
+			}
+			want := "Normal NeedPods The service service1 needs pods"
+			if event := <-recorder.Events; event != want {
+				t.Errorf("event = %q, want %q", event, want)
+			}
+		})
+	}
+}
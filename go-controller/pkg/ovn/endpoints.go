@@ -1,6 +1,7 @@
 package ovn
 
 import (
+	"context"
 	"fmt"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/loadbalancer"
@@ -17,26 +18,98 @@ type lbEndpoints struct {
 	Port int32
 }
 
-func (ovn *Controller) getLbEndpoints(ep *kapi.Endpoints) map[kapi.Protocol]map[string]lbEndpoints {
+// endpointAddresses returns the addresses of an endpoint subset that are load balanced to,
+// which include the not ready ones when the service publishes them
+func endpointAddresses(subset kapi.EndpointSubset, svc *kapi.Service) []kapi.EndpointAddress {
+	if !svc.Spec.PublishNotReadyAddresses || len(subset.NotReadyAddresses) == 0 {
+		return subset.Addresses
+	}
+	addresses := make([]kapi.EndpointAddress, 0, len(subset.Addresses)+len(subset.NotReadyAddresses))
+	addresses = append(addresses, subset.Addresses...)
+	return append(addresses, subset.NotReadyAddresses...)
+}
+
+// serviceHasReadyEndpoints returns whether the endpoints of a service have any address that is
+// load balanced to: a ready address, or a not ready one when the service publishes them. The
+// subsets alone do not tell, a service matching no pods or only pods that are not ready has
+// endpoints without addresses. Every path deciding between load balancing and rejecting traffic
+// to a service goes through it.
+func serviceHasReadyEndpoints(ep *kapi.Endpoints, svc *kapi.Service) bool {
+	for _, subset := range ep.Subsets {
+		if len(endpointAddresses(subset, svc)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// endpointTerminatingFunc tells whether the pod behind an address of the endpoints of namespace is
+// terminating
+type endpointTerminatingFunc func(namespace string, address kapi.EndpointAddress) bool
+
+// endpointTerminating returns whether the pod behind address, an address of the endpoints of
+// namespace, is terminating. An address whose pod is not known is not.
+func (ovn *Controller) endpointTerminating(namespace string, address kapi.EndpointAddress) bool {
+	if address.TargetRef == nil || address.TargetRef.Kind != "Pod" {
+		return false
+	}
+	if address.TargetRef.Namespace != "" {
+		namespace = address.TargetRef.Namespace
+	}
+	pod, err := ovn.watchFactory.GetPod(namespace, address.TargetRef.Name)
+	return err == nil && pod.DeletionTimestamp != nil
+}
+
+// getLbEndpoints returns the targets of the VIPs of every port of svc, by protocol and port name.
+// The addresses of terminating pods, as told by terminating, are left out of the ports that have
+// others, so that new connections are not sent to draining pods, and are only targeted by the
+// ports they are the last addresses of. A nil terminating takes no address for terminating.
+func getLbEndpoints(ep *kapi.Endpoints, svc *kapi.Service, terminating endpointTerminatingFunc) map[kapi.Protocol]map[string]lbEndpoints {
 	protoPortMap := map[kapi.Protocol]map[string]lbEndpoints{
 		kapi.ProtocolTCP:  make(map[string]lbEndpoints),
 		kapi.ProtocolUDP:  make(map[string]lbEndpoints),
 		kapi.ProtocolSCTP: make(map[string]lbEndpoints),
 	}
+	terminatingPortMap := map[kapi.Protocol]map[string]lbEndpoints{
+		kapi.ProtocolTCP:  make(map[string]lbEndpoints),
+		kapi.ProtocolUDP:  make(map[string]lbEndpoints),
+		kapi.ProtocolSCTP: make(map[string]lbEndpoints),
+	}
 	for _, s := range ep.Subsets {
-		for _, ip := range s.Addresses {
+		for _, ip := range endpointAddresses(s, svc) {
+			portMap := protoPortMap
+			if terminating != nil && terminating(ep.Namespace, ip) {
+				portMap = terminatingPortMap
+			}
 			for _, port := range s.Ports {
 				var ips []string
 				if err := util.ValidatePort(port.Protocol, port.Port); err != nil {
 					klog.Errorf("Invalid endpoint port: %s: %v", port.Name, err)
 					continue
 				}
-				if lbEps, ok := protoPortMap[port.Protocol][port.Name]; ok {
+				if lbEps, ok := portMap[port.Protocol][port.Name]; ok {
 					ips = append(lbEps.IPs, ip.IP)
 				} else {
 					ips = []string{ip.IP}
 				}
-				protoPortMap[port.Protocol][port.Name] = lbEndpoints{IPs: ips, Port: port.Port}
+				portMap[port.Protocol][port.Name] = lbEndpoints{IPs: ips, Port: port.Port}
+			}
+		}
+	}
+	for protocol, portMap := range terminatingPortMap {
+		for name, lbEps := range portMap {
+			if _, ok := protoPortMap[protocol][name]; !ok {
+				klog.V(5).Infof("Only terminating endpoints left for %s port %s of service %s, targeting them",
+					protocol, name, svcKey(svc))
+				protoPortMap[protocol][name] = lbEps
+			}
+		}
+	}
+	// an invalid annotation is reported when the service is created or updated
+	if weights, err := svcEndpointWeights(svc); err == nil && len(weights) > 0 {
+		for _, portMap := range protoPortMap {
+			for name, lbEps := range portMap {
+				portMap[name] = lbEndpoints{IPs: loadbalancer.WeightTargetIPs(lbEps.IPs, weights), Port: lbEps.Port}
 			}
 		}
 	}
@@ -46,6 +119,14 @@ func (ovn *Controller) getLbEndpoints(ep *kapi.Endpoints) map[kapi.Protocol]map[
 
 // AddEndpoints adds endpoints and creates corresponding resources in OVN
 func (ovn *Controller) AddEndpoints(ep *kapi.Endpoints, addClusterLBs bool) error {
+	// stop programming the endpoints once the controller is stopped
+	ctx, cancel := ovn.stopContext()
+	defer cancel()
+	return ovn.addEndpointsContext(ctx, ep, addClusterLBs)
+}
+
+// addEndpointsContext is AddEndpoints stopping once ctx is cancelled
+func (ovn *Controller) addEndpointsContext(ctx context.Context, ep *kapi.Endpoints, addClusterLBs bool) error {
 	klog.Infof("Adding endpoints: %s for namespace: %s", ep.Name, ep.Namespace)
 	// get service
 	// TODO: cache the service
@@ -57,72 +138,92 @@ func (ovn *Controller) AddEndpoints(ep *kapi.Endpoints, addClusterLBs bool) erro
 		return nil
 	}
 	if !util.IsClusterIPSet(svc) {
-		klog.V(5).Infof("Skipping service %s due to clusterIP = %q", svc.Name, svc.Spec.ClusterIP)
+		klog.V(5).Infof("Skipping service %s due to clusterIP = %q", svcKey(svc), svc.Spec.ClusterIP)
+		return nil
+	}
+	if svcSkipsLoadBalancing(svc) {
+		klog.V(5).Infof("Skipping service %s opted out of load balancing", svcKey(svc))
 		return nil
 	}
 
-	klog.V(5).Infof("Matching service %s found for ep: %s, with cluster IP: %s", svc.Name, ep.Name, svc.Spec.ClusterIP)
+	klog.V(5).Infof("Matching service %s found for ep: %s, with cluster IP: %s", svcKey(svc), ep.Name, svc.Spec.ClusterIP)
 
-	protoPortMap := ovn.getLbEndpoints(ep)
-	klog.V(5).Infof("Matching service %s ports: %v", svc.Name, svc.Spec.Ports)
+	protoPortMap := getLbEndpoints(ep, svc, ovn.endpointTerminating)
+	klog.V(5).Infof("Matching service %s ports: %v", svcKey(svc), svc.Spec.Ports)
+	// the external IPs are checked against the node IPs once, by the first port with endpoints
+	var extIPs []string
+	extIPsChecked := false
 	for _, svcPort := range svc.Spec.Ports {
 		lbEps, isFound := protoPortMap[svcPort.Protocol][svcPort.Name]
 		if !isFound {
 			continue
 		}
-		if !ovn.SCTPSupport && svcPort.Protocol == kapi.ProtocolSCTP {
-			klog.Errorf("Rejecting endpoint creation for unsupported SCTP protocol: %s, %s", ep.Namespace, ep.Name)
+		if !ovn.serviceProtocolSupported(svcPort.Protocol) {
+			klog.Errorf("Rejecting endpoint creation for unsupported %s protocol: %s", svcPort.Protocol, svcKey(svc))
 			continue
 		}
-		if util.ServiceTypeHasNodePort(svc) {
-			if err := ovn.createPerNodeVIPs(nil, svcPort.Protocol, svcPort.NodePort, lbEps.IPs, lbEps.Port); err != nil {
-				klog.Errorf("Error in creating Node Port for svc %s, node port: %d - %v\n", svc.Name, svcPort.NodePort, err)
+		if util.ServicePortHasNodePort(svc, &svcPort) {
+			if owner, ok := ovn.claimNodePort(svc, svcPort.Protocol, svcPort.NodePort); !ok {
+				klog.Errorf("Not configuring %s NodePort %d of service %s: already allocated to service %s",
+					svcPort.Protocol, svcPort.NodePort, svcKey(svc), owner)
+			} else if err := ovn.createPerNodeVIPs(ctx, nil, svcPort.Protocol, svcPort.NodePort, lbEps.IPs, lbEps.Port); err != nil {
+				klog.Errorf("Error in creating %s Node Port for svc %s, node port: %d - %v", svcPort.Protocol, svcKey(svc),
+					svcPort.NodePort, err)
 				continue
 			}
 		}
 
 		if util.ServiceTypeHasClusterIP(svc) {
 			var loadBalancer string
-			loadBalancer, err = ovn.getLoadBalancer(svcPort.Protocol)
+			loadBalancer, err = ovn.lbOps.GetLoadBalancer(svcPort.Protocol)
 			if err != nil {
-				klog.Errorf("Failed to get load balancer for %s (%v)", svcPort.Protocol, err)
+				klog.Errorf("Failed to get %s load balancer for svc %s (%v)", svcPort.Protocol, svcKey(svc), err)
 				continue
 			}
 
+			// only the ClusterIPs that were allocated have a VIP, one per IP family
+			clusterIPs := svcClusterIPs(svc)
 			// If any of the lbEps contain the a host IP we add to worker/GR LB separately, and not to cluster LB
 			if hasHostEndpoints(lbEps.IPs) && config.Gateway.Mode == config.GatewayModeShared {
-				if err := ovn.createPerNodeVIPs([]string{svc.Spec.ClusterIP}, svcPort.Protocol, svcPort.Port, lbEps.IPs, lbEps.Port); err != nil {
-					klog.Errorf("Error in creating Cluster IP for svc %s, target port: %d - %v\n", svc.Name, lbEps.Port, err)
+				if err := ovn.createPerNodeVIPs(ctx, clusterIPs, svcPort.Protocol, svcPort.Port, lbEps.IPs, lbEps.Port); err != nil {
+					klog.Errorf("Error in creating %s Cluster IP for svc %s, target port: %d - %v", svcPort.Protocol,
+						svcKey(svc), lbEps.Port, err)
 					continue
 				}
 				// Need to ensure that if vip exists on cluster LB we remove it
 				// This can happen if endpoints originally had cluster only ips but now have host ips
-				vip := util.JoinHostPortInt32(svc.Spec.ClusterIP, svcPort.Port)
-				if err := ovn.deleteLoadBalancerVIP(loadBalancer, vip); err != nil {
-					klog.Error(err)
+				for _, clusterIP := range clusterIPs {
+					vip := util.JoinHostPortInt32(clusterIP, svcPort.Port)
+					if err := ovn.lbOps.RemoveVIP(ctx, loadBalancer, vip); err != nil {
+						klog.Error(err)
+					}
 				}
 			} else if addClusterLBs {
-				if err = ovn.createLoadBalancerVIPs(loadBalancer, []string{svc.Spec.ClusterIP}, svcPort.Port, lbEps.IPs, lbEps.Port); err != nil {
-					klog.Errorf("Error in creating Cluster IP for svc %s, target port: %d - %v\n", svc.Name, lbEps.Port, err)
+				if err = ovn.lbOps.EnsureVIP(ctx, loadBalancer, clusterIPs, svcPort.Port, lbEps.IPs, lbEps.Port); err != nil {
+					klog.Errorf("Error in creating %s Cluster IP for svc %s, target port: %d - %v", svcPort.Protocol,
+						svcKey(svc), lbEps.Port, err)
 					continue
 				}
 				// Need to ensure if this vip exists in the worker LBs that we remove it
 				// This can happen if the endpoints originally had host eps but now have cluster only ips
-				ovn.deleteNodeVIPs([]string{svc.Spec.ClusterIP}, svcPort.Protocol, svcPort.Port)
+				ovn.deleteNodeVIPs(ctx, clusterIPs, svcPort.Protocol, svcPort.Port)
+			}
+			if !extIPsChecked {
+				extIPs = ovn.svcExternalIPs(svc, newGatewayCache(ovn.lbOps))
+				extIPsChecked = true
 			}
-			if len(svc.Spec.ExternalIPs) > 0 {
-				if err := ovn.createPerNodeVIPs(svc.Spec.ExternalIPs, svcPort.Protocol, svcPort.Port, lbEps.IPs, lbEps.Port); err != nil {
-					klog.Errorf("Error in creating ExternalIP for svc %s, target port: %d - %v\n", svc.Name, lbEps.Port, err)
+			if len(extIPs) > 0 {
+				if err := ovn.createPerNodeVIPs(ctx, extIPs, svcPort.Protocol, svcPort.Port, lbEps.IPs, lbEps.Port); err != nil {
+					klog.Errorf("Error in creating %s ExternalIP for svc %s, target port: %d - %v", svcPort.Protocol,
+						svcKey(svc), lbEps.Port, err)
 				}
 			}
 			// Cloud load balancers: directly load balance that traffic from pods
 			// Apply to gateway load-balancers to handle ingress traffic to the GR as well as worker switches
-			for _, ing := range svc.Status.LoadBalancer.Ingress {
-				if ing.IP == "" {
-					continue
-				}
-				if err := ovn.createPerNodeVIPs([]string{ing.IP}, svcPort.Protocol, svcPort.Port, lbEps.IPs, lbEps.Port); err != nil {
-					klog.Errorf("Error in creating Ingress LB IP for svc %s, target port: %d - %v\n", svc.Name, lbEps.Port, err)
+			for _, ingIP := range svcFamilyIPs(svc, svcIngressIPs(svc)) {
+				if err := ovn.createPerNodeVIPs(ctx, []string{ingIP}, svcPort.Protocol, svcPort.Port, lbEps.IPs, lbEps.Port); err != nil {
+					klog.Errorf("Error in creating %s Ingress LB IP for svc %s, target port: %d - %v", svcPort.Protocol,
+						svcKey(svc), lbEps.Port, err)
 				}
 			}
 		}
@@ -141,6 +242,9 @@ func (ovn *Controller) handleNodePortLB(node *kapi.Node) error {
 		}
 	}
 	// END OCP HACK
+	// stop adding the endpoints once the controller is stopped
+	ctx, cancel := ovn.stopContext()
+	defer cancel()
 	namespaces, err := ovn.watchFactory.GetNamespaces()
 	if err != nil {
 		return fmt.Errorf("failed to get k8s namespaces: %v", err)
@@ -152,7 +256,7 @@ func (ovn *Controller) handleNodePortLB(node *kapi.Node) error {
 			continue
 		}
 		for _, ep := range endpoints {
-			if err := ovn.AddEndpoints(ep, false); err != nil {
+			if err := ovn.addEndpointsContext(ctx, ep, false); err != nil {
 				return fmt.Errorf("unable to handle adding endpoints for new node: %s, error: %v",
 					node.Name, err)
 			}
@@ -172,87 +276,111 @@ func (ovn *Controller) deleteEndpoints(ep *kapi.Endpoints) error {
 		klog.V(5).Infof("No service found for endpoint %s in namespace %s", ep.Name, ep.Namespace)
 		return nil
 	}
-	if !util.IsClusterIPSet(svc) {
+	if !util.IsClusterIPSet(svc) || svcSkipsLoadBalancing(svc) {
 		return nil
 	}
-	gateways, _, err := ovn.getOvnGateways()
+	// stop clearing the VIPs once the controller is stopped
+	ctx, cancel := ovn.stopContext()
+	defer cancel()
+	// The informer cache is updated before the handlers run, so it may already hold newer
+	// endpoints than ep, like when the last pod of a service is replaced. Clearing the VIPs then
+	// would drop the new endpoints and reject traffic to a service that has some, so the VIPs are
+	// pointed at the latest endpoints instead: the targets of a VIP are always replaced at once,
+	// so they never go through an empty set.
+	if latest, err := ovn.watchFactory.GetEndpoint(ep.Namespace, ep.Name); err == nil && serviceHasReadyEndpoints(latest, svc) {
+		klog.Infof("Not clearing the VIPs of service %s: its endpoints have addresses again", svcKey(svc))
+		return ovn.addEndpointsContext(ctx, latest, true)
+	}
+	ovn.clearServiceVIPs(ctx, svc)
+	return nil
+}
+
+// clearServiceVIPs clears the targets of every VIP of svc, giving them reject ACLs when svc
+// qualifies for them and removing their reject ACLs otherwise
+func (ovn *Controller) clearServiceVIPs(ctx context.Context, svc *kapi.Service) {
+	gateways, _, err := ovn.lbOps.GetOvnGateways()
 	if err != nil {
 		klog.Error(err)
 	}
 
 	for _, svcPort := range svc.Spec.Ports {
-		clusterLB, err := ovn.getLoadBalancer(svcPort.Protocol)
+		clusterLB, err := ovn.lbOps.GetLoadBalancer(svcPort.Protocol)
 		if err != nil {
 			klog.Errorf("Failed to get load balancer for %s (%v)", clusterLB, err)
 			continue
 		}
+		clusterIPs := svcClusterIPs(svc)
 		// Cluster IP service
-		ovn.clearVIPsAddRejectACL(svc, clusterLB, svc.Spec.ClusterIP, svcPort.Port, svcPort.Protocol)
+		for _, clusterIP := range clusterIPs {
+			ovn.clearVIPsAddRejectACL(ctx, svc, clusterLB, clusterIP, svcPort.Port, svcPort.Protocol)
+		}
 
 		for _, gateway := range gateways {
-			gatewayLB, err := ovn.getGatewayLoadBalancer(gateway, svcPort.Protocol)
+			gatewayLB, err := ovn.lbOps.GetGatewayLoadBalancer(gateway, svcPort.Protocol)
 			if err != nil {
 				klog.Errorf("Gateway router %s does not have load balancer (%v)", gateway, err)
 				continue
 			}
 			// ClusterIP may be on gateway or worker LBs, so need to remove here as well
 			if config.Gateway.Mode == config.GatewayModeShared {
-				ovn.clearVIPsAddRejectACL(svc, gatewayLB, svc.Spec.ClusterIP, svcPort.Port, svcPort.Protocol)
+				for _, clusterIP := range clusterIPs {
+					ovn.clearVIPsAddRejectACL(ctx, svc, gatewayLB, clusterIP, svcPort.Port, svcPort.Protocol)
+				}
 			}
 			workerNode := util.GetWorkerFromGatewayRouter(gateway)
-			workerLB, err := loadbalancer.GetWorkerLoadBalancer(workerNode, svcPort.Protocol)
+			workerLB, err := ovn.lbOps.GetWorkerLoadBalancer(workerNode, svcPort.Protocol)
 			if err != nil {
 				klog.Errorf("Worker switch %s does not have load balancer (%v)", workerNode, err)
 				continue
 			}
 			if config.Gateway.Mode == config.GatewayModeShared {
-				ovn.clearVIPsAddRejectACL(svc, workerLB, svc.Spec.ClusterIP, svcPort.Port, svcPort.Protocol)
+				for _, clusterIP := range clusterIPs {
+					ovn.clearVIPsAddRejectACL(ctx, svc, workerLB, clusterIP, svcPort.Port, svcPort.Protocol)
+				}
 			}
 
 			// Cloud load balancers: directly reject traffic from pods
-			for _, ing := range svc.Status.LoadBalancer.Ingress {
-				if ing.IP == "" {
-					continue
-				}
-				ovn.clearVIPsAddRejectACL(svc, gatewayLB, ing.IP, svcPort.Port, svcPort.Protocol)
-				ovn.clearVIPsAddRejectACL(svc, workerLB, ing.IP, svcPort.Port, svcPort.Protocol)
+			for _, ingIP := range svcIngressIPs(svc) {
+				ovn.clearVIPsAddRejectACL(ctx, svc, gatewayLB, ingIP, svcPort.Port, svcPort.Protocol)
+				ovn.clearVIPsAddRejectACL(ctx, svc, workerLB, ingIP, svcPort.Port, svcPort.Protocol)
 			}
-			// Node Port services
-			if util.ServiceTypeHasNodePort(svc) {
-				physicalIPs, err := ovn.getGatewayPhysicalIPs(gateway)
+			// Node Port services, unless the NodePort is programmed for another service
+			if util.ServicePortHasNodePort(svc, &svcPort) && ovn.ownsNodePort(svc, svcPort.Protocol, svcPort.NodePort) {
+				physicalIPs, err := ovn.lbOps.GetGatewayPhysicalIPs(gateway)
 				if err != nil {
 					klog.Errorf("Gateway router %s does not have physical ip (%v)", gateway, err)
 					continue
 				}
 				for _, physicalIP := range physicalIPs {
-					ovn.clearVIPsAddRejectACL(svc, gatewayLB, physicalIP, svcPort.NodePort, svcPort.Protocol)
-					ovn.clearVIPsAddRejectACL(svc, workerLB, physicalIP, svcPort.NodePort, svcPort.Protocol)
+					ovn.clearVIPsAddRejectACL(ctx, svc, gatewayLB, physicalIP, svcPort.NodePort, svcPort.Protocol)
+					ovn.clearVIPsAddRejectACL(ctx, svc, workerLB, physicalIP, svcPort.NodePort, svcPort.Protocol)
 				}
 			}
 			// External IP services
 			for _, extIP := range svc.Spec.ExternalIPs {
-				ovn.clearVIPsAddRejectACL(svc, gatewayLB, extIP, svcPort.Port, svcPort.Protocol)
-				ovn.clearVIPsAddRejectACL(svc, workerLB, extIP, svcPort.NodePort, svcPort.Protocol)
+				ovn.clearVIPsAddRejectACL(ctx, svc, gatewayLB, extIP, svcPort.Port, svcPort.Protocol)
+				ovn.clearVIPsAddRejectACL(ctx, svc, workerLB, extIP, svcPort.NodePort, svcPort.Protocol)
 			}
 		}
 	}
-	return nil
 }
 
-func (ovn *Controller) clearVIPsAddRejectACL(svc *kapi.Service, lb, ip string, port int32, proto kapi.Protocol) {
-	aclLogging := ovn.GetNetworkPolicyACLLogging(svc.Namespace).Deny
-	if svcQualifiesForReject(svc) {
-		aclUUID, err := ovn.createLoadBalancerRejectACL(lb, ip, port, proto, aclLogging)
-		if err != nil {
-			klog.Errorf("Failed to create reject ACL for VIP: %s:%d, load balancer: %s, error: %v",
-				ip, port, lb, err)
-		} else {
-			klog.Infof("Reject ACL created for VIP: %s:%d, load balancer: %s, %s", ip, port, lb, aclUUID)
-		}
-	}
-	err := ovn.configureLoadBalancer(lb, ip, port, nil)
+// clearVIPsAddRejectACL clears the targets of the VIP for ip:port of lb and, when the service
+// qualifies for one, creates its reject ACL in the same transaction, so that an interruption, like
+// a shutdown, never leaves a reject ACL in front of a VIP with targets. Otherwise the reject ACL
+// the VIP may have, like when the service was just idled, is removed in the same transaction.
+func (ovn *Controller) clearVIPsAddRejectACL(ctx context.Context, svc *kapi.Service, lb, ip string, port int32, proto kapi.Protocol) {
+	aclLogging, aclMeter := ovn.getRejectACLLogging(svc.Namespace)
+	vip := util.JoinHostPortInt32(ip, port)
+	aclUUID, err := ovn.lbOps.ClearVIP(ctx, lb, ip, port, proto, aclLogging, aclMeter, svcEmptyServiceACLAction(svc))
 	if err != nil {
-		klog.Errorf("Error in clearing endpoints for lb %s: %v", lb, err)
+		klog.Errorf("Error in clearing endpoints of %s VIP %s of service %s for lb %s: %v", proto,
+			vip, svcKey(svc), lb, err)
+		return
+	}
+	if aclUUID != "" {
+		klog.Infof("Reject ACL created for %s VIP %s of service %s, load balancer: %s, %s", proto, vip,
+			svcKey(svc), lb, aclUUID)
 	}
 }
 
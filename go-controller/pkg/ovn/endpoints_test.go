@@ -3,6 +3,7 @@ package ovn
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -64,6 +65,7 @@ func (e endpoints) addNodePortPortCmds(fexec *ovntest.FakeExec, service v1.Servi
 			Output: "169.254.33.2",
 		})
 		fexec.AddFakeCmdsNoOutputNoError([]string{
+			fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=load_balancer_%d-%s\\:%v", idx, "169.254.33.2", service.Spec.Ports[0].NodePort),
 			fmt.Sprintf("ovn-nbctl --timeout=15 set load_balancer load_balancer_%s vips:\"%s:%v\"=\"%s:%v\"", strconv.Itoa(idx), "169.254.33.2", service.Spec.Ports[0].NodePort, endpoint.Subsets[0].Addresses[0].IP, endpoint.Subsets[0].Ports[0].Port),
 		})
 		workerIdx := idx + 100
@@ -72,6 +74,7 @@ func (e endpoints) addNodePortPortCmds(fexec *ovntest.FakeExec, service v1.Servi
 			Output: "load_balancer_" + strconv.Itoa(workerIdx),
 		})
 		fexec.AddFakeCmdsNoOutputNoError([]string{
+			fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=load_balancer_%d-%s\\:%v", workerIdx, "169.254.33.2", service.Spec.Ports[0].NodePort),
 			fmt.Sprintf("ovn-nbctl --timeout=15 set load_balancer load_balancer_%s vips:\"%s:%v\"=\"%s:%v\"", strconv.Itoa(workerIdx), "169.254.33.2", service.Spec.Ports[0].NodePort, endpoint.Subsets[0].Addresses[0].IP, endpoint.Subsets[0].Ports[0].Port),
 		})
 	}
@@ -104,6 +107,7 @@ func (e endpoints) addCmds(fexec *ovntest.FakeExec, service v1.Service, endpoint
 			Output: "254.254.254.254",
 		})
 		fexec.AddFakeCmdsNoOutputNoError([]string{
+			fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=load_balancer_%d-%s\\:%v", idx, service.Spec.ClusterIP, service.Spec.Ports[0].Port),
 			fmt.Sprintf("ovn-nbctl --timeout=15 set load_balancer load_balancer_%d vips:\"%s:%v\"=\"%s:%v\"", idx, service.Spec.ClusterIP, service.Spec.Ports[0].Port, endpoint.Subsets[0].Addresses[0].IP, endpoint.Subsets[0].Ports[0].Port),
 		})
 		workerIdx := idx + 100
@@ -112,6 +116,7 @@ func (e endpoints) addCmds(fexec *ovntest.FakeExec, service v1.Service, endpoint
 			Output: fmt.Sprintf("load_balancer_%d", workerIdx),
 		})
 		fexec.AddFakeCmdsNoOutputNoError([]string{
+			fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=load_balancer_%d-%s\\:%v", workerIdx, service.Spec.ClusterIP, service.Spec.Ports[0].Port),
 			fmt.Sprintf("ovn-nbctl --timeout=15 set load_balancer load_balancer_%d vips:\"%s:%v\"=\"%s:%v\"", workerIdx, service.Spec.ClusterIP, service.Spec.Ports[0].Port, endpoint.Subsets[0].Addresses[0].IP, endpoint.Subsets[0].Ports[0].Port),
 		})
 	}
@@ -122,6 +127,17 @@ func (e endpoints) addCmds(fexec *ovntest.FakeExec, service v1.Service, endpoint
 }
 
 func (e endpoints) addExternalIPCmds(fexec *ovntest.FakeExec, loadBalancerIPs []string, service v1.Service, endpoint v1.Endpoints) {
+	// the external IPs are checked against the physical IPs of the gateway routers first
+	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+		Output: FakeGRs,
+	})
+	for _, gatewayR := range strings.Fields(FakeGRs) {
+		fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+			Cmd:    "ovn-nbctl --timeout=15 get logical_router " + gatewayR + " external_ids:physical_ips",
+			Output: "254.254.254.254",
+		})
+	}
 	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
 		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
 		Output: FakeGRs,
@@ -135,22 +151,32 @@ func (e endpoints) addExternalIPCmds(fexec *ovntest.FakeExec, loadBalancerIPs []
 			Cmd:    "ovn-nbctl --timeout=15 get logical_router " + gatewayR + " external_ids:physical_ips",
 			Output: "254.254.254.254",
 		})
-		for _, loadBalancerIP := range loadBalancerIPs {
-			fexec.AddFakeCmdsNoOutputNoError([]string{
-				fmt.Sprintf("ovn-nbctl --timeout=15 set load_balancer load_balancer_%d vips:\"%s:%v\"=\"%s:%v\"", idx, loadBalancerIP, service.Spec.Ports[0].Port, endpoint.Subsets[0].Addresses[0].IP, endpoint.Subsets[0].Ports[0].Port),
-			})
-		}
+		e.addVIPsCmds(fexec, fmt.Sprintf("load_balancer_%d", idx), loadBalancerIPs, service, endpoint)
 		workerIdx := idx + 100
 		fexec.AddFakeCmd(&ovntest.ExpectedCmd{
 			Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:" + ovntypes.WorkerLBTCP + "=" + strings.TrimPrefix(gatewayR, "GR_"),
 			Output: fmt.Sprintf("load_balancer_%d", workerIdx),
 		})
-		for _, loadBalancerIP := range loadBalancerIPs {
-			fexec.AddFakeCmdsNoOutputNoError([]string{
-				fmt.Sprintf("ovn-nbctl --timeout=15 set load_balancer load_balancer_%d vips:\"%s:%v\"=\"%s:%v\"", workerIdx, loadBalancerIP, service.Spec.Ports[0].Port, endpoint.Subsets[0].Addresses[0].IP, endpoint.Subsets[0].Ports[0].Port),
-			})
-		}
+		e.addVIPsCmds(fexec, fmt.Sprintf("load_balancer_%d", workerIdx), loadBalancerIPs, service, endpoint)
+	}
+}
+
+// addVIPsCmds adds the commands setting the VIPs of the first port of service on every IP of ips, whose
+// reject ACLs are looked up one by one before the VIPs are all set, sorted, in a single transaction
+func (e endpoints) addVIPsCmds(fexec *ovntest.FakeExec, lb string, ips []string, service v1.Service, endpoint v1.Endpoints) {
+	port := service.Spec.Ports[0].Port
+	ips = append([]string{}, ips...)
+	sort.Slice(ips, func(i, j int) bool {
+		return fmt.Sprintf("%s:%v", ips[i], port) < fmt.Sprintf("%s:%v", ips[j], port)
+	})
+	set := "ovn-nbctl --timeout=15 set load_balancer " + lb
+	for _, ip := range ips {
+		fexec.AddFakeCmdsNoOutputNoError([]string{
+			fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=%s-%s\\:%v", lb, ip, port),
+		})
+		set += fmt.Sprintf(" vips:\"%s:%v\"=\"%s:%v\"", ip, port, endpoint.Subsets[0].Addresses[0].IP, endpoint.Subsets[0].Ports[0].Port)
 	}
+	fexec.AddFakeCmdsNoOutputNoError([]string{set})
 }
 
 func (e endpoints) delCmds(fexec *ovntest.FakeExec, service v1.Service, isNodePort bool) {
@@ -286,6 +312,69 @@ var _ = ginkgo.Describe("OVN Namespace Operations", func() {
 			gomega.Expect(err).NotTo(gomega.HaveOccurred())
 		})
 
+		ginkgo.It("reconciles existing not ready endpoints of a service publishing them", func() {
+			app.Action = func(ctx *cli.Context) error {
+
+				testE := endpoints{}
+
+				readyEndpointsT := *newEndpoints("endpoint-service1", "namespace1",
+					[]v1.EndpointAddress{
+						{
+							IP: "10.125.0.2",
+						},
+					},
+					[]v1.EndpointPort{
+						{
+							Name:     "portTcp1",
+							Port:     8080,
+							Protocol: v1.ProtocolTCP,
+						},
+					})
+				endpointsT := *readyEndpointsT.DeepCopy()
+				endpointsT.Subsets[0].NotReadyAddresses = endpointsT.Subsets[0].Addresses
+				endpointsT.Subsets[0].Addresses = nil
+
+				serviceT := *newService("endpoint-service1", "namespace1", "172.124.0.2",
+					[]v1.ServicePort{
+						{
+							Name:     "portTcp1",
+							Port:     8032,
+							Protocol: v1.ProtocolTCP,
+						},
+					},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+				serviceT.Spec.PublishNotReadyAddresses = true
+
+				// the not ready address is load balanced to as if it was ready
+				testE.addCmds(tExec, serviceT, readyEndpointsT)
+
+				fakeOvn.start(ctx,
+					&v1.EndpointsList{
+						Items: []v1.Endpoints{
+							endpointsT,
+						},
+					},
+					&v1.ServiceList{
+						Items: []v1.Service{
+							serviceT,
+						},
+					},
+				)
+				fakeOvn.controller.WatchEndpoints()
+
+				_, err := fakeOvn.fakeClient.KubeClient.CoreV1().Endpoints(endpointsT.Namespace).Get(context.TODO(), endpointsT.Name, metav1.GetOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(tExec.CalledMatchesExpected()).To(gomega.BeTrue(), tExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
 		ginkgo.It("reconciles existing endpoints with ExternalIP", func() {
 			app.Action = func(ctx *cli.Context) error {
 
@@ -472,6 +561,70 @@ var _ = ginkgo.Describe("OVN Namespace Operations", func() {
 			gomega.Expect(err).NotTo(gomega.HaveOccurred())
 		})
 
+		ginkgo.It("reconciles endpoints updated to only not ready addresses as deleted endpoints", func() {
+			app.Action = func(ctx *cli.Context) error {
+
+				testE := endpoints{}
+
+				endpointsT := *newEndpoints("endpoint-service1", "namespace1",
+					[]v1.EndpointAddress{
+						{
+							IP: "10.125.0.2",
+						},
+					},
+					[]v1.EndpointPort{
+						{
+							Name:     "portTcp1",
+							Port:     8080,
+							Protocol: v1.ProtocolTCP,
+						},
+					})
+
+				serviceT := *newService("endpoint-service1", "namespace1", "172.124.0.2",
+					[]v1.ServicePort{
+						{
+							Port:     8032,
+							Protocol: v1.ProtocolTCP,
+							Name:     "portTcp1",
+						},
+					},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+				testE.addCmds(tExec, serviceT, endpointsT)
+
+				fakeOvn.start(ctx,
+					&v1.EndpointsList{
+						Items: []v1.Endpoints{
+							endpointsT,
+						},
+					},
+					&v1.ServiceList{
+						Items: []v1.Service{
+							serviceT,
+						},
+					},
+				)
+				fakeOvn.controller.WatchEndpoints()
+				gomega.Eventually(tExec.CalledMatchesExpected).Should(gomega.BeTrue(), tExec.ErrorDesc)
+
+				// The subset is kept, but its only address is not ready anymore
+				testE.delCmds(tExec, serviceT, false)
+
+				notReadyEndpointsT := endpointsT.DeepCopy()
+				notReadyEndpointsT.Subsets[0].NotReadyAddresses = notReadyEndpointsT.Subsets[0].Addresses
+				notReadyEndpointsT.Subsets[0].Addresses = nil
+				_, err := fakeOvn.fakeClient.KubeClient.CoreV1().Endpoints(endpointsT.Namespace).Update(context.TODO(), notReadyEndpointsT, metav1.UpdateOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Eventually(tExec.CalledMatchesExpected).Should(gomega.BeTrue(), tExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
 		ginkgo.It("reconciles deleted NodePort endpoints", func() {
 			app.Action = func(ctx *cli.Context) error {
 
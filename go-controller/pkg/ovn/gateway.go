@@ -1,6 +1,7 @@
 package ovn
 
 import (
+	"context"
 	"fmt"
 	"net"
 
@@ -11,7 +12,10 @@ import (
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 
 	kapi "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
+	utilnet "k8s.io/utils/net"
 )
 
 func (ovn *Controller) getOvnGateways() ([]string, string, error) {
@@ -31,39 +35,70 @@ func getGatewayLoadBalancers(gatewayRouter string) (string, string, string, erro
 	return gateway.GetGatewayLoadBalancers(gatewayRouter)
 }
 
+// filterPhysicalIPsByFamily returns the physical IPs of gatewayRouter of the address family of
+// one of familyIPs, or every valid physical IP when familyIPs is empty. A gateway router of a
+// single stack node lacks the physical IP of the other family, so a VIP of that family has no
+// place on it. Malformed physical IPs are skipped.
+func filterPhysicalIPsByFamily(gatewayRouter string, physicalIPs, familyIPs []string) []string {
+	var hasIPv4, hasIPv6 bool
+	for _, ip := range familyIPs {
+		if utilnet.IsIPv6String(ip) {
+			hasIPv6 = true
+		} else {
+			hasIPv4 = true
+		}
+	}
+	filtered := make([]string, 0, len(physicalIPs))
+	for _, physicalIP := range physicalIPs {
+		ip := net.ParseIP(physicalIP)
+		if ip == nil {
+			klog.V(5).Infof("Skipping malformed physical IP %q of gateway router %s", physicalIP, gatewayRouter)
+			continue
+		}
+		if len(familyIPs) > 0 && !(hasIPv6 && utilnet.IsIPv6(ip)) && !(hasIPv4 && !utilnet.IsIPv6(ip)) {
+			klog.V(5).Infof("Skipping physical IP %s of gateway router %s: no VIP of its IP family",
+				physicalIP, gatewayRouter)
+			continue
+		}
+		filtered = append(filtered, physicalIP)
+	}
+	return filtered
+}
+
 // createPerNodeVIPs adds load balancers on a per node basis for GR and worker switch LBs
 // if empty svcIP is provided, then the physical IPs will be used for the node
-func (ovn *Controller) createPerNodeVIPs(svcIPs []string, protocol kapi.Protocol, sourcePort int32, targetIPs []string, targetPort int32) error {
+func (ovn *Controller) createPerNodeVIPs(ctx context.Context, svcIPs []string, protocol kapi.Protocol, sourcePort int32, targetIPs []string, targetPort int32) error {
 	klog.V(5).Infof("Creating Node VIPs - %s, %d, [%v], %d", protocol, sourcePort, targetIPs, targetPort)
 	// Each gateway has a separate load-balancer for N/S traffic
-	gatewayRouters, _, err := ovn.getOvnGateways()
+	gatewayRouters, _, err := ovn.lbOps.GetOvnGateways()
 	if err != nil {
 		return err
 	}
 
 	for _, gatewayRouter := range gatewayRouters {
-		gatewayLB, err := ovn.getGatewayLoadBalancer(gatewayRouter, protocol)
+		gatewayLB, err := ovn.lbOps.GetGatewayLoadBalancer(gatewayRouter, protocol)
 		if err != nil {
 			klog.Errorf("Gateway router %s does not have load balancer (%v)",
 				gatewayRouter, err)
 			continue
 		}
-		physicalIPs, err := ovn.getGatewayPhysicalIPs(gatewayRouter)
+		physicalIPs, err := ovn.lbOps.GetGatewayPhysicalIPs(gatewayRouter)
 		if err != nil {
 			klog.Errorf("Gateway router %s does not have physical ip (%v)", gatewayRouter, err)
 			continue
 		}
 
-		vips := physicalIPs
-		if len(svcIPs) > 0 {
-			vips = svcIPs
+		vips := svcIPs
+		if len(vips) == 0 {
+			// only the physical IPs of the family of the targets can reach them
+			vips = filterPhysicalIPsByFamily(gatewayRouter, physicalIPs, targetIPs)
 		}
 		// If self ip is in target list, we need to use special IP to allow hairpin back to host
 		newTargets := util.UpdateIPsSlice(targetIPs, physicalIPs, []string{types.V4HostMasqueradeIP, types.V6HostMasqueradeIP})
 
 		// With the physical_ip:sourcePort as the VIP, add an entry in
 		// 'load_balancer'.
-		err = ovn.createLoadBalancerVIPs(gatewayLB, vips, sourcePort, newTargets, targetPort)
+		err = ovn.lbOps.EnsureVIP(ctx, gatewayLB, vips, sourcePort, newTargets, targetPort)
 		if err != nil {
 			klog.Errorf("Failed to create VIP in load balancer %s - %v", gatewayLB, err)
 			continue
@@ -71,12 +106,12 @@ func (ovn *Controller) createPerNodeVIPs(svcIPs []string, protocol kapi.Protocol
 
 		if config.Gateway.Mode == config.GatewayModeShared {
 			workerNode := util.GetWorkerFromGatewayRouter(gatewayRouter)
-			workerLB, err := loadbalancer.GetWorkerLoadBalancer(workerNode, protocol)
+			workerLB, err := ovn.lbOps.GetWorkerLoadBalancer(workerNode, protocol)
 			if err != nil {
 				klog.Errorf("Worker switch %s does not have load balancer (%v)", workerNode, err)
 				continue
 			}
-			err = ovn.createLoadBalancerVIPs(workerLB, vips, sourcePort, targetIPs, targetPort)
+			err = ovn.lbOps.EnsureVIP(ctx, workerLB, vips, sourcePort, targetIPs, targetPort)
 			if err != nil {
 				klog.Errorf("Failed to create VIP in load balancer %s - %v", workerLB, err)
 				continue
@@ -88,9 +123,9 @@ func (ovn *Controller) createPerNodeVIPs(svcIPs []string, protocol kapi.Protocol
 
 // deleteNodeVIPs removes load balancers on a per node basis for GR and worker switch LBs
 // if empty svcIP is provided, then the physical IPs will be used for the node
-func (ovn *Controller) deleteNodeVIPs(svcIPs []string, protocol kapi.Protocol, sourcePort int32) {
+func (ovn *Controller) deleteNodeVIPs(ctx context.Context, svcIPs []string, protocol kapi.Protocol, sourcePort int32) {
 	klog.V(5).Infof("Searching to remove Gateway VIPs - %s, %d", protocol, sourcePort)
-	gatewayRouters, _, err := ovn.getOvnGateways()
+	gatewayRouters, _, err := ovn.lbOps.GetOvnGateways()
 	if err != nil {
 		klog.Errorf("Error while searching for gateways: %v", err)
 		return
@@ -98,14 +133,14 @@ func (ovn *Controller) deleteNodeVIPs(svcIPs []string, protocol kapi.Protocol, s
 
 	for _, gatewayRouter := range gatewayRouters {
 		var loadBalancers []string
-		gatewayLB, err := ovn.getGatewayLoadBalancer(gatewayRouter, protocol)
+		gatewayLB, err := ovn.lbOps.GetGatewayLoadBalancer(gatewayRouter, protocol)
 		if err != nil {
 			klog.Errorf("Gateway router %s does not have load balancer (%v)", gatewayRouter, err)
 			continue
 		}
 		physicalIPs := svcIPs
 		if len(physicalIPs) == 0 {
-			physicalIPs, err = ovn.getGatewayPhysicalIPs(gatewayRouter)
+			physicalIPs, err = ovn.lbOps.GetGatewayPhysicalIPs(gatewayRouter)
 			if err != nil {
 				klog.Errorf("Gateway router %s does not have physical ip (%v)", gatewayRouter, err)
 				continue
@@ -114,19 +149,20 @@ func (ovn *Controller) deleteNodeVIPs(svcIPs []string, protocol kapi.Protocol, s
 		loadBalancers = append(loadBalancers, gatewayLB)
 		if config.Gateway.Mode == config.GatewayModeShared {
 			workerNode := util.GetWorkerFromGatewayRouter(gatewayRouter)
-			workerLB, err := loadbalancer.GetWorkerLoadBalancer(workerNode, protocol)
+			workerLB, err := ovn.lbOps.GetWorkerLoadBalancer(workerNode, protocol)
 			if err != nil {
+				// still clean up the gateway load balancer, along with its reject ACLs
 				klog.Errorf("Worker switch %s does not have load balancer (%v)", workerNode, err)
-				continue
+			} else {
+				loadBalancers = append(loadBalancers, workerLB)
 			}
-			loadBalancers = append(loadBalancers, workerLB)
 		}
 		for _, loadBalancer := range loadBalancers {
 			for _, physicalIP := range physicalIPs {
 				// With the physical_ip:sourcePort as the VIP, delete an entry in 'load_balancer'.
 				vip := util.JoinHostPortInt32(physicalIP, sourcePort)
 				klog.V(5).Infof("Removing gateway VIP: %s from load balancer: %s", vip, loadBalancer)
-				if err := ovn.deleteLoadBalancerVIP(loadBalancer, vip); err != nil {
+				if err := ovn.lbOps.RemoveVIP(ctx, loadBalancer, vip); err != nil {
 					klog.Error(err)
 				}
 			}
@@ -134,54 +170,154 @@ func (ovn *Controller) deleteNodeVIPs(svcIPs []string, protocol kapi.Protocol, s
 	}
 }
 
-func (ovn *Controller) deleteExternalVIPs(service *kapi.Service, svcPort kapi.ServicePort) error {
-	if len(service.Spec.ExternalIPs) == 0 {
-		return nil
-	}
-	gateways, stderr, err := ovn.getOvnGateways()
+// createHealthCheckNodePortVIPs adds a VIP for the health check NodePort of a service on the
+// TCP load balancer of every gateway router. The VIP forwards the probes of cloud load balancers
+// to the health check server of the node, which only answers successfully when the node has
+// local endpoints for the service.
+func (ovn *Controller) createHealthCheckNodePortVIPs(ctx context.Context, service *kapi.Service) error {
+	port := service.Spec.HealthCheckNodePort
+	gatewayRouters, _, err := ovn.lbOps.GetOvnGateways()
 	if err != nil {
-		return fmt.Errorf("error: failed to get ovn gateways, stderr: %s, err: %v)", stderr, err)
+		return err
 	}
-	for _, extIP := range service.Spec.ExternalIPs {
-		klog.V(5).Infof("Searching to remove ExternalIP VIPs - %s, %d", svcPort.Protocol, svcPort.Port)
-		for _, gateway := range gateways {
-			loadBalancer, err := ovn.getGatewayLoadBalancer(gateway, svcPort.Protocol)
-			if err != nil {
-				klog.Errorf("Gateway router: %s does not have load balancer, err: %v", gateway, err)
-				continue
-			}
-			vip := util.JoinHostPortInt32(extIP, svcPort.Port)
-			if err := ovn.deleteLoadBalancerVIP(loadBalancer, vip); err != nil {
-				klog.Error(err)
-			}
+	for _, gatewayRouter := range gatewayRouters {
+		loadBalancer, err := ovn.lbOps.GetGatewayLoadBalancer(gatewayRouter, kapi.ProtocolTCP)
+		if err != nil {
+			klog.Errorf("Gateway router %s does not have load balancer (%v)", gatewayRouter, err)
+			continue
+		}
+		physicalIPs, err := ovn.lbOps.GetGatewayPhysicalIPs(gatewayRouter)
+		if err != nil {
+			klog.Errorf("Gateway router %s does not have physical ip (%v)", gatewayRouter, err)
+			continue
+		}
+		// The health check server listens on the node itself, which the gateway
+		// router reaches through the host masquerade IPs
+		err = ovn.createLoadBalancerVIPs(ctx, loadBalancer, physicalIPs, port,
+			[]string{types.V4HostMasqueradeIP, types.V6HostMasqueradeIP}, port)
+		if err != nil {
+			return fmt.Errorf("failed to create health check VIP for service %s/%s on gateway router %s: %v",
+				service.Namespace, service.Name, gatewayRouter, err)
 		}
 	}
 	return nil
 }
 
-func (ovn *Controller) deleteIngressVIPs(service *kapi.Service, svcPort kapi.ServicePort) error {
-	gateways, stderr, err := ovn.getOvnGateways()
+// reconcileGatewayNodePortVIPs makes the NodePort VIPs on the load balancers of gatewayRouter
+// match its current physical IPs: VIPs of physical IPs the gateway no longer has are removed
+// and the VIPs of new physical IPs are added for every NodePort service. The NodePorts skipped
+// when the services were created, outside of the node port range or allocated to another
+// service, stay skipped, and a service only gets the physical IPs of its IP families.
+func (ovn *Controller) reconcileGatewayNodePortVIPs(gatewayRouter string) error {
+	ovn.serviceReconcileLock.Lock()
+	defer ovn.serviceReconcileLock.Unlock()
+
+	// stop reconciling the VIPs once the controller is stopped
+	ctx, cancel := ovn.stopContext()
+	defer cancel()
+
+	physicalIPs, err := ovn.lbOps.GetGatewayPhysicalIPs(gatewayRouter)
+	if err != nil {
+		return fmt.Errorf("gateway router %s does not have physical ip (%v)", gatewayRouter, err)
+	}
+	services, err := ovn.watchFactory.GetServices()
 	if err != nil {
-		return fmt.Errorf("error: failed to get ovn gateways, stderr: %s, err: %v)", stderr, err)
+		return fmt.Errorf("failed to get k8s services: %v", err)
 	}
-	for _, ing := range service.Status.LoadBalancer.Ingress {
-		if ing.IP == "" {
+
+	// IPs owned by services may also be VIPs on the gateway load balancers and
+	// must not be mistaken for a stale physical IP
+	serviceIPs := sets.NewString()
+	for _, service := range services {
+		serviceIPs.Insert(service.Spec.ClusterIP)
+		serviceIPs.Insert(service.Spec.ExternalIPs...)
+		serviceIPs.Insert(svcIngressIPs(service)...)
+	}
+	currentIPs := sets.NewString(physicalIPs...)
+
+	var errs []error
+	for _, protocol := range ovn.supportedServiceProtocols() {
+		loadBalancer, err := ovn.lbOps.GetGatewayLoadBalancer(gatewayRouter, protocol)
+		if err != nil {
+			klog.V(5).Infof("Gateway router %s does not have %s load balancer (%v)", gatewayRouter, protocol, err)
 			continue
 		}
-		klog.V(5).Infof("Searching to remove Ingress VIPs - %s, %d", svcPort.Protocol, svcPort.Port)
-		ingressVIP := util.JoinHostPortInt32(ing.IP, svcPort.Port)
-		for _, gw := range gateways {
-			loadBalancer, err := ovn.getGatewayLoadBalancer(gw, svcPort.Protocol)
+		vips, err := loadbalancer.GetLoadBalancerVIPs(loadBalancer)
+		if err != nil && vips == nil {
+			errs = append(errs, fmt.Errorf("failed to get load balancer vips for %s (%v)", loadBalancer, err))
+			continue
+		}
+		if err != nil {
+			klog.Errorf("Ignoring the malformed vips of load balancer %s: %v", loadBalancer, err)
+		}
+
+		nodePorts := sets.NewString()
+		for _, service := range services {
+			if !util.ServiceTypeHasNodePort(service) || !util.IsClusterIPSet(service) || svcSkipsLoadBalancing(service) {
+				continue
+			}
+			var lbEps map[string]lbEndpoints
+			if ep, err := ovn.watchFactory.GetEndpoint(service.Namespace, service.Name); err == nil {
+				lbEps = getLbEndpoints(ep, service, ovn.endpointTerminating)[protocol]
+			}
+			familyIPs := filterPhysicalIPsByFamily(gatewayRouter, physicalIPs,
+				svcFamilyIPs(service, util.GetClusterIPs(service)))
+			for _, svcPort := range service.Spec.Ports {
+				if svcPort.Protocol != protocol || !util.ServicePortHasNodePort(service, &svcPort) {
+					continue
+				}
+				if err := util.ValidatePort(svcPort.Protocol, svcPort.NodePort); err != nil ||
+					!config.Kubernetes.NodePortRange.Contains(int(svcPort.NodePort)) {
+					continue
+				}
+				nodePorts.Insert(fmt.Sprintf("%d", svcPort.NodePort))
+				if !ovn.ownsNodePort(service, protocol, svcPort.NodePort) {
+					continue
+				}
+				var missing []string
+				for _, physicalIP := range familyIPs {
+					if _, ok := vips[util.JoinHostPortInt32(physicalIP, svcPort.NodePort)]; !ok {
+						missing = append(missing, physicalIP)
+					}
+				}
+				if len(missing) == 0 {
+					continue
+				}
+				klog.Infof("Adding NodePort %d VIPs of physical IPs %v to load balancer %s of gateway router %s",
+					svcPort.NodePort, missing, loadBalancer, gatewayRouter)
+				if eps, ok := lbEps[svcPort.Name]; ok {
+					if err := ovn.createPerNodeVIPs(ctx, nil, protocol, svcPort.NodePort, eps.IPs, eps.Port); err != nil {
+						errs = append(errs, err)
+					}
+				} else if action := svcEmptyServiceACLAction(service); action != "" {
+					aclDenyLogging, aclMeter := ovn.getRejectACLLogging(service.Namespace)
+					for _, physicalIP := range missing {
+						if _, err := ovn.lbOps.EnsureRejectACL(ctx, loadBalancer, physicalIP, svcPort.NodePort,
+							protocol, aclDenyLogging, aclMeter, action); err != nil {
+							errs = append(errs, err)
+						}
+					}
+				}
+			}
+		}
+
+		for vip := range vips {
+			ip, port, err := net.SplitHostPort(vip)
 			if err != nil {
-				klog.Errorf("Gateway router %s does not have load balancer (%v)", gw, err)
+				klog.Errorf("Failed to split %s to vip and port (%v)", vip, err)
 				continue
 			}
-			if err := ovn.deleteLoadBalancerVIP(loadBalancer, ingressVIP); err != nil {
-				klog.Error(err)
+			if !nodePorts.Has(port) || currentIPs.Has(ip) || serviceIPs.Has(ip) {
+				continue
+			}
+			klog.Infof("Deleting stale NodePort vip %s from load balancer %s of gateway router %s",
+				vip, loadBalancer, gatewayRouter)
+			if err := ovn.lbOps.RemoveVIP(ctx, loadBalancer, vip); err != nil {
+				errs = append(errs, err)
 			}
 		}
 	}
-	return nil
+	return utilerrors.NewAggregate(errs)
 }
 
 // getJoinLRPAddresses check if IPs of gateway logical router port are within the join switch IP range, and return them if true.
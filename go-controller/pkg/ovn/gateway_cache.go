@@ -0,0 +1,85 @@
+package ovn
+
+import (
+	"sync"
+
+	kapi "k8s.io/api/core/v1"
+)
+
+// gatewayCache memoizes the gateway routers, their load balancers and their physical IPs, errors
+// included, on top of a ServiceLBBackend. The gateway topology does not change during a single
+// reconcile, like a sync of the services, so each reconcile creates its own cache and drops it once
+// done: it must not outlive the reconcile, or topology changes would be missed. The other operations
+// are passed through. It is safe for concurrent use.
+type gatewayCache struct {
+	ServiceLBBackend
+
+	sync.Mutex
+	gatewayRouters *gatewayRoutersResult
+	loadBalancers  map[string]map[kapi.Protocol]*loadBalancerResult
+	physicalIPs    map[string]*physicalIPsResult
+}
+
+type gatewayRoutersResult struct {
+	gatewayRouters []string
+	stderr         string
+	err            error
+}
+
+type loadBalancerResult struct {
+	loadBalancer string
+	err          error
+}
+
+type physicalIPsResult struct {
+	physicalIPs []string
+	err         error
+}
+
+var _ ServiceLBBackend = &gatewayCache{}
+
+// newGatewayCache returns an empty gatewayCache on top of ops, for a single reconcile
+func newGatewayCache(ops ServiceLBBackend) *gatewayCache {
+	return &gatewayCache{
+		ServiceLBBackend: ops,
+		loadBalancers:    make(map[string]map[kapi.Protocol]*loadBalancerResult),
+		physicalIPs:      make(map[string]*physicalIPsResult),
+	}
+}
+
+func (c *gatewayCache) GetOvnGateways() ([]string, string, error) {
+	c.Lock()
+	defer c.Unlock()
+	if c.gatewayRouters == nil {
+		gatewayRouters, stderr, err := c.ServiceLBBackend.GetOvnGateways()
+		c.gatewayRouters = &gatewayRoutersResult{gatewayRouters, stderr, err}
+	}
+	return c.gatewayRouters.gatewayRouters, c.gatewayRouters.stderr, c.gatewayRouters.err
+}
+
+func (c *gatewayCache) GetGatewayLoadBalancer(gatewayRouter string, protocol kapi.Protocol) (string, error) {
+	c.Lock()
+	defer c.Unlock()
+	if c.loadBalancers[gatewayRouter] == nil {
+		c.loadBalancers[gatewayRouter] = make(map[kapi.Protocol]*loadBalancerResult)
+	}
+	result, ok := c.loadBalancers[gatewayRouter][protocol]
+	if !ok {
+		loadBalancer, err := c.ServiceLBBackend.GetGatewayLoadBalancer(gatewayRouter, protocol)
+		result = &loadBalancerResult{loadBalancer, err}
+		c.loadBalancers[gatewayRouter][protocol] = result
+	}
+	return result.loadBalancer, result.err
+}
+
+func (c *gatewayCache) GetGatewayPhysicalIPs(gatewayRouter string) ([]string, error) {
+	c.Lock()
+	defer c.Unlock()
+	result, ok := c.physicalIPs[gatewayRouter]
+	if !ok {
+		physicalIPs, err := c.ServiceLBBackend.GetGatewayPhysicalIPs(gatewayRouter)
+		result = &physicalIPsResult{physicalIPs, err}
+		c.physicalIPs[gatewayRouter] = result
+	}
+	return result.physicalIPs, result.err
+}
@@ -136,7 +136,8 @@ func gatewayInit(nodeName string, clusterIPSubnet []*net.IPNet, hostSubnets []*n
 		kapi.ProtocolUDP:  k8sNSLbUDP,
 		kapi.ProtocolSCTP: k8sNSLbSCTP,
 	}
-	workerK8sNSLbTCP, workerK8sNSLbUDP, workerK8sNSLbSCTP, err := loadbalancer.GetWorkerLoadBalancers(nodeName)
+	// Create load balancers for workers (to be applied to GR and node switch)
+	workerK8sNSLbTCP, workerK8sNSLbUDP, workerK8sNSLbSCTP, err := loadbalancer.EnsureWorkerLoadBalancers(nodeName, sctpSupport)
 	if err != nil {
 		return err
 	}
@@ -164,6 +165,16 @@ func gatewayInit(nodeName string, clusterIPSubnet []*net.IPNet, hostSubnets []*n
 						"stderr: %q, error: %v", gatewayRouter, proto, stderr, err)
 				}
 			}
+			if err := loadbalancer.SetLoadBalancerSelectionFields(gatewayProtoLBMap[proto],
+				config.Kubernetes.LBSelectionFields); err != nil {
+				return fmt.Errorf("failed to set the selection fields of the load balancer of gateway router %s "+
+					"for protocol %s: %v", gatewayRouter, proto, err)
+			}
+			if err := loadbalancer.SetLoadBalancerOption(gatewayProtoLBMap[proto],
+				loadbalancer.LoadBalancerOptionNeighborResponder, config.Gateway.LBNeighborResponder); err != nil {
+				return fmt.Errorf("failed to set the neighbor responder of the load balancer of gateway router %s "+
+					"for protocol %s: %v", gatewayRouter, proto, err)
+			}
 		}
 
 		// Local gateway mode does not use GR for ingress node port traffic, it uses mp0 instead
@@ -199,20 +210,6 @@ func gatewayInit(nodeName string, clusterIPSubnet []*net.IPNet, hostSubnets []*n
 		}
 	}
 
-	// Create load balancers for workers (to be applied to GR and node switch)
-	for _, proto := range enabledProtos {
-		if workerProtoLBMap[proto] == "" {
-			workerProtoLBMap[proto], stderr, err = util.RunOVNNbctl("--", "create",
-				"load_balancer",
-				fmt.Sprintf("external_ids:%s-%s=%s", types.WorkerLBPrefix, strings.ToLower(string(proto)), nodeName),
-				fmt.Sprintf("protocol=%s", strings.ToLower(string(proto))))
-			if err != nil {
-				return fmt.Errorf("failed to create load balancer for worker node %s for protocol %s: "+
-					"stderr: %q, error: %v", nodeName, proto, stderr, err)
-			}
-		}
-	}
-
 	if config.Gateway.Mode != config.GatewayModeLocal {
 		// Ensure north-south load-balancers are not on local switches for pod -> nodePort traffic
 		// For upgrade path REMOVEME later
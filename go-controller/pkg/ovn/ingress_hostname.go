@@ -0,0 +1,202 @@
+package ovn
+
+import (
+	"context"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	kapi "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	utilwait "k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// ingressHostnameTTL is how long the IPs of a load balancer ingress hostname are used before
+	// the hostname is resolved again
+	ingressHostnameTTL = 5 * time.Minute
+
+	// maxIngressHostnames is the number of ingress hostnames whose IPs are cached
+	maxIngressHostnames = 1024
+)
+
+// ingressHostnames resolves the hostnames some cloud providers set on the load balancer ingresses
+// of services instead of IPs
+var ingressHostnames = newIngressHostnameResolver(net.LookupHost)
+
+// ingressHostnameResolver caches the IPs of load balancer ingress hostnames. A hostname is only
+// resolved when it is first asked for and then by Refresh, so that the IPs the VIPs of a service
+// were programmed with are known when they change.
+type ingressHostnameResolver struct {
+	sync.Mutex
+	// lookup resolves a hostname to its addresses
+	lookup func(hostname string) ([]string, error)
+	// IPs of each hostname, sorted
+	cache map[string][]string
+}
+
+func newIngressHostnameResolver(lookup func(hostname string) ([]string, error)) *ingressHostnameResolver {
+	return &ingressHostnameResolver{
+		lookup: lookup,
+		cache:  make(map[string][]string),
+	}
+}
+
+// resolve looks hostname up and returns its sorted IPs, leaving out the addresses that are not IPs
+func (r *ingressHostnameResolver) resolve(hostname string) ([]string, error) {
+	addrs, err := r.lookup(hostname)
+	if err != nil {
+		return nil, err
+	}
+	ips := sets.NewString()
+	for _, addr := range addrs {
+		if ip := net.ParseIP(addr); ip != nil {
+			ips.Insert(ip.String())
+		}
+	}
+	return ips.List(), nil
+}
+
+// IPs returns the IPs of hostname, resolving it when it is not cached yet. A hostname that fails
+// to resolve has no IPs, and is not cached so that it is resolved again next time. Once the cache
+// is full, new hostnames are resolved every time.
+func (r *ingressHostnameResolver) IPs(hostname string) []string {
+	r.Lock()
+	ips, ok := r.cache[hostname]
+	r.Unlock()
+	if ok {
+		return append([]string{}, ips...)
+	}
+
+	ips, err := r.resolve(hostname)
+	if err != nil {
+		klog.Warningf("Failed to resolve load balancer ingress hostname %s: %v", hostname, err)
+		return nil
+	}
+	r.Lock()
+	defer r.Unlock()
+	if cached, ok := r.cache[hostname]; ok {
+		// resolved concurrently, keep the IPs that may already be programmed
+		return append([]string{}, cached...)
+	}
+	if len(r.cache) >= maxIngressHostnames {
+		klog.Warningf("Not caching the IPs of load balancer ingress hostname %s: %d hostnames are cached already",
+			hostname, len(r.cache))
+		return ips
+	}
+	r.cache[hostname] = ips
+	return append([]string{}, ips...)
+}
+
+// Refresh drops the cached hostnames missing from hostnames and resolves the other ones again. It
+// returns the hostnames whose IPs changed, along with the IPs they no longer resolve to. A
+// hostname that fails to resolve keeps its IPs.
+func (r *ingressHostnameResolver) Refresh(hostnames sets.String) map[string][]string {
+	r.Lock()
+	cached := make(map[string][]string)
+	for hostname, ips := range r.cache {
+		if !hostnames.Has(hostname) {
+			delete(r.cache, hostname)
+			continue
+		}
+		cached[hostname] = ips
+	}
+	r.Unlock()
+
+	changed := make(map[string][]string)
+	for hostname, oldIPs := range cached {
+		ips, err := r.resolve(hostname)
+		if err != nil {
+			klog.Warningf("Failed to resolve load balancer ingress hostname %s again, keeping IPs %v: %v",
+				hostname, oldIPs, err)
+			continue
+		}
+		if sets.NewString(ips...).Equal(sets.NewString(oldIPs...)) {
+			continue
+		}
+		klog.Infof("Load balancer ingress hostname %s resolves to %v instead of %v", hostname, ips, oldIPs)
+		changed[hostname] = sets.NewString(oldIPs...).Difference(sets.NewString(ips...)).List()
+		r.Lock()
+		r.cache[hostname] = ips
+		r.Unlock()
+	}
+	return changed
+}
+
+// startIngressHostnameRefresh resolves the load balancer ingress hostnames of the services again
+// every ingressHostnameTTL until the controller is stopped
+func (ovn *Controller) startIngressHostnameRefresh() {
+	go utilwait.Until(ovn.refreshIngressHostnames, ingressHostnameTTL, ovn.stopChan)
+}
+
+// refreshIngressHostnames resolves the load balancer ingress hostnames of the services again. The
+// VIPs of the IPs a hostname no longer resolves to are removed, and the services using a hostname
+// whose IPs changed are reconciled to program the new ones.
+func (ovn *Controller) refreshIngressHostnames() {
+	services, err := ovn.watchFactory.GetServices()
+	if err != nil {
+		klog.Errorf("Failed to list the services to refresh their ingress hostnames: %v", err)
+		return
+	}
+	hostnames := sets.NewString()
+	for _, service := range services {
+		hostnames.Insert(svcIngressHostnames(service)...)
+	}
+	changed := ingressHostnames.Refresh(hostnames)
+	if len(changed) == 0 {
+		return
+	}
+
+	sort.Slice(services, func(i, j int) bool { return svcKey(services[i]) < svcKey(services[j]) })
+	for _, service := range services {
+		if !svcHasVIPs(service) || svcSkipsLoadBalancing(service) {
+			continue
+		}
+		affected := false
+		removed := sets.NewString()
+		for _, hostname := range svcIngressHostnames(service) {
+			if ips, ok := changed[hostname]; ok {
+				affected = true
+				removed.Insert(ips...)
+			}
+		}
+		if !affected {
+			continue
+		}
+		klog.Infof("Reconciling service %s: its ingress hostnames resolve to other IPs", svcKey(service))
+		if removed.Len() > 0 {
+			ctx, cancel := ovn.stopContext()
+			ovn.deleteIngressIPVIPs(ctx, service, removed.List())
+			cancel()
+		}
+		if err := ovn.ReconcileService(service.Namespace, service.Name); err != nil {
+			klog.Errorf("Failed to reconcile service %s for its ingress hostnames: %v", svcKey(service), err)
+		}
+	}
+}
+
+// deleteIngressIPVIPs removes the VIPs of the ingress IPs ips of every port of service, along with
+// their reject ACLs
+func (ovn *Controller) deleteIngressIPVIPs(ctx context.Context, service *kapi.Service, ips []string) {
+	for _, svcPort := range service.Spec.Ports {
+		if !ovn.serviceProtocolSupported(svcPort.Protocol) {
+			continue
+		}
+		if err := ovn.deleteServiceVIPs(ctx, ips, svcPort.Protocol, svcPort.Port); err != nil {
+			klog.Errorf("Failed to remove the VIPs of ingress IPs %v of service %s: %v", ips, svcKey(service), err)
+		}
+	}
+}
+
+// svcIngressHostnames returns the hostnames of the load balancer ingresses of service without an IP
+func svcIngressHostnames(service *kapi.Service) []string {
+	var hostnames []string
+	for _, ing := range service.Status.LoadBalancer.Ingress {
+		if ing.IP == "" && ing.Hostname != "" {
+			hostnames = append(hostnames, ing.Hostname)
+		}
+	}
+	return hostnames
+}
@@ -0,0 +1,44 @@
+package ovn
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestIngressHostnameResolver(t *testing.T) {
+	answers := map[string][]string{
+		"lb1.example.com": {"5.5.5.6", "5.5.5.5", "not-an-ip"},
+		"lb2.example.com": {"6.6.6.6"},
+	}
+	lookups := 0
+	r := newIngressHostnameResolver(func(hostname string) ([]string, error) {
+		lookups++
+		if ips, ok := answers[hostname]; ok {
+			return ips, nil
+		}
+		return nil, fmt.Errorf("no such host %s", hostname)
+	})
+
+	// the hostnames are resolved once and cached
+	assert.Equal(t, []string{"5.5.5.5", "5.5.5.6"}, r.IPs("lb1.example.com"))
+	assert.Equal(t, []string{"5.5.5.5", "5.5.5.6"}, r.IPs("lb1.example.com"))
+	assert.Equal(t, []string{"6.6.6.6"}, r.IPs("lb2.example.com"))
+	assert.Equal(t, 2, lookups)
+	assert.Empty(t, r.IPs("unknown.example.com"))
+
+	// a refresh reports the IPs a hostname no longer resolves to
+	answers["lb1.example.com"] = []string{"5.5.5.5", "5.5.5.7"}
+	changed := r.Refresh(sets.NewString("lb1.example.com", "lb2.example.com"))
+	assert.Equal(t, map[string][]string{"lb1.example.com": {"5.5.5.6"}}, changed)
+	assert.Equal(t, []string{"5.5.5.5", "5.5.5.7"}, r.IPs("lb1.example.com"))
+
+	// a hostname failing to resolve keeps its IPs, and a hostname no service uses is dropped
+	delete(answers, "lb1.example.com")
+	changed = r.Refresh(sets.NewString("lb1.example.com"))
+	assert.Empty(t, changed)
+	assert.Equal(t, []string{"5.5.5.5", "5.5.5.7"}, r.IPs("lb1.example.com"))
+	assert.NotContains(t, r.cache, "lb2.example.com")
+}
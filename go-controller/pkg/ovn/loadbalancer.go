@@ -1,21 +1,29 @@
 package ovn
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net"
+	"strconv"
 	"strings"
 
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/loadbalancer"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 
 	kapi "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
 	utilnet "k8s.io/utils/net"
 )
 
 func (ovn *Controller) getLoadBalancer(protocol kapi.Protocol) (string, error) {
-	if outStr, ok := ovn.loadbalancerClusterCache[protocol]; ok {
+	ovn.loadbalancerClusterCacheLock.RLock()
+	outStr, ok := ovn.loadbalancerClusterCache[protocol]
+	ovn.loadbalancerClusterCacheLock.RUnlock()
+	if ok {
 		return outStr, nil
 	}
 
@@ -34,7 +42,9 @@ func (ovn *Controller) getLoadBalancer(protocol kapi.Protocol) (string, error) {
 	if out == "" {
 		return "", fmt.Errorf("no load balancer found in the database")
 	}
+	ovn.loadbalancerClusterCacheLock.Lock()
 	ovn.loadbalancerClusterCache[protocol] = out
+	ovn.loadbalancerClusterCacheLock.Unlock()
 	return out, nil
 }
 
@@ -62,9 +72,9 @@ func (ovn *Controller) getLoadBalancerVIPs(loadBalancer string) (map[string]inte
 }
 
 // deleteLoadBalancerVIP removes the VIP as well as any reject ACLs associated to the LB
-func (ovn *Controller) deleteLoadBalancerVIP(loadBalancer, vip string) error {
+func (ovn *Controller) deleteLoadBalancerVIP(ctx context.Context, loadBalancer, vip string) error {
 	vipQuotes := fmt.Sprintf("\"%s\"", vip)
-	stdout, stderr, err := util.RunOVNNbctl("--if-exists", "remove", "load_balancer", loadBalancer, "vips", vipQuotes)
+	stdout, stderr, err := loadbalancer.RunMutatingOVNNbctlContext(ctx, "--if-exists", "remove", "load_balancer", loadBalancer, "vips", vipQuotes)
 	if err != nil {
 		// if we hit an error and fail to remove load balancer, we skip removing the rejectACL
 		return fmt.Errorf("error in deleting load balancer vip %s for %s"+
@@ -72,40 +82,90 @@ func (ovn *Controller) deleteLoadBalancerVIP(loadBalancer, vip string) error {
 			vip, loadBalancer, stdout, stderr, err)
 	}
 	ovn.removeServiceEndpoints(loadBalancer, vip)
-	ovn.deleteLoadBalancerRejectACL(loadBalancer, vip)
+	ovn.deleteLoadBalancerRejectACL(ctx, loadBalancer, vip)
 	ovn.removeServiceLB(loadBalancer, vip)
 	return nil
 }
 
+// deleteLoadBalancerVIPs removes every VIP of vips from loadBalancer, along with their reject
+// ACLs, in a single transaction, so that none of the ACLs outlives its VIP
+func (ovn *Controller) deleteLoadBalancerVIPs(ctx context.Context, loadBalancer string, vips []string) error {
+	if len(vips) == 0 {
+		return nil
+	}
+	args := []string{"--if-exists", "remove", "load_balancer", loadBalancer, "vips"}
+	var txn []string
+	var rejectRemoved []string
+	for _, vip := range vips {
+		args = append(args, fmt.Sprintf("\"%s\"", vip))
+		if removal := ovn.rejectACLRemovalArgs(loadBalancer, vip); len(removal) > 0 {
+			txn = append(txn, removal...)
+			rejectRemoved = append(rejectRemoved, vip)
+		}
+	}
+	stdout, stderr, err := loadbalancer.RunMutatingOVNNbctlContext(ctx, append(args, txn...)...)
+	if err != nil {
+		return fmt.Errorf("error in deleting load balancer vips %v for %s "+
+			"stdout: %q, stderr: %q, error: %v",
+			vips, loadBalancer, stdout, stderr, err)
+	}
+	for _, vip := range rejectRemoved {
+		ovn.removeServiceACL(loadBalancer, vip)
+	}
+	for _, vip := range vips {
+		ovn.removeServiceEndpoints(loadBalancer, vip)
+		ovn.removeServiceLB(loadBalancer, vip)
+	}
+	return nil
+}
+
 // configureLoadBalancer updates the VIP for sourceIP:sourcePort to point to targets (an
-// array of IP:port strings)
-func (ovn *Controller) configureLoadBalancer(lb, sourceIP string, sourcePort int32, targets []string) error {
+// array of IP:port strings). txn are more ovn-nbctl commands, each starting with "--", that
+// are committed in the same transaction as the VIP.
+func (ovn *Controller) configureLoadBalancer(ctx context.Context, lb, sourceIP string, sourcePort int32, targets []string, txn ...string) error {
+	vip := util.JoinHostPortInt32(sourceIP, sourcePort)
+	return ovn.configureLoadBalancerVIPs(ctx, lb, map[string][]string{vip: targets}, txn...)
+}
+
+// configureLoadBalancerVIPs updates each VIP (IP:port) of vipTargets to point to its targets
+// (an array of IP:port strings) with a single ovn-nbctl command. txn are more ovn-nbctl
+// commands, each starting with "--", that are committed in the same transaction as the VIPs.
+func (ovn *Controller) configureLoadBalancerVIPs(ctx context.Context, lb string, vipTargets map[string][]string, txn ...string) error {
+	if len(vipTargets) == 0 {
+		return nil
+	}
 	ovn.serviceLBLock.Lock()
 	defer ovn.serviceLBLock.Unlock()
 
-	vip := util.JoinHostPortInt32(sourceIP, sourcePort)
-	lbTarget := fmt.Sprintf(`vips:"%s"="%s"`, vip, strings.Join(targets, ","))
+	vips := sets.StringKeySet(vipTargets).List()
+	args := []string{"set", "load_balancer", lb}
+	for _, vip := range vips {
+		args = append(args, fmt.Sprintf(`vips:"%s"="%s"`, vip, strings.Join(vipTargets[vip], ",")))
+	}
 
-	out, stderr, err := util.RunOVNNbctl("set", "load_balancer", lb, lbTarget)
+	out, stderr, err := loadbalancer.RunMutatingOVNNbctlContext(ctx, append(args, txn...)...)
 	if err != nil {
 		return fmt.Errorf("error in configuring load balancer: %s "+
 			"stdout: %q, stderr: %q, error: %v", lb, out, stderr, err)
 	}
-	ovn.setServiceEndpointsToLB(lb, vip, targets)
-	klog.V(5).Infof("LB entry set for %s, %s, %v", lb, lbTarget,
-		ovn.serviceLBMap[lb][vip])
+	for _, vip := range vips {
+		ovn.setServiceEndpointsToLB(lb, vip, vipTargets[vip])
+		klog.V(5).Infof("LB entry set for %s, %s, %v", lb, vip, ovn.serviceLBMap[lb][vip])
+	}
 	return nil
 }
 
 // createLoadBalancerVIPs either creates or updates a set of load balancer VIPs mapping
 // from sourcePort on each IP of a given address family in sourceIPs, to targetPort on
 // each IP of the same address family in targetIPs, removing the reject ACL for any
-// source IP that is now in use.
-func (ovn *Controller) createLoadBalancerVIPs(lb string,
+// source IP that is now in use. The VIPs are all set in a single transaction.
+func (ovn *Controller) createLoadBalancerVIPs(ctx context.Context, lb string,
 	sourceIPs []string, sourcePort int32,
 	targetIPs []string, targetPort int32) error {
-	klog.V(5).Infof("Creating lb with %s, [%v], %d, [%v], %d", lb, sourceIPs, sourcePort, targetIPs, targetPort)
+	serviceLogger{}.V(5).Info("Creating load balancer VIPs", "lb", lb, "sourceIPs", sourceIPs, "sourcePort", sourcePort,
+		"targetIPs", targetIPs, "targetPort", targetPort)
 
+	vipTargets := make(map[string][]string, len(sourceIPs))
 	for _, sourceIP := range sourceIPs {
 		isIPv6 := utilnet.IsIPv6String(sourceIP)
 
@@ -115,15 +175,39 @@ func (ovn *Controller) createLoadBalancerVIPs(lb string,
 				targets = append(targets, util.JoinHostPortInt32(targetIP, targetPort))
 			}
 		}
-		err := ovn.configureLoadBalancer(lb, sourceIP, sourcePort, targets)
-		if len(targets) > 0 {
-			// ensure the ACL is removed if it exists
-			ovn.deleteLoadBalancerRejectACL(lb, util.JoinHostPortInt32(sourceIP, sourcePort))
+		vipTargets[util.JoinHostPortInt32(sourceIP, sourcePort)] = targets
+	}
+	return ovn.setLoadBalancerVIPs(ctx, lb, vipTargets)
+}
+
+// setLoadBalancerVIP points the VIP for sourceIP:sourcePort of lb at targets, like
+// setLoadBalancerVIPs does.
+func (ovn *Controller) setLoadBalancerVIP(ctx context.Context, lb, sourceIP string, sourcePort int32, targets []string) error {
+	vip := util.JoinHostPortInt32(sourceIP, sourcePort)
+	return ovn.setLoadBalancerVIPs(ctx, lb, map[string][]string{vip: targets})
+}
+
+// setLoadBalancerVIPs points each VIP of lb in vipTargets at its targets. A VIP getting targets
+// loses its reject ACL in the same transaction, so that an interruption, like a shutdown, never
+// leaves a reject ACL in front of a VIP with targets nor a VIP with neither.
+func (ovn *Controller) setLoadBalancerVIPs(ctx context.Context, lb string, vipTargets map[string][]string) error {
+	var txn []string
+	var rejectRemoved []string
+	for _, vip := range sets.StringKeySet(vipTargets).List() {
+		if len(vipTargets[vip]) == 0 {
+			continue
 		}
-		if err != nil {
-			return err
+		if args := ovn.rejectACLRemovalArgs(lb, vip); len(args) > 0 {
+			txn = append(txn, args...)
+			rejectRemoved = append(rejectRemoved, vip)
 		}
 	}
+	if err := ovn.configureLoadBalancerVIPs(ctx, lb, vipTargets, txn...); err != nil {
+		return err
+	}
+	for _, vip := range rejectRemoved {
+		ovn.removeServiceACL(lb, vip)
+	}
 	return nil
 }
 
@@ -197,66 +281,241 @@ func generateACLNameForOVNCommand(lb string, sourceIP string, sourcePort int32)
 	return strings.ReplaceAll(generateACLName(lb, sourceIP, sourcePort), ":", "\\:")
 }
 
-func (ovn *Controller) createLoadBalancerRejectACL(lb, sourceIP string, sourcePort int32, proto kapi.Protocol, aclLogging string) (string, error) {
-	applyToPortGroup := false
-	ovn.serviceLBLock.Lock()
-	defer ovn.serviceLBLock.Unlock()
-	switches, err := ovn.getLogicalSwitchesForLoadBalancer(lb)
-	if err != nil {
-		return "", fmt.Errorf("error finding logical switch that contains load balancer %s: %v", lb, err)
+// legacyRejectACLName returns the name of a reject ACL created by releases predating #1749, which
+// stored its colons escaped as in OVN commands, as generateACLName names it, and whether it is such
+// a name.
+// Deprecated: remove once upgrades from releases predating #1749 are no longer supported
+func legacyRejectACLName(aclName string) (string, bool) {
+	if !strings.Contains(aclName, "\\:") {
+		return aclName, false
 	}
+	return strings.ReplaceAll(aclName, "\\:", ":"), true
+}
 
-	if len(switches) > 0 {
-		applyToPortGroup = true
+// getRejectACLSeverity returns the log severity of a reject ACL, which is the one of the namespace
+// ACL logging when it is set
+func getRejectACLSeverity(aclLogging string) string {
+	if aclLogging != "" {
+		return aclLogging
+	}
+	return config.OVNKubernetesFeature.RejectACLSeverity
+}
+
+// getRejectACLMeter returns the meter of a reject ACL logging with the aclLogging severity, none
+// when it does not log
+func getRejectACLMeter(aclLogging, meter string) string {
+	if aclLogging == "" {
+		return ""
+	}
+	return meter
+}
+
+// rejectACLMeterArg returns the ovn-nbctl argument setting the meter of an existing reject ACL
+// logging with the aclLogging severity, clearing it when there is none
+func rejectACLMeterArg(aclLogging, meter string) string {
+	if meter = getRejectACLMeter(aclLogging, meter); meter == "" {
+		return "meter=[]"
+	}
+	return "meter=" + meter
+}
+
+// getRejectACLLogging returns the log severity of the reject ACLs of the services of a namespace, and
+// the meter rate-limiting their logging. Namespaces setting a logging rate get a meter of their own,
+// created if it does not exist yet and kept at the rate of the namespace, the other ones share the
+// configured meter, if any.
+func (ovn *Controller) getRejectACLLogging(namespace string) (string, string) {
+	return ovn.rejectACLLogging(namespace, ovn.GetNetworkPolicyACLLogging(namespace))
+}
+
+// rejectACLLogging is getRejectACLLogging for a namespace with the aclLogging levels, for callers
+// already holding the lock of the namespace
+func (ovn *Controller) rejectACLLogging(namespace string, aclLogging *ACLLoggingLevels) (string, string) {
+	if !usesACLLoggingMeter(aclLogging) {
+		return aclLogging.Deny, config.OVNKubernetesFeature.RejectACLMeter
+	}
+	meter := aclLoggingMeter(namespace)
+	if err := ovn.ensureACLLoggingMeter(meter, aclLogging.Rate); err != nil {
+		klog.Warningf("Unable to set up ACL logging meter %s of namespace %s, falling back to %s: %v",
+			meter, namespace, config.OVNKubernetesFeature.RejectACLMeter, err)
+		return aclLogging.Deny, config.OVNKubernetesFeature.RejectACLMeter
+	}
+	return aclLogging.Deny, meter
+}
+
+// usesACLLoggingMeter tells if the reject ACLs of a namespace with the aclLogging levels log on a
+// meter of the namespace
+func usesACLLoggingMeter(aclLogging *ACLLoggingLevels) bool {
+	return aclLogging.Deny != "" && aclLogging.Rate != 0
+}
+
+func aclLoggingMeter(namespace string) string {
+	return types.OvnACLLoggingMeter + "-" + namespace
+}
+
+// ensureACLLoggingMeter creates the meter, or sets the rate of its bands when it already exists
+// with another one. The rates are cached, OVN is only looked up for a meter not set up yet.
+func (ovn *Controller) ensureACLLoggingMeter(meter string, rate int) error {
+	ovn.aclLoggingMetersLock.Lock()
+	defer ovn.aclLoggingMetersLock.Unlock()
+	if cached, ok := ovn.aclLoggingMeters[meter]; ok && cached == rate {
+		return nil
+	}
+	bands, stderr, err := util.RunOVNNbctl("--data=bare", "--no-heading", "--columns=bands", "find", "meter", "name="+meter)
+	if err != nil {
+		return fmt.Errorf("failed to find meter %s, stderr: %q, error: %v", meter, stderr, err)
+	}
+	if bands == "" {
+		_, stderr, err = loadbalancer.RunMutatingOVNNbctl("meter-add", meter, "drop", strconv.Itoa(rate), "pktps")
+		if err != nil {
+			return fmt.Errorf("failed to add meter %s, stderr: %q, error: %v", meter, stderr, err)
+		}
 	} else {
-		klog.V(5).Infof("Ignoring creating reject ACL for port group with load balancer %s. It has no "+
-			"logical switches", lb)
+		for _, band := range strings.Fields(bands) {
+			_, stderr, err = loadbalancer.RunMutatingOVNNbctl("set", "meter_band", band, fmt.Sprintf("rate=%d", rate))
+			if err != nil {
+				return fmt.Errorf("failed to set the rate of meter %s, stderr: %q, error: %v", meter, stderr, err)
+			}
+		}
 	}
+	ovn.aclLoggingMeters[meter] = rate
+	return nil
+}
 
-	// check if the load balancer is on a GR, if so we need to get the external switches
-	gwRouterExtSwitches, err := ovn.getGRLogicalSwitchesForLoadBalancer(lb)
+// deleteACLLoggingMeter deletes the ACL logging meter of a namespace, once its reject ACLs stopped
+// logging on it
+func (ovn *Controller) deleteACLLoggingMeter(namespace string) {
+	meter := aclLoggingMeter(namespace)
+	ovn.aclLoggingMetersLock.Lock()
+	defer ovn.aclLoggingMetersLock.Unlock()
+	_, stderr, err := loadbalancer.RunMutatingOVNNbctl("meter-del", meter)
+	if err != nil {
+		klog.Errorf("Failed to delete ACL logging meter %s of namespace %s, stderr: %q, error: %v",
+			meter, namespace, stderr, err)
+		return
+	}
+	delete(ovn.aclLoggingMeters, meter)
+}
+
+// updateServiceRejectACLLogging brings the logging of the existing reject ACLs of the services of
+// namespace in line with aclLogging, the new ACL logging levels of the namespace: they log with
+// the deny severity when it is set, and do not log otherwise. The NodePort reject ACLs are found
+// on the load balancers of the gateway routers.
+func (ovn *Controller) updateServiceRejectACLLogging(namespace string, aclLogging *ACLLoggingLevels) error {
+	services, err := ovn.watchFactory.GetServices()
 	if err != nil {
-		return "", fmt.Errorf("unable to query logical switches for GR with load balancer: %s, error: %v", lb, err)
+		return fmt.Errorf("failed to get k8s services: %v", err)
+	}
+	vips := sets.NewString()
+	nodePorts := sets.NewInt32()
+	gatewayLBs := sets.NewString()
+	gateways := newGatewayCache(ovn.lbOps)
+	for _, service := range services {
+		if service.Namespace != namespace || !svcHasVIPs(service) {
+			continue
+		}
+		var ips []string
+		ips = append(ips, util.GetClusterIPs(service)...)
+		ips = append(ips, service.Spec.ExternalIPs...)
+		ips = append(ips, svcIngressIPs(service)...)
+		for _, svcPort := range service.Spec.Ports {
+			for _, ip := range ips {
+				vips.Insert(util.JoinHostPortInt32(ip, svcPort.Port))
+			}
+			if !util.ServicePortHasNodePort(service, &svcPort) {
+				continue
+			}
+			nodePorts.Insert(svcPort.NodePort)
+			gatewayRouters, _, err := gateways.GetOvnGateways()
+			if err != nil {
+				klog.Warningf("Unable to get the gateways to update the logging of the NodePort reject ACLs "+
+					"of namespace %s: %v", namespace, err)
+				continue
+			}
+			for _, gatewayRouter := range gatewayRouters {
+				if loadBalancer, err := gateways.GetGatewayLoadBalancer(gatewayRouter, svcPort.Protocol); err == nil {
+					gatewayLBs.Insert(loadBalancer)
+				}
+			}
+		}
 	}
 
-	if len(switches) == 0 && len(gwRouterExtSwitches) == 0 {
-		return "", fmt.Errorf("load balancer %s does not apply to any switches in the cluster. Will not create "+
-			"Reject ACL", lb)
+	aclUUIDs := sets.NewString()
+	ovn.serviceLBLock.Lock()
+	for lb, lbVIPs := range ovn.serviceLBMap {
+		for vip, conf := range lbVIPs {
+			if conf.rejectACL == "" {
+				continue
+			}
+			if vips.Has(vip) {
+				aclUUIDs.Insert(conf.rejectACL)
+			} else if _, port, err := util.SplitHostPortInt32(vip); err == nil && gatewayLBs.Has(lb) && nodePorts.Has(port) {
+				aclUUIDs.Insert(conf.rejectACL)
+			}
+		}
+	}
+	ovn.serviceLBLock.Unlock()
+	if aclUUIDs.Len() == 0 {
+		return nil
 	}
 
-	ip := net.ParseIP(sourceIP)
-	if ip == nil {
-		return "", fmt.Errorf("cannot create reject ACL, invalid source IP: %s", sourceIP)
+	deny, meter := ovn.rejectACLLogging(namespace, aclLogging)
+	var args []string
+	for _, aclUUID := range aclUUIDs.List() {
+		args = append(args, "--", "set", "acl", aclUUID, fmt.Sprintf("log=%t", deny != ""),
+			fmt.Sprintf("severity=%s", getRejectACLSeverity(deny)), rejectACLMeterArg(deny, meter))
 	}
-	var l3Prefix, aclMatch string
-	if utilnet.IsIPv6(ip) {
-		l3Prefix = "ip6"
-	} else {
-		l3Prefix = "ip4"
+	if _, stderr, err := loadbalancer.RunMutatingOVNNbctl(args...); err != nil {
+		return fmt.Errorf("failed to update the logging of the reject ACLs of namespace %s, stderr: %q (%v)",
+			namespace, stderr, err)
+	}
+	return nil
+}
+
+// ensureLoadBalancerRejectACL creates the ACL rejecting the traffic to sourceIP:sourcePort of lb, or
+// dropping it when action is "drop", and applies it to the switches the load balancer is on. Its
+// logging is rate-limited by meter. An ACL of the same name that already exists, after a restart or
+// a configuration change, is reused and its fields are updated in place when they differ.
+func (ovn *Controller) ensureLoadBalancerRejectACL(ctx context.Context, lb, sourceIP string, sourcePort int32, proto kapi.Protocol, aclLogging, meter, action string) (string, error) {
+	return ovn.ensureRejectACL(ctx, lb, sourceIP, sourcePort, proto, aclLogging, meter, action, nil)
+}
+
+// ensureRejectACL is ensureLoadBalancerRejectACL committing the ovn-nbctl commands of txn, each
+// starting with "--", in the same transaction as the ACL
+func (ovn *Controller) ensureRejectACL(ctx context.Context, lb, sourceIP string, sourcePort int32, proto kapi.Protocol, aclLogging, meter, action string,
+	txn []string) (string, error) {
+	ovn.serviceLBLock.Lock()
+	defer ovn.serviceLBLock.Unlock()
+	switches, gwRouterExtSwitches, err := ovn.getRejectACLSwitches(lb)
+	if err != nil {
+		return "", err
 	}
 	vip := util.JoinHostPortInt32(sourceIP, sourcePort)
-	// NOTE: doesn't use vip, to avoid having brackets in the name with IPv6
-	aclName := generateACLNameForOVNCommand(lb, sourceIP, sourcePort)
+	logger := serviceLogger{}.WithValues("vip", vip, "protocol", proto, "lb", lb)
+	aclName, err := rejectACLName(lb, sourceIP, sourcePort)
+	if err != nil {
+		return "", err
+	}
 	// If ovn-k8s was restarted, we lost the cache, and an ACL may already exist in OVN. In that case we need to check
-	// using ACL name
-	aclUUID, stderr, err := util.RunOVNNbctl("--data=bare", "--no-heading", "--columns=_uuid", "find", "acl",
-		fmt.Sprintf("name=%s", aclName))
+	// using ACL name. Not knowing whether it does, creating it could duplicate it, so the service is retried instead.
+	aclUUIDs, err := findRejectACLsByName(aclName)
 	if err != nil {
-		klog.Errorf("Error while querying ACLs by name: %s, %v", stderr, err)
-	} else if len(aclUUID) > 0 {
-		klog.Infof("Existing Service Reject ACL found: %s for %s", aclUUID, aclName)
-		var cmd []string
-		if applyToPortGroup {
-			cmd = append(cmd, "--", "add", "port_group", ovn.clusterPortGroupUUID, "acls", aclUUID)
-		}
-		for _, extSwitch := range gwRouterExtSwitches {
-			cmd = append(cmd, "--", "add", "logical_switch", extSwitch, "acls", aclUUID)
-		}
+		return "", err
+	}
+	if len(aclUUIDs) > 0 {
+		aclUUID := aclUUIDs[0]
+		logger.Info("Existing service reject ACL found", "acl", aclUUID, "aclName", aclName)
+		cmd := rejectACLUpdateArgs(aclUUID, sourceIP, sourcePort, proto, aclLogging, meter, action)
+		cmd = append(cmd, ovn.rejectACLAttachArgs([]string{aclUUID}, len(switches) > 0, gwRouterExtSwitches)...)
+		cmd = append(cmd, ovn.rejectACLDetachArgs(aclUUIDs[1:], switches, gwRouterExtSwitches)...)
+		cmd = append(cmd, txn...)
 		if len(cmd) > 0 {
-			_, _, err = util.RunOVNNbctl(cmd...)
+			_, stderr, err := loadbalancer.RunMutatingOVNNbctlContext(ctx, cmd...)
 			if err != nil {
-				klog.Errorf("Failed to add LB %s, ACL %s, %q, to cluster port group/switches, stderr: %q,"+
-					"error: %v", lb, aclUUID, aclName, stderr, err)
+				logger.Error(err, "Failed to add reject ACL to cluster port group/switches", "acl", aclUUID,
+					"aclName", aclName, "stderr", stderr)
+				if len(txn) > 0 {
+					return "", err
+				}
 			}
 		}
 
@@ -265,26 +524,17 @@ func (ovn *Controller) createLoadBalancerRejectACL(lb, sourceIP string, sourcePo
 		// If reject ACL exist, ensures that the _uuid is removed from logical_switch acls list.
 		// This step is required to ensure the clean-up when ovn upgrades from logical_switch acls
 		// to port_group based acls.
-		ovn.removeACLFromNodeSwitches(switches, aclUUID)
+		ovn.removeACLFromNodeSwitches(ctx, logger, switches, aclUUID)
 		return aclUUID, nil
 	}
 
-	aclMatch = fmt.Sprintf("match=\"%s.dst==%s && %s && %s.dst==%d\"", l3Prefix, sourceIP,
-		strings.ToLower(string(proto)), strings.ToLower(string(proto)), sourcePort)
-	cmd := []string{"--id=@reject-acl", "create", "acl", "direction=" + types.DirectionFromLPort, "priority=" + types.DefaultDenyPriority, aclMatch, "action=reject",
-		fmt.Sprintf("log=%t", aclLogging != ""), fmt.Sprintf("severity=%s", getACLLoggingSeverity(aclLogging)),
-		fmt.Sprintf("meter=%s", types.OvnACLLoggingMeter),
-		fmt.Sprintf("name=%s", aclName)}
-	if applyToPortGroup {
-		cmd = append(cmd, "--", "add", "port_group", ovn.clusterPortGroupUUID, "acls", "@reject-acl")
-	}
-	for _, extSwitch := range gwRouterExtSwitches {
-		cmd = append(cmd, "--", "add", "logical_switch", extSwitch, "acls", "@reject-acl")
-	}
-	aclUUID, stderr, err = util.RunOVNNbctl(cmd...)
+	cmd := rejectACLCreateArgs("reject-acl", aclName, sourceIP, sourcePort, proto, aclLogging, meter, action)
+	cmd = append(cmd, ovn.rejectACLAttachArgs([]string{"@reject-acl"}, len(switches) > 0, gwRouterExtSwitches)...)
+	cmd = append(cmd, txn...)
+	aclUUID, stderr, err := loadbalancer.RunMutatingOVNNbctlContext(ctx, cmd...)
 	if err != nil {
-		klog.Errorf("Failed to add LB: %s ACL: %s, %q, to cluster port group/switches, stderr: %q, error: %v",
-			lb, aclUUID, aclName, stderr, err)
+		logger.Error(err, "Failed to add reject ACL to cluster port group/switches", "acl", aclUUID,
+			"aclName", aclName, "stderr", stderr)
 		return "", err
 	}
 
@@ -295,43 +545,377 @@ func (ovn *Controller) createLoadBalancerRejectACL(lb, sourceIP string, sourcePo
 	return aclUUID, nil
 }
 
-func (ovn *Controller) deleteLoadBalancerRejectACL(lb, vip string) {
-	aclUUID, hasEndpoints := ovn.getServiceLBInfo(lb, vip)
-	if aclUUID == "" && !hasEndpoints {
-		// If no ACL and does not have endpoints, we can assume there is no valid entry in the cache here as
-		// this is an illegal state.
-		// Determine and remove ACL by name.
-		ip, port, err := util.SplitHostPortInt32(vip)
+// rejectACLVIP is the IP and port of a VIP to create a reject ACL for
+type rejectACLVIP struct {
+	ip   string
+	port int32
+}
+
+func (v rejectACLVIP) String() string {
+	return util.JoinHostPortInt32(v.ip, v.port)
+}
+
+// ensureLoadBalancerRejectACLs is ensureLoadBalancerRejectACL for every VIP of vips on lb, which
+// may have different ports, committing all the ACLs in a single transaction that adds them to the
+// cluster port group and to each switch at once. It returns their UUIDs in the order of vips. A
+// single VIP is left to ensureLoadBalancerRejectACL.
+func (ovn *Controller) ensureLoadBalancerRejectACLs(ctx context.Context, lb string, vips []rejectACLVIP, proto kapi.Protocol,
+	aclLogging, meter, action string) ([]string, error) {
+	aclUUIDs, err := ovn.ensureRejectACLsOfLoadBalancers(ctx, []lbRejectACLVIPs{{lb: lb, protocol: proto, vips: vips}},
+		aclLogging, meter, action)
+	if err != nil {
+		return nil, err
+	}
+	return aclUUIDs[0], nil
+}
+
+// lbRejectACLVIPs are the VIPs of a load balancer of protocol to create reject ACLs for
+type lbRejectACLVIPs struct {
+	lb       string
+	protocol kapi.Protocol
+	vips     []rejectACLVIP
+}
+
+// ensureRejectACLsOfLoadBalancers is ensureLoadBalancerRejectACLs for several load balancers, such
+// as the ones of the protocols of a service, committing the ACLs of all of them in a single
+// transaction. It returns the UUIDs of the ACLs of each load balancer, in the order of lbs and of
+// their vips.
+func (ovn *Controller) ensureRejectACLsOfLoadBalancers(ctx context.Context, lbs []lbRejectACLVIPs, aclLogging, meter,
+	action string) ([][]string, error) {
+	if len(lbs) == 1 && len(lbs[0].vips) == 1 {
+		vip := lbs[0].vips[0]
+		aclUUID, err := ovn.ensureLoadBalancerRejectACL(ctx, lbs[0].lb, vip.ip, vip.port, lbs[0].protocol, aclLogging, meter, action)
 		if err != nil {
-			klog.Errorf("Unable to parse vip for Reject ACL deletion: %v", err)
-			return
+			return nil, err
 		}
-		aclUUID, err = ovn.findStaleRejectACL(lb, ip, port)
+		return [][]string{{aclUUID}}, nil
+	}
+	ovn.serviceLBLock.Lock()
+	defer ovn.serviceLBLock.Unlock()
+	aclUUIDs := make([][]string, len(lbs))
+	// the switches of each load balancer
+	lbSwitches := make([][]string, len(lbs))
+	// the ACLs to attach to the cluster port group and to each external switch, in order, as UUIDs
+	// or @ids of the transaction
+	var portGroupACLs []string
+	var extSwitches []string
+	extSwitchACLs := make(map[string][]string)
+	// the load balancer and vip indexes of the ACLs created by the transaction, in the order of its
+	// output, and of the ACLs that already exist in OVN
+	type aclIndex struct{ lb, vip int }
+	var created, existing []aclIndex
+	var cmd []string
+	// the ACLs are numbered across the load balancers, for their ids to be unique in the transaction
+	n := 0
+	for l, lbVIPs := range lbs {
+		switches, gwRouterExtSwitches, err := ovn.getRejectACLSwitches(lbVIPs.lb)
 		if err != nil {
-			klog.Infof("Unable to delete Reject ACL for load-balancer: %s, vip: %s. No entry in cache and "+
-				"error occurred while trying to find the ACL by name in OVN, error: %v", lb, vip, err)
-			return
+			return nil, err
 		}
-		if aclUUID == "" {
-			klog.Infof("No reject ACL found in cache or in OVN to remove for load-balancer: %s, vip: %s", lb, vip)
-			return
+		lbSwitches[l] = switches
+		aclUUIDs[l] = make([]string, len(lbVIPs.vips))
+		var acls []string
+		for i, vip := range lbVIPs.vips {
+			id := fmt.Sprintf("reject-acl-%d", n)
+			n++
+			aclName, err := rejectACLName(lbVIPs.lb, vip.ip, vip.port)
+			if err != nil {
+				return nil, err
+			}
+			found, err := findRejectACLsByName(aclName)
+			if err != nil {
+				return nil, err
+			}
+			if len(found) > 0 {
+				aclUUID := found[0]
+				serviceLogger{}.Info("Existing service reject ACL found", "vip", vip, "protocol", lbVIPs.protocol,
+					"lb", lbVIPs.lb, "acl", aclUUID, "aclName", aclName)
+				aclUUIDs[l][i] = aclUUID
+				existing = append(existing, aclIndex{l, i})
+				acls = append(acls, aclUUID)
+				cmd = append(cmd, rejectACLUpdateArgs(aclUUID, vip.ip, vip.port, lbVIPs.protocol, aclLogging, meter, action)...)
+				cmd = append(cmd, ovn.rejectACLDetachArgs(found[1:], switches, gwRouterExtSwitches)...)
+				continue
+			}
+			if len(cmd) > 0 {
+				cmd = append(cmd, "--")
+			}
+			cmd = append(cmd, rejectACLCreateArgs(id, aclName, vip.ip, vip.port, lbVIPs.protocol, aclLogging, meter, action)...)
+			acls = append(acls, "@"+id)
+			created = append(created, aclIndex{l, i})
 		}
-	} else if aclUUID == "" {
-		// Must have endpoints and no reject ACL to remove
-		klog.V(5).Infof("No reject ACL found to remove for load balancer: %s, vip: %s", lb, vip)
+		if len(switches) > 0 {
+			portGroupACLs = append(portGroupACLs, acls...)
+		}
+		for _, extSwitch := range gwRouterExtSwitches {
+			if _, ok := extSwitchACLs[extSwitch]; !ok {
+				extSwitches = append(extSwitches, extSwitch)
+			}
+			extSwitchACLs[extSwitch] = append(extSwitchACLs[extSwitch], acls...)
+		}
+	}
+	cmd = append(cmd, ovn.rejectACLAttachArgs(portGroupACLs, true, nil)...)
+	for _, extSwitch := range extSwitches {
+		cmd = append(cmd, ovn.rejectACLAttachArgs(extSwitchACLs[extSwitch], false, []string{extSwitch})...)
+	}
+	if len(cmd) > 0 {
+		if cmd[0] == "--" {
+			cmd = cmd[1:]
+		}
+		out, stderr, err := loadbalancer.RunMutatingOVNNbctlContext(ctx, cmd...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add the reject ACLs of LBs %s to cluster port group/switches, "+
+				"stderr: %q, error: %v", lbRejectACLsNames(lbs), stderr, err)
+		}
+		uuids := strings.Fields(out)
+		if config.Kubernetes.ServiceDryRun {
+			// nothing was created
+			uuids = make([]string, len(created))
+		}
+		if len(uuids) != len(created) {
+			return nil, fmt.Errorf("unexpected output %q creating %d reject ACLs of LBs %s", out, len(created),
+				lbRejectACLsNames(lbs))
+		}
+		for j, index := range created {
+			aclUUIDs[index.lb][index.vip] = uuids[j]
+		}
+	}
+	for l, lbVIPs := range lbs {
+		for i, vip := range lbVIPs.vips {
+			ovn.setServiceACLToLB(lbVIPs.lb, vip.String(), aclUUIDs[l][i])
+		}
+	}
+	// like ensureLoadBalancerRejectACL, clean up the existing ACLs from the node switches
+	for _, index := range existing {
+		lbVIPs := lbs[index.lb]
+		logger := serviceLogger{}.WithValues("vip", lbVIPs.vips[index.vip], "protocol", lbVIPs.protocol, "lb", lbVIPs.lb)
+		ovn.removeACLFromNodeSwitches(ctx, logger, lbSwitches[index.lb], aclUUIDs[index.lb][index.vip])
+	}
+	return aclUUIDs, nil
+}
+
+// lbRejectACLsNames returns the comma separated load balancers of lbs
+func lbRejectACLsNames(lbs []lbRejectACLVIPs) string {
+	names := make([]string, 0, len(lbs))
+	for _, lbVIPs := range lbs {
+		names = append(names, lbVIPs.lb)
+	}
+	return strings.Join(names, ",")
+}
+
+// getRejectACLSwitches returns the switches lb is on, which the reject ACLs of its VIPs apply to
+// through the cluster port group, and the external switches of its gateway router, if any
+func (ovn *Controller) getRejectACLSwitches(lb string) ([]string, []string, error) {
+	switches, err := ovn.getLogicalSwitchesForLoadBalancer(lb)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error finding logical switch that contains load balancer %s: %v", lb, err)
+	}
+
+	if len(switches) == 0 {
+		klog.V(5).Infof("Ignoring creating reject ACL for port group with load balancer %s. It has no "+
+			"logical switches", lb)
+	}
+
+	// check if the load balancer is on a GR, if so we need to get the external switches
+	gwRouterExtSwitches, err := ovn.getGRLogicalSwitchesForLoadBalancer(lb)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to query logical switches for GR with load balancer: %s, error: %v", lb, err)
+	}
+
+	if len(switches) == 0 && len(gwRouterExtSwitches) == 0 {
+		return nil, nil, fmt.Errorf("load balancer %s does not apply to any switches in the cluster. Will not create "+
+			"Reject ACL", lb)
+	}
+	return switches, gwRouterExtSwitches, nil
+}
+
+// rejectACLName returns the name of the reject ACL of sourceIP:sourcePort on lb, failing when
+// sourceIP is not an IP
+func rejectACLName(lb, sourceIP string, sourcePort int32) (string, error) {
+	if net.ParseIP(sourceIP) == nil {
+		return "", fmt.Errorf("cannot create reject ACL, invalid source IP: %s", sourceIP)
+	}
+	// NOTE: doesn't use vip, to avoid having brackets in the name with IPv6
+	return generateACLNameForOVNCommand(lb, sourceIP, sourcePort), nil
+}
+
+// rejectACLCreateArgs returns the ovn-nbctl command creating the reject ACL aclName of
+// sourceIP:sourcePort, which the rest of the transaction refers to as @id. The ACL only has a meter
+// when it logs.
+func rejectACLCreateArgs(id, aclName, sourceIP string, sourcePort int32, proto kapi.Protocol,
+	aclLogging, meter, action string) []string {
+	args := []string{"--id=@" + id, "create", "acl", "direction=" + types.DirectionFromLPort,
+		fmt.Sprintf("priority=%d", config.OVNKubernetesFeature.RejectACLPriority),
+		getRejectACLMatch(sourceIP, sourcePort, proto), "action=" + action,
+		fmt.Sprintf("log=%t", aclLogging != ""), fmt.Sprintf("severity=%s", getRejectACLSeverity(aclLogging))}
+	if meter = getRejectACLMeter(aclLogging, meter); meter != "" {
+		args = append(args, fmt.Sprintf("meter=%s", meter))
+	}
+	return append(args, fmt.Sprintf("name=%s", aclName))
+}
+
+// rejectACLUpdateArgs returns the ovn-nbctl command, starting with "--", setting the fields of the
+// existing reject ACL aclUUID of sourceIP:sourcePort that differ from the ones rejectACLCreateArgs
+// would create it with, so that its priority, match, action and logging follow the configuration.
+// It returns nil when they all match or when the ACL cannot be read.
+func rejectACLUpdateArgs(aclUUID, sourceIP string, sourcePort int32, proto kapi.Protocol,
+	aclLogging, meter, action string) []string {
+	data, stderr, err := util.RunOVNNbctl("--columns=priority,match,action,log,severity,meter", "--format=json",
+		"list", "acl", aclUUID)
+	if err != nil {
+		klog.Errorf("Error while reading reject ACL %s: %s, %v", aclUUID, stderr, err)
+		return nil
+	}
+	x := struct {
+		Data [][]interface{}
+	}{}
+	if err := json.Unmarshal([]byte(data), &x); err != nil || len(x.Data) != 1 || len(x.Data[0]) != 6 {
+		klog.Errorf("Unable to parse reject ACL %s: %q, %v", aclUUID, data, err)
+		return nil
+	}
+	fields := x.Data[0]
+	var args []string
+	if priority, _ := fields[0].(float64); int(priority) != config.OVNKubernetesFeature.RejectACLPriority {
+		args = append(args, fmt.Sprintf("priority=%d", config.OVNKubernetesFeature.RejectACLPriority))
+	}
+	if match := getRejectACLMatch(sourceIP, sourcePort, proto); fields[1] != strings.TrimSuffix(strings.TrimPrefix(match, "match=\""), "\"") {
+		args = append(args, match)
+	}
+	if fields[2] != action {
+		args = append(args, "action="+action)
+	}
+	if logging, _ := fields[3].(bool); logging != (aclLogging != "") {
+		args = append(args, fmt.Sprintf("log=%t", aclLogging != ""))
+	}
+	// the severity and the meter are empty sets, not strings, when they are not set
+	if severity, _ := fields[4].(string); severity != getRejectACLSeverity(aclLogging) {
+		args = append(args, fmt.Sprintf("severity=%s", getRejectACLSeverity(aclLogging)))
+	}
+	if currentMeter, _ := fields[5].(string); currentMeter != getRejectACLMeter(aclLogging, meter) {
+		args = append(args, rejectACLMeterArg(aclLogging, meter))
+	}
+	if len(args) == 0 {
+		return nil
+	}
+	klog.Infof("Updating reject ACL %s of %s with %s", aclUUID, util.JoinHostPortInt32(sourceIP, sourcePort),
+		strings.Join(args, " "))
+	return append([]string{"--", "set", "acl", aclUUID}, args...)
+}
+
+// getRejectACLMatch returns the match of the reject ACL of sourceIP:sourcePort, on the destination
+// address of the IP family of sourceIP and on the destination port of proto
+func getRejectACLMatch(sourceIP string, sourcePort int32, proto kapi.Protocol) string {
+	l3Prefix := "ip4"
+	if utilnet.IsIPv6String(sourceIP) {
+		l3Prefix = "ip6"
+	}
+	l4Prefix := strings.ToLower(string(proto))
+	return fmt.Sprintf("match=\"%s.dst==%s && %s && %s.dst==%d\"", l3Prefix, sourceIP, l4Prefix, l4Prefix, sourcePort)
+}
+
+// rejectACLAttachArgs returns the ovn-nbctl commands, each starting with "--", adding the reject
+// ACLs acls, UUIDs or @ids of the transaction, to the cluster port group when toPortGroup is set
+// and to the external switches of a gateway router, with a single command for each
+func (ovn *Controller) rejectACLAttachArgs(acls []string, toPortGroup bool, gwRouterExtSwitches []string) []string {
+	if len(acls) == 0 {
+		return nil
+	}
+	var cmd []string
+	if toPortGroup {
+		cmd = append(append(cmd, "--", "add", "port_group", ovn.clusterPortGroupUUID, "acls"), acls...)
+	}
+	for _, extSwitch := range gwRouterExtSwitches {
+		cmd = append(append(cmd, "--", "add", "logical_switch", extSwitch, "acls"), acls...)
+	}
+	return cmd
+}
+
+// deleteLoadBalancerRejectACL removes the reject ACL of vip on lb. When the cache does not know of
+// one, which is the case after a restart or when the ACL was created before the endpoints of the
+// service were seen, it is looked up by name, so that a VIP getting targets never keeps one.
+func (ovn *Controller) deleteLoadBalancerRejectACL(ctx context.Context, lb, vip string) {
+	aclUUID := ovn.findRejectACL(lb, vip)
+	if aclUUID == "" {
 		return
 	}
+	logger := serviceLogger{}.WithValues("vip", vip, "lb", lb)
 	// check if the load balancer is on a GR, if so we need to get the join/external switches
 	gwRouterSwitches, err := ovn.getGRLogicalSwitchesForLoadBalancer(lb)
 	if err != nil {
-		klog.Errorf("Unable to query logical switches for GR with load balancer: %s, error: %v", lb, err)
+		logger.Error(err, "Unable to query logical switches for GR with load balancer")
 	} else {
-		ovn.removeACLFromNodeSwitches(gwRouterSwitches, aclUUID)
+		ovn.removeACLFromNodeSwitches(ctx, logger, gwRouterSwitches, aclUUID)
 	}
-	ovn.removeACLFromPortGroup(lb, aclUUID)
+	ovn.removeACLFromPortGroup(ctx, logger, lb, aclUUID)
 	ovn.removeServiceACL(lb, vip)
 }
 
+// findRejectACL returns the UUID of the reject ACL of vip on lb, from the cache or by name in OVN
+// when the cache does not know of one, or "" when there is none
+func (ovn *Controller) findRejectACL(lb, vip string) string {
+	aclUUID, _ := ovn.getServiceLBInfo(lb, vip)
+	if aclUUID != "" {
+		return aclUUID
+	}
+	logger := serviceLogger{}.WithValues("vip", vip, "lb", lb)
+	ip, port, err := util.SplitHostPortInt32(vip)
+	if err != nil {
+		logger.Error(err, "Unable to parse vip for reject ACL deletion")
+		return ""
+	}
+	aclUUID, err = ovn.findStaleRejectACL(lb, ip, port)
+	if err != nil {
+		logger.V(5).Info("No reject ACL to delete: no entry in cache and none found by name in OVN", "err", err)
+		return ""
+	}
+	return aclUUID
+}
+
+// rejectACLRemovalArgs returns the ovn-nbctl commands removing the reject ACL of vip on lb, as
+// found by findRejectACL, from the cluster port group and the switches of its gateway router, or
+// nil when there is none
+func (ovn *Controller) rejectACLRemovalArgs(lb, vip string) []string {
+	aclUUID := ovn.findRejectACL(lb, vip)
+	if aclUUID == "" {
+		return nil
+	}
+	var args []string
+	gwRouterSwitches, err := ovn.getGRLogicalSwitchesForLoadBalancer(lb)
+	if err != nil {
+		klog.Errorf("Unable to query logical switches for GR with load balancer: %s, error: %v", lb, err)
+	}
+	for _, ls := range gwRouterSwitches {
+		args = append(args, "--", "--if-exists", "remove", "logical_switch", ls, "acls", aclUUID)
+	}
+	return append(args, "--", "--if-exists", "remove", "port_group", ovn.clusterPortGroupUUID, "acls", aclUUID)
+}
+
+// findRejectACLsByName returns the UUIDs of the ACLs named aclName in OVN. There is more than one
+// only when a previous attempt created the reject ACL of a VIP again.
+func findRejectACLsByName(aclName string) ([]string, error) {
+	out, stderr, err := util.RunOVNNbctl("--data=bare", "--no-heading", "--columns=_uuid", "find", "acl",
+		fmt.Sprintf("name=%s", aclName))
+	if err != nil {
+		return nil, fmt.Errorf("error while querying ACLs by name %s, stderr: %q, error: %v", aclName, stderr, err)
+	}
+	return strings.Fields(out), nil
+}
+
+// rejectACLDetachArgs returns the ovn-nbctl commands removing the duplicate reject ACLs acls from
+// the cluster port group, the node switches of an older implementation and the external switches
+// of a gateway router, or nil when there are none. OVN deletes them once nothing references them.
+func (ovn *Controller) rejectACLDetachArgs(acls, switches, gwRouterExtSwitches []string) []string {
+	if len(acls) == 0 {
+		return nil
+	}
+	klog.Warningf("Removing duplicate Service Reject ACLs: %v", acls)
+	cmd := append([]string{"--", "--if-exists", "remove", "port_group", ovn.clusterPortGroupUUID, "acls"}, acls...)
+	for _, ls := range append(append([]string{}, switches...), gwRouterExtSwitches...) {
+		cmd = append(append(cmd, "--", "--if-exists", "remove", "logical_switch", ls, "acls"), acls...)
+	}
+	return cmd
+}
+
 func (ovn *Controller) findStaleRejectACL(lb, ip string, port int32) (string, error) {
 	aclName := generateACLNameForOVNCommand(lb, ip, port)
 	aclUUID, stderr, err := util.RunOVNNbctl("--data=bare", "--no-heading", "--columns=_uuid", "find", "acl",
@@ -340,34 +924,146 @@ func (ovn *Controller) findStaleRejectACL(lb, ip string, port int32) (string, er
 		klog.Errorf("Error while querying ACLs by name: %s, %v", stderr, err)
 		return "", err
 	} else if len(aclUUID) == 0 {
-		klog.Infof("Reject ACL not found to remove for name: %s", aclName)
+		klog.V(5).Infof("Reject ACL not found to remove for name: %s", aclName)
 		return "", fmt.Errorf("reject ACL not found to remove for name: %s", aclName)
 	}
 	return aclUUID, nil
 }
 
+// clearLoadBalancerVIP clears the targets of the VIP for sourceIP:sourcePort of lb. With an action,
+// the VIP gets a reject ACL in the same transaction, whose UUID is returned; should that fail, the
+// targets are cleared on their own. Without one, its reject ACL, if any, is removed in the same
+// transaction. Either way, a VIP is never left with both targets and a reject ACL.
+func (ovn *Controller) clearLoadBalancerVIP(ctx context.Context, lb, sourceIP string, sourcePort int32, proto kapi.Protocol,
+	aclLogging, meter, action string) (string, error) {
+	vip := util.JoinHostPortInt32(sourceIP, sourcePort)
+	if action != "" {
+		aclUUID, err := ovn.ensureRejectACL(ctx, lb, sourceIP, sourcePort, proto, aclLogging, meter, action,
+			[]string{"--", "set", "load_balancer", lb, fmt.Sprintf(`vips:"%s"=""`, vip)})
+		if err == nil {
+			ovn.removeServiceEndpoints(lb, vip)
+			return aclUUID, nil
+		}
+		klog.Errorf("Failed to create reject ACL for %s VIP %s, load balancer: %s, error: %v", proto, vip, lb, err)
+	}
+	var txn []string
+	if action == "" {
+		txn = ovn.rejectACLRemovalArgs(lb, vip)
+	}
+	if err := ovn.configureLoadBalancer(ctx, lb, sourceIP, sourcePort, nil, txn...); err != nil {
+		return "", err
+	}
+	if len(txn) > 0 {
+		ovn.removeServiceACL(lb, vip)
+	}
+	return "", nil
+}
+
+// removeStaleRejectACL removes the reject ACL aclUUID of lb from the cluster port group and from
+// every switch earlier implementations may have applied it to
+func (ovn *Controller) removeStaleRejectACL(ctx context.Context, lb, aclUUID string) {
+	logger := serviceLogger{}.WithValues("acl", aclUUID, "lb", lb)
+	ovn.removeACLFromPortGroup(ctx, logger, lb, aclUUID)
+	var foundSwitches []string
+	// For upgrade from a non-port group Reject ACL implementation
+	// Deprecated: remove in the future
+	switches, err := ovn.getLogicalSwitchesForLoadBalancer(lb)
+	if err != nil {
+		logger.Error(err, "Service Sync: Error finding node logical switches for load balancer")
+	} else {
+		foundSwitches = append(foundSwitches, switches...)
+	}
+	// Look for load balancer on join/external switches
+	grExtSwitches, err := ovn.getGRLogicalSwitchesForLoadBalancer(lb)
+	if err != nil {
+		logger.Error(err, "Service Sync: Error finding GR logical switches for load balancer")
+	} else {
+		// For upgrade from a previous implementation the ACL may also be on join switch
+		for _, grExtSwitch := range grExtSwitches {
+			routerName := strings.TrimPrefix(grExtSwitch, types.ExternalSwitchPrefix)
+			grJoinSwitch := types.JoinSwitchPrefix + routerName
+			foundSwitches = append(foundSwitches, grExtSwitch, grJoinSwitch)
+		}
+	}
+	if len(foundSwitches) > 0 {
+		logger.V(5).Info("Service Sync: Removing OVN stale reject ACL from logical switches "+
+			"that contain load balancer", "switches", foundSwitches)
+		ovn.removeACLFromNodeSwitches(ctx, logger, foundSwitches, aclUUID)
+	}
+}
+
 // Remove the ACL uuid entry from Logical Switch acl's list.
-func (ovn *Controller) removeACLFromNodeSwitches(switches []string, aclUUID string) {
+func (ovn *Controller) removeACLFromNodeSwitches(ctx context.Context, logger serviceLogger, switches []string, aclUUID string) {
 	args := []string{}
 	for _, ls := range switches {
-		args = append(args, "--", "--if-exists", "remove", "logical_switch", ls, "acl", aclUUID)
+		args = append(args, "--", "--if-exists", "remove", "logical_switch", ls, "acls", aclUUID)
 	}
 
 	if len(args) > 0 {
-		_, _, err := util.RunOVNNbctl(args...)
+		_, _, err := loadbalancer.RunMutatingOVNNbctlContext(ctx, args...)
 		if err != nil {
-			klog.Errorf("Error while removing ACL: %s, from switches, error: %v", aclUUID, err)
+			logger.Error(err, "Error while removing ACL from switches", "acl", aclUUID, "switches", switches)
 		} else {
-			klog.Infof("ACL: %s, removed from switches: %s", aclUUID, switches)
+			logger.Info("ACL removed from switches", "acl", aclUUID, "switches", switches)
+		}
+	}
+}
+
+// migrateRejectACLToPortGroup moves the reject ACL aclUUID of lb off the logical switches the
+// implementations predating the cluster port group attached it to, and onto the port group when lb
+// is on node switches. The external switches of the gateway router of lb keep it. Nothing is done
+// once the ACL is on no other switch, so that it can run on every sync.
+func (ovn *Controller) migrateRejectACLToPortGroup(ctx context.Context, logger serviceLogger, lb, aclUUID string) {
+	logger = logger.WithValues("acl", aclUUID)
+	attached, stderr, err := util.RunOVNNbctl("--data=bare", "--no-heading", "--columns=name", "find",
+		"logical_switch", "acls{>=}"+aclUUID)
+	if err != nil {
+		logger.Error(err, "Failed to find the logical switches of reject ACL", "stderr", stderr)
+		return
+	}
+	if attached == "" {
+		return
+	}
+	gwRouterExtSwitches, err := ovn.getGRLogicalSwitchesForLoadBalancer(lb)
+	if err != nil {
+		logger.Error(err, "Unable to query logical switches for GR with load balancer")
+		return
+	}
+	extSwitches := sets.NewString(gwRouterExtSwitches...)
+	var legacySwitches []string
+	for _, ls := range strings.Fields(attached) {
+		if !extSwitches.Has(ls) {
+			legacySwitches = append(legacySwitches, ls)
 		}
 	}
+	if len(legacySwitches) == 0 {
+		return
+	}
+	switches, err := ovn.getLogicalSwitchesForLoadBalancer(lb)
+	if err != nil {
+		logger.Error(err, "Error finding node logical switches for load balancer")
+		return
+	}
+
+	var args []string
+	if len(switches) > 0 {
+		args = append(args, "--", "add", "port_group", ovn.clusterPortGroupUUID, "acls", aclUUID)
+	}
+	for _, ls := range legacySwitches {
+		args = append(args, "--", "--if-exists", "remove", "logical_switch", ls, "acls", aclUUID)
+	}
+	logger.Info("Moving reject ACL from logical switches to the cluster port group", "switches", legacySwitches,
+		"portGroup", ovn.clusterPortGroupUUID)
+	if _, stderr, err := loadbalancer.RunMutatingOVNNbctlContext(ctx, args...); err != nil {
+		logger.Error(err, "Failed to move reject ACL to the cluster port group", "stderr", stderr)
+	}
 }
 
-func (ovn *Controller) removeACLFromPortGroup(lb, aclUUID string) {
-	_, stderr, err := util.RunOVNNbctl("--", "--if-exists", "remove", "port_group", ovn.clusterPortGroupUUID, "acls", aclUUID)
+func (ovn *Controller) removeACLFromPortGroup(ctx context.Context, logger serviceLogger, lb, aclUUID string) {
+	_, stderr, err := loadbalancer.RunMutatingOVNNbctlContext(ctx, "--", "--if-exists", "remove", "port_group", ovn.clusterPortGroupUUID, "acls", aclUUID)
 	if err != nil {
-		klog.Errorf("Failed to remove reject ACL %s from LB %s: stderr: %q, error: %v", aclUUID, lb, stderr, err)
+		logger.Error(err, "Failed to remove reject ACL from the cluster port group", "acl", aclUUID, "stderr", stderr)
 	} else {
-		klog.Infof("ACL: %s, removed from the port group : %s", aclUUID, ovn.clusterPortGroupUUID)
+		logger.Info("ACL removed from the cluster port group", "acl", aclUUID, "portGroup", ovn.clusterPortGroupUUID)
 	}
 }
@@ -1,25 +1,58 @@
 package loadbalancer
 
 import (
-	"encoding/json"
+	"bytes"
+	"context"
 	"fmt"
 	"github.com/pkg/errors"
 	utilnet "k8s.io/utils/net"
+	"net"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/gateway"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
 
 	kapi "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
 )
 
+// MetricNbctlOpDuration is the latency of the ovn-nbctl calls of the load balancer operations, by
+// operation. It is set only once the ovnkube master controller starts.
+var MetricNbctlOpDuration *prometheus.HistogramVec
+
+// The load balancer operations reported by MetricNbctlOpDuration
+const (
+	nbctlOpGetOVNKubeLoadBalancer = "get_ovnkube_load_balancer"
+	nbctlOpGetLoadBalancerVIPs    = "get_load_balancer_vips"
+	nbctlOpUpdateLoadBalancer     = "update_load_balancer"
+	nbctlOpDeleteLoadBalancerVIP  = "delete_load_balancer_vip"
+)
+
+// runNbctlOp runs the ovn-nbctl command of the load balancer operation op with run, recording its
+// latency in MetricNbctlOpDuration
+func runNbctlOp(op string, run func(args ...string) (string, string, error), args ...string) (string, string, error) {
+	start := time.Now()
+	stdout, stderr, err := run(args...)
+	if MetricNbctlOpDuration != nil {
+		MetricNbctlOpDuration.WithLabelValues(op).Observe(time.Since(start).Seconds())
+	}
+	return stdout, stderr, err
+}
+
 // GetOVNKubeLoadBalancer returns the LoadBalancer matching the protocol
 // in the OVN database using the external_ids = k8s-cluster-lb-${protocol}
 func GetOVNKubeLoadBalancer(protocol kapi.Protocol) (string, error) {
 	id := fmt.Sprintf("external_ids:k8s-cluster-lb-%s=yes", strings.ToLower(string(protocol)))
-	out, _, err := util.RunOVNNbctl("--data=bare", "--no-heading", "--columns=_uuid",
-		"find", "load_balancer", id)
+	out, _, err := runNbctlOp(nbctlOpGetOVNKubeLoadBalancer, util.RunOVNNbctl, "--data=bare", "--no-heading",
+		"--columns=_uuid", "find", "load_balancer", id)
 	if err != nil {
 		return "", err
 	}
@@ -29,32 +62,267 @@ func GetOVNKubeLoadBalancer(protocol kapi.Protocol) (string, error) {
 	return out, nil
 }
 
-// GetLoadBalancerVIPs returns a map whose keys are VIPs (IP:port) on loadBalancer
+// GetLoadBalancerVIPs returns a map whose keys are VIPs (IP:port) on loadBalancer. When some
+// entries of the vips column cannot be parsed, the map of the other entries is returned along
+// with an error listing the malformed ones, so that a single bad VIP does not hide the others.
 func GetLoadBalancerVIPs(loadBalancer string) (map[string]string, error) {
-	var vips map[string]string
-	outStr, _, err := util.RunOVNNbctl("--data=bare", "--no-heading",
+	outStr, _, err := runNbctlOp(nbctlOpGetLoadBalancerVIPs, util.RunOVNNbctl, "--data=bare", "--no-heading",
 		"get", "load_balancer", loadBalancer, "vips")
 	if err != nil {
 		return nil, err
 	}
 	if outStr == "" {
-		return vips, nil
+		return nil, nil
+	}
+	return ParseLoadBalancerVIPs(outStr)
+}
+
+// ParseLoadBalancerVIPs parses the vips column of a load balancer, in the OVN map format printed
+// by ovn-nbctl:
+// - {"192.168.0.1:80"="10.1.1.1:80,10.2.2.2:80"}
+// - {"[fd01::]:80"="[fd02::]:80,[fd03::]:80"}
+// Quoted keys and values may contain any character, escaped as in C. Every entry is parsed on its
+// own: the entries whose syntax is broken or whose key is not an IP or IP:port are left out of
+// the returned map and reported in the returned error.
+func ParseLoadBalancerVIPs(out string) (map[string]string, error) {
+	out = strings.TrimSpace(out)
+	if !strings.HasPrefix(out, "{") || !strings.HasSuffix(out, "}") {
+		return nil, fmt.Errorf("invalid load balancer vips %q: not a map", out)
+	}
+	vips := make(map[string]string)
+	var errs []error
+	for _, entry := range splitOVNMapEntries(out[1 : len(out)-1]) {
+		vip, targets, err := parseOVNMapEntry(entry)
+		if err == nil {
+			err = validateVIP(vip)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid load balancer vip %q: %v", entry, err))
+			continue
+		}
+		vips[vip] = targets
+	}
+	return vips, utilerrors.NewAggregate(errs)
+}
+
+// splitOVNMapEntries splits the entries of an OVN map, without its braces, at the commas that are
+// not quoted
+func splitOVNMapEntries(s string) []string {
+	var entries []string
+	quoted, escaped := false, false
+	start := 0
+	for i, c := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case quoted && c == '\\':
+			escaped = true
+		case c == '"':
+			quoted = !quoted
+		case !quoted && c == ',':
+			entries = append(entries, s[start:i])
+			start = i + 1
+		}
+	}
+	entries = append(entries, s[start:])
+	var nonEmpty []string
+	for _, entry := range entries {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			nonEmpty = append(nonEmpty, entry)
+		}
+	}
+	return nonEmpty
+}
+
+// parseOVNMapEntry parses a key=value entry of an OVN map
+func parseOVNMapEntry(entry string) (string, string, error) {
+	key, rest, err := parseOVNString(entry)
+	if err != nil {
+		return "", "", err
+	}
+	rest = strings.TrimSpace(rest)
+	if !strings.HasPrefix(rest, "=") {
+		return "", "", fmt.Errorf("missing = after the key")
+	}
+	value, rest, err := parseOVNString(strings.TrimSpace(rest[1:]))
+	if err != nil {
+		return "", "", err
+	}
+	if strings.TrimSpace(rest) != "" {
+		return "", "", fmt.Errorf("unexpected %q after the value", rest)
+	}
+	return key, value, nil
+}
+
+// parseOVNString parses the quoted or bare string at the start of s, and returns it along with
+// the rest of s
+func parseOVNString(s string) (string, string, error) {
+	if !strings.HasPrefix(s, "\"") {
+		end := strings.IndexAny(s, "= ")
+		if end < 0 {
+			end = len(s)
+		}
+		return s[:end], s[end:], nil
+	}
+	escaped := false
+	for i := 1; i < len(s); i++ {
+		switch {
+		case escaped:
+			escaped = false
+		case s[i] == '\\':
+			escaped = true
+		case s[i] == '"':
+			unquoted, err := strconv.Unquote(s[:i+1])
+			if err != nil {
+				return "", "", err
+			}
+			return unquoted, s[i+1:], nil
+		}
+	}
+	return "", "", fmt.Errorf("unterminated quoted string")
+}
+
+// validateVIP returns an error if vip is neither an IP nor an IP:port
+func validateVIP(vip string) error {
+	if net.ParseIP(vip) != nil {
+		return nil
 	}
-	// sample outStr:
-	// - {"192.168.0.1:80"="10.1.1.1:80,10.2.2.2:80"}
-	// - {"[fd01::]:80"="[fd02::]:80,[fd03::]:80"}
-	outStrMap := strings.Replace(outStr, "=", ":", -1)
-	err = json.Unmarshal([]byte(outStrMap), &vips)
+	if ip, _, err := util.SplitHostPortInt32(vip); err != nil || net.ParseIP(ip) == nil {
+		return fmt.Errorf("not an IP or IP:port")
+	}
+	return nil
+}
+
+// LoadBalancerRole is the role of a load balancer managed by ovn-kubernetes
+type LoadBalancerRole string
+
+const (
+	// LoadBalancerRoleCluster is a cluster wide load balancer for east-west traffic
+	LoadBalancerRoleCluster LoadBalancerRole = "cluster"
+	// LoadBalancerRoleGateway is the load balancer of a gateway router
+	LoadBalancerRoleGateway LoadBalancerRole = "gateway"
+	// LoadBalancerRoleWorker is the load balancer of a node logical switch
+	LoadBalancerRoleWorker LoadBalancerRole = "worker"
+)
+
+// LoadBalancerVIPs describes a load balancer managed by ovn-kubernetes and its VIPs
+type LoadBalancerVIPs struct {
+	Role     LoadBalancerRole
+	Protocol kapi.Protocol
+	// Owner is the gateway router of a gateway load balancer or the node of a worker
+	// load balancer, and is empty for cluster load balancers
+	Owner string
+	// VIPs maps each VIP (IP:port) to its comma separated targets
+	VIPs map[string]string
+}
+
+// ListAllLoadBalancerVIPs returns, keyed by UUID, the VIPs (IP:port) of every load balancer
+// managed by ovn-kubernetes (cluster, gateway router and worker ones) mapped to their comma
+// separated targets. Load balancers that cannot be looked up, or whose VIPs cannot be read or
+// parsed, are logged and skipped, as ListLoadBalancerVIPs does.
+func ListAllLoadBalancerVIPs() (map[string]map[string]string, error) {
+	lbs, err := ListLoadBalancerVIPs(gateway.GetOvnGateways)
 	if err != nil {
 		return nil, err
 	}
+	vips := make(map[string]map[string]string, len(lbs))
+	for lb, info := range lbs {
+		vips[lb] = info.VIPs
+	}
 	return vips, nil
 }
 
+// ListLoadBalancerVIPs returns, keyed by UUID, every load balancer managed by ovn-kubernetes
+// along with its role, protocol, owner and VIPs, getting the gateway routers from
+// getGatewayRouters, for callers that already looked them up. Only a failure to look up the
+// cluster load balancers is returned: the gateway router and worker load balancers that cannot
+// be looked up, and the load balancers whose VIPs cannot be read, are logged and skipped.
+func ListLoadBalancerVIPs(getGatewayRouters func() ([]string, string, error)) (map[string]*LoadBalancerVIPs, error) {
+	lbs := make(map[string]*LoadBalancerVIPs)
+	add := func(lb string, role LoadBalancerRole, protocol kapi.Protocol, owner string) {
+		if lb != "" {
+			lbs[lb] = &LoadBalancerVIPs{Role: role, Protocol: protocol, Owner: owner}
+		}
+	}
+	protocols := []kapi.Protocol{kapi.ProtocolTCP, kapi.ProtocolUDP, kapi.ProtocolSCTP}
+
+	for i, externalID := range []string{types.ClusterLBTCP, types.ClusterLBUDP, types.ClusterLBSCTP} {
+		lb, stderr, err := util.FindOVNLoadBalancer(externalID, "yes")
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get cluster %s load balancer, stderr: %q", protocols[i], stderr)
+		}
+		add(lb, LoadBalancerRoleCluster, protocols[i], "")
+	}
+
+	gatewayRouters, stderr, err := getGatewayRouters()
+	if err != nil {
+		klog.Errorf("Skipping the gateway router load balancers, failed to get the gateway routers, "+
+			"stderr: %q, error: %v", stderr, err)
+	}
+	for _, gatewayRouter := range gatewayRouters {
+		lbTCP, lbUDP, lbSCTP, err := gateway.GetGatewayLoadBalancers(gatewayRouter)
+		if err != nil {
+			klog.Errorf("Skipping the load balancers of gateway router %s: %v", gatewayRouter, err)
+			continue
+		}
+		for i, lb := range []string{lbTCP, lbUDP, lbSCTP} {
+			add(lb, LoadBalancerRoleGateway, protocols[i], gatewayRouter)
+		}
+	}
+
+	nodes, err := GetWorkerLoadBalancerNodes()
+	if err != nil {
+		klog.Errorf("Skipping the worker load balancers, failed to get their nodes: %v", err)
+	}
+	for _, node := range nodes {
+		lbTCP, lbUDP, lbSCTP, err := GetWorkerLoadBalancers(node)
+		if err != nil {
+			klog.Errorf("Skipping the worker load balancers of node %s: %v", node, err)
+			continue
+		}
+		for i, lb := range []string{lbTCP, lbUDP, lbSCTP} {
+			add(lb, LoadBalancerRoleWorker, protocols[i], node)
+		}
+	}
+
+	for lb, info := range lbs {
+		vips, err := GetLoadBalancerVIPs(lb)
+		if err != nil && vips == nil {
+			klog.Errorf("Skipping %s %s load balancer %s, failed to get its VIPs: %v", info.Role, info.Protocol, lb, err)
+			delete(lbs, lb)
+			continue
+		}
+		if err != nil {
+			klog.Errorf("Ignoring the malformed VIPs of %s %s load balancer %s: %v", info.Role, info.Protocol, lb, err)
+		}
+		if vips == nil {
+			vips = make(map[string]string)
+		}
+		info.VIPs = vips
+	}
+	return lbs, nil
+}
+
+// RunMutatingOVNNbctl runs an ovn-nbctl command changing the load balancers of services or their
+// reject ACLs. With the service dry run, the command is only logged and nothing is output.
+func RunMutatingOVNNbctl(args ...string) (string, string, error) {
+	return RunMutatingOVNNbctlContext(context.Background(), args...)
+}
+
+// RunMutatingOVNNbctlContext is RunMutatingOVNNbctl killing the command once ctx is cancelled
+func RunMutatingOVNNbctlContext(ctx context.Context, args ...string) (string, string, error) {
+	if config.Kubernetes.ServiceDryRun {
+		klog.Infof("Service dry run: skipping ovn-nbctl %s", strings.Join(args, " "))
+		return "", "", nil
+	}
+	return util.RunOVNNbctlContext(ctx, args...)
+}
+
 // DeleteLoadBalancerVIP removes the VIP as well as any reject ACLs associated to the LB
 func DeleteLoadBalancerVIP(loadBalancer, vip string) error {
 	vipQuotes := fmt.Sprintf("\"%s\"", vip)
-	stdout, stderr, err := util.RunOVNNbctl("--if-exists", "remove", "load_balancer", loadBalancer, "vips", vipQuotes)
+	stdout, stderr, err := runNbctlOp(nbctlOpDeleteLoadBalancerVIP, RunMutatingOVNNbctl, "--if-exists", "remove",
+		"load_balancer", loadBalancer, "vips", vipQuotes)
 	if err != nil {
 		// if we hit an error and fail to remove load balancer, we skip removing the rejectACL
 		return fmt.Errorf("error in deleting load balancer vip %s for %s"+
@@ -64,12 +332,141 @@ func DeleteLoadBalancerVIP(loadBalancer, vip string) error {
 	return nil
 }
 
+// appProtocolExternalIDPrefix prefixes the external_ids key recording the app protocol of a VIP
+const appProtocolExternalIDPrefix = "app-protocol-"
+
+// SetLoadBalancerVIPAppProtocol records appProtocol, the app protocol of a service port, for vip
+// in the external_ids of loadBalancer, or removes the record when appProtocol is empty. OVN load
+// balancers do not terminate L7, so this is metadata for tooling and debugging only.
+func SetLoadBalancerVIPAppProtocol(ctx context.Context, loadBalancer, vip, appProtocol string) error {
+	key := appProtocolExternalIDPrefix + vip
+	var args []string
+	if appProtocol == "" {
+		args = []string{"--if-exists", "remove", "load_balancer", loadBalancer, "external_ids", fmt.Sprintf("%q", key)}
+	} else {
+		args = []string{"set", "load_balancer", loadBalancer, fmt.Sprintf("external_ids:%q=%q", key, appProtocol)}
+	}
+	stdout, stderr, err := RunMutatingOVNNbctlContext(ctx, args...)
+	if err != nil {
+		return fmt.Errorf("error in setting the app protocol of load balancer %s vip %s to %q, "+
+			"stdout: %q, stderr: %q, error: %v", loadBalancer, vip, appProtocol, stdout, stderr, err)
+	}
+	return nil
+}
+
+// proxyProtocolExternalIDPrefix prefixes the external_ids key recording that the backends of a VIP
+// expect the PROXY protocol
+const proxyProtocolExternalIDPrefix = "proxy-protocol-"
+
+// SetLoadBalancerVIPProxyProtocol records that the backends of vip expect the PROXY protocol in
+// the external_ids of loadBalancer, or removes the record when enabled is false. OVN does not send
+// PROXY headers, so this is metadata for a dataplane outside of OVN only.
+func SetLoadBalancerVIPProxyProtocol(ctx context.Context, loadBalancer, vip string, enabled bool) error {
+	key := proxyProtocolExternalIDPrefix + vip
+	var args []string
+	if !enabled {
+		args = []string{"--if-exists", "remove", "load_balancer", loadBalancer, "external_ids", fmt.Sprintf("%q", key)}
+	} else {
+		args = []string{"set", "load_balancer", loadBalancer, fmt.Sprintf("external_ids:%q=\"true\"", key)}
+	}
+	stdout, stderr, err := RunMutatingOVNNbctlContext(ctx, args...)
+	if err != nil {
+		return fmt.Errorf("error in setting the proxy protocol of load balancer %s vip %s to %t, "+
+			"stdout: %q, stderr: %q, error: %v", loadBalancer, vip, enabled, stdout, stderr, err)
+	}
+	return nil
+}
+
+// selectionFields are the packet fields OVN can hash on to select the backend of a VIP
+var selectionFields = sets.NewString("eth_src", "eth_dst", "ip_src", "ip_dst", "tp_src", "tp_dst")
+
+// ValidateLoadBalancerSelectionFields returns an error if a field is not one OVN can hash on
+func ValidateLoadBalancerSelectionFields(fields []string) error {
+	for _, field := range fields {
+		if !selectionFields.Has(field) {
+			return fmt.Errorf("invalid load balancer selection field %q, must be one of %v", field,
+				selectionFields.List())
+		}
+	}
+	return nil
+}
+
+// SetLoadBalancerSelectionFields makes loadBalancer select the backend of its VIPs by hashing
+// fields, like ip_src and ip_dst for symmetric hashing, or clears them when fields is empty so
+// that OVN hashes on the 5-tuple
+func SetLoadBalancerSelectionFields(loadBalancer string, fields []string) error {
+	if err := ValidateLoadBalancerSelectionFields(fields); err != nil {
+		return err
+	}
+	var args []string
+	if len(fields) == 0 {
+		args = []string{"clear", "load_balancer", loadBalancer, "selection_fields"}
+	} else {
+		args = []string{"set", "load_balancer", loadBalancer,
+			"selection_fields=" + strings.Join(sets.NewString(fields...).List(), ",")}
+	}
+	stdout, stderr, err := RunMutatingOVNNbctl(args...)
+	if err != nil {
+		return fmt.Errorf("error in setting the selection fields of load balancer %s to %v, "+
+			"stdout: %q, stderr: %q, error: %v", loadBalancer, fields, stdout, stderr, err)
+	}
+	return nil
+}
+
+// LoadBalancerOptionNeighborResponder is the option of a load balancer telling the routers it is
+// attached to which of its VIPs to answer ARP and neighbor solicitations for
+const LoadBalancerOptionNeighborResponder = "neighbor_responder"
+
+// SetLoadBalancerOption sets the key option of loadBalancer to value, or removes the option when
+// value is empty
+func SetLoadBalancerOption(loadBalancer, key, value string) error {
+	var args []string
+	if value == "" {
+		args = []string{"--if-exists", "remove", "load_balancer", loadBalancer, "options", key}
+	} else {
+		args = []string{"set", "load_balancer", loadBalancer, fmt.Sprintf("options:%s=%q", key, value)}
+	}
+	stdout, stderr, err := RunMutatingOVNNbctl(args...)
+	if err != nil {
+		return fmt.Errorf("error in setting the %s option of load balancer %s to %q, "+
+			"stdout: %q, stderr: %q, error: %v", key, loadBalancer, value, stdout, stderr, err)
+	}
+	return nil
+}
+
 // UpdateLoadBalancer updates the VIP for sourceIP:sourcePort to point to targets (an
 // array of IP:port strings)
 func UpdateLoadBalancer(lb, vip string, targets []string) error {
-	lbTarget := fmt.Sprintf(`vips:"%s"="%s"`, vip, strings.Join(targets, ","))
+	return UpdateLoadBalancerVIPs(lb, map[string][]string{vip: targets})
+}
+
+// UpdateLoadBalancerVIPs updates, in a single transaction, each VIP in vipTargets to
+// point to its targets (an array of IP:port strings). The targets are sorted so that
+// the same set of targets always produces the same VIP, and VIPs that already have
+// their targets are not written.
+func UpdateLoadBalancerVIPs(lb string, vipTargets map[string][]string) error {
+	if len(vipTargets) == 0 {
+		return nil
+	}
+	vips, err := GetLoadBalancerVIPs(lb)
+	if err != nil {
+		klog.Warningf("Failed to get the VIPs of load balancer %s, updating them anyway: %v", lb, err)
+	}
 
-	out, stderr, err := util.RunOVNNbctl("set", "load_balancer", lb, lbTarget)
+	args := []string{"set", "load_balancer", lb}
+	for _, vip := range sets.StringKeySet(vipTargets).List() {
+		lbTargets := strings.Join(sortTargets(vipTargets[vip]), ",")
+		if current, ok := vips[vip]; ok && sortedTargetsString(current) == lbTargets {
+			klog.V(5).Infof("Load balancer %s VIP %s already has targets %s", lb, vip, lbTargets)
+			continue
+		}
+		args = append(args, fmt.Sprintf(`vips:"%s"="%s"`, vip, lbTargets))
+	}
+	if len(args) == 3 {
+		return nil
+	}
+
+	out, stderr, err := runNbctlOp(nbctlOpUpdateLoadBalancer, RunMutatingOVNNbctl, args...)
 	if err != nil {
 		return fmt.Errorf("error in configuring load balancer: %s "+
 			"stdout: %q, stderr: %q, error: %v", lb, out, stderr, err)
@@ -78,6 +475,121 @@ func UpdateLoadBalancer(lb, vip string, targets []string) error {
 	return nil
 }
 
+// UpdateLoadBalancerTargets adds targets (an array of IP:port strings) to the current targets of
+// vip, creating the VIP if it does not exist yet. Targets the VIP already has are not added again.
+// Only vip is written, so that a new endpoint of a service with many of them is a small update.
+func UpdateLoadBalancerTargets(lb, vip string, targets []string) error {
+	return updateLoadBalancerVIPTargets(lb, vip, true, func(current []string) []string {
+		has := sets.NewString(current...)
+		for _, target := range targets {
+			if !has.Has(target) {
+				current = append(current, target)
+				has.Insert(target)
+			}
+		}
+		return current
+	})
+}
+
+// RemoveLoadBalancerTarget removes target (an IP:port string) from the targets of vip, every time
+// it appears in them as for a weighted endpoint, and leaves the other targets as they are. A VIP
+// losing its last target is kept without targets, like UpdateLoadBalancer does, and a VIP that does
+// not exist is left alone.
+func RemoveLoadBalancerTarget(lb, vip, target string) error {
+	return updateLoadBalancerVIPTargets(lb, vip, false, func(current []string) []string {
+		var kept []string
+		for _, t := range current {
+			if t != target {
+				kept = append(kept, t)
+			}
+		}
+		return kept
+	})
+}
+
+// updateLoadBalancerVIPTargets reads the targets of vip and writes back, when they differ, the
+// ones update returns. A missing VIP is created when create is true, and left alone otherwise.
+// OVN map values are plain strings, which ovn-nbctl cannot mutate in place, so the targets are
+// read and written in two transactions: the callers serialize the changes of a load balancer.
+func updateLoadBalancerVIPTargets(lb, vip string, create bool, update func(current []string) []string) error {
+	vips, err := GetLoadBalancerVIPs(lb)
+	current, ok := vips[vip]
+	if !ok && err != nil {
+		// writing without the current targets would drop them
+		return fmt.Errorf("failed to get the targets of load balancer %s vip %s: %v", lb, vip, err)
+	}
+	if !ok && !create {
+		klog.V(5).Infof("Load balancer %s has no VIP %s to update the targets of", lb, vip)
+		return nil
+	}
+	var targets []string
+	if current != "" {
+		targets = strings.Split(current, ",")
+	}
+	lbTargets := strings.Join(sortTargets(update(targets)), ",")
+	if ok && sortedTargetsString(current) == lbTargets {
+		klog.V(5).Infof("Load balancer %s VIP %s already has targets %s", lb, vip, lbTargets)
+		return nil
+	}
+
+	out, stderr, err := runNbctlOp(nbctlOpUpdateLoadBalancer, RunMutatingOVNNbctl,
+		"set", "load_balancer", lb, fmt.Sprintf(`vips:"%s"="%s"`, vip, lbTargets))
+	if err != nil {
+		return fmt.Errorf("error in configuring load balancer: %s "+
+			"stdout: %q, stderr: %q, error: %v", lb, out, stderr, err)
+	}
+	return nil
+}
+
+// sortTargets returns a sorted copy of targets (an array of IP:port strings), ordered
+// by IP and then by port. Targets that cannot be parsed are sorted as plain strings
+// after the others.
+func sortTargets(targets []string) []string {
+	sorted := make([]string, len(targets))
+	copy(sorted, targets)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ipI, portI, okI := parseTarget(sorted[i])
+		ipJ, portJ, okJ := parseTarget(sorted[j])
+		switch {
+		case okI && okJ:
+			if c := bytes.Compare(ipI, ipJ); c != 0 {
+				return c < 0
+			}
+			return portI < portJ
+		case okI != okJ:
+			return okI
+		default:
+			return sorted[i] < sorted[j]
+		}
+	})
+	return sorted
+}
+
+// parseTarget splits an IP:port target into its 16 byte IP and its port
+func parseTarget(target string) (net.IP, int, bool) {
+	host, port, err := net.SplitHostPort(target)
+	if err != nil {
+		return nil, 0, false
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return nil, 0, false
+	}
+	portNum, err := strconv.Atoi(port)
+	if err != nil {
+		return nil, 0, false
+	}
+	return ip.To16(), portNum, true
+}
+
+// sortedTargetsString sorts the comma separated targets of a VIP
+func sortedTargetsString(targets string) string {
+	if targets == "" {
+		return ""
+	}
+	return strings.Join(sortTargets(strings.Split(targets, ",")), ",")
+}
+
 // GetLogicalSwitchesForLoadBalancer get the switches associated to a LoadBalancer
 func GetLogicalSwitchesForLoadBalancer(lb string) ([]string, error) {
 	out, _, err := util.RunOVNNbctl("--data=bare", "--no-heading",
@@ -121,6 +633,43 @@ func GetGRLogicalSwitchForLoadBalancer(lb string) (string, error) {
 	return "", fmt.Errorf("router detected with load balancer that is not a GR")
 }
 
+// GetTopologyForVIP returns the names of the logical switches and routers that the load balancers
+// with vip (IP:port), as listed by ListAllLoadBalancerVIPs, are on. For a gateway router, its join
+// and external switches are returned too, as syncServices derives them. Both are sorted and free of
+// duplicates.
+func GetTopologyForVIP(vip string) ([]string, []string, error) {
+	lbs, err := ListAllLoadBalancerVIPs()
+	if err != nil {
+		return nil, nil, err
+	}
+	switches := sets.NewString()
+	routers := sets.NewString()
+	for lb, vips := range lbs {
+		if _, ok := vips[vip]; !ok {
+			continue
+		}
+		out, stderr, err := util.RunOVNNbctl("--data=bare", "--no-heading", "--columns=name", "find",
+			"logical_switch", fmt.Sprintf("load_balancer{>=}%s", lb))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to find the switches of load balancer %s, stderr: %q, error: %v",
+				lb, stderr, err)
+		}
+		switches.Insert(strings.Fields(out)...)
+		lbRouters, err := GetLogicalRoutersForLoadBalancer(lb)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to find the routers of load balancer %s: %v", lb, err)
+		}
+		for _, router := range lbRouters {
+			routers.Insert(router)
+			if strings.HasPrefix(router, types.GWRouterPrefix) {
+				node := strings.TrimPrefix(router, types.GWRouterPrefix)
+				switches.Insert(types.ExternalSwitchPrefix+node, types.JoinSwitchPrefix+node)
+			}
+		}
+	}
+	return switches.List(), routers.List(), nil
+}
+
 // GenerateACLName generates a deterministic ACL name based on the load_balancer parameters
 func GenerateACLName(lb string, sourceIP string, sourcePort int32) string {
 	aclName := fmt.Sprintf("%s-%s:%d", lb, sourceIP, sourcePort)
@@ -174,6 +723,26 @@ func GetWorkerLoadBalancer(node string, protocol kapi.Protocol) (string, error)
 	return out, nil
 }
 
+// CreateWorkerLoadBalancerVIPs either creates or updates vip (IP:port) on the protocol load
+// balancer of the worker switch of node, to point to targets (an array of IP:port strings)
+func CreateWorkerLoadBalancerVIPs(node string, protocol kapi.Protocol, vip string, targets []string) error {
+	lb, err := GetWorkerLoadBalancer(node, protocol)
+	if err != nil {
+		return err
+	}
+	return UpdateLoadBalancer(lb, vip, targets)
+}
+
+// DeleteWorkerLoadBalancerVIP removes vip (IP:port) from the protocol load balancer of the
+// worker switch of node
+func DeleteWorkerLoadBalancerVIP(node string, protocol kapi.Protocol, vip string) error {
+	lb, err := GetWorkerLoadBalancer(node, protocol)
+	if err != nil {
+		return err
+	}
+	return DeleteLoadBalancerVIP(lb, vip)
+}
+
 // GetWorkerLoadBalancers find TCP, SCTP, UDP load-balancers from worker
 func GetWorkerLoadBalancers(node string) (string, string, string, error) {
 	lbTCP, stderr, err := util.FindOVNLoadBalancer(types.WorkerLBTCP, node)
@@ -196,28 +765,164 @@ func GetWorkerLoadBalancers(node string) (string, string, string, error) {
 	return lbTCP, lbUDP, lbSCTP, nil
 }
 
+// EnsureWorkerLoadBalancers returns the TCP, UDP and SCTP load balancers of the worker switch of
+// node, creating the ones that do not exist yet with the external_ids GetWorkerLoadBalancer finds
+// them by. The SCTP load balancer is only created when OVN supports SCTP, and is empty otherwise.
+func EnsureWorkerLoadBalancers(node string, sctpSupport bool) (string, string, string, error) {
+	lbTCP, lbUDP, lbSCTP, err := GetWorkerLoadBalancers(node)
+	if err != nil {
+		return "", "", "", err
+	}
+	lbs := map[kapi.Protocol]string{
+		kapi.ProtocolTCP:  lbTCP,
+		kapi.ProtocolUDP:  lbUDP,
+		kapi.ProtocolSCTP: lbSCTP,
+	}
+	protocols := []kapi.Protocol{kapi.ProtocolTCP, kapi.ProtocolUDP}
+	if sctpSupport {
+		protocols = append(protocols, kapi.ProtocolSCTP)
+	}
+	for _, protocol := range protocols {
+		if lbs[protocol] != "" {
+			continue
+		}
+		proto := strings.ToLower(string(protocol))
+		lb, stderr, err := util.RunOVNNbctl("--", "create", "load_balancer",
+			fmt.Sprintf("external_ids:%s-%s=%s", types.WorkerLBPrefix, proto, node),
+			fmt.Sprintf("protocol=%s", proto))
+		if err != nil {
+			return "", "", "", fmt.Errorf("failed to create load balancer for worker node %s for protocol %s: "+
+				"stderr: %q, error: %v", node, protocol, stderr, err)
+		}
+		klog.Infof("Created worker %s %s load balancer %s", node, protocol, lb)
+		lbs[protocol] = lb
+	}
+	return lbs[kapi.ProtocolTCP], lbs[kapi.ProtocolUDP], lbs[kapi.ProtocolSCTP], nil
+}
+
+// CleanupWorkerLoadBalancers removes the TCP, UDP and SCTP load balancers of a worker node,
+// detaching them first from the logical switches they are applied to
+func CleanupWorkerLoadBalancers(node string) error {
+	lbTCP, lbUDP, lbSCTP, err := GetWorkerLoadBalancers(node)
+	if err != nil {
+		return err
+	}
+	for _, lb := range []string{lbTCP, lbUDP, lbSCTP} {
+		if lb == "" {
+			continue
+		}
+		switches, err := GetLogicalSwitchesForLoadBalancer(lb)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get logical switches for worker %q load balancer %s", node, lb)
+		}
+		var args []string
+		for _, ls := range switches {
+			args = append(args, "--", "--if-exists", "ls-lb-del", ls, lb)
+		}
+		args = append(args, "--", "--if-exists", "lb-del", lb)
+		stdout, stderr, err := util.RunOVNNbctl(args...)
+		if err != nil {
+			return fmt.Errorf("failed to delete worker %q load balancer %s, "+
+				"stdout: %q, stderr: %q, error: %v", node, lb, stdout, stderr, err)
+		}
+		klog.Infof("Deleted worker %s load balancer %s", node, lb)
+	}
+	return nil
+}
+
+// GetWorkerLoadBalancerNodes returns the names of the nodes that have at least one
+// worker load balancer in the OVN database
+func GetWorkerLoadBalancerNodes() ([]string, error) {
+	out, stderr, err := util.RunOVNNbctl("--format=csv", "--data=bare", "--no-heading",
+		"--columns=external_ids", "find", "load_balancer")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list load balancers, stderr: %q, error: %v", stderr, err)
+	}
+	nodes := sets.NewString()
+	for _, externalID := range strings.Fields(out) {
+		for _, prefix := range []string{types.WorkerLBTCP, types.WorkerLBUDP, types.WorkerLBSCTP} {
+			if strings.HasPrefix(externalID, prefix+"=") {
+				nodes.Insert(strings.TrimPrefix(externalID, prefix+"="))
+			}
+		}
+	}
+	return nodes.List(), nil
+}
+
 // CreateLoadBalancerVIPs either creates or updates a set of load balancer VIPs mapping
 // from sourcePort on each IP of a given address family in sourceIPs, to targetPort on
-// each IP of the same address family in targetIPs
+// each IP of the same address family in targetIPs. It fails without updating any VIP
+// when a source IP has no target of its family while targetIPs is not empty.
 func CreateLoadBalancerVIPs(lb string,
 	sourceIPs []string, sourcePort int32,
 	targetIPs []string, targetPort int32) error {
-	klog.V(5).Infof("Creating lb with %s, [%v], %d, [%v], %d", lb, sourceIPs, sourcePort, targetIPs, targetPort)
+	return CreateWeightedLoadBalancerVIPs(lb, sourceIPs, sourcePort, targetIPs, targetPort, nil)
+}
 
+// CreateWeightedLoadBalancerVIPs is like CreateLoadBalancerVIPs, with each target IP repeated as
+// many times as its weight in weights, as done by WeightTargetIPs
+func CreateWeightedLoadBalancerVIPs(lb string,
+	sourceIPs []string, sourcePort int32,
+	targetIPs []string, targetPort int32, weights map[string]int) error {
+	targetIPs = WeightTargetIPs(targetIPs, weights)
+	targets := make([]string, 0, len(targetIPs))
+	for _, targetIP := range targetIPs {
+		targets = append(targets, util.JoinHostPortInt32(targetIP, targetPort))
+	}
+	return CreateLoadBalancerVIPTargets(lb, sourceIPs, sourcePort, targets)
+}
+
+// WeightTargetIPs returns targetIPs with each IP repeated as many times as its weight in weights,
+// so that an OVN load balancer, which picks its targets evenly, sends it a proportional share of
+// the traffic. IPs without a weight, or with a weight below 1, appear once. targetIPs is returned
+// as is when there are no weights.
+func WeightTargetIPs(targetIPs []string, weights map[string]int) []string {
+	if len(weights) == 0 {
+		return targetIPs
+	}
+	weighted := make([]string, 0, len(targetIPs))
+	for _, targetIP := range targetIPs {
+		weighted = append(weighted, targetIP)
+		for i := 1; i < weights[targetIP]; i++ {
+			weighted = append(weighted, targetIP)
+		}
+	}
+	return weighted
+}
+
+// CreateLoadBalancerVIPTargets is like CreateLoadBalancerVIPs, for targets given as IP:port
+// pairs so that each backend can be reached on its own port, as with named target ports
+// resolving to different port numbers on different pods.
+func CreateLoadBalancerVIPTargets(lb string, sourceIPs []string, sourcePort int32, targets []string) error {
+	klog.V(5).Infof("Creating lb with %s, [%v], %d, [%v]", lb, sourceIPs, sourcePort, targets)
+
+	targetIsIPv6 := make([]bool, len(targets))
+	for i, target := range targets {
+		ip, _, ok := parseTarget(target)
+		if !ok {
+			return fmt.Errorf("failed to create VIPs on load balancer %s: invalid target %q", lb, target)
+		}
+		targetIsIPv6[i] = ip.To4() == nil
+	}
+
+	vipTargets := make(map[string][]string, len(sourceIPs))
 	for _, sourceIP := range sourceIPs {
 		isIPv6 := utilnet.IsIPv6String(sourceIP)
 
-		var targets []string
-		for _, targetIP := range targetIPs {
-			if utilnet.IsIPv6String(targetIP) == isIPv6 {
-				targets = append(targets, util.JoinHostPortInt32(targetIP, targetPort))
+		var familyTargets []string
+		for i, target := range targets {
+			if targetIsIPv6[i] == isIPv6 {
+				familyTargets = append(familyTargets, target)
 			}
 		}
 		vip := util.JoinHostPortInt32(sourceIP, sourcePort)
-		err := UpdateLoadBalancer(lb, vip, targets)
-		if err != nil {
-			return err
+		// A VIP without targets is only expected when there are no targets at all, so that
+		// traffic gets rejected. Targets of the other family only would black hole it.
+		if len(familyTargets) == 0 && len(targets) > 0 {
+			return fmt.Errorf("failed to create VIP %s on load balancer %s: no target of the same "+
+				"IP family in %v", vip, lb, targets)
 		}
+		vipTargets[vip] = familyTargets
 	}
-	return nil
+	return UpdateLoadBalancerVIPs(lb, vipTargets)
 }
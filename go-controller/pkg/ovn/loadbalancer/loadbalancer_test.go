@@ -1,12 +1,17 @@
 package loadbalancer
 
 import (
+	"context"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/gateway"
 	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	kapi "k8s.io/api/core/v1"
 )
 
@@ -92,6 +97,53 @@ func TestGetLoadBalancerVIPs(t *testing.T) {
 			want:    nil,
 			wantErr: false,
 		},
+		{
+			name:         "load balancer without VIPs",
+			loadBalancer: "my-lb",
+			ovnCmd: ovntest.ExpectedCmd{
+				Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer my-lb vips",
+				Output: "{}",
+			},
+			want:    map[string]string{},
+			wantErr: false,
+		},
+		{
+			name:         "IPv6 VIPs whose targets have brackets and special characters",
+			loadBalancer: "my-lb",
+			ovnCmd: ovntest.ExpectedCmd{
+				Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer my-lb vips",
+				Output: `{"[fd00:10:96::1]:443"="[fd00:10:244::3]:6443,[fd00:10:244::5]:6443", "[fd00:10:96::a]:53"="[fd00:10:244::2]:53=a,b\"c", "fd00:10:96::b"=""}`,
+			},
+			want: map[string]string{
+				"[fd00:10:96::1]:443": "[fd00:10:244::3]:6443,[fd00:10:244::5]:6443",
+				"[fd00:10:96::a]:53":  `[fd00:10:244::2]:53=a,b"c`,
+				"fd00:10:96::b":       "",
+			},
+			wantErr: false,
+		},
+		{
+			name:         "malformed entries are left out",
+			loadBalancer: "my-lb",
+			ovnCmd: ovntest.ExpectedCmd{
+				Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer my-lb vips",
+				Output: `{"[fd00:10:96::1:443"="[fd00:10:244::3]:6443", "10.96.0.10:53"="10.244.2.3:53", "10.96.0.11:53" "10.244.2.4:53", "10.96.0.1:443"="172.19.0.3:6443"}`,
+			},
+			want: map[string]string{
+				"10.96.0.10:53": "10.244.2.3:53",
+				"10.96.0.1:443": "172.19.0.3:6443",
+			},
+			wantErr: true,
+		},
+		{
+			name:         "not a map",
+			loadBalancer: "my-lb",
+			ovnCmd: ovntest.ExpectedCmd{
+				Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer my-lb vips",
+				Output: `"10.96.0.10:53"="10.244.2.3:53"`,
+			},
+			want:    nil,
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -101,6 +153,7 @@ func TestGetLoadBalancerVIPs(t *testing.T) {
 			if err != nil {
 				t.Errorf("fexec error: %v", err)
 			}
+			// the well formed VIPs are returned even along with an error
 			got, err := GetLoadBalancerVIPs(tt.loadBalancer)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetLoadBalancerVIPs() error = %v, wantErr %v", err, tt.wantErr)
@@ -160,19 +213,68 @@ func TestDeleteLoadBalancerVIP(t *testing.T) {
 	}
 }
 
-func TestUpdateLoadBalancer(t *testing.T) {
-	type args struct {
-		lb      string
-		vip     string
-		targets []string
+func TestDeleteLoadBalancerVIPOpDuration(t *testing.T) {
+	MetricNbctlOpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "test_nbctl_op_duration_seconds",
+	}, []string{"operation"})
+	defer func() {
+		MetricNbctlOpDuration = nil
+	}()
+	fexec := ovntest.NewLooseCompareFakeExec()
+	fexec.AddFakeCmdsNoOutputNoError([]string{
+		`ovn-nbctl --timeout=15 --if-exists remove load_balancer my-lb vips "10.96.0.10:53"`,
+	})
+	if err := util.SetExec(fexec); err != nil {
+		t.Fatalf("fexec error: %v", err)
+	}
+
+	if err := DeleteLoadBalancerVIP("my-lb", "10.96.0.10:53"); err != nil {
+		t.Fatalf("DeleteLoadBalancerVIP() error = %v", err)
 	}
+	for op, want := range map[string]uint64{nbctlOpDeleteLoadBalancerVIP: 1, nbctlOpUpdateLoadBalancer: 0} {
+		metric := &dto.Metric{}
+		if err := MetricNbctlOpDuration.WithLabelValues(op).(prometheus.Histogram).Write(metric); err != nil {
+			t.Fatalf("failed to read the %s histogram: %v", op, err)
+		}
+		if got := metric.GetHistogram().GetSampleCount(); got != want {
+			t.Errorf("%s histogram got %d samples, want %d", op, got, want)
+		}
+	}
+}
+
+func TestSetLoadBalancerVIPAppProtocol(t *testing.T) {
 	tests := []struct {
-		name    string
-		args    args
-		ovnCmd  ovntest.ExpectedCmd
-		wantErr bool
+		name        string
+		vip         string
+		appProtocol string
+		ovnCmd      ovntest.ExpectedCmd
+		wantErr     bool
 	}{
-		// TODO: Add test cases.
+		{
+			name:        "set the app protocol of a VIP",
+			vip:         "10.96.0.10:80",
+			appProtocol: "kubernetes.io/h2c",
+			ovnCmd: ovntest.ExpectedCmd{
+				Cmd: `ovn-nbctl --timeout=15 set load_balancer my-lb external_ids:"app-protocol-10.96.0.10:80"="kubernetes.io/h2c"`,
+			},
+		},
+		{
+			name: "clear the app protocol of an IPv6 VIP",
+			vip:  "[fd00:10:96::10]:80",
+			ovnCmd: ovntest.ExpectedCmd{
+				Cmd: `ovn-nbctl --timeout=15 --if-exists remove load_balancer my-lb external_ids "app-protocol-[fd00:10:96::10]:80"`,
+			},
+		},
+		{
+			name:        "OVN error",
+			vip:         "10.96.0.10:80",
+			appProtocol: "kubernetes.io/h2c",
+			ovnCmd: ovntest.ExpectedCmd{
+				Cmd: `ovn-nbctl --timeout=15 set load_balancer my-lb external_ids:"app-protocol-10.96.0.10:80"="kubernetes.io/h2c"`,
+				Err: fmt.Errorf("connection failed"),
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -182,25 +284,51 @@ func TestUpdateLoadBalancer(t *testing.T) {
 			if err != nil {
 				t.Errorf("fexec error: %v", err)
 			}
-			if err := UpdateLoadBalancer(tt.args.lb, tt.args.vip, tt.args.targets); (err != nil) != tt.wantErr {
-				t.Errorf("UpdateLoadBalancer() error = %v, wantErr %v", err, tt.wantErr)
+			err = SetLoadBalancerVIPAppProtocol(context.Background(), "my-lb", tt.vip, tt.appProtocol)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SetLoadBalancerVIPAppProtocol() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !fexec.CalledMatchesExpected() {
+				t.Error(fexec.ErrorDesc())
 			}
 		})
 	}
 }
 
-func TestGetLogicalSwitchesForLoadBalancer(t *testing.T) {
-	type args struct {
-		lb string
-	}
+func TestSetLoadBalancerVIPProxyProtocol(t *testing.T) {
 	tests := []struct {
 		name    string
-		args    args
+		vip     string
+		enabled bool
 		ovnCmd  ovntest.ExpectedCmd
-		want    []string
 		wantErr bool
 	}{
-		// TODO: Add test cases.
+		{
+			name:    "set the proxy protocol of a VIP",
+			vip:     "10.96.0.10:80",
+			enabled: true,
+			ovnCmd: ovntest.ExpectedCmd{
+				Cmd: `ovn-nbctl --timeout=15 set load_balancer my-lb external_ids:"proxy-protocol-10.96.0.10:80"="true"`,
+			},
+		},
+		{
+			name: "clear the proxy protocol of an IPv6 VIP",
+			vip:  "[fd00:10:96::10]:80",
+			ovnCmd: ovntest.ExpectedCmd{
+				Cmd: `ovn-nbctl --timeout=15 --if-exists remove load_balancer my-lb external_ids "proxy-protocol-[fd00:10:96::10]:80"`,
+			},
+		},
+		{
+			name:    "OVN error",
+			vip:     "10.96.0.10:80",
+			enabled: true,
+			ovnCmd: ovntest.ExpectedCmd{
+				Cmd: `ovn-nbctl --timeout=15 set load_balancer my-lb external_ids:"proxy-protocol-10.96.0.10:80"="true"`,
+				Err: fmt.Errorf("connection failed"),
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -210,116 +338,1415 @@ func TestGetLogicalSwitchesForLoadBalancer(t *testing.T) {
 			if err != nil {
 				t.Errorf("fexec error: %v", err)
 			}
-			got, err := GetLogicalSwitchesForLoadBalancer(tt.args.lb)
+			err = SetLoadBalancerVIPProxyProtocol(context.Background(), "my-lb", tt.vip, tt.enabled)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("GetLogicalSwitchesForLoadBalancer() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("SetLoadBalancerVIPProxyProtocol() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("GetLogicalSwitchesForLoadBalancer() = %v, want %v", got, tt.want)
+			if !fexec.CalledMatchesExpected() {
+				t.Error(fexec.ErrorDesc())
 			}
 		})
 	}
 }
 
-func TestGetLogicalRoutersForLoadBalancer(t *testing.T) {
-	type args struct {
-		lb string
-	}
+func TestSetLoadBalancerSelectionFields(t *testing.T) {
 	tests := []struct {
 		name    string
-		args    args
-		ovnCmd  ovntest.ExpectedCmd
-		want    []string
+		fields  []string
+		ovnCmds []string
 		wantErr bool
 	}{
-		// TODO: Add test cases.
+		{
+			name:    "symmetric hashing on the IPs",
+			fields:  []string{"ip_src", "ip_dst"},
+			ovnCmds: []string{"ovn-nbctl --timeout=15 set load_balancer my-lb selection_fields=ip_dst,ip_src"},
+		},
+		{
+			name:    "default 5-tuple hashing",
+			ovnCmds: []string{"ovn-nbctl --timeout=15 clear load_balancer my-lb selection_fields"},
+		},
+		{
+			name:    "invalid field",
+			fields:  []string{"ip_src", "ip_proto"},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			fexec := ovntest.NewLooseCompareFakeExec()
-			fexec.AddFakeCmd(&tt.ovnCmd)
+			fexec := ovntest.NewFakeExec()
+			fexec.AddFakeCmdsNoOutputNoError(tt.ovnCmds)
 			err := util.SetExec(fexec)
 			if err != nil {
 				t.Errorf("fexec error: %v", err)
 			}
-			got, err := GetLogicalRoutersForLoadBalancer(tt.args.lb)
+			err = SetLoadBalancerSelectionFields("my-lb", tt.fields)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("GetLogicalRoutersForLoadBalancer() error = %v, wantErr %v", err, tt.wantErr)
-				return
+				t.Errorf("SetLoadBalancerSelectionFields() error = %v, wantErr %v", err, tt.wantErr)
 			}
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("GetLogicalRoutersForLoadBalancer() = %v, want %v", got, tt.want)
+			if !fexec.CalledMatchesExpected() {
+				t.Error(fexec.ErrorDesc())
 			}
 		})
 	}
 }
 
-func TestGenerateACLName(t *testing.T) {
-	type args struct {
-		lb         string
-		sourceIP   string
-		sourcePort int32
-	}
+func TestSetLoadBalancerOption(t *testing.T) {
 	tests := []struct {
-		name string
-		args args
-		want string
+		name    string
+		value   string
+		ovnCmds []string
 	}{
-		// TODO: Add test cases.
+		{
+			name:    "set the neighbor responder",
+			value:   "reachable",
+			ovnCmds: []string{`ovn-nbctl --timeout=15 set load_balancer my-lb options:neighbor_responder="reachable"`},
+		},
+		{
+			name:    "remove the neighbor responder",
+			ovnCmds: []string{"ovn-nbctl --timeout=15 --if-exists remove load_balancer my-lb options neighbor_responder"},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := GenerateACLName(tt.args.lb, tt.args.sourceIP, tt.args.sourcePort); got != tt.want {
-				t.Errorf("GenerateACLName() = %v, want %v", got, tt.want)
+			fexec := ovntest.NewFakeExec()
+			fexec.AddFakeCmdsNoOutputNoError(tt.ovnCmds)
+			err := util.SetExec(fexec)
+			if err != nil {
+				t.Errorf("fexec error: %v", err)
+			}
+			err = SetLoadBalancerOption("my-lb", LoadBalancerOptionNeighborResponder, tt.value)
+			if err != nil {
+				t.Errorf("SetLoadBalancerOption() error = %v", err)
+			}
+			if !fexec.CalledMatchesExpected() {
+				t.Error(fexec.ErrorDesc())
 			}
 		})
 	}
 }
 
-func TestGenerateACLNameForOVNCommand(t *testing.T) {
+func TestUpdateLoadBalancer(t *testing.T) {
 	type args struct {
-		lb         string
-		sourceIP   string
-		sourcePort int32
+		lb      string
+		vip     string
+		targets []string
 	}
 	tests := []struct {
-		name string
-		args args
-		want string
+		name    string
+		args    args
+		ovnCmds []ovntest.ExpectedCmd
+		wantErr bool
 	}{
-		// TODO: Add test cases.
+		{
+			name: "new VIP with targets sorted by IP then port",
+			args: args{
+				lb:      "a08ea426-2288-11eb-a30b-a8a1590cda29",
+				vip:     "192.168.1.1:80",
+				targets: []string{"10.0.0.10:8080", "10.0.0.2:8081", "10.0.0.2:8080"},
+			},
+			ovnCmds: []ovntest.ExpectedCmd{
+				{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer a08ea426-2288-11eb-a30b-a8a1590cda29 vips",
+					Output: "",
+				},
+				{
+					Cmd:    `ovn-nbctl --timeout=15 set load_balancer a08ea426-2288-11eb-a30b-a8a1590cda29 vips:"192.168.1.1:80"="10.0.0.2:8080,10.0.0.2:8081,10.0.0.10:8080"`,
+					Output: "",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "VIP already pointing to the targets in another order",
+			args: args{
+				lb:      "a08ea426-2288-11eb-a30b-a8a1590cda29",
+				vip:     "[fd00::1]:80",
+				targets: []string{"[fd01::2]:8080", "[fd01::1]:8080"},
+			},
+			ovnCmds: []ovntest.ExpectedCmd{
+				{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer a08ea426-2288-11eb-a30b-a8a1590cda29 vips",
+					Output: `{"[fd00::1]:80"="[fd01::2]:8080,[fd01::1]:8080"}`,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "failure to read the VIPs still updates the VIP",
+			args: args{
+				lb:      "a08ea426-2288-11eb-a30b-a8a1590cda29",
+				vip:     "192.168.1.1:80",
+				targets: []string{"10.0.0.2:8080"},
+			},
+			ovnCmds: []ovntest.ExpectedCmd{
+				{
+					Cmd: "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer a08ea426-2288-11eb-a30b-a8a1590cda29 vips",
+					Err: fmt.Errorf("error while getting VIPs"),
+				},
+				{
+					Cmd:    `ovn-nbctl --timeout=15 set load_balancer a08ea426-2288-11eb-a30b-a8a1590cda29 vips:"192.168.1.1:80"="10.0.0.2:8080"`,
+					Output: "",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "failure to update the VIP",
+			args: args{
+				lb:      "a08ea426-2288-11eb-a30b-a8a1590cda29",
+				vip:     "192.168.1.1:80",
+				targets: []string{"10.0.0.2:8080"},
+			},
+			ovnCmds: []ovntest.ExpectedCmd{
+				{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer a08ea426-2288-11eb-a30b-a8a1590cda29 vips",
+					Output: `{"192.168.1.1:80"="10.0.0.3:8080"}`,
+				},
+				{
+					Cmd: `ovn-nbctl --timeout=15 set load_balancer a08ea426-2288-11eb-a30b-a8a1590cda29 vips:"192.168.1.1:80"="10.0.0.2:8080"`,
+					Err: fmt.Errorf("error while setting VIP"),
+				},
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := GenerateACLNameForOVNCommand(tt.args.lb, tt.args.sourceIP, tt.args.sourcePort); got != tt.want {
-				t.Errorf("GenerateACLNameForOVNCommand() = %v, want %v", got, tt.want)
+			fexec := ovntest.NewLooseCompareFakeExec()
+			for i := range tt.ovnCmds {
+				fexec.AddFakeCmd(&tt.ovnCmds[i])
+			}
+			err := util.SetExec(fexec)
+			if err != nil {
+				t.Errorf("fexec error: %v", err)
+			}
+			if err := UpdateLoadBalancer(tt.args.lb, tt.args.vip, tt.args.targets); (err != nil) != tt.wantErr {
+				t.Errorf("UpdateLoadBalancer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !fexec.CalledMatchesExpected() {
+				t.Error(fexec.ErrorDesc())
 			}
 		})
 	}
 }
 
-func TestGetGRLogicalSwitchForLoadBalancer(t *testing.T) {
-	type args struct {
-		lb string
+func TestUpdateLoadBalancerTargets(t *testing.T) {
+	lb := "a08ea426-2288-11eb-a30b-a8a1590cda29"
+	getVIPs := "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer " + lb + " vips"
+	tests := []struct {
+		name    string
+		targets []string
+		ovnCmds []ovntest.ExpectedCmd
+		wantErr bool
+	}{
+		{
+			name:    "add a target to a VIP",
+			targets: []string{"10.0.0.3:8080"},
+			ovnCmds: []ovntest.ExpectedCmd{
+				{Cmd: getVIPs, Output: `{"192.168.1.1:80"="10.0.0.10:8080,10.0.0.2:8080"}`},
+				{Cmd: `ovn-nbctl --timeout=15 set load_balancer ` + lb + ` vips:"192.168.1.1:80"="10.0.0.2:8080,10.0.0.3:8080,10.0.0.10:8080"`},
+			},
+		},
+		{
+			name:    "target the VIP already has",
+			targets: []string{"10.0.0.2:8080"},
+			ovnCmds: []ovntest.ExpectedCmd{
+				{Cmd: getVIPs, Output: `{"192.168.1.1:80"="10.0.0.10:8080,10.0.0.2:8080"}`},
+			},
+		},
+		{
+			name:    "create a missing VIP",
+			targets: []string{"10.0.0.2:8080"},
+			ovnCmds: []ovntest.ExpectedCmd{
+				{Cmd: getVIPs, Output: ""},
+				{Cmd: `ovn-nbctl --timeout=15 set load_balancer ` + lb + ` vips:"192.168.1.1:80"="10.0.0.2:8080"`},
+			},
+		},
+		{
+			name:    "failure to read the targets",
+			targets: []string{"10.0.0.3:8080"},
+			ovnCmds: []ovntest.ExpectedCmd{
+				{Cmd: getVIPs, Err: fmt.Errorf("error while getting VIPs")},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fexec := ovntest.NewLooseCompareFakeExec()
+			for i := range tt.ovnCmds {
+				fexec.AddFakeCmd(&tt.ovnCmds[i])
+			}
+			err := util.SetExec(fexec)
+			if err != nil {
+				t.Errorf("fexec error: %v", err)
+			}
+			if err := UpdateLoadBalancerTargets(lb, "192.168.1.1:80", tt.targets); (err != nil) != tt.wantErr {
+				t.Errorf("UpdateLoadBalancerTargets() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !fexec.CalledMatchesExpected() {
+				t.Error(fexec.ErrorDesc())
+			}
+		})
 	}
+}
+
+func TestRemoveLoadBalancerTarget(t *testing.T) {
+	lb := "a08ea426-2288-11eb-a30b-a8a1590cda29"
+	getVIPs := "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer " + lb + " vips"
 	tests := []struct {
 		name    string
-		args    args
-		want    string
+		vip     string
+		target  string
+		ovnCmds []ovntest.ExpectedCmd
 		wantErr bool
 	}{
-		// TODO: Add test cases.
+		{
+			name:   "remove one of three targets",
+			vip:    "192.168.1.1:80",
+			target: "10.0.0.3:8080",
+			ovnCmds: []ovntest.ExpectedCmd{
+				{Cmd: getVIPs, Output: `{"192.168.1.1:80"="10.0.0.2:8080,10.0.0.3:8080,10.0.0.10:8080", "192.168.1.2:80"="10.0.0.3:8080"}`},
+				{Cmd: `ovn-nbctl --timeout=15 set load_balancer ` + lb + ` vips:"192.168.1.1:80"="10.0.0.2:8080,10.0.0.10:8080"`},
+			},
+		},
+		{
+			name:   "remove every copy of a weighted IPv6 target",
+			vip:    "[fd00::1]:80",
+			target: "[fd01::2]:8080",
+			ovnCmds: []ovntest.ExpectedCmd{
+				{Cmd: getVIPs, Output: `{"[fd00::1]:80"="[fd01::1]:8080,[fd01::2]:8080,[fd01::2]:8080"}`},
+				{Cmd: `ovn-nbctl --timeout=15 set load_balancer ` + lb + ` vips:"[fd00::1]:80"="[fd01::1]:8080"`},
+			},
+		},
+		{
+			name:   "remove the last target",
+			vip:    "192.168.1.1:80",
+			target: "10.0.0.2:8080",
+			ovnCmds: []ovntest.ExpectedCmd{
+				{Cmd: getVIPs, Output: `{"192.168.1.1:80"="10.0.0.2:8080"}`},
+				{Cmd: `ovn-nbctl --timeout=15 set load_balancer ` + lb + ` vips:"192.168.1.1:80"=""`},
+			},
+		},
+		{
+			name:   "target the VIP does not have",
+			vip:    "192.168.1.1:80",
+			target: "10.0.0.3:8080",
+			ovnCmds: []ovntest.ExpectedCmd{
+				{Cmd: getVIPs, Output: `{"192.168.1.1:80"="10.0.0.2:8080"}`},
+			},
+		},
+		{
+			name:   "missing VIP",
+			vip:    "192.168.1.1:80",
+			target: "10.0.0.2:8080",
+			ovnCmds: []ovntest.ExpectedCmd{
+				{Cmd: getVIPs, Output: ""},
+			},
+		},
+		{
+			name:   "failure to update the VIP",
+			vip:    "192.168.1.1:80",
+			target: "10.0.0.3:8080",
+			ovnCmds: []ovntest.ExpectedCmd{
+				{Cmd: getVIPs, Output: `{"192.168.1.1:80"="10.0.0.2:8080,10.0.0.3:8080"}`},
+				{
+					Cmd: `ovn-nbctl --timeout=15 set load_balancer ` + lb + ` vips:"192.168.1.1:80"="10.0.0.2:8080"`,
+					Err: fmt.Errorf("error while setting VIP"),
+				},
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := GetGRLogicalSwitchForLoadBalancer(tt.args.lb)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("GetGRLogicalSwitchForLoadBalancer() error = %v, wantErr %v", err, tt.wantErr)
-				return
+			fexec := ovntest.NewLooseCompareFakeExec()
+			for i := range tt.ovnCmds {
+				fexec.AddFakeCmd(&tt.ovnCmds[i])
 			}
-			if got != tt.want {
-				t.Errorf("GetGRLogicalSwitchForLoadBalancer() = %v, want %v", got, tt.want)
+			err := util.SetExec(fexec)
+			if err != nil {
+				t.Errorf("fexec error: %v", err)
+			}
+			if err := RemoveLoadBalancerTarget(lb, tt.vip, tt.target); (err != nil) != tt.wantErr {
+				t.Errorf("RemoveLoadBalancerTarget() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !fexec.CalledMatchesExpected() {
+				t.Error(fexec.ErrorDesc())
+			}
+		})
+	}
+}
+
+func TestCreateLoadBalancerVIPsTargetOrder(t *testing.T) {
+	lb := "a08ea426-2288-11eb-a30b-a8a1590cda29"
+	fexec := ovntest.NewLooseCompareFakeExec()
+	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer " + lb + " vips",
+		Output: "",
+	})
+	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+		Cmd:    `ovn-nbctl --timeout=15 set load_balancer ` + lb + ` vips:"192.168.1.1:80"="10.0.0.2:8080,10.0.0.10:8080,10.0.1.1:8080"`,
+		Output: "",
+	})
+	// the second call finds the targets written by the first one and must not write again
+	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer " + lb + " vips",
+		Output: `{"192.168.1.1:80"="10.0.0.2:8080,10.0.0.10:8080,10.0.1.1:8080"}`,
+	})
+	err := util.SetExec(fexec)
+	if err != nil {
+		t.Errorf("fexec error: %v", err)
+	}
+
+	for _, targetIPs := range [][]string{
+		{"10.0.1.1", "10.0.0.10", "10.0.0.2"},
+		{"10.0.0.10", "10.0.0.2", "10.0.1.1"},
+	} {
+		if err := CreateLoadBalancerVIPs(lb, []string{"192.168.1.1"}, 80, targetIPs, 8080); err != nil {
+			t.Errorf("CreateLoadBalancerVIPs() error = %v", err)
+		}
+	}
+	if !fexec.CalledMatchesExpected() {
+		t.Error(fexec.ErrorDesc())
+	}
+}
+
+func TestCreateLoadBalancerVIPs(t *testing.T) {
+	lb := "a08ea426-2288-11eb-a30b-a8a1590cda29"
+	fexec := ovntest.NewLooseCompareFakeExec()
+	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer " + lb + " vips",
+		Output: `{"192.168.1.2:80"="10.0.0.2:8080"}`,
+	})
+	// a single transaction sets every VIP that changed, each with the targets of its family
+	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+		Cmd: `ovn-nbctl --timeout=15 set load_balancer ` + lb +
+			` vips:"192.168.1.1:80"="10.0.0.2:8080"` +
+			` vips:"192.168.1.3:80"="10.0.0.2:8080"` +
+			` vips:"[fd00::1]:80"="[fd01::2]:8080"` +
+			` vips:"[fd00::2]:80"="[fd01::2]:8080"`,
+		Output: "",
+	})
+	err := util.SetExec(fexec)
+	if err != nil {
+		t.Errorf("fexec error: %v", err)
+	}
+
+	err = CreateLoadBalancerVIPs(lb,
+		[]string{"192.168.1.1", "fd00::1", "192.168.1.2", "fd00::2", "192.168.1.3"}, 80,
+		[]string{"10.0.0.2", "fd01::2"}, 8080)
+	if err != nil {
+		t.Errorf("CreateLoadBalancerVIPs() error = %v", err)
+	}
+	if !fexec.CalledMatchesExpected() {
+		t.Error(fexec.ErrorDesc())
+	}
+}
+
+func TestCreateWeightedLoadBalancerVIPs(t *testing.T) {
+	lb := "a08ea426-2288-11eb-a30b-a8a1590cda29"
+	fexec := ovntest.NewLooseCompareFakeExec()
+	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer " + lb + " vips",
+		Output: "",
+	})
+	// the target of weight 2 appears twice, the one without a weight once
+	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+		Cmd:    `ovn-nbctl --timeout=15 set load_balancer ` + lb + ` vips:"192.168.1.1:80"="10.0.0.2:8080,10.0.0.3:8080,10.0.0.3:8080"`,
+		Output: "",
+	})
+	err := util.SetExec(fexec)
+	if err != nil {
+		t.Errorf("fexec error: %v", err)
+	}
+
+	err = CreateWeightedLoadBalancerVIPs(lb, []string{"192.168.1.1"}, 80, []string{"10.0.0.3", "10.0.0.2"}, 8080,
+		map[string]int{"10.0.0.3": 2})
+	if err != nil {
+		t.Errorf("CreateWeightedLoadBalancerVIPs() error = %v", err)
+	}
+	if !fexec.CalledMatchesExpected() {
+		t.Error(fexec.ErrorDesc())
+	}
+}
+
+func TestCreateLoadBalancerVIPTargets(t *testing.T) {
+	lb := "a08ea426-2288-11eb-a30b-a8a1590cda29"
+	fexec := ovntest.NewLooseCompareFakeExec()
+	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer " + lb + " vips",
+		Output: "",
+	})
+	// the two backends expose the same named port on different port numbers
+	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+		Cmd: `ovn-nbctl --timeout=15 set load_balancer ` + lb +
+			` vips:"192.168.1.1:80"="10.0.0.2:8080,10.0.0.3:9090"` +
+			` vips:"[fd00::1]:80"="[fd01::2]:8080"`,
+		Output: "",
+	})
+	err := util.SetExec(fexec)
+	if err != nil {
+		t.Errorf("fexec error: %v", err)
+	}
+
+	err = CreateLoadBalancerVIPTargets(lb, []string{"192.168.1.1", "fd00::1"}, 80,
+		[]string{"10.0.0.3:9090", "[fd01::2]:8080", "10.0.0.2:8080"})
+	if err != nil {
+		t.Errorf("CreateLoadBalancerVIPTargets() error = %v", err)
+	}
+	if !fexec.CalledMatchesExpected() {
+		t.Error(fexec.ErrorDesc())
+	}
+
+	// targets must carry their port
+	err = CreateLoadBalancerVIPTargets(lb, []string{"192.168.1.1"}, 80, []string{"10.0.0.2"})
+	if err == nil {
+		t.Errorf("CreateLoadBalancerVIPTargets() expected an error for a target without port")
+	}
+}
+
+func TestCreateLoadBalancerVIPsIPFamilies(t *testing.T) {
+	lb := "a08ea426-2288-11eb-a30b-a8a1590cda29"
+	tests := []struct {
+		name      string
+		sourceIPs []string
+		targetIPs []string
+		ovnCmds   []ovntest.ExpectedCmd
+		wantErr   bool
+	}{
+		{
+			name:      "IPv6 source with IPv4 targets only",
+			sourceIPs: []string{"192.168.1.1", "fd00::1"},
+			targetIPs: []string{"10.0.0.2"},
+			wantErr:   true,
+		},
+		{
+			name:      "no targets of any family",
+			sourceIPs: []string{"fd00::1"},
+			targetIPs: []string{},
+			ovnCmds: []ovntest.ExpectedCmd{
+				{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer " + lb + " vips",
+					Output: "",
+				},
+				{
+					Cmd:    `ovn-nbctl --timeout=15 set load_balancer ` + lb + ` vips:"[fd00::1]:80"=""`,
+					Output: "",
+				},
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fexec := ovntest.NewLooseCompareFakeExec()
+			for i := range tt.ovnCmds {
+				fexec.AddFakeCmd(&tt.ovnCmds[i])
+			}
+			err := util.SetExec(fexec)
+			if err != nil {
+				t.Errorf("fexec error: %v", err)
+			}
+			err = CreateLoadBalancerVIPs(lb, tt.sourceIPs, 80, tt.targetIPs, 8080)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CreateLoadBalancerVIPs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !fexec.CalledMatchesExpected() {
+				t.Error(fexec.ErrorDesc())
+			}
+		})
+	}
+}
+
+func TestGetLogicalSwitchesForLoadBalancer(t *testing.T) {
+	type args struct {
+		lb string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		ovnCmd  ovntest.ExpectedCmd
+		want    []string
+		wantErr bool
+	}{
+		// TODO: Add test cases.
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fexec := ovntest.NewLooseCompareFakeExec()
+			fexec.AddFakeCmd(&tt.ovnCmd)
+			err := util.SetExec(fexec)
+			if err != nil {
+				t.Errorf("fexec error: %v", err)
+			}
+			got, err := GetLogicalSwitchesForLoadBalancer(tt.args.lb)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetLogicalSwitchesForLoadBalancer() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetLogicalSwitchesForLoadBalancer() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetLogicalRoutersForLoadBalancer(t *testing.T) {
+	type args struct {
+		lb string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		ovnCmd  ovntest.ExpectedCmd
+		want    []string
+		wantErr bool
+	}{
+		// TODO: Add test cases.
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fexec := ovntest.NewLooseCompareFakeExec()
+			fexec.AddFakeCmd(&tt.ovnCmd)
+			err := util.SetExec(fexec)
+			if err != nil {
+				t.Errorf("fexec error: %v", err)
+			}
+			got, err := GetLogicalRoutersForLoadBalancer(tt.args.lb)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetLogicalRoutersForLoadBalancer() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetLogicalRoutersForLoadBalancer() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateACLName(t *testing.T) {
+	type args struct {
+		lb         string
+		sourceIP   string
+		sourcePort int32
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		// TODO: Add test cases.
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GenerateACLName(tt.args.lb, tt.args.sourceIP, tt.args.sourcePort); got != tt.want {
+				t.Errorf("GenerateACLName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateACLNameForOVNCommand(t *testing.T) {
+	type args struct {
+		lb         string
+		sourceIP   string
+		sourcePort int32
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		// TODO: Add test cases.
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GenerateACLNameForOVNCommand(tt.args.lb, tt.args.sourceIP, tt.args.sourcePort); got != tt.want {
+				t.Errorf("GenerateACLNameForOVNCommand() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetGRLogicalSwitchForLoadBalancer(t *testing.T) {
+	type args struct {
+		lb string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		// TODO: Add test cases.
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetGRLogicalSwitchForLoadBalancer(tt.args.lb)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetGRLogicalSwitchForLoadBalancer() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("GetGRLogicalSwitchForLoadBalancer() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCleanupWorkerLoadBalancers(t *testing.T) {
+	tests := []struct {
+		name    string
+		node    string
+		ovnCmds []ovntest.ExpectedCmd
+		wantErr bool
+	}{
+		{
+			name: "deleted node with TCP, UDP and SCTP worker load balancers",
+			node: "node1",
+			ovnCmds: []ovntest.ExpectedCmd{
+				{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-worker-lb-tcp=node1",
+					Output: "tcp-lb",
+				},
+				{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-worker-lb-udp=node1",
+					Output: "udp-lb",
+				},
+				{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-worker-lb-sctp=node1",
+					Output: "sctp-lb",
+				},
+				{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_switch load_balancer{>=}tcp-lb",
+					Output: "node1-switch",
+				},
+				{
+					Cmd: "ovn-nbctl --timeout=15 -- --if-exists ls-lb-del node1-switch tcp-lb -- --if-exists lb-del tcp-lb",
+				},
+				{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_switch load_balancer{>=}udp-lb",
+					Output: "node1-switch",
+				},
+				{
+					Cmd: "ovn-nbctl --timeout=15 -- --if-exists ls-lb-del node1-switch udp-lb -- --if-exists lb-del udp-lb",
+				},
+				{
+					Cmd: "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_switch load_balancer{>=}sctp-lb",
+				},
+				{
+					Cmd: "ovn-nbctl --timeout=15 -- --if-exists lb-del sctp-lb",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "node without worker load balancers",
+			node: "node2",
+			ovnCmds: []ovntest.ExpectedCmd{
+				{
+					Cmd: "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-worker-lb-tcp=node2",
+				},
+				{
+					Cmd: "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-worker-lb-udp=node2",
+				},
+				{
+					Cmd: "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-worker-lb-sctp=node2",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "OVN error deleting a worker load balancer",
+			node: "node3",
+			ovnCmds: []ovntest.ExpectedCmd{
+				{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-worker-lb-tcp=node3",
+					Output: "tcp-lb",
+				},
+				{
+					Cmd: "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-worker-lb-udp=node3",
+				},
+				{
+					Cmd: "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-worker-lb-sctp=node3",
+				},
+				{
+					Cmd: "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_switch load_balancer{>=}tcp-lb",
+				},
+				{
+					Cmd: "ovn-nbctl --timeout=15 -- --if-exists lb-del tcp-lb",
+					Err: fmt.Errorf("transaction failed"),
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fexec := ovntest.NewFakeExec()
+			for i := range tt.ovnCmds {
+				fexec.AddFakeCmd(&tt.ovnCmds[i])
+			}
+			err := util.SetExec(fexec)
+			if err != nil {
+				t.Errorf("fexec error: %v", err)
+			}
+			err = CleanupWorkerLoadBalancers(tt.node)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CleanupWorkerLoadBalancers() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !fexec.CalledMatchesExpected() {
+				t.Error(fexec.ErrorDesc())
+			}
+		})
+	}
+}
+
+func TestEnsureWorkerLoadBalancers(t *testing.T) {
+	tests := []struct {
+		name        string
+		sctpSupport bool
+		ovnCmds     []ovntest.ExpectedCmd
+		wantLBs     []string
+		wantErr     bool
+	}{
+		{
+			name:        "node without worker load balancers",
+			sctpSupport: true,
+			ovnCmds: []ovntest.ExpectedCmd{
+				{Cmd: "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-worker-lb-tcp=node1"},
+				{Cmd: "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-worker-lb-udp=node1"},
+				{Cmd: "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-worker-lb-sctp=node1"},
+				{
+					Cmd:    "ovn-nbctl --timeout=15 -- create load_balancer external_ids:k8s-worker-lb-tcp=node1 protocol=tcp",
+					Output: "tcp-lb",
+				},
+				{
+					Cmd:    "ovn-nbctl --timeout=15 -- create load_balancer external_ids:k8s-worker-lb-udp=node1 protocol=udp",
+					Output: "udp-lb",
+				},
+				{
+					Cmd:    "ovn-nbctl --timeout=15 -- create load_balancer external_ids:k8s-worker-lb-sctp=node1 protocol=sctp",
+					Output: "sctp-lb",
+				},
+			},
+			wantLBs: []string{"tcp-lb", "udp-lb", "sctp-lb"},
+		},
+		{
+			name: "node with a TCP worker load balancer only, without SCTP support",
+			ovnCmds: []ovntest.ExpectedCmd{
+				{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-worker-lb-tcp=node1",
+					Output: "tcp-lb",
+				},
+				{Cmd: "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-worker-lb-udp=node1"},
+				{Cmd: "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-worker-lb-sctp=node1"},
+				{
+					Cmd:    "ovn-nbctl --timeout=15 -- create load_balancer external_ids:k8s-worker-lb-udp=node1 protocol=udp",
+					Output: "udp-lb",
+				},
+			},
+			wantLBs: []string{"tcp-lb", "udp-lb", ""},
+		},
+		{
+			name: "OVN error creating a worker load balancer",
+			ovnCmds: []ovntest.ExpectedCmd{
+				{Cmd: "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-worker-lb-tcp=node1"},
+				{Cmd: "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-worker-lb-udp=node1"},
+				{Cmd: "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-worker-lb-sctp=node1"},
+				{
+					Cmd: "ovn-nbctl --timeout=15 -- create load_balancer external_ids:k8s-worker-lb-tcp=node1 protocol=tcp",
+					Err: fmt.Errorf("transaction failed"),
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fexec := ovntest.NewFakeExec()
+			for i := range tt.ovnCmds {
+				fexec.AddFakeCmd(&tt.ovnCmds[i])
+			}
+			err := util.SetExec(fexec)
+			if err != nil {
+				t.Errorf("fexec error: %v", err)
+			}
+			lbTCP, lbUDP, lbSCTP, err := EnsureWorkerLoadBalancers("node1", tt.sctpSupport)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("EnsureWorkerLoadBalancers() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual([]string{lbTCP, lbUDP, lbSCTP}, tt.wantLBs) {
+				t.Errorf("EnsureWorkerLoadBalancers() = %v, want %v", []string{lbTCP, lbUDP, lbSCTP}, tt.wantLBs)
+			}
+			if !fexec.CalledMatchesExpected() {
+				t.Error(fexec.ErrorDesc())
+			}
+		})
+	}
+}
+
+func TestCreateWorkerLoadBalancerVIPs(t *testing.T) {
+	tests := []struct {
+		name     string
+		protocol kapi.Protocol
+		ovnCmds  []ovntest.ExpectedCmd
+		wantErr  bool
+	}{
+		{
+			name:     "TCP",
+			protocol: kapi.ProtocolTCP,
+			ovnCmds: []ovntest.ExpectedCmd{
+				{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-worker-lb-tcp=node1",
+					Output: "worker-tcp-lb",
+				},
+				{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer worker-tcp-lb vips",
+					Output: "",
+				},
+				{
+					Cmd: `ovn-nbctl --timeout=15 set load_balancer worker-tcp-lb vips:"192.168.0.1:30080"="10.128.0.5:8080"`,
+				},
+			},
+		},
+		{
+			name:     "UDP",
+			protocol: kapi.ProtocolUDP,
+			ovnCmds: []ovntest.ExpectedCmd{
+				{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-worker-lb-udp=node1",
+					Output: "worker-udp-lb",
+				},
+				{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer worker-udp-lb vips",
+					Output: "",
+				},
+				{
+					Cmd: `ovn-nbctl --timeout=15 set load_balancer worker-udp-lb vips:"192.168.0.1:30080"="10.128.0.5:8080"`,
+				},
+			},
+		},
+		{
+			name:     "SCTP",
+			protocol: kapi.ProtocolSCTP,
+			ovnCmds: []ovntest.ExpectedCmd{
+				{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-worker-lb-sctp=node1",
+					Output: "worker-sctp-lb",
+				},
+				{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer worker-sctp-lb vips",
+					Output: "",
+				},
+				{
+					Cmd: `ovn-nbctl --timeout=15 set load_balancer worker-sctp-lb vips:"192.168.0.1:30080"="10.128.0.5:8080"`,
+				},
+			},
+		},
+		{
+			name:     "node without worker load balancer",
+			protocol: kapi.ProtocolTCP,
+			ovnCmds: []ovntest.ExpectedCmd{
+				{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-worker-lb-tcp=node1",
+					Output: "",
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fexec := ovntest.NewFakeExec()
+			for i := range tt.ovnCmds {
+				fexec.AddFakeCmd(&tt.ovnCmds[i])
+			}
+			err := util.SetExec(fexec)
+			if err != nil {
+				t.Errorf("fexec error: %v", err)
+			}
+			err = CreateWorkerLoadBalancerVIPs("node1", tt.protocol, "192.168.0.1:30080", []string{"10.128.0.5:8080"})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CreateWorkerLoadBalancerVIPs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !fexec.CalledMatchesExpected() {
+				t.Error(fexec.ErrorDesc())
+			}
+		})
+	}
+}
+
+func TestDeleteWorkerLoadBalancerVIP(t *testing.T) {
+	tests := []struct {
+		name     string
+		protocol kapi.Protocol
+		lb       string
+	}{
+		{name: "TCP", protocol: kapi.ProtocolTCP, lb: "worker-tcp-lb"},
+		{name: "UDP", protocol: kapi.ProtocolUDP, lb: "worker-udp-lb"},
+		{name: "SCTP", protocol: kapi.ProtocolSCTP, lb: "worker-sctp-lb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fexec := ovntest.NewFakeExec()
+			fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+				Cmd: fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-worker-lb-%s=node1",
+					strings.ToLower(string(tt.protocol))),
+				Output: tt.lb,
+			})
+			fexec.AddFakeCmdsNoOutputNoError([]string{
+				fmt.Sprintf(`ovn-nbctl --timeout=15 --if-exists remove load_balancer %s vips "192.168.0.1:30080"`, tt.lb),
+			})
+			err := util.SetExec(fexec)
+			if err != nil {
+				t.Errorf("fexec error: %v", err)
+			}
+			if err := DeleteWorkerLoadBalancerVIP("node1", tt.protocol, "192.168.0.1:30080"); err != nil {
+				t.Errorf("DeleteWorkerLoadBalancerVIP() error = %v", err)
+			}
+			if !fexec.CalledMatchesExpected() {
+				t.Error(fexec.ErrorDesc())
+			}
+		})
+	}
+}
+
+func TestGetWorkerLoadBalancerNodes(t *testing.T) {
+	tests := []struct {
+		name    string
+		ovnCmd  ovntest.ExpectedCmd
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "cluster, gateway and worker load balancers",
+			ovnCmd: ovntest.ExpectedCmd{
+				Cmd: "ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=external_ids find load_balancer",
+				Output: "k8s-cluster-lb-tcp=yes\n" +
+					"TCP_lb_gateway_router=GR_node1\n" +
+					"k8s-worker-lb-tcp=node1\n" +
+					"k8s-worker-lb-udp=node1\n" +
+					"k8s-worker-lb-sctp=node2\n",
+			},
+			want:    []string{"node1", "node2"},
+			wantErr: false,
+		},
+		{
+			name: "OVN error",
+			ovnCmd: ovntest.ExpectedCmd{
+				Cmd: "ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=external_ids find load_balancer",
+				Err: fmt.Errorf("connection refused"),
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fexec := ovntest.NewLooseCompareFakeExec()
+			fexec.AddFakeCmd(&tt.ovnCmd)
+			err := util.SetExec(fexec)
+			if err != nil {
+				t.Errorf("fexec error: %v", err)
+			}
+			got, err := GetWorkerLoadBalancerNodes()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetWorkerLoadBalancerNodes() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetWorkerLoadBalancerNodes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListLoadBalancerVIPs(t *testing.T) {
+	findCmds := func(clusterUDP, gatewaySCTP string) []ovntest.ExpectedCmd {
+		return []ovntest.ExpectedCmd{
+			{
+				Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
+				Output: "cluster-tcp",
+			},
+			{
+				Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-udp=yes",
+				Output: clusterUDP,
+			},
+			{
+				Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-sctp=yes",
+				Output: "",
+			},
+			{
+				Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+				Output: "GR_node1",
+			},
+			{
+				Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:TCP_lb_gateway_router=GR_node1",
+				Output: "",
+			},
+			{
+				Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:UDP_lb_gateway_router=GR_node1",
+				Output: "",
+			},
+			{
+				Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:SCTP_lb_gateway_router=GR_node1",
+				Output: gatewaySCTP,
+			},
+			{
+				Cmd:    "ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=external_ids find load_balancer",
+				Output: "k8s-cluster-lb-tcp=yes\nk8s-worker-lb-tcp=node1",
+			},
+			{
+				Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-worker-lb-tcp=node1",
+				Output: "worker-tcp",
+			},
+			{
+				Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-worker-lb-udp=node1",
+				Output: "",
+			},
+			{
+				Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-worker-lb-sctp=node1",
+				Output: "",
+			},
+		}
+	}
+	tests := []struct {
+		name    string
+		ovnCmds []ovntest.ExpectedCmd
+		want    map[string]*LoadBalancerVIPs
+		wantErr bool
+	}{
+		{
+			name: "cluster, gateway and worker load balancers",
+			ovnCmds: append(findCmds("cluster-udp", "gateway-sctp"),
+				ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer cluster-tcp vips",
+					Output: `{"10.96.0.10:53"="10.244.2.3:53,10.244.2.5:53"}`,
+				},
+				ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer cluster-udp vips",
+					Output: `{"[fd00:10:96::10]:53"="[fd00:10:244:2::3]:53"}`,
+				},
+				ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer gateway-sctp vips",
+					Output: "",
+				},
+				ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer worker-tcp vips",
+					Output: `{"172.18.0.2:30080"="10.244.1.3:8080"}`,
+				},
+			),
+			want: map[string]*LoadBalancerVIPs{
+				"cluster-tcp": {
+					Role:     LoadBalancerRoleCluster,
+					Protocol: kapi.ProtocolTCP,
+					VIPs:     map[string]string{"10.96.0.10:53": "10.244.2.3:53,10.244.2.5:53"},
+				},
+				"cluster-udp": {
+					Role:     LoadBalancerRoleCluster,
+					Protocol: kapi.ProtocolUDP,
+					VIPs:     map[string]string{"[fd00:10:96::10]:53": "[fd00:10:244:2::3]:53"},
+				},
+				"gateway-sctp": {
+					Role:     LoadBalancerRoleGateway,
+					Protocol: kapi.ProtocolSCTP,
+					Owner:    "GR_node1",
+					VIPs:     map[string]string{},
+				},
+				"worker-tcp": {
+					Role:     LoadBalancerRoleWorker,
+					Protocol: kapi.ProtocolTCP,
+					Owner:    "node1",
+					VIPs:     map[string]string{"172.18.0.2:30080": "10.244.1.3:8080"},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "malformed VIPs are skipped",
+			ovnCmds: append(findCmds("", ""),
+				ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer cluster-tcp vips",
+					Output: `{"10.96.0.10:53"=`,
+				},
+				ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer worker-tcp vips",
+					Output: "",
+				},
+			),
+			want: map[string]*LoadBalancerVIPs{
+				"worker-tcp": {
+					Role:     LoadBalancerRoleWorker,
+					Protocol: kapi.ProtocolTCP,
+					Owner:    "node1",
+					VIPs:     map[string]string{},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "gateway router and worker load balancers that cannot be looked up are skipped",
+			ovnCmds: []ovntest.ExpectedCmd{
+				{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
+					Output: "cluster-tcp",
+				},
+				{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-udp=yes",
+					Output: "",
+				},
+				{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-sctp=yes",
+					Output: "",
+				},
+				{
+					Cmd: "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+					Err: fmt.Errorf("connection refused"),
+				},
+				{
+					Cmd:    "ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=external_ids find load_balancer",
+					Output: "k8s-worker-lb-tcp=node1",
+				},
+				{
+					Cmd: "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-worker-lb-tcp=node1",
+					Err: fmt.Errorf("connection refused"),
+				},
+				{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer cluster-tcp vips",
+					Output: `{"10.96.0.10:53"="10.244.2.3:53"}`,
+				},
+			},
+			want: map[string]*LoadBalancerVIPs{
+				"cluster-tcp": {
+					Role:     LoadBalancerRoleCluster,
+					Protocol: kapi.ProtocolTCP,
+					VIPs:     map[string]string{"10.96.0.10:53": "10.244.2.3:53"},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "OVN error",
+			ovnCmds: []ovntest.ExpectedCmd{
+				{
+					Cmd: "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
+					Err: fmt.Errorf("connection refused"),
+				},
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the VIPs of the load balancers are read in no particular order
+			fexec := ovntest.NewLooseCompareFakeExec()
+			for i := range tt.ovnCmds {
+				fexec.AddFakeCmd(&tt.ovnCmds[i])
+			}
+			err := util.SetExec(fexec)
+			if err != nil {
+				t.Errorf("fexec error: %v", err)
+			}
+			got, err := ListLoadBalancerVIPs(gateway.GetOvnGateways)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ListLoadBalancerVIPs() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ListLoadBalancerVIPs() = %v, want %v", got, tt.want)
+			}
+			if !fexec.CalledMatchesExpected() {
+				t.Error(fexec.ErrorDesc())
+			}
+		})
+	}
+}
+
+func TestListAllLoadBalancerVIPs(t *testing.T) {
+	fexec := ovntest.NewLooseCompareFakeExec()
+	fexec.AddFakeCmdsNoOutputNoError([]string{
+		"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-sctp=yes",
+		"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+		"ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=external_ids find load_balancer",
+	})
+	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
+		Output: "cluster-tcp",
+	})
+	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-udp=yes",
+		Output: "cluster-udp",
+	})
+	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer cluster-tcp vips",
+		Output: `{"10.96.0.10:53"="10.244.2.3:53,10.244.2.5:53"}`,
+	})
+	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer cluster-udp vips",
+		Output: `{"[fd00:10:96::10]:53"="[fd00:10:244:2::3]:53"}`,
+	})
+	if err := util.SetExec(fexec); err != nil {
+		t.Fatalf("fexec error: %v", err)
+	}
+	got, err := ListAllLoadBalancerVIPs()
+	if err != nil {
+		t.Fatalf("ListAllLoadBalancerVIPs() error = %v", err)
+	}
+	want := map[string]map[string]string{
+		"cluster-tcp": {"10.96.0.10:53": "10.244.2.3:53,10.244.2.5:53"},
+		"cluster-udp": {"[fd00:10:96::10]:53": "[fd00:10:244:2::3]:53"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ListAllLoadBalancerVIPs() = %v, want %v", got, want)
+	}
+	if !fexec.CalledMatchesExpected() {
+		t.Error(fexec.ErrorDesc())
+	}
+}
+
+func TestGetTopologyForVIP(t *testing.T) {
+	listCmds := []ovntest.ExpectedCmd{
+		{
+			Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
+			Output: "cluster-tcp",
+		},
+		{
+			Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-udp=yes",
+			Output: "",
+		},
+		{
+			Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-sctp=yes",
+			Output: "",
+		},
+		{
+			Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+			Output: "GR_node1",
+		},
+		{
+			Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:TCP_lb_gateway_router=GR_node1",
+			Output: "gateway-tcp",
+		},
+		{
+			Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:UDP_lb_gateway_router=GR_node1",
+			Output: "",
+		},
+		{
+			Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:SCTP_lb_gateway_router=GR_node1",
+			Output: "",
+		},
+		{
+			Cmd:    "ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=external_ids find load_balancer",
+			Output: "k8s-cluster-lb-tcp=yes\nk8s-worker-lb-tcp=node1",
+		},
+		{
+			Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-worker-lb-tcp=node1",
+			Output: "worker-tcp",
+		},
+		{
+			Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-worker-lb-udp=node1",
+			Output: "",
+		},
+		{
+			Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-worker-lb-sctp=node1",
+			Output: "",
+		},
+		{
+			Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer cluster-tcp vips",
+			Output: `{"10.96.0.10:80"="10.244.1.3:8080"}`,
+		},
+		{
+			Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer gateway-tcp vips",
+			Output: `{"172.18.0.2:30080"="10.244.1.3:8080"}`,
+		},
+		{
+			Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer worker-tcp vips",
+			Output: `{"172.18.0.2:30080"="10.244.1.3:8080"}`,
+		},
+	}
+	tests := []struct {
+		name         string
+		vip          string
+		ovnCmds      []ovntest.ExpectedCmd
+		wantSwitches []string
+		wantRouters  []string
+		wantErr      bool
+	}{
+		{
+			name: "VIP on a gateway router and a node switch",
+			vip:  "172.18.0.2:30080",
+			ovnCmds: append(append([]ovntest.ExpectedCmd{}, listCmds...),
+				ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_switch load_balancer{>=}gateway-tcp",
+					Output: "",
+				},
+				ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}gateway-tcp",
+					Output: "GR_node1",
+				},
+				ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_switch load_balancer{>=}worker-tcp",
+					Output: "node1",
+				},
+				ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}worker-tcp",
+					Output: "",
+				},
+			),
+			wantSwitches: []string{"ext_node1", "join_node1", "node1"},
+			wantRouters:  []string{"GR_node1"},
+		},
+		{
+			name:         "VIP on no load balancer",
+			vip:          "10.96.0.20:80",
+			ovnCmds:      listCmds,
+			wantSwitches: []string{},
+			wantRouters:  []string{},
+		},
+		{
+			name: "OVN error",
+			vip:  "10.96.0.10:80",
+			ovnCmds: append(append([]ovntest.ExpectedCmd{}, listCmds...),
+				ovntest.ExpectedCmd{
+					Cmd: "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_switch load_balancer{>=}cluster-tcp",
+					Err: fmt.Errorf("connection refused"),
+				},
+			),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the load balancers are looked at in no particular order
+			fexec := ovntest.NewLooseCompareFakeExec()
+			for i := range tt.ovnCmds {
+				fexec.AddFakeCmd(&tt.ovnCmds[i])
+			}
+			err := util.SetExec(fexec)
+			if err != nil {
+				t.Errorf("fexec error: %v", err)
+			}
+			switches, routers, err := GetTopologyForVIP(tt.vip)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetTopologyForVIP() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(switches, tt.wantSwitches) {
+				t.Errorf("GetTopologyForVIP() switches = %v, want %v", switches, tt.wantSwitches)
+			}
+			if !reflect.DeepEqual(routers, tt.wantRouters) {
+				t.Errorf("GetTopologyForVIP() routers = %v, want %v", routers, tt.wantRouters)
+			}
+			if !fexec.CalledMatchesExpected() {
+				t.Error(fexec.ErrorDesc())
 			}
 		})
 	}
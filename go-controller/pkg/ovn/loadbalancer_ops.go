@@ -0,0 +1,123 @@
+package ovn
+
+import (
+	"context"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/loadbalancer"
+
+	kapi "k8s.io/api/core/v1"
+)
+
+// ServiceLBBackend abstracts the OVN load balancer operations used to program
+// services, so that the service logic can be tested without an OVN database. The
+// operations changing the database stop once their ctx is cancelled.
+type ServiceLBBackend interface {
+	// GetOvnGateways returns the names of all the gateway routers
+	GetOvnGateways() ([]string, string, error)
+	// GetLoadBalancer returns the cluster load balancer of the given protocol
+	GetLoadBalancer(protocol kapi.Protocol) (string, error)
+	// GetGatewayLoadBalancer returns the load balancer of the given protocol on a gateway router
+	GetGatewayLoadBalancer(gatewayRouter string, protocol kapi.Protocol) (string, error)
+	// GetGatewayPhysicalIPs returns the physical IPs of a gateway router
+	GetGatewayPhysicalIPs(gatewayRouter string) ([]string, error)
+	// GetWorkerLoadBalancer returns the load balancer of the given protocol on the switch of a node
+	GetWorkerLoadBalancer(node string, protocol kapi.Protocol) (string, error)
+	// EnsureVIP makes every sourceIP:sourcePort a VIP of a load balancer, pointing at the targetIPs
+	// of the same IP family on targetPort. The reject ACL of a VIP getting targets is removed.
+	EnsureVIP(ctx context.Context, lb string, sourceIPs []string, sourcePort int32, targetIPs []string, targetPort int32) error
+	// RemoveVIP removes a VIP from a load balancer, along with its reject ACL
+	RemoveVIP(ctx context.Context, lb, vip string) error
+	// RemoveVIPs removes every VIP of vips from a load balancer, along with their reject ACLs, at once
+	RemoveVIPs(ctx context.Context, lb string, vips []string) error
+	// SetVIPAppProtocol records the app protocol of a VIP on its load balancer, as metadata only,
+	// or removes the record when appProtocol is empty
+	SetVIPAppProtocol(ctx context.Context, lb, vip, appProtocol string) error
+	// SetVIPProxyProtocol records that the backends of a VIP expect the PROXY protocol on its load
+	// balancer, as metadata only, or removes the record when enabled is false
+	SetVIPProxyProtocol(ctx context.Context, lb, vip string, enabled bool) error
+	// EnsureRejectACL makes sure a reject ACL, with the given action and logging meter, exists for
+	// sourceIP:sourcePort of a load balancer and returns its UUID. An existing ACL of the same name
+	// is updated in place when its fields differ.
+	EnsureRejectACL(ctx context.Context, lb, sourceIP string, sourcePort int32, proto kapi.Protocol, aclLogging, meter, action string) (string, error)
+	// EnsureRejectACLs is EnsureRejectACL for every VIP of vips of a load balancer at once, and
+	// returns their UUIDs in the order of vips
+	EnsureRejectACLs(ctx context.Context, lb string, vips []rejectACLVIP, proto kapi.Protocol, aclLogging, meter, action string) ([]string, error)
+	// EnsureRejectACLsOfLoadBalancers is EnsureRejectACLs for the VIPs of several load balancers at
+	// once, and returns the UUIDs of the ACLs of each of them in the order of lbs
+	EnsureRejectACLsOfLoadBalancers(ctx context.Context, lbs []lbRejectACLVIPs, aclLogging, meter, action string) ([][]string, error)
+	// RemoveRejectACL removes the reject ACL aclUUID of a load balancer from the cluster port group
+	// and from the switches earlier implementations applied it to. Failures are logged.
+	RemoveRejectACL(ctx context.Context, lb, aclUUID string)
+	// ClearVIP removes the targets of sourceIP:sourcePort of a load balancer but keeps the VIP. With
+	// an action, the VIP gets a reject ACL in the same transaction and its UUID is returned, or ""
+	// when only the targets could be cleared. Without one, its reject ACL, if any, is removed in the
+	// same transaction.
+	ClearVIP(ctx context.Context, lb, sourceIP string, sourcePort int32, proto kapi.Protocol, aclLogging, meter, action string) (string, error)
+}
+
+// ovnServiceLBBackend implements ServiceLBBackend on top of ovn-nbctl
+type ovnServiceLBBackend struct {
+	oc *Controller
+}
+
+var _ ServiceLBBackend = &ovnServiceLBBackend{}
+
+func (o *ovnServiceLBBackend) GetOvnGateways() ([]string, string, error) {
+	return o.oc.getOvnGateways()
+}
+
+func (o *ovnServiceLBBackend) GetLoadBalancer(protocol kapi.Protocol) (string, error) {
+	return o.oc.getLoadBalancer(protocol)
+}
+
+func (o *ovnServiceLBBackend) GetGatewayLoadBalancer(gatewayRouter string, protocol kapi.Protocol) (string, error) {
+	return o.oc.getGatewayLoadBalancer(gatewayRouter, protocol)
+}
+
+func (o *ovnServiceLBBackend) GetGatewayPhysicalIPs(gatewayRouter string) ([]string, error) {
+	return o.oc.getGatewayPhysicalIPs(gatewayRouter)
+}
+
+func (o *ovnServiceLBBackend) GetWorkerLoadBalancer(node string, protocol kapi.Protocol) (string, error) {
+	return loadbalancer.GetWorkerLoadBalancer(node, protocol)
+}
+
+func (o *ovnServiceLBBackend) EnsureVIP(ctx context.Context, lb string, sourceIPs []string, sourcePort int32, targetIPs []string, targetPort int32) error {
+	return o.oc.createLoadBalancerVIPs(ctx, lb, sourceIPs, sourcePort, targetIPs, targetPort)
+}
+
+func (o *ovnServiceLBBackend) RemoveVIP(ctx context.Context, lb, vip string) error {
+	return o.oc.deleteLoadBalancerVIP(ctx, lb, vip)
+}
+
+func (o *ovnServiceLBBackend) RemoveVIPs(ctx context.Context, lb string, vips []string) error {
+	return o.oc.deleteLoadBalancerVIPs(ctx, lb, vips)
+}
+
+func (o *ovnServiceLBBackend) SetVIPAppProtocol(ctx context.Context, lb, vip, appProtocol string) error {
+	return loadbalancer.SetLoadBalancerVIPAppProtocol(ctx, lb, vip, appProtocol)
+}
+
+func (o *ovnServiceLBBackend) SetVIPProxyProtocol(ctx context.Context, lb, vip string, enabled bool) error {
+	return loadbalancer.SetLoadBalancerVIPProxyProtocol(ctx, lb, vip, enabled)
+}
+
+func (o *ovnServiceLBBackend) EnsureRejectACL(ctx context.Context, lb, sourceIP string, sourcePort int32, proto kapi.Protocol, aclLogging, meter, action string) (string, error) {
+	return o.oc.ensureLoadBalancerRejectACL(ctx, lb, sourceIP, sourcePort, proto, aclLogging, meter, action)
+}
+
+func (o *ovnServiceLBBackend) EnsureRejectACLs(ctx context.Context, lb string, vips []rejectACLVIP, proto kapi.Protocol, aclLogging, meter, action string) ([]string, error) {
+	return o.oc.ensureLoadBalancerRejectACLs(ctx, lb, vips, proto, aclLogging, meter, action)
+}
+
+func (o *ovnServiceLBBackend) EnsureRejectACLsOfLoadBalancers(ctx context.Context, lbs []lbRejectACLVIPs, aclLogging, meter, action string) ([][]string, error) {
+	return o.oc.ensureRejectACLsOfLoadBalancers(ctx, lbs, aclLogging, meter, action)
+}
+
+func (o *ovnServiceLBBackend) RemoveRejectACL(ctx context.Context, lb, aclUUID string) {
+	o.oc.removeStaleRejectACL(ctx, lb, aclUUID)
+}
+
+func (o *ovnServiceLBBackend) ClearVIP(ctx context.Context, lb, sourceIP string, sourcePort int32, proto kapi.Protocol, aclLogging, meter, action string) (string, error) {
+	return o.oc.clearLoadBalancerVIP(ctx, lb, sourceIP, sourcePort, proto, aclLogging, meter, action)
+}
@@ -0,0 +1,197 @@
+package ovn
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+	"github.com/stretchr/testify/assert"
+	kapi "k8s.io/api/core/v1"
+)
+
+func TestGetRejectACLMatch(t *testing.T) {
+	testcases := []struct {
+		desc     string
+		sourceIP string
+		port     int32
+		proto    kapi.Protocol
+		expected string
+	}{
+		{
+			desc:     "IPv4 cluster IP over TCP",
+			sourceIP: "10.96.0.10",
+			port:     80,
+			proto:    kapi.ProtocolTCP,
+			expected: "match=\"ip4.dst==10.96.0.10 && tcp && tcp.dst==80\"",
+		},
+		{
+			desc:     "IPv6 cluster IP over TCP",
+			sourceIP: "fd00:10:96::10",
+			port:     80,
+			proto:    kapi.ProtocolTCP,
+			expected: "match=\"ip6.dst==fd00:10:96::10 && tcp && tcp.dst==80\"",
+		},
+		{
+			desc:     "IPv6 external IP over UDP",
+			sourceIP: "2001:db8::1",
+			port:     53,
+			proto:    kapi.ProtocolUDP,
+			expected: "match=\"ip6.dst==2001:db8::1 && udp && udp.dst==53\"",
+		},
+		{
+			desc:     "IPv4 NodePort over SCTP",
+			sourceIP: "192.168.0.1",
+			port:     30080,
+			proto:    kapi.ProtocolSCTP,
+			expected: "match=\"ip4.dst==192.168.0.1 && sctp && sctp.dst==30080\"",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.desc, func(t *testing.T) {
+			assert.Equal(t, tc.expected, getRejectACLMatch(tc.sourceIP, tc.port, tc.proto))
+		})
+	}
+}
+
+func TestRejectACLCreateArgsMeter(t *testing.T) {
+	defer func() {
+		config.OVNKubernetesFeature.RejectACLMeter = "acl-logging"
+	}()
+	testcases := []struct {
+		desc     string
+		meter    string
+		deny     string
+		expected string
+	}{
+		{
+			desc:     "logging with the default meter",
+			meter:    "acl-logging",
+			deny:     "alert",
+			expected: "meter=acl-logging",
+		},
+		{
+			desc:     "logging with a custom meter",
+			meter:    "reject-meter",
+			deny:     "alert",
+			expected: "meter=reject-meter",
+		},
+		{
+			desc:  "logging without a meter",
+			meter: "",
+			deny:  "alert",
+		},
+		{
+			desc:  "not logging",
+			meter: "reject-meter",
+			deny:  "",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.desc, func(t *testing.T) {
+			config.OVNKubernetesFeature.RejectACLMeter = tc.meter
+			aclLogging, meter := (&Controller{}).rejectACLLogging("namespace1", &ACLLoggingLevels{Deny: tc.deny})
+			args := rejectACLCreateArgs("reject-acl", "acl1", "172.30.0.10", 80, kapi.ProtocolTCP, aclLogging, meter, "reject")
+			var meterArgs []string
+			for _, arg := range args {
+				if strings.HasPrefix(arg, "meter=") {
+					meterArgs = append(meterArgs, arg)
+				}
+			}
+			if tc.expected == "" {
+				assert.Empty(t, meterArgs)
+			} else {
+				assert.Equal(t, []string{tc.expected}, meterArgs)
+			}
+			assert.Contains(t, args, "log="+strconv.FormatBool(tc.deny != ""))
+		})
+	}
+}
+
+func TestRejectACLPerProtocol(t *testing.T) {
+	// OVN picks the reply of the reject action from the protocol of the traffic: a TCP RST, an
+	// ICMP or ICMPv6 port unreachable for UDP and an SCTP ABORT, so only the match differs
+	testcases := []struct {
+		desc          string
+		sourceIP      string
+		proto         kapi.Protocol
+		action        string
+		expectedMatch string
+	}{
+		{
+			desc:          "TCP",
+			sourceIP:      "172.30.0.10",
+			proto:         kapi.ProtocolTCP,
+			action:        "reject",
+			expectedMatch: `match="ip4.dst==172.30.0.10 && tcp && tcp.dst==80"`,
+		},
+		{
+			desc:          "UDP",
+			sourceIP:      "172.30.0.10",
+			proto:         kapi.ProtocolUDP,
+			action:        "reject",
+			expectedMatch: `match="ip4.dst==172.30.0.10 && udp && udp.dst==80"`,
+		},
+		{
+			desc:          "IPv6 UDP",
+			sourceIP:      "fd00:10:96::10",
+			proto:         kapi.ProtocolUDP,
+			action:        "reject",
+			expectedMatch: `match="ip6.dst==fd00:10:96::10 && udp && udp.dst==80"`,
+		},
+		{
+			desc:          "SCTP",
+			sourceIP:      "172.30.0.10",
+			proto:         kapi.ProtocolSCTP,
+			action:        "reject",
+			expectedMatch: `match="ip4.dst==172.30.0.10 && sctp && sctp.dst==80"`,
+		},
+		{
+			desc:          "dropped UDP",
+			sourceIP:      "172.30.0.10",
+			proto:         kapi.ProtocolUDP,
+			action:        "drop",
+			expectedMatch: `match="ip4.dst==172.30.0.10 && udp && udp.dst==80"`,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.desc, func(t *testing.T) {
+			args := rejectACLCreateArgs("reject-acl", "acl1", tc.sourceIP, 80, tc.proto, "", "", tc.action)
+			assert.Contains(t, args, tc.expectedMatch)
+			assert.Contains(t, args, "action="+tc.action)
+		})
+	}
+}
+
+func TestCreateLoadBalancerVIPs(t *testing.T) {
+	lb := "a08ea426-2288-11eb-a30b-a8a1590cda29"
+	oc := &Controller{
+		serviceLBMap:         make(map[string]map[string]*loadBalancerConf),
+		clusterPortGroupUUID: "cluster-port-group",
+	}
+	oc.setServiceACLToLB(lb, "192.168.0.1:30080", "reject-acl")
+
+	fexec := ovntest.NewLooseCompareFakeExec()
+	fexec.AddFakeCmdsNoOutputNoError([]string{
+		"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}" + lb,
+		"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=" + lb + "-192.168.0.2\\:30080",
+		// both VIPs are set, and the reject ACL of the first one removed, with a single command
+		"ovn-nbctl --timeout=15 set load_balancer " + lb +
+			` vips:"192.168.0.1:30080"="10.128.0.5:8080" vips:"192.168.0.2:30080"="10.128.0.5:8080"` +
+			" -- --if-exists remove port_group cluster-port-group acls reject-acl",
+	})
+	err := util.SetExec(fexec)
+	assert.NoError(t, err)
+
+	err = oc.createLoadBalancerVIPs(context.Background(), lb, []string{"192.168.0.2", "192.168.0.1"}, 30080, []string{"10.128.0.5"}, 8080)
+	assert.NoError(t, err)
+	assert.True(t, fexec.CalledMatchesExpected(), fexec.ErrorDesc())
+	for _, vip := range []string{"192.168.0.1:30080", "192.168.0.2:30080"} {
+		aclUUID, hasEndpoints := oc.getServiceLBInfo(lb, vip)
+		assert.Empty(t, aclUUID, vip)
+		assert.True(t, hasEndpoints, vip)
+	}
+}
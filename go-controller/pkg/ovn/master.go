@@ -27,6 +27,7 @@ import (
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/informer"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/metrics"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/ipallocator"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/loadbalancer"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 )
@@ -37,6 +38,30 @@ const (
 	OvnServiceIdledAt              = "k8s.ovn.org/idled-at"
 	OvnNodeAnnotationRetryInterval = 100 * time.Millisecond
 	OvnNodeAnnotationRetryTimeout  = 1 * time.Second
+
+	// OvnServiceSkipLoadBalancing is the Service annotation key which, when set to "true",
+	// makes ovn-kubernetes leave the Service alone: no VIP and no reject ACL is programmed
+	OvnServiceSkipLoadBalancing = "k8s.ovn.org/skip-load-balancing"
+
+	// OvnServiceEmptyServiceAction is the Service annotation key which, when set to "drop",
+	// makes the ACL of a Service without endpoints silently drop traffic instead of rejecting it
+	OvnServiceEmptyServiceAction = "k8s.ovn.org/empty-service-action"
+
+	// OvnServiceEndpointWeights is the Service annotation key whose value, a comma separated list
+	// of endpoint IPs and weights like "10.128.0.5=3,10.128.1.7=1", makes the load balancers of the
+	// Service send each endpoint a share of the traffic proportional to its weight. Endpoints that
+	// are not listed have a weight of 1.
+	OvnServiceEndpointWeights = "k8s.ovn.org/endpoint-weights"
+
+	// OvnServiceResyncGeneration is the Service annotation key whose value, when it is set or
+	// changed to any new value, makes ovn-kubernetes delete and program again all the load
+	// balancer VIPs and reject ACLs of the Service, like after an out of band edit of OVN.
+	OvnServiceResyncGeneration = "k8s.ovn.org/resync-generation"
+
+	// OvnServiceProxyProtocol is the Service annotation key whose value, "true", records on the
+	// load balancers that the backends of the VIPs of the Service expect the PROXY protocol, for a
+	// dataplane outside of OVN to act on. OVN does not add PROXY headers itself.
+	OvnServiceProxyProtocol = "k8s.ovn.org/proxy-protocol"
 )
 
 type ovnkubeMasterLeaderMetrics struct{}
@@ -57,6 +82,9 @@ func (_ ovnkubeMasterLeaderMetricsProvider) NewLeaderMetric() leaderelection.Swi
 
 // Start waits until this process is the leader before starting master functions
 func (oc *Controller) Start(nodeName string, wg *sync.WaitGroup) error {
+	// the load balancer operations report their latency in the metric of the master
+	loadbalancer.MetricNbctlOpDuration = metrics.MetricNbctlOpDuration
+
 	// Set up leader election process first
 	rl, err := resourcelock.New(
 		resourcelock.ConfigMapsResourceLock,
@@ -333,6 +361,15 @@ func (oc *Controller) StartClusterMaster(masterNodeName string) error {
 			oc.aclLoggingEnabled = false
 		}
 	}
+	// a reject ACL meter other than the acl-logging one is created with the same rate
+	if meter := config.OVNKubernetesFeature.RejectACLMeter; meter != "" && meter != types.OvnACLLoggingMeter {
+		if uuid, _, err := util.RunOVNNbctl("--data=bare", "--columns=_uuid", "find", "meter", "name="+meter); err == nil && uuid == "" {
+			dropRate := strconv.Itoa(config.Logging.ACLLoggingRateLimit)
+			if _, _, err := util.RunOVNNbctl("meter-add", meter, "drop", dropRate, "pktps"); err != nil {
+				klog.Warningf("Reject ACL meter %s could not be created, the logging of reject ACLs will not be rate-limited", meter)
+			}
+		}
+	}
 
 	if err := oc.SetupMaster(masterNodeName); err != nil {
 		klog.Errorf("Failed to setup master (%v)", err)
@@ -479,6 +516,17 @@ func (oc *Controller) SetupMaster(masterNodeName string) error {
 		}
 	}
 
+	// The selection fields apply to every service, so they are reset on each start
+	for _, lb := range []string{oc.TCPLoadBalancerUUID, oc.UDPLoadBalancerUUID, oc.SCTPLoadBalancerUUID} {
+		if lb == "" {
+			continue
+		}
+		if err := loadbalancer.SetLoadBalancerSelectionFields(lb, config.Kubernetes.LBSelectionFields); err != nil {
+			klog.Errorf("Failed to set the selection fields of load balancer %s: %v", lb, err)
+			return err
+		}
+	}
+
 	// Initialize the OVNJoinSwitch switch IP manager
 	// The OVNJoinSwitch will be allocated IP addresses in the range 100.64.0.0/16 or fd98::/64.
 	oc.joinSwIPManager, err = initJoinLogicalSwitchIPManager()
@@ -530,6 +578,99 @@ func (oc *Controller) SetupMaster(masterNodeName string) error {
 	return nil
 }
 
+// sctpSupported returns whether the running OVN supports SCTP load balancers
+func (oc *Controller) sctpSupported() bool {
+	oc.sctpSupportLock.RLock()
+	defer oc.sctpSupportLock.RUnlock()
+	return oc.SCTPSupport
+}
+
+// supportedServiceProtocols returns the protocols of the services the running OVN load balances:
+// TCP and UDP, along with SCTP once OVN supports it. Code iterating over the protocols of the
+// load balancers goes through it, so that a protocol OVN does not support is skipped uniformly.
+func (oc *Controller) supportedServiceProtocols() []kapi.Protocol {
+	protocols := []kapi.Protocol{kapi.ProtocolTCP, kapi.ProtocolUDP}
+	if oc.sctpSupported() {
+		protocols = append(protocols, kapi.ProtocolSCTP)
+	}
+	return protocols
+}
+
+// serviceProtocolSupported returns whether the running OVN load balances services of protocol
+func (oc *Controller) serviceProtocolSupported(protocol kapi.Protocol) bool {
+	for _, supported := range oc.supportedServiceProtocols() {
+		if supported == protocol {
+			return true
+		}
+	}
+	return false
+}
+
+// refreshSCTPSupport queries the running OVN for SCTP support, so that an OVN upgraded in
+// place is picked up without restarting the master. When support shows up the cluster SCTP
+// load balancer is created and added to the node switches; gateway routers get their SCTP
+// load balancers the next time they are synced. The previous value is kept if OVN cannot
+// be queried.
+func (oc *Controller) refreshSCTPSupport() {
+	supported, err := util.DetectSCTPSupport()
+	if err != nil {
+		klog.Warningf("Failed to refresh SCTP support, keeping previous value %t: %v", oc.sctpSupported(), err)
+		return
+	}
+	if supported == oc.sctpSupported() {
+		return
+	}
+	if !supported {
+		klog.Warningf("SCTP is no longer supported by this version of OVN")
+		oc.sctpSupportLock.Lock()
+		oc.SCTPSupport = false
+		oc.sctpSupportLock.Unlock()
+		return
+	}
+
+	if err := oc.ensureClusterSCTPLoadBalancer(); err != nil {
+		klog.Errorf("SCTP support detected in OVN but the cluster SCTP load balancer could not be set up: %v", err)
+		return
+	}
+	klog.Info("SCTP support detected in OVN")
+	oc.sctpSupportLock.Lock()
+	oc.SCTPSupport = true
+	oc.sctpSupportLock.Unlock()
+}
+
+// ensureClusterSCTPLoadBalancer creates the cluster SCTP load balancer if needed and adds
+// it to the logical switch of every node
+func (oc *Controller) ensureClusterSCTPLoadBalancer() error {
+	lb, stderr, err := util.RunOVNNbctl("--data=bare", "--no-heading", "--columns=_uuid", "find", "load_balancer", "external_ids:k8s-cluster-lb-sctp=yes")
+	if err != nil {
+		return fmt.Errorf("failed to get sctp load balancer, stderr: %q, error: %v", stderr, err)
+	}
+	if lb == "" {
+		lb, stderr, err = util.RunOVNNbctl("--", "create", "load_balancer", "external_ids:k8s-cluster-lb-sctp=yes", "protocol=sctp")
+		if err != nil {
+			return fmt.Errorf("failed to create sctp load balancer, stderr: %q, error: %v", stderr, err)
+		}
+	}
+
+	nodes, err := oc.watchFactory.GetNodes()
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %v", err)
+	}
+	for _, node := range nodes {
+		// nodes whose switch is not set up yet get the load balancer when it is
+		if noHostSubnet(node) || oc.lsManager.GetSwitchSubnets(node.Name) == nil {
+			continue
+		}
+		_, stderr, err = util.RunOVNNbctl("--may-exist", "ls-lb-add", node.Name, lb)
+		if err != nil {
+			return fmt.Errorf("failed to add sctp load balancer to logical switch %s, stderr: %q, error: %v",
+				node.Name, stderr, err)
+		}
+	}
+	oc.SCTPLoadBalancerUUID = lb
+	return nil
+}
+
 func addNodeLogicalSwitchPort(logicalSwitch, portName, portType, addresses, options string) (string, error) {
 	stdout, stderr, err := util.RunOVNNbctl("--", "--may-exist", "lsp-add", logicalSwitch, portName,
 		"--", "lsp-set-type", portName, portType,
@@ -637,13 +778,13 @@ func (oc *Controller) syncGatewayLogicalNetwork(node *kapi.Node, l3GatewayConfig
 	// See https://github.com/openshift/ovn-kubernetes/pull/281
 	drLRPIPs, _ := oc.joinSwIPManager.getJoinLRPCacheIPs(types.OVNClusterRouter)
 	if l3GatewayConfig.Mode == config.GatewayModeLocal {
-		err = gatewayInitMinimal(node.Name, l3GatewayConfig, oc.SCTPSupport)
+		err = gatewayInitMinimal(node.Name, l3GatewayConfig, oc.sctpSupported())
 		if err != nil {
 			return fmt.Errorf("failed to init local gateway with no OVS bridge: %v", err)
 		}
 		// END OCP HACK
 	} else {
-		err = gatewayInit(node.Name, clusterSubnets, hostSubnets, l3GatewayConfig, oc.SCTPSupport, gwLRPIPs, drLRPIPs)
+		err = gatewayInit(node.Name, clusterSubnets, hostSubnets, l3GatewayConfig, oc.sctpSupported(), gwLRPIPs, drLRPIPs)
 		if err != nil {
 			return fmt.Errorf("failed to init shared interface gateway: %v", err)
 		}
@@ -652,7 +793,7 @@ func (oc *Controller) syncGatewayLogicalNetwork(node *kapi.Node, l3GatewayConfig
 	// Add cluster load balancers to GR for Host -> Cluster IP Service traffic
 	if config.Gateway.Mode != config.GatewayModeLocal {
 		clusterLBs := []string{oc.TCPLoadBalancerUUID, oc.UDPLoadBalancerUUID}
-		if oc.SCTPSupport {
+		if oc.sctpSupported() {
 			clusterLBs = append(clusterLBs, oc.SCTPLoadBalancerUUID)
 		}
 		gr := util.GetGatewayRouterFromNode(node.Name)
@@ -861,7 +1002,7 @@ func (oc *Controller) ensureNodeLogicalNetwork(nodeName string, hostSubnets []*n
 		return err
 	}
 
-	if oc.SCTPSupport {
+	if oc.sctpSupported() {
 		if oc.SCTPLoadBalancerUUID == "" {
 			return fmt.Errorf("SCTP cluster load balancer not created")
 		}
@@ -1033,6 +1174,13 @@ func (oc *Controller) addNode(node *kapi.Node) ([]*net.IPNet, error) {
 		return nil, err
 	}
 
+	// Create the worker load balancers of the node, so that services can be programmed on them
+	// before the gateway of the node is set up
+	_, _, _, err = loadbalancer.EnsureWorkerLoadBalancers(node.Name, oc.sctpSupported())
+	if err != nil {
+		return nil, err
+	}
+
 	// Set the HostSubnet annotation on the node object to signal
 	// to nodes that their logical infrastructure is set up and they can
 	// proceed with their initialization
@@ -1111,6 +1259,10 @@ func (oc *Controller) deleteNode(nodeName string, hostSubnets []*net.IPNet, node
 		klog.Errorf("Failed to clean up node %s gateway: (%v)", nodeName, err)
 	}
 
+	if err := loadbalancer.CleanupWorkerLoadBalancers(nodeName); err != nil {
+		klog.Errorf("Failed to clean up node %s worker load balancers: %v", nodeName, err)
+	}
+
 	if err := oc.joinSwIPManager.releaseJoinLRPIPs(nodeName); err != nil {
 		klog.Errorf("Failed to clean up GR LRP IPs for node %s: %v", nodeName, err)
 	}
@@ -1321,6 +1473,22 @@ func (oc *Controller) syncNodes(nodes []interface{}) {
 		delete(chassisMap, nodeName)
 	}
 
+	// Worker load balancers may outlive their node logical switch, remove the ones
+	// left behind by nodes that no longer exist
+	workerLBNodes, err := loadbalancer.GetWorkerLoadBalancerNodes()
+	if err != nil {
+		klog.Errorf("Failed to get worker load balancers: %v", err)
+	} else {
+		for _, nodeName := range workerLBNodes {
+			if _, ok := foundNodes[nodeName]; ok {
+				continue
+			}
+			if err := loadbalancer.CleanupWorkerLoadBalancers(nodeName); err != nil {
+				klog.Errorf("Failed to clean up stale worker load balancers for node %s: %v", nodeName, err)
+			}
+		}
+	}
+
 	deleteChassis(oc.ovnSBClient, chassisMap)
 }
 
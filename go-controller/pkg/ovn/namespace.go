@@ -289,15 +289,23 @@ func (oc *Controller) updateNamespace(old, newer *kapi.Namespace) {
 	}
 	aclAnnotation := newer.Annotations[aclLoggingAnnotation]
 	oldACLAnnotation := old.Annotations[aclLoggingAnnotation]
+	oldACLLogging := nsInfo.aclLogging
 	// support for ACL logging update, if new annotation is empty, make sure we propagate new setting
-	if aclAnnotation != oldACLAnnotation && (oc.aclLoggingCanEnable(aclAnnotation, nsInfo) || aclAnnotation == "") &&
-		len(nsInfo.networkPolicies) > 0 {
-		// deny rules are all one per namespace
-		if err := oc.setACLDenyLogging(old.Name, nsInfo, nsInfo.aclLogging.Deny); err != nil {
+	if aclAnnotation != oldACLAnnotation && (oc.aclLoggingCanEnable(aclAnnotation, nsInfo) || aclAnnotation == "") {
+		if len(nsInfo.networkPolicies) > 0 {
+			// deny rules are all one per namespace
+			if err := oc.setACLDenyLogging(old.Name, nsInfo, nsInfo.aclLogging.Deny); err != nil {
+				klog.Warningf(err.Error())
+			} else {
+				klog.Infof("Namespace %s: ACL logging setting updated to deny=%s allow=%s",
+					old.Name, nsInfo.aclLogging.Deny, nsInfo.aclLogging.Allow)
+			}
+		}
+		// the reject ACLs of the services log like the deny rules
+		if err := oc.updateServiceRejectACLLogging(old.Name, &nsInfo.aclLogging); err != nil {
 			klog.Warningf(err.Error())
-		} else {
-			klog.Infof("Namespace %s: ACL logging setting updated to deny=%s allow=%s",
-				old.Name, nsInfo.aclLogging.Deny, nsInfo.aclLogging.Allow)
+		} else if usesACLLoggingMeter(&oldACLLogging) && !usesACLLoggingMeter(&nsInfo.aclLogging) {
+			oc.deleteACLLoggingMeter(old.Name)
 		}
 	}
 	oc.multicastUpdateNamespace(newer, nsInfo)
@@ -319,6 +327,9 @@ func (oc *Controller) deleteNamespace(ns *kapi.Namespace) {
 	}
 	oc.deleteGWRoutesForNamespace(nsInfo)
 	oc.multicastDeleteNamespace(ns, nsInfo)
+	if usesACLLoggingMeter(&nsInfo.aclLogging) {
+		oc.deleteACLLoggingMeter(ns.Name)
+	}
 }
 
 // waitForNamespaceLocked waits up to 10 seconds for a Namespace to be known; use this
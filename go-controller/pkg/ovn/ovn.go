@@ -2,6 +2,7 @@ package ovn
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net"
@@ -43,6 +44,7 @@ import (
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	ref "k8s.io/client-go/tools/reference"
+	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 )
 
@@ -65,6 +67,9 @@ type loadBalancerConf struct {
 type ACLLoggingLevels struct {
 	Allow string `json:"allow,omitempty"`
 	Deny  string `json:"deny,omitempty"`
+	// Rate is the packets per second rate at which the reject ACLs of the namespace services are
+	// logged, on a meter of the namespace. The cluster wide meter is used when unset.
+	Rate int `json:"rate,omitempty"`
 }
 
 // namespaceInfo contains information related to a Namespace. Use oc.getNamespaceLocked()
@@ -132,10 +137,23 @@ type Controller struct {
 	UDPLoadBalancerUUID  string
 	SCTPLoadBalancerUUID string
 	SCTPSupport          bool
+	// sctpSupportLock guards SCTPSupport, which is refreshed periodically
+	sctpSupportLock sync.RWMutex
 
 	// For TCP, UDP, and SCTP type traffic, cache OVN load-balancers used for the
 	// cluster's east-west traffic.
-	loadbalancerClusterCache map[kapi.Protocol]string
+	loadbalancerClusterCache     map[kapi.Protocol]string
+	loadbalancerClusterCacheLock sync.RWMutex
+
+	// serializes on demand reconciliations of single services, the reconciles of the service queue
+	// and the periodic full syncs of the services
+	serviceReconcileLock sync.Mutex
+
+	// keys of the services to reconcile, queued by the service handler
+	serviceQueue workqueue.RateLimitingInterface
+	// last state of each service programmed in OVN by the service queue, by namespace/name
+	programmedServices     map[string]*kapi.Service
+	programmedServicesLock sync.Mutex
 
 	// A cache of all logical switches seen by the watcher and their subnets
 	lsManager *logicalSwitchManager
@@ -187,17 +205,35 @@ type Controller struct {
 
 	serviceLBLock sync.Mutex
 
+	// rate of the ACL logging meter of each namespace with a logging rate, by meter name
+	aclLoggingMeters     map[string]int
+	aclLoggingMetersLock sync.Mutex
+
+	// namespace/name of the service each NodePort is programmed for, by protocol/port
+	nodePortOwners     map[string]string
+	nodePortOwnersLock sync.Mutex
+
 	joinSwIPManager *joinSwitchIPManager
 
 	// event recorder used to post events to k8s
 	recorder record.EventRecorder
 
+	// last time each service event was recorded, used to drop duplicate events
+	serviceEvents     map[string]time.Time
+	serviceEventsLock sync.Mutex
+
 	// go-ovn northbound client interface
 	ovnNBClient goovn.Client
 
 	// go-ovn southbound client interface
 	ovnSBClient goovn.Client
 
+	// load balancer operations used to program services in OVN
+	lbOps ServiceLBBackend
+
+	// endpoints lists the endpoints of the services for their full sync
+	endpoints endpointsLister
+
 	// v4HostSubnetsUsed keeps track of number of v4 subnets currently assigned to nodes
 	v4HostSubnetsUsed float64
 
@@ -246,7 +282,7 @@ func NewOvnController(ovnClient *util.OVNClientset, wf *factory.WatchFactory,
 	if addressSetFactory == nil {
 		addressSetFactory = addressset.NewOvnAddressSetFactory()
 	}
-	return &Controller{
+	oc := &Controller{
 		client: ovnClient.KubeClient,
 		kube: &kube.Kube{
 			KClient:              ovnClient.KubeClient,
@@ -281,12 +317,20 @@ func NewOvnController(ovnClient *util.OVNClientset, wf *factory.WatchFactory,
 		aclLoggingEnabled:        true,
 		serviceLBMap:             make(map[string]map[string]*loadBalancerConf),
 		serviceLBLock:            sync.Mutex{},
+		aclLoggingMeters:         make(map[string]int),
+		nodePortOwners:           make(map[string]string),
 		joinSwIPManager:          nil,
 		retryPods:                make(map[types.UID]retryEntry),
 		recorder:                 recorder,
+		serviceEvents:            make(map[string]time.Time),
+		serviceQueue:             newServiceQueue(),
+		programmedServices:       make(map[string]*kapi.Service),
 		ovnNBClient:              ovnNBClient,
 		ovnSBClient:              ovnSBClient,
 	}
+	oc.lbOps = &ovnServiceLBBackend{oc: oc}
+	oc.endpoints = wf
+	return oc
 }
 
 // Run starts the actual watching.
@@ -407,17 +451,35 @@ func (oc *Controller) Run(wg *sync.WaitGroup, nodeName string) error {
 	return nil
 }
 
+// stopContext returns a context that is cancelled once the controller is stopped, to abort the
+// OVN commands run on its behalf. cancel must be called once the context is no longer used.
+func (oc *Controller) stopContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		select {
+		case <-oc.stopChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
 // syncPeriodic adds a goroutine that periodically does some work
-// right now there is only one ticker registered
+// right now there are two tickers registered
 // for syncNodesPeriodic which deletes chassis records from the sbdb
-// every 5 minutes
+// every 5 minutes, and for refreshSCTPSupport which picks up SCTP
+// support of an upgraded OVN every minute
 func (oc *Controller) syncPeriodic() {
 	go func() {
 		nodeSyncTicker := time.NewTicker(5 * time.Minute)
+		sctpSupportTicker := time.NewTicker(time.Minute)
 		for {
 			select {
 			case <-nodeSyncTicker.C:
 				oc.syncNodesPeriodic()
+			case <-sctpSupportTicker.C:
+				oc.refreshSCTPSupport()
 			case <-oc.stopChan:
 				return
 			}
@@ -565,27 +627,17 @@ func (oc *Controller) WatchPods() {
 // appropriate handler logic
 func (oc *Controller) WatchServices() {
 	start := time.Now()
+	// the handler only queues the services, the worker reconciles their latest state
 	oc.watchFactory.AddServiceHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc: func(obj interface{}) {
-			service := obj.(*kapi.Service)
-			err := oc.createService(service)
-			if err != nil {
-				klog.Errorf("Error in adding service: %v", err)
-			}
-		},
+		AddFunc: oc.enqueueService,
 		UpdateFunc: func(old, new interface{}) {
-			svcOld := old.(*kapi.Service)
-			svcNew := new.(*kapi.Service)
-			err := oc.updateService(svcOld, svcNew)
-			if err != nil {
-				klog.Errorf("Error while updating service: %v", err)
-			}
-		},
-		DeleteFunc: func(obj interface{}) {
-			service := obj.(*kapi.Service)
-			oc.deleteService(service)
+			oc.enqueueService(new)
 		},
+		DeleteFunc: oc.enqueueDeletedService,
 	}, oc.syncServices)
+	oc.startServiceWorker()
+	oc.startServiceResync()
+	oc.startIngressHostnameRefresh()
 	klog.Infof("Bootstrapping existing services and cleaning stale services took %v", time.Since(start))
 }
 
@@ -606,7 +658,9 @@ func (oc *Controller) WatchEndpoints() {
 			if reflect.DeepEqual(epNew.Subsets, epOld.Subsets) {
 				return
 			}
-			if len(epNew.Subsets) == 0 {
+			// endpoints without addresses, even with subsets, leave the service rejecting traffic
+			if svc, err := oc.watchFactory.GetService(epNew.Namespace, epNew.Name); err == nil &&
+				!serviceHasReadyEndpoints(epNew, svc) {
 				err := oc.deleteEndpoints(epNew)
 				if err != nil {
 					klog.Errorf("Error in deleting endpoints - %v", err)
@@ -1008,6 +1062,11 @@ func (oc *Controller) WatchNodes() {
 					gatewaysFailed.Store(node.Name, true)
 				} else {
 					gatewaysFailed.Delete(node.Name)
+					// the physical IPs of the gateway may have changed, in which case
+					// the NodePort VIPs built from them need to follow
+					if err := oc.reconcileGatewayNodePortVIPs(ovntypes.GWRouterPrefix + node.Name); err != nil {
+						klog.Errorf("Failed to reconcile NodePort VIPs for node %s: %v", node.Name, err)
+					}
 				}
 			}
 		},
@@ -1046,6 +1105,7 @@ func (oc *Controller) aclLoggingCanEnable(annotation string, nsInfo *namespaceIn
 	if !oc.aclLoggingEnabled || annotation == "" {
 		nsInfo.aclLogging.Deny = ""
 		nsInfo.aclLogging.Allow = ""
+		nsInfo.aclLogging.Rate = 0
 		return false
 	}
 	var aclLevels ACLLoggingLevels
@@ -1053,6 +1113,10 @@ func (oc *Controller) aclLoggingCanEnable(annotation string, nsInfo *namespaceIn
 	if err != nil {
 		return false
 	}
+	if aclLevels.Rate < 0 {
+		return false
+	}
+	nsInfo.aclLogging.Rate = aclLevels.Rate
 	okCnt := 0
 	for _, s := range []string{"alert", "warning", "notice", "info", "debug"} {
 		if aclLevels.Deny != "" && s == aclLevels.Deny {
@@ -1067,8 +1131,13 @@ func (oc *Controller) aclLoggingCanEnable(annotation string, nsInfo *namespaceIn
 	return okCnt > 0
 }
 
-// setServiceLBToACL associates an empty load balancer with its associated ACL reject rule
+// setServiceLBToACL associates an empty load balancer with its associated ACL reject rule.
+// Like the other updates of serviceLBMap, it is skipped with the service dry run, which does
+// not change the load balancers nor their reject ACLs.
 func (oc *Controller) setServiceACLToLB(lb, vip, acl string) {
+	if config.Kubernetes.ServiceDryRun {
+		return
+	}
 	if _, ok := oc.serviceLBMap[lb]; !ok {
 		oc.serviceLBMap[lb] = make(map[string]*loadBalancerConf)
 		oc.serviceLBMap[lb][vip] = &loadBalancerConf{rejectACL: acl}
@@ -1083,6 +1152,9 @@ func (oc *Controller) setServiceACLToLB(lb, vip, acl string) {
 
 // setServiceEndpointsToLB associates a load balancer with endpoints
 func (oc *Controller) setServiceEndpointsToLB(lb, vip string, eps []string) {
+	if config.Kubernetes.ServiceDryRun {
+		return
+	}
 	if _, ok := oc.serviceLBMap[lb]; !ok {
 		oc.serviceLBMap[lb] = make(map[string]*loadBalancerConf)
 		oc.serviceLBMap[lb][vip] = &loadBalancerConf{endpoints: eps}
@@ -1108,6 +1180,9 @@ func (oc *Controller) getServiceLBInfo(lb, vip string) (string, bool) {
 
 // removeServiceLB removes the entire LB entry for a VIP
 func (oc *Controller) removeServiceLB(lb, vip string) {
+	if config.Kubernetes.ServiceDryRun {
+		return
+	}
 	oc.serviceLBLock.Lock()
 	defer oc.serviceLBLock.Unlock()
 	delete(oc.serviceLBMap[lb], vip)
@@ -1115,6 +1190,9 @@ func (oc *Controller) removeServiceLB(lb, vip string) {
 
 // removeServiceACL removes a specific ACL associated with a load balancer and ip:port
 func (oc *Controller) removeServiceACL(lb, vip string) {
+	if config.Kubernetes.ServiceDryRun {
+		return
+	}
 	oc.serviceLBLock.Lock()
 	defer oc.serviceLBLock.Unlock()
 	if _, ok := oc.serviceLBMap[lb][vip]; ok {
@@ -1124,6 +1202,9 @@ func (oc *Controller) removeServiceACL(lb, vip string) {
 
 // removeServiceEndpoints removes endpoints associated with a load balancer and ip:port
 func (oc *Controller) removeServiceEndpoints(lb, vip string) {
+	if config.Kubernetes.ServiceDryRun {
+		return
+	}
 	oc.serviceLBLock.Lock()
 	defer oc.serviceLBLock.Unlock()
 	if _, ok := oc.serviceLBMap[lb][vip]; ok {
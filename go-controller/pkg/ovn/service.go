@@ -1,23 +1,36 @@
 package ovn
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
 	"net"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/metrics"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/gateway"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/loadbalancer"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 
 	kapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/tools/reference"
+	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 )
 
+// serviceEventInterval is the minimum time between two identical events recorded on a service
+const serviceEventInterval = time.Minute
+
 func addRejectACLs(rejectACLs map[string]map[string]bool, lb, ip string, port int32, hasEndpoints bool) {
 	if ip != "" {
 		name := generateACLName(lb, ip, port)
@@ -28,7 +41,52 @@ func addRejectACLs(rejectACLs map[string]map[string]bool, lb, ip string, port in
 	}
 }
 
+// endpointsLister looks the endpoints up in the informer cache of the watch factory
+type endpointsLister interface {
+	GetEndpoint(namespace, name string) (*kapi.Endpoints, error)
+	GetEndpoints(namespace string) ([]*kapi.Endpoints, error)
+}
+
+// syncEndpointsLookup returns a lookup of the endpoints of a service by namespace and name, reading
+// them from a single list of the endpoints of all the namespaces, or looking each of them up when
+// they cannot be listed. The lookup returns nil when the service has no endpoints.
+func (ovn *Controller) syncEndpointsLookup() func(namespace, name string) *kapi.Endpoints {
+	endpoints, err := ovn.endpoints.GetEndpoints(metav1.NamespaceAll)
+	if err != nil {
+		klog.Warningf("Service Sync: failed to list the endpoints, looking them up for each service: %v", err)
+		return func(namespace, name string) *kapi.Endpoints {
+			ep, err := ovn.endpoints.GetEndpoint(namespace, name)
+			if err != nil {
+				return nil
+			}
+			return ep
+		}
+	}
+	byService := make(map[string]*kapi.Endpoints, len(endpoints))
+	for _, ep := range endpoints {
+		byService[ep.Namespace+"/"+ep.Name] = ep
+	}
+	return func(namespace, name string) *kapi.Endpoints {
+		return byService[namespace+"/"+name]
+	}
+}
+
 func (ovn *Controller) syncServices(services []interface{}) {
+	start := time.Now()
+	// abort the sync once the controller is stopped
+	ctx, cancel := ovn.stopContext()
+	defer cancel()
+	// the gateway topology does not change during the sync, so look it up only once
+	gateways := newGatewayCache(ovn.lbOps)
+	// phases of the sync that failed, each counted once
+	failedPhases := sets.NewString()
+	defer func() {
+		metrics.MetricServiceSyncDuration.Observe(time.Since(start).Seconds())
+		for _, phase := range failedPhases.List() {
+			metrics.MetricServiceSyncErrors.WithLabelValues(phase).Inc()
+		}
+	}()
+
 	// For all clusterIP in k8s, we will populate the below slice with
 	// IP:port. In OVN's database those are the keys. We need to
 	// have separate slice for TCP, SCTP, and UDP load-balancers (hence the dict).
@@ -47,6 +105,16 @@ func (ovn *Controller) syncServices(services []interface{}) {
 	// Track which services found should have reject ACLs. Format is name, load balancer, and value is if service has endpoints
 	svcRejectACLs := make(map[string]map[string]bool)
 
+	// The services to reconcile the load balancer VIPs of, along with their endpoints by namespace/name
+	var vipServices []*kapi.Service
+	vipEndpoints := make(map[string]*kapi.Endpoints)
+
+	// A NodePort allocated to several services is only kept for the oldest of them
+	ovn.syncNodePortOwners(services)
+
+	// the endpoints of the services, listed once rather than for each service
+	getEndpoints := ovn.syncEndpointsLookup()
+
 	// Go through the k8s services and populate 'clusterServices',
 	// 'nodeportServices' and 'lbServices'
 	for _, serviceInterface := range services {
@@ -60,43 +128,48 @@ func (ovn *Controller) syncServices(services []interface{}) {
 			continue
 		}
 
+		logger := newServiceLogger(service)
+		if svcSkipsLoadBalancing(service) {
+			logger.V(5).Info("Service Sync: Skipping service opted out of load balancing")
+			continue
+		}
+
 		if !util.IsClusterIPSet(service) {
-			klog.V(5).Infof("Skipping service %s due to clusterIP = %q", service.Name, service.Spec.ClusterIP)
+			logger.V(5).Info("Service Sync: Skipping service without cluster IP", "clusterIP", service.Spec.ClusterIP)
 			continue
 		}
 
 		// detect if service has endpoints for stale reject ACL check. If there are endpoints, we need to wipe any
 		// old stale ACLs
-		ep, err := ovn.watchFactory.GetEndpoint(service.Namespace, service.Name)
 		hasEndpoints := false
-		if err == nil {
-			if len(ep.Subsets) > 0 {
-				hasEndpoints = true
-			}
+		if ep := getEndpoints(service.Namespace, service.Name); ep != nil {
+			hasEndpoints = serviceHasReadyEndpoints(ep, service)
+			vipEndpoints[service.Namespace+"/"+service.Name] = ep
 		}
+		vipServices = append(vipServices, service)
 
 		for _, svcPort := range service.Spec.Ports {
+			portLogger := logger.WithValues("port", svcPort.Name, "protocol", svcPort.Protocol)
 			if err := util.ValidatePort(svcPort.Protocol, svcPort.Port); err != nil {
-				klog.Errorf("Error validating port %s: %v", svcPort.Name, err)
+				portLogger.Error(err, "Service Sync: Error validating port")
 				continue
 			}
-
-			if util.ServiceTypeHasNodePort(service) {
+			if util.ServicePortHasNodePort(service, &svcPort) && ovn.ownsNodePort(service, svcPort.Protocol, svcPort.NodePort) {
 				port := fmt.Sprintf("%d", svcPort.NodePort)
 				nodeportServices[svcPort.Protocol] = append(nodeportServices[svcPort.Protocol], port)
-				gatewayRouters, _, err := ovn.getOvnGateways()
+				gatewayRouters, _, err := gateways.GetOvnGateways()
 				if err == nil {
 					for _, gatewayRouter := range gatewayRouters {
-						lb, err := ovn.getGatewayLoadBalancer(gatewayRouter, svcPort.Protocol)
+						lb, err := gateways.GetGatewayLoadBalancer(gatewayRouter, svcPort.Protocol)
 						if err != nil {
-							klog.Warningf("Service Sync: Gateway router %s does not have load balancer (%v)",
-								gatewayRouter, err)
+							portLogger.Warning("Service Sync: Gateway router does not have a load balancer",
+								"gatewayRouter", gatewayRouter, "err", err)
 							continue
 						}
-						physicalIPs, err := ovn.getGatewayPhysicalIPs(gatewayRouter)
+						physicalIPs, err := gateways.GetGatewayPhysicalIPs(gatewayRouter)
 						if err != nil {
-							klog.Warningf("Service Sync: Gateway router %s does not have physical ips: %v",
-								gatewayRouter, err)
+							portLogger.Warning("Service Sync: Gateway router does not have physical IPs",
+								"gatewayRouter", gatewayRouter, "err", err)
 							continue
 						}
 						for _, physicalIP := range physicalIPs {
@@ -106,31 +179,36 @@ func (ovn *Controller) syncServices(services []interface{}) {
 				}
 			}
 
-			key := util.JoinHostPortInt32(service.Spec.ClusterIP, svcPort.Port)
-			clusterServices[svcPort.Protocol] = append(clusterServices[svcPort.Protocol], key)
+			for _, clusterIP := range svcClusterIPs(service) {
+				key := util.JoinHostPortInt32(clusterIP, svcPort.Port)
+				clusterServices[svcPort.Protocol] = append(clusterServices[svcPort.Protocol], key)
+			}
 			lb, err := ovn.getLoadBalancer(svcPort.Protocol)
 			if err != nil {
-				klog.Warningf("Unable to get existing load balancer from ovn. Reject ACLs may not be synced!")
+				portLogger.Warning("Service Sync: Unable to get existing load balancer from OVN, reject ACLs may not be synced",
+					"err", err)
 			} else {
-				addRejectACLs(svcRejectACLs, lb, service.Spec.ClusterIP, svcPort.Port, hasEndpoints)
+				for _, clusterIP := range svcClusterIPs(service) {
+					addRejectACLs(svcRejectACLs, lb, clusterIP, svcPort.Port, hasEndpoints)
+				}
 
 				// Cloud load balancers: directly load balance that traffic from pods
-				for _, ing := range service.Status.LoadBalancer.Ingress {
-					addRejectACLs(svcRejectACLs, lb, ing.IP, svcPort.Port, hasEndpoints)
+				for _, ingIP := range svcIngressIPs(service) {
+					addRejectACLs(svcRejectACLs, lb, ingIP, svcPort.Port, hasEndpoints)
 				}
 			}
 			for _, extIP := range service.Spec.ExternalIPs {
 				key := util.JoinHostPortInt32(extIP, svcPort.Port)
 				lbServices[svcPort.Protocol] = append(lbServices[svcPort.Protocol], key)
-				gateways, _, err := ovn.getOvnGateways()
+				gatewayRouters, _, err := gateways.GetOvnGateways()
 				if err != nil {
 					continue
 				}
-				for _, gateway := range gateways {
-					lb, err := ovn.getGatewayLoadBalancer(gateway, svcPort.Protocol)
+				for _, gatewayRouter := range gatewayRouters {
+					lb, err := gateways.GetGatewayLoadBalancer(gatewayRouter, svcPort.Protocol)
 					if err != nil {
-						klog.Errorf("Service Sync: Gateway router %s does not have load balancer (%v)",
-							gateway, err)
+						portLogger.Error(err, "Service Sync: Gateway router does not have a load balancer",
+							"gatewayRouter", gatewayRouter)
 						continue
 					}
 					addRejectACLs(svcRejectACLs, lb, extIP, svcPort.Port, hasEndpoints)
@@ -139,149 +217,294 @@ func (ovn *Controller) syncServices(services []interface{}) {
 		}
 	}
 
-	// Get OVN's current reject ACLs. Note, currently only services use reject ACLs.
+	// Get OVN's current reject ACLs. Note, currently only services use reject ACLs. Services may
+	// also ask for their ACL to drop traffic, in which case only the drop ACLs named after a
+	// service VIP are considered, the other ones belong to network policies.
 	type ovnACLData struct {
 		Data [][]interface{}
 	}
-	data, stderr, err := util.RunOVNNbctl("--columns=name,_uuid", "--format=json", "find", "acl", "action=reject")
-	if err != nil {
-		klog.Errorf("Error while querying ACLs with reject action: %s, %v", stderr, err)
-	} else {
+	var aclEntries [][]interface{}
+	queryFailed := false
+	for _, action := range []string{"reject", "drop"} {
+		data, stderr, err := util.RunOVNNbctlContext(ctx, "--columns=name,_uuid,priority,severity,log,meter", "--format=json",
+			"find", "acl", "action="+action)
+		if err != nil {
+			klog.Errorf("Error while querying ACLs with %s action: %s, %v", action, stderr, err)
+			queryFailed = true
+			break
+		}
 		x := ovnACLData{}
 		if err := json.Unmarshal([]byte(data), &x); err != nil {
-			klog.Errorf("Unable to get current OVN reject ACLs. Unable to sync reject ACLs!: %v", err)
-		} else if len(x.Data) == 0 {
-			klog.Infof("Service Sync: No reject ACLs currently configured in OVN")
-		} else {
-			for _, entry := range x.Data {
-				// ACL entry format is a slice: [<aclName>, ["_uuid", <uuid>]]
-				if len(entry) != 2 {
+			klog.Errorf("Unable to get current OVN %s ACLs. Unable to sync reject ACLs!: %v", action, err)
+			queryFailed = true
+			break
+		}
+		for _, entry := range x.Data {
+			if action == "drop" {
+				if len(entry) == 0 {
 					continue
 				}
 				name, ok := entry[0].(string)
 				if !ok {
 					continue
 				}
-				uuidData, ok := entry[1].([]interface{})
-				if !ok || len(uuidData) != 2 {
-					continue
-				}
-				uuid, ok := uuidData[1].(string)
-				if !ok {
+				if name, _ = legacyRejectACLName(name); svcRejectACLs[name] == nil {
 					continue
 				}
-				if svcCacheEntry, ok := svcRejectACLs[name]; ok {
-					for lb, hasEps := range svcCacheEntry {
-						if hasEps {
-							klog.Infof("Service Sync: Removing OVN stale reject ACL: %s", name)
-							ovn.removeACLFromPortGroup(lb, uuid)
-							var foundSwitches []string
-							// For upgrade from a non-port group Reject ACL implementation
-							// Deprecated: remove in the future
-							switches, err := ovn.getLogicalSwitchesForLoadBalancer(lb)
-							if err != nil {
-								klog.Errorf("Error finding node logical switches for load balancer "+
-									"%s: %v", lb, err)
-							} else {
-								foundSwitches = append(foundSwitches, switches...)
-							}
-							// Look for load balancer on join/external switches
-							grExtSwitches, err := ovn.getGRLogicalSwitchesForLoadBalancer(lb)
-							if err != nil {
-								klog.Errorf("Error finding GR logical switches for load balancer "+
-									"%s: %v", lb, err)
-							} else {
-								// For upgrade from a previous implementation the ACL may also be on join switch
-								for _, grExtSwitch := range grExtSwitches {
-									routerName := strings.TrimPrefix(grExtSwitch, types.ExternalSwitchPrefix)
-									grJoinSwitch := types.JoinSwitchPrefix + routerName
-									foundSwitches = append(foundSwitches, grExtSwitch, grJoinSwitch)
-								}
-							}
-							if len(foundSwitches) > 0 {
-								klog.V(5).Infof("Service Sync: Removing OVN stale reject ACL (%s) "+
-									"from logical switches that contains load balancer %s, switches: %s", name, lb,
-									foundSwitches)
-								ovn.removeACLFromNodeSwitches(foundSwitches, uuid)
-							}
-						}
+			}
+			aclEntries = append(aclEntries, entry)
+		}
+	}
+	if queryFailed {
+		failedPhases.Insert(metrics.ServiceSyncPhaseRejectACL)
+	} else if len(aclEntries) == 0 {
+		klog.Infof("Service Sync: No reject ACLs currently configured in OVN")
+		metrics.MetricRejectACLCount.Set(0)
+	} else {
+		metrics.MetricRejectACLCount.Set(float64(len(aclEntries)))
+		for _, entry := range aclEntries {
+			// ACL entry format is a slice: [<aclName>, ["_uuid", <uuid>], <priority>, <severity>, <log>]
+			if len(entry) < 2 {
+				continue
+			}
+			name, ok := entry[0].(string)
+			if !ok {
+				continue
+			}
+			uuidData, ok := entry[1].([]interface{})
+			if !ok || len(uuidData) != 2 {
+				continue
+			}
+			uuid, ok := uuidData[1].(string)
+			if !ok {
+				continue
+			}
+			// For upgrade from releases naming the reject ACLs with escaped colons, whose ACLs
+			// are never found by name again and are replaced by ones named the current way
+			// Deprecated: remove in the future
+			name, legacy := legacyRejectACLName(name)
+			if svcCacheEntry, ok := svcRejectACLs[name]; ok {
+				for lb, hasEps := range svcCacheEntry {
+					aclLogger := serviceLogger{}.WithValues("acl", name, "lb", lb)
+					// reject ACLs are stale once the service has endpoints, or when
+					// they were created before reject ACLs got disabled
+					if hasEps || config.Kubernetes.DisableServiceRejectACLs || legacy {
+						aclLogger.Info("Service Sync: Removing OVN stale reject ACL")
+						ovn.lbOps.RemoveRejectACL(ctx, lb, uuid)
+					} else {
+						// For upgrade from a non-port group Reject ACL implementation
+						ovn.migrateRejectACLToPortGroup(ctx, aclLogger, lb, uuid)
+						updateRejectACLSettings(ctx, name, uuid, entry[2:])
 					}
 				}
 			}
 		}
 	}
 
+	// VIPs that no service could have added belong to other components sharing the load
+	// balancers, and are left alone
+	servicePorts := serviceVIPPorts(vipServices)
+	isForeignVIP := func(loadBalancer, vip string, protocol kapi.Protocol) bool {
+		if isServiceVIP(vip, protocol, servicePorts) {
+			return false
+		}
+		klog.V(5).Infof("Service Sync: Leaving VIP %s of load balancer %s alone, no service could have added it",
+			vip, loadBalancer)
+		return true
+	}
+
+	// Every (load balancer, protocol) pair can be cleaned up independently of
+	// the others, so collect the cleanups and run them on a bounded pool of workers.
+	var cleanups []func() error
+	// the sync phase of each cleanup
+	var cleanupPhases []string
+
 	// Get OVN's current cluster load balancer VIPs and delete them if they
 	// are stale.
-	for _, protocol := range []kapi.Protocol{kapi.ProtocolTCP, kapi.ProtocolUDP, kapi.ProtocolSCTP} {
+	for _, protocol := range ovn.supportedServiceProtocols() {
+		// the cluster load balancer cache is not safe for concurrent use, so
+		// look the load balancers up before starting the workers
 		loadBalancer, err := ovn.getLoadBalancer(protocol)
 		if err != nil {
 			klog.Errorf("Failed to get load balancer for %s (%v)", kapi.Protocol(protocol), err)
+			failedPhases.Insert(metrics.ServiceSyncPhaseClusterVIP)
 			continue
 		}
-
-		loadBalancerVIPs, err := ovn.getLoadBalancerVIPs(loadBalancer)
-		if err != nil {
-			klog.Errorf("Failed to get load balancer vips for %s (%v)", loadBalancer, err)
-			continue
-		}
-		for vip := range loadBalancerVIPs {
-			if !stringSliceMembership(clusterServices[protocol], vip) {
-				klog.V(5).Infof("Deleting stale cluster vip %s in load balancer %s", vip, loadBalancer)
-				if err := ovn.deleteLoadBalancerVIP(loadBalancer, vip); err != nil {
-					klog.Error(err)
-				}
-			}
-		}
+		protocol := protocol
+		cleanups = append(cleanups, func() error {
+			return ovn.deleteStaleLoadBalancerVIPs(ctx, loadBalancer, func(vip string) bool {
+				return stringSliceMembership(clusterServices[protocol], vip) ||
+					isForeignVIP(loadBalancer, vip, protocol)
+			})
+		})
+		cleanupPhases = append(cleanupPhases, metrics.ServiceSyncPhaseClusterVIP)
 	}
 
 	// For each gateway, remove any VIP that does not exist in
 	// 'nodeportServices'.
-	gateways, stderr, err := ovn.getOvnGateways()
+	gatewayRouters, stderr, err := gateways.GetOvnGateways()
 	if err != nil {
-		klog.Errorf("Failed to get ovn gateways. Not syncing nodeport stdout: %q, stderr: %q (%v)", gateways, stderr, err)
+		klog.Errorf("Failed to get ovn gateways. Not syncing nodeport stdout: %q, stderr: %q (%v)", gatewayRouters, stderr, err)
+		failedPhases.Insert(metrics.ServiceSyncPhaseGatewayVIP)
+	} else {
+		for _, gatewayRouter := range gatewayRouters {
+			for _, protocol := range ovn.supportedServiceProtocols() {
+				gatewayRouter, protocol := gatewayRouter, protocol
+				cleanups = append(cleanups, func() error {
+					loadBalancer, err := gateways.GetGatewayLoadBalancer(gatewayRouter, protocol)
+					if err == gateway.OVNGatewayLBIsEmpty {
+						klog.V(5).Infof("Gateway router %s does not have %s load balancer", gatewayRouter, protocol)
+						return nil
+					} else if err != nil {
+						return fmt.Errorf("gateway router %s does not have %s load balancer (%v)", gatewayRouter, protocol, err)
+					}
+					return ovn.deleteStaleLoadBalancerVIPs(ctx, loadBalancer, func(vip string) bool {
+						_, port, err := net.SplitHostPort(vip)
+						if err != nil {
+							// In a OVN load-balancer, we should always have vip:port.
+							// In the unlikely event that it is not the case, skip it.
+							klog.Errorf("Failed to split %s to vip and port (%v)", vip, err)
+							return true
+						}
+						return stringSliceMembership(nodeportServices[protocol], port) ||
+							stringSliceMembership(lbServices[protocol], vip) ||
+							isForeignVIP(loadBalancer, vip, protocol)
+					})
+				})
+				cleanupPhases = append(cleanupPhases, metrics.ServiceSyncPhaseGatewayVIP)
+			}
+		}
+	}
+
+	workers := config.Kubernetes.ServiceSyncWorkers
+	if workers < 1 {
+		workers = 1
+	}
+	errs := make([]error, len(cleanups))
+	workqueue.ParallelizeUntil(ctx, workers, len(cleanups), func(i int) {
+		errs[i] = cleanups[i]()
+	})
+	for i, err := range errs {
+		if err != nil {
+			failedPhases.Insert(cleanupPhases[i])
+		}
+	}
+	if err := utilerrors.NewAggregate(errs); err != nil {
+		klog.Errorf("Service Sync: failed to remove stale load balancer VIPs: %v", err)
+	}
+
+	if ctx.Err() != nil {
+		klog.Warningf("Service Sync: aborted, the controller is stopping")
 		return
 	}
+	// the VIPs of a NodePort allocated to several services go to the oldest of them
+	sortServicesByAge(vipServices)
+	if err := ovn.reconcileServiceVIPs(ctx, gateways, vipServices, vipEndpoints); err != nil {
+		klog.Errorf("Service Sync: failed to reconcile the load balancer VIPs: %v", err)
+		failedPhases.Insert(metrics.ServiceSyncPhaseVIPReconcile)
+	}
+}
 
-	for _, gateway := range gateways {
-		for _, protocol := range []kapi.Protocol{kapi.ProtocolTCP, kapi.ProtocolUDP, kapi.ProtocolSCTP} {
-			loadBalancer, err := ovn.getGatewayLoadBalancer(gateway, protocol)
-			if err != nil {
-				klog.Errorf("Gateway router %s does not have load balancer (%v)", gateway, err)
-				continue
-			}
-			loadBalancerVIPs, err := ovn.getLoadBalancerVIPs(loadBalancer)
-			if err != nil {
-				klog.Errorf("Failed to get load balancer vips for %s (%v)", loadBalancer, err)
-				continue
-			}
-			for vip := range loadBalancerVIPs {
-				_, port, err := net.SplitHostPort(vip)
-				if err != nil {
-					// In a OVN load-balancer, we should always have vip:port.
-					// In the unlikely event that it is not the case, skip it.
-					klog.Errorf("Failed to split %s to vip and port (%v)", vip, err)
-					continue
-				}
+// updateRejectACLSettings updates an existing reject ACL when its priority differs from the configured
+// one, or when its severity or meter does, unless they come from the ACL logging of the namespace. A
+// reject ACL that does not log has no meter. settings are the priority, severity, log and meter
+// columns of the ACL.
+func updateRejectACLSettings(ctx context.Context, name, uuid string, settings []interface{}) {
+	if len(settings) != 4 {
+		return
+	}
+	var args []string
+	if priority, ok := settings[0].(float64); ok && int(priority) != config.OVNKubernetesFeature.RejectACLPriority {
+		args = append(args, fmt.Sprintf("priority=%d", config.OVNKubernetesFeature.RejectACLPriority))
+	}
+	// the severity is an empty set, not a string, when it is not set
+	severity, _ := settings[1].(string)
+	if logging, ok := settings[2].(bool); ok && !logging && severity != config.OVNKubernetesFeature.RejectACLSeverity {
+		args = append(args, fmt.Sprintf("severity=%s", config.OVNKubernetesFeature.RejectACLSeverity))
+	}
+	// the meter is an empty set too when it is not set, namespaces with a logging rate have their own
+	meter, _ := settings[3].(string)
+	if logging, ok := settings[2].(bool); ok && !logging && meter != "" {
+		args = append(args, "meter=[]")
+	} else if ok && logging && meter != config.OVNKubernetesFeature.RejectACLMeter &&
+		!strings.HasPrefix(meter, types.OvnACLLoggingMeter+"-") {
+		if config.OVNKubernetesFeature.RejectACLMeter == "" {
+			args = append(args, "meter=[]")
+		} else {
+			args = append(args, "meter="+config.OVNKubernetesFeature.RejectACLMeter)
+		}
+	}
+	if len(args) == 0 {
+		return
+	}
+	klog.Infof("Service Sync: Updating reject ACL %s with %s", name, strings.Join(args, " "))
+	_, stderr, err := loadbalancer.RunMutatingOVNNbctlContext(ctx, append([]string{"set", "acl", uuid}, args...)...)
+	if err != nil {
+		klog.Errorf("Failed to update reject ACL %s, stderr: %q, error: %v", name, stderr, err)
+	}
+}
 
-				if !stringSliceMembership(nodeportServices[protocol], port) && !stringSliceMembership(lbServices[protocol], vip) {
-					klog.V(5).Infof("Deleting stale nodeport vip %s in load balancer %s", vip, loadBalancer)
-					if err := ovn.deleteLoadBalancerVIP(loadBalancer, vip); err != nil {
-						klog.Error(err)
-					}
-				}
-			}
+// deleteStaleLoadBalancerVIPs removes every VIP of loadBalancer for which isValid returns false,
+// until ctx is cancelled
+func (ovn *Controller) deleteStaleLoadBalancerVIPs(ctx context.Context, loadBalancer string, isValid func(vip string) bool) error {
+	loadBalancerVIPs, err := ovn.getLoadBalancerVIPs(loadBalancer)
+	if err != nil {
+		return fmt.Errorf("failed to get load balancer vips for %s (%v)", loadBalancer, err)
+	}
+	var errs []error
+	for vip := range loadBalancerVIPs {
+		if isValid(vip) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, fmt.Errorf("aborted deleting stale vips of load balancer %s: %v", loadBalancer, err))
+			break
+		}
+		klog.V(5).Infof("Deleting stale vip %s in load balancer %s", vip, loadBalancer)
+		if err := ovn.lbOps.RemoveVIP(ctx, loadBalancer, vip); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// addRejectACLVIP adds vip to the VIPs of lb, of protocol, in lbs
+func addRejectACLVIP(lbs []lbRejectACLVIPs, lb string, protocol kapi.Protocol, vip rejectACLVIP) []lbRejectACLVIPs {
+	for i := range lbs {
+		if lbs[i].lb == lb {
+			lbs[i].vips = append(lbs[i].vips, vip)
+			return lbs
 		}
 	}
+	return append(lbs, lbRejectACLVIPs{lb: lb, protocol: protocol, vips: []rejectACLVIP{vip}})
 }
 
 func (ovn *Controller) createService(service *kapi.Service) error {
-	klog.Infof("Creating service %s", service.Name)
+	// stop programming the service once the controller is stopped
+	ctx, cancel := ovn.stopContext()
+	defer cancel()
+	return ovn.createServiceWithGateways(ctx, service, newGatewayCache(ovn.lbOps))
+}
+
+// createServiceWithGateways is createService looking the gateway routers, their load balancers and
+// their physical IPs up in gateways, the cache of the reconcile creating the service, until ctx is
+// cancelled
+func (ovn *Controller) createServiceWithGateways(ctx context.Context, service *kapi.Service, gateways *gatewayCache) error {
+	logger := newServiceLogger(service)
+	if service.Spec.Type == kapi.ServiceTypeExternalName {
+		logger.V(5).Info("Skipping service create: the service is of type ExternalName")
+		return nil
+	}
+	if svcSkipsLoadBalancing(service) {
+		logger.V(5).Info("Skipping service create: the service opted out of load balancing")
+		return nil
+	}
+	logger.Info("Creating service")
 	if !util.IsClusterIPSet(service) {
-		klog.V(5).Infof("Skipping service create: No cluster IP for service %s found", service.Name)
+		logger.V(5).Info("Skipping service create: no cluster IP found")
 		return nil
 	} else if len(service.Spec.Ports) == 0 {
-		klog.V(5).Info("Skipping service create: No Ports specified for service")
+		logger.V(5).Info("Skipping service create: no ports specified")
 		return nil
 	}
 
@@ -289,301 +512,1400 @@ func (ovn *Controller) createService(service *kapi.Service) error {
 	// we should not be creating the reject ACLs if this endpoint exists, because that would result in an unreachable service
 	// eventough the endpoint exists.
 	// NOTE: we can also end up in a situation where a service matching no pods is created. Such a service still has an endpoint, but with no subsets.
-	// make sure to treat that service as an ACL reject.
+	// make sure to treat that service as an ACL reject. Likewise for an endpoint with only not ready addresses, unless the service publishes them.
 	ep, err := ovn.watchFactory.GetEndpoint(service.Namespace, service.Name)
 	if err == nil {
-		if len(ep.Subsets) > 0 {
-			klog.V(5).Infof("service: %s has endpoint, will create load balancer VIPs", service.Name)
+		if serviceHasReadyEndpoints(ep, service) {
+			logger.V(5).Info("Service has endpoints, will create load balancer VIPs")
 		} else {
-			klog.V(5).Infof("service: %s has empty endpoint", service.Name)
+			logger.V(5).Info("Service has empty endpoints")
 			ep = nil
 		}
 	}
 
+	// The gateway routers are the same for every port, so look them up only once.
+	// A failure is only reported by the paths that need the gateway routers.
+	var gatewayRouters []string
+	var gatewayRoutersErr error
+	if svcHasNodePorts(service) || svcEmptyServiceACLAction(service) != "" {
+		gatewayRouters, _, gatewayRoutersErr = gateways.GetOvnGateways()
+	}
+
+	// VIPs configured for the service, reported in an event once done
+	var configured []string
+	// failures that did not stop the other ports and VIPs from being configured
+	var errs []error
+	// the ClusterIP VIPs of every port to reject, by cluster load balancer, created at once for
+	// every protocol of the service
+	var rejectLBs []lbRejectACLVIPs
+	var rejectLogging, rejectMeter string
 	for _, svcPort := range service.Spec.Ports {
+		portLogger := logger.WithValues("port", svcPort.Name, "protocol", svcPort.Protocol)
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("aborted creating service %s: %v", svcKey(service), err)
+		}
 		var port int32
-		if util.ServiceTypeHasNodePort(service) {
+		if util.ServicePortHasNodePort(service, &svcPort) {
 			port = svcPort.NodePort
 		} else {
 			port = svcPort.Port
 		}
 
 		if err := util.ValidatePort(svcPort.Protocol, port); err != nil {
-			klog.Errorf("Error validating port %s: %v", svcPort.Name, err)
+			portLogger.Error(err, "Error validating port")
 			continue
 		}
 
-		if !ovn.SCTPSupport && svcPort.Protocol == kapi.ProtocolSCTP {
+		if !ovn.serviceProtocolSupported(svcPort.Protocol) {
 			ref, err := reference.GetReference(scheme.Scheme, service)
 			if err != nil {
-				klog.Errorf("Could not get reference for pod %v: %v\n", service.Name, err)
+				logger.Error(err, "Could not get reference for service")
 			} else {
-				ovn.recorder.Event(ref, kapi.EventTypeWarning, "Unsupported protocol error", "SCTP protocol is unsupported by this version of OVN")
+				ovn.recorder.Event(ref, kapi.EventTypeWarning, "Unsupported protocol error",
+					fmt.Sprintf("%s protocol is unsupported by this version of OVN", svcPort.Protocol))
 			}
-			return fmt.Errorf("invalid service port %s: SCTP is unsupported by this version of OVN", svcPort.Name)
+			return fmt.Errorf("invalid port %s of service %s: %s is unsupported by this version of OVN",
+				svcPort.Name, svcKey(service), svcPort.Protocol)
 		}
 
-		if util.ServiceTypeHasNodePort(service) {
-			// Each gateway has a separate load-balancer for N/S traffic
-
-			gatewayRouters, _, err := ovn.getOvnGateways()
-			if err != nil {
-				return err
+		// A NodePort outside of the node port range may collide with the ports of the hosts, so
+		// it is not programmed. The cluster IP of the port still is.
+		hasNodePort := util.ServicePortHasNodePort(service, &svcPort)
+		if hasNodePort && !config.Kubernetes.NodePortRange.Contains(int(svcPort.NodePort)) {
+			portLogger.Warning("Skipping NodePort: outside of the node port range", "nodePort", svcPort.NodePort,
+				"nodePortRange", config.Kubernetes.NodePortRange.String())
+			ovn.recordServiceEvent(service, kapi.EventTypeWarning, "InvalidNodePort",
+				fmt.Sprintf("NodePort %d is outside of the node port range %s and is not configured",
+					svcPort.NodePort, config.Kubernetes.NodePortRange.String()))
+			hasNodePort = false
+		}
+		// A NodePort allocated to another service is not programmed either, so that the VIPs of
+		// the service programmed first are not overwritten.
+		if hasNodePort {
+			if owner, ok := ovn.claimNodePort(service, svcPort.Protocol, svcPort.NodePort); !ok {
+				portLogger.Error(fmt.Errorf("already allocated to service %s", owner), "Skipping NodePort",
+					"nodePort", svcPort.NodePort)
+				ovn.recordServiceEvent(service, kapi.EventTypeWarning, "DuplicateNodePort",
+					fmt.Sprintf("%s NodePort %d is already allocated to service %s and is not configured",
+						svcPort.Protocol, svcPort.NodePort, owner))
+				hasNodePort = false
 			}
+		}
 
+		if hasNodePort {
+			// Each gateway has a separate load-balancer for N/S traffic. A gateway that cannot be
+			// programmed is skipped, the NodePort only fails when no gateway could be programmed.
+			failedGateways := 0
+			if gatewayRoutersErr != nil {
+				portLogger.Error(gatewayRoutersErr, "Cannot get gateways for NodePort", "nodePort", port)
+			}
 			for _, gatewayRouter := range gatewayRouters {
-				loadBalancer, err := ovn.getGatewayLoadBalancer(gatewayRouter, svcPort.Protocol)
+				loadBalancer, err := gateways.GetGatewayLoadBalancer(gatewayRouter, svcPort.Protocol)
 				if err != nil {
-					klog.Errorf("Gateway router %s does not have load balancer (%v)", gatewayRouter, err)
+					portLogger.Error(err, "Gateway router does not have a load balancer", "gatewayRouter", gatewayRouter)
+					ovn.recordGatewayLBLookupFailure(service, gatewayRouter, svcPort.Protocol, err)
+					failedGateways++
 					continue
 				}
-				physicalIPs, err := ovn.getGatewayPhysicalIPs(gatewayRouter)
+				physicalIPs, err := gateways.GetGatewayPhysicalIPs(gatewayRouter)
 				if err != nil {
-					klog.Errorf("Gateway router %s does not have physical ip (%v)", gatewayRouter, err)
+					portLogger.Error(err, "Gateway router does not have a physical IP", "gatewayRouter", gatewayRouter)
+					failedGateways++
 					continue
 				}
+				physicalIPs = filterPhysicalIPsByFamily(gatewayRouter, physicalIPs, svcFamilyIPs(service, util.GetClusterIPs(service)))
 				for _, physicalIP := range physicalIPs {
 					// With the physical_ip:port as the VIP, add an entry in
 					// 'load balancer'.
 					vip := util.JoinHostPortInt32(physicalIP, port)
+					vipLogger := portLogger.WithValues("vip", vip, "lb", loadBalancer)
 					// Skip creating LB if endpoints watcher already did it
 					if _, hasEps := ovn.getServiceLBInfo(loadBalancer, vip); hasEps {
-						klog.V(5).Infof("Load balancer already configured for %s, %s", loadBalancer, vip)
+						vipLogger.V(5).Info("Load balancer already configured for NodePort VIP")
 					} else if ep != nil {
-						if err := ovn.AddEndpoints(ep, true); err != nil {
+						if err := ovn.addEndpointsContext(ctx, ep, true); err != nil {
+							ovn.recordVIPConfigurationFailure(service, svcPort.Protocol, vip, err)
 							return err
 						}
-					} else if svcQualifiesForReject(service) {
-						aclDenyLogging := ovn.GetNetworkPolicyACLLogging(service.Namespace).Deny
-						aclUUID, err := ovn.createLoadBalancerRejectACL(loadBalancer, physicalIP, port,
-							svcPort.Protocol, aclDenyLogging)
+					} else if svcEmptyServiceACLAction(service) != "" {
+						aclDenyLogging, aclMeter := ovn.getRejectACLLogging(service.Namespace)
+						aclUUID, err := ovn.lbOps.EnsureRejectACL(ctx, loadBalancer, physicalIP, port,
+							svcPort.Protocol, aclDenyLogging, aclMeter, svcEmptyServiceACLAction(service))
 						if err != nil {
-							return fmt.Errorf("failed to create service ACL: %v", err)
+							vipLogger.Error(err, "Failed to create reject ACL for NodePort VIP", "gatewayRouter", gatewayRouter)
+							ovn.recordVIPConfigurationFailure(service, svcPort.Protocol, vip, err)
+							failedGateways++
+							break
 						}
-						klog.Infof("Service Reject ACL created for NodePort service: %s, namespace: %s, via "+
-							"gateway router: %s:%s:%d, ACL UUID:%s", service.Name, service.Namespace,
-							svcPort.Protocol, physicalIP, port, aclUUID)
+						vipLogger.Info("Service reject ACL created for NodePort VIP", "gatewayRouter", gatewayRouter,
+							"acl", aclUUID)
 					}
 				}
 			}
+			if gatewayRoutersErr != nil || (len(gatewayRouters) > 0 && failedGateways == len(gatewayRouters)) {
+				errs = append(errs, fmt.Errorf("failed to configure %s NodePort %d of service %s on any gateway router",
+					svcPort.Protocol, port, svcKey(service)))
+			} else {
+				configured = append(configured, fmt.Sprintf("%s NodePort %d", svcPort.Protocol, port))
+			}
 		}
 		if util.ServiceTypeHasClusterIP(service) {
-			loadBalancer, err := ovn.getLoadBalancer(svcPort.Protocol)
+			loadBalancer, err := ovn.lbOps.GetLoadBalancer(svcPort.Protocol)
 			if err != nil {
-				klog.Errorf("Failed to get load balancer for %s (%v)", svcPort.Protocol, err)
-				break
+				return fmt.Errorf("failed to get load balancer for %s (%v)", svcPort.Protocol, err)
 			}
-			if svcQualifiesForReject(service) {
-				gateways, _, err := ovn.getOvnGateways()
-				if err != nil {
-					return err
+			if svcEmptyServiceACLAction(service) != "" {
+				// the gateways are only needed for the external and ingress IPs
+				if gatewayRoutersErr != nil && (len(service.Spec.ExternalIPs) > 0 ||
+					len(service.Status.LoadBalancer.Ingress) > 0) {
+					errs = append(errs, fmt.Errorf("failed to get gateways for the external IPs of service %s: %v",
+						svcKey(service), gatewayRoutersErr))
 				}
-				vip := util.JoinHostPortInt32(service.Spec.ClusterIP, svcPort.Port)
-				// Skip creating LB if endpoints watcher already did it
-				if _, hasEps := ovn.getServiceLBInfo(loadBalancer, vip); hasEps {
-					klog.V(5).Infof("Load balancer already configured for %s, %s", loadBalancer, vip)
-				} else if ep != nil {
-					if err := ovn.AddEndpoints(ep, true); err != nil {
-						return err
-					}
-				} else {
-					aclDenyLogging := ovn.GetNetworkPolicyACLLogging(service.Namespace).Deny
-					aclUUID, err := ovn.createLoadBalancerRejectACL(loadBalancer, service.Spec.ClusterIP,
-						svcPort.Port, svcPort.Protocol, aclDenyLogging)
-					if err != nil {
-						return fmt.Errorf("failed to create service ACL: %v", err)
+				// program exactly the ClusterIPs that were allocated, one per IP family
+				var clusterVIPs []string
+				rejected, added := false, false
+				aclDenyLogging, aclMeter := ovn.getRejectACLLogging(service.Namespace)
+				rejectLogging, rejectMeter = aclDenyLogging, aclMeter
+				for _, clusterIP := range svcClusterIPs(service) {
+					vip := util.JoinHostPortInt32(clusterIP, svcPort.Port)
+					clusterVIPs = append(clusterVIPs, vip)
+					// Skip creating LB if endpoints watcher already did it
+					if _, hasEps := ovn.getServiceLBInfo(loadBalancer, vip); hasEps {
+						portLogger.V(5).Info("Load balancer already configured for VIP", "vip", vip, "lb", loadBalancer)
+					} else if ep != nil {
+						// the endpoints program the VIPs of every ClusterIP at once
+						if !added {
+							if err := ovn.addEndpointsContext(ctx, ep, true); err != nil {
+								ovn.recordVIPConfigurationFailure(service, svcPort.Protocol, vip, err)
+								return err
+							}
+							added = true
+						}
+					} else {
+						rejectLBs = addRejectACLVIP(rejectLBs, loadBalancer, svcPort.Protocol,
+							rejectACLVIP{ip: clusterIP, port: svcPort.Port})
+						rejected = true
 					}
-					klog.Infof("Service Reject ACL created for ClusterIP service: %s, namespace: %s, via: "+
-						"%s:%s:%d, ACL UUID: %s", service.Name, service.Namespace, svcPort.Protocol,
-						service.Spec.ClusterIP, svcPort.Port, aclUUID)
+				}
+				if rejected {
 					// Cloud load balancers reject ACLs
-					for _, ing := range service.Status.LoadBalancer.Ingress {
-						if ing.IP == "" {
-							continue
-						}
-						for _, gateway := range gateways {
-							loadBalancer, err := ovn.getGatewayLoadBalancer(gateway, svcPort.Protocol)
+					for _, ingIP := range svcFamilyIPs(service, svcIngressIPs(service)) {
+						for _, gateway := range gatewayRouters {
+							loadBalancer, err := gateways.GetGatewayLoadBalancer(gateway, svcPort.Protocol)
 							if err != nil {
-								klog.Errorf("Gateway router %s does not have load balancer (%v)", gateway, err)
+								portLogger.Error(err, "Gateway router does not have a load balancer", "gatewayRouter", gateway)
+								ovn.recordGatewayLBLookupFailure(service, gateway, svcPort.Protocol, err)
 								continue
 							}
-							aclUUID, err := ovn.createLoadBalancerRejectACL(loadBalancer, ing.IP, svcPort.Port, svcPort.Protocol, aclDenyLogging)
+							aclUUID, err := ovn.lbOps.EnsureRejectACL(ctx, loadBalancer, ingIP, svcPort.Port,
+								svcPort.Protocol, aclDenyLogging, aclMeter, svcEmptyServiceACLAction(service))
+							vipLogger := portLogger.WithValues("vip", util.JoinHostPortInt32(ingIP, svcPort.Port),
+								"lb", loadBalancer)
 							if err != nil {
-								klog.Errorf("Failed to create reject ACL for Ingress IP: %s, load balancer: %s, error: %v",
-									ing.IP, loadBalancer, err)
+								vipLogger.Error(err, "Failed to create reject ACL for ingress IP")
 							} else {
-								klog.Infof("Reject ACL created for Ingress IP: %s, load balancer: %s, %s", ing.IP,
-									loadBalancer, aclUUID)
+								vipLogger.Info("Reject ACL created for ingress IP", "acl", aclUUID)
 							}
 						}
 					}
 				}
 				if len(service.Spec.ExternalIPs) > 0 {
-					for _, extIP := range service.Spec.ExternalIPs {
-						for _, gateway := range gateways {
-							loadBalancer, err := ovn.getGatewayLoadBalancer(gateway, svcPort.Protocol)
-							if err != nil {
-								klog.Errorf("Gateway router %s does not have load balancer (%v)", gateway, err)
-								continue
-							}
-							vip := util.JoinHostPortInt32(extIP, svcPort.Port)
-							// Skip creating LB if endpoints watcher already did it
-							if _, hasEps := ovn.getServiceLBInfo(loadBalancer, vip); hasEps {
-								klog.V(5).Infof("Load Balancer already configured for %s, %s", loadBalancer, vip)
-							} else {
-								aclDenyLogging := ovn.GetNetworkPolicyACLLogging(service.Namespace).Deny
-								aclUUID, err := ovn.createLoadBalancerRejectACL(loadBalancer, extIP, svcPort.Port,
-									svcPort.Protocol, aclDenyLogging)
-								if err != nil {
-									return fmt.Errorf("failed to create service ACL for external IP")
-								}
-								klog.Infof("Service Reject ACL created for ExternalIP service: %s, namespace: %s,"+
-									"via: %s:%s:%d, ACL UUID: %s", service.Name, service.Namespace, svcPort.Protocol,
-									extIP, svcPort.Port, aclUUID)
-							}
-						}
+					extIPs := ovn.svcExternalIPs(service, gateways)
+					if err := ovn.createExternalIPRejectACLs(ctx, service, svcPort, extIPs, gateways, gatewayRouters); err != nil {
+						return err
+					}
+					for _, extIP := range extIPs {
+						configured = append(configured, fmt.Sprintf("%s %s", svcPort.Protocol,
+							util.JoinHostPortInt32(extIP, svcPort.Port)))
 					}
 				}
+				for _, vip := range clusterVIPs {
+					configured = append(configured, fmt.Sprintf("%s %s", svcPort.Protocol, vip))
+				}
 			}
 		}
+		if svcPort.AppProtocol != nil {
+			ovn.setServicePortAppProtocol(ctx, service, svcPort, gateways, *svcPort.AppProtocol)
+		}
 	}
-	return nil
+
+	if len(rejectLBs) > 0 {
+		aclUUIDs, err := ovn.lbOps.EnsureRejectACLsOfLoadBalancers(ctx, rejectLBs, rejectLogging, rejectMeter,
+			svcEmptyServiceACLAction(service))
+		if err != nil {
+			var vips []string
+			for _, lbVIPs := range rejectLBs {
+				for _, vip := range lbVIPs.vips {
+					ovn.recordVIPConfigurationFailure(service, lbVIPs.protocol, vip.String(), err)
+					vips = append(vips, fmt.Sprintf("%s %s", lbVIPs.protocol, vip))
+				}
+			}
+			return fmt.Errorf("failed to create the reject ACLs of VIPs %v of service %s: %v",
+				vips, svcKey(service), err)
+		}
+		for l, lbVIPs := range rejectLBs {
+			for i, vip := range lbVIPs.vips {
+				logger.Info("Service reject ACL created for ClusterIP VIP", "protocol", lbVIPs.protocol,
+					"vip", vip, "lb", lbVIPs.lb, "acl", aclUUIDs[l][i])
+			}
+		}
+	}
+
+	if _, ok := service.Annotations[OvnServiceProxyProtocol]; ok {
+		if enabled, err := svcProxyProtocol(service); err != nil {
+			logger.Warning("Ignoring the proxy protocol", "err", err)
+			ovn.recordServiceEvent(service, kapi.EventTypeWarning, "InvalidProxyProtocol", err.Error())
+		} else if enabled {
+			for _, svcPort := range service.Spec.Ports {
+				ovn.setServicePortProxyProtocol(ctx, service, svcPort, gateways, true)
+			}
+		}
+	}
+
+	// the weights are applied to the endpoints when they are added
+	if _, err := svcEndpointWeights(service); err != nil {
+		logger.Warning("Ignoring the endpoint weights", "err", err)
+		ovn.recordServiceEvent(service, kapi.EventTypeWarning, "InvalidEndpointWeights", err.Error())
+	}
+
+	if service.Spec.HealthCheckNodePort != 0 {
+		if err := ovn.createHealthCheckNodePortVIPs(ctx, service); err != nil {
+			return err
+		}
+	}
+	if len(configured) > 0 {
+		ovn.recordServiceEvent(service, kapi.EventTypeNormal, "LoadBalancerConfigured",
+			fmt.Sprintf("Configured load balancer VIPs: %s", strings.Join(configured, ", ")))
+	}
+	return utilerrors.NewAggregate(errs)
 }
 
 func (ovn *Controller) updateService(oldSvc, newSvc *kapi.Service) error {
+	// Services without VIPs, like ExternalName and headless ones, and services opted out of
+	// load balancing are never programmed in OVN, so a transition into or out of either only
+	// needs to delete or create the other side
+	oldIsLoadBalanced := svcHasVIPs(oldSvc) && !svcSkipsLoadBalancing(oldSvc)
+	newIsLoadBalanced := svcHasVIPs(newSvc) && !svcSkipsLoadBalancing(newSvc)
+	logger := newServiceLogger(newSvc)
+	if !oldIsLoadBalanced && !newIsLoadBalanced {
+		logger.V(5).Info("Skipping service update: the service is not load balanced by OVN")
+		return nil
+	}
+	// stop updating the service once the controller is stopped
+	ctx, cancel := ovn.stopContext()
+	defer cancel()
+	if !oldIsLoadBalanced {
+		return ovn.createServiceWithGateways(ctx, newSvc, newGatewayCache(ovn.lbOps))
+	} else if !newIsLoadBalanced {
+		ovn.deleteServiceContext(ctx, oldSvc)
+		return nil
+	}
+
+	// a new resync generation rebuilds the service whatever changed. oldSvc is the last state of
+	// the service that was programmed, so the same generation never triggers twice, and a failed
+	// rebuild is retried.
+	if generation := newSvc.Annotations[OvnServiceResyncGeneration]; generation != "" &&
+		generation != oldSvc.Annotations[OvnServiceResyncGeneration] {
+		logger.Info("Rebuilding service for resync generation", "generation", generation)
+		ovn.deleteServiceContext(ctx, oldSvc)
+		return ovn.createServiceWithGateways(ctx, newSvc, newGatewayCache(ovn.lbOps))
+	}
+
+	// idling a service without endpoints removes the reject ACLs of its VIPs, so that OVN reports
+	// the traffic to them as empty load balancer backends events to unidle it, and unidling it
+	// brings them back. Changing the action asked for by the service updates them in place.
+	if action := svcEmptyServiceACLAction(newSvc); svcEmptyServiceACLAction(oldSvc) != action {
+		if ep, err := ovn.watchFactory.GetEndpoint(newSvc.Namespace, newSvc.Name); err != nil || !serviceHasReadyEndpoints(ep, newSvc) {
+			logger.Info("Updating the reject ACLs of service without endpoints", "action", action)
+			ovn.clearServiceVIPs(ctx, newSvc)
+		}
+	}
+
+	// the VIPs of the ingress IPs the cloud provider removed go first, whatever path rebuilds the
+	// rest of the service
+	ovn.deleteIngressVIPs(ctx, oldSvc, newSvc)
+
+	// the proxy protocol of the VIPs is recorded apart from them
+	if oldSvc.Annotations[OvnServiceProxyProtocol] != newSvc.Annotations[OvnServiceProxyProtocol] {
+		enabled, err := svcProxyProtocol(newSvc)
+		if err != nil {
+			logger.Warning("Ignoring the proxy protocol", "err", err)
+			ovn.recordServiceEvent(newSvc, kapi.EventTypeWarning, "InvalidProxyProtocol", err.Error())
+		}
+		gateways := newGatewayCache(ovn.lbOps)
+		for _, svcPort := range newSvc.Spec.Ports {
+			ovn.setServicePortProxyProtocol(ctx, newSvc, svcPort, gateways, enabled)
+		}
+	}
+
+	// the weights only change the targets of the VIPs, which the endpoints of the service set
+	if oldSvc.Annotations[OvnServiceEndpointWeights] != newSvc.Annotations[OvnServiceEndpointWeights] {
+		if _, err := svcEndpointWeights(newSvc); err != nil {
+			logger.Warning("Ignoring the endpoint weights", "err", err)
+			ovn.recordServiceEvent(newSvc, kapi.EventTypeWarning, "InvalidEndpointWeights", err.Error())
+		}
+		if ep, err := ovn.watchFactory.GetEndpoint(newSvc.Namespace, newSvc.Name); err == nil && serviceHasReadyEndpoints(ep, newSvc) {
+			if err := ovn.addEndpointsContext(ctx, ep, true); err != nil {
+				return err
+			}
+		}
+	}
+
+	// the IP families select the ClusterIPs, external IPs and physical IPs that get VIPs
+	sameClusterIPs := reflect.DeepEqual(svcClusterIPs(newSvc), svcClusterIPs(oldSvc)) &&
+		reflect.DeepEqual(newSvc.Spec.IPFamilies, oldSvc.Spec.IPFamilies)
 	if reflect.DeepEqual(newSvc.Spec.Ports, oldSvc.Spec.Ports) &&
 		reflect.DeepEqual(newSvc.Spec.ExternalIPs, oldSvc.Spec.ExternalIPs) &&
-		reflect.DeepEqual(newSvc.Spec.ClusterIP, oldSvc.Spec.ClusterIP) &&
+		sameClusterIPs &&
 		reflect.DeepEqual(newSvc.Spec.Type, oldSvc.Spec.Type) &&
+		util.ServiceTypeHasNodePort(newSvc) == util.ServiceTypeHasNodePort(oldSvc) &&
+		reflect.DeepEqual(newSvc.Spec.HealthCheckNodePort, oldSvc.Spec.HealthCheckNodePort) &&
 		reflect.DeepEqual(newSvc.Status.LoadBalancer.Ingress, oldSvc.Status.LoadBalancer.Ingress) {
-		klog.V(5).Infof("Skipping service update for: %s as change does not apply to any of .Spec.Ports, "+
-			".Spec.ExternalIP, .Spec.ClusterIP, .Spec.Type, .Status.LoadBalancer.Ingress", newSvc.Name)
+		logger.V(5).Info("Skipping service update: change does not apply to any of .Spec.Ports, " +
+			".Spec.ExternalIP, .Spec.ClusterIPs, .Spec.IPFamilies, .Spec.Type, .Spec.AllocateLoadBalancerNodePorts, " +
+			".Spec.HealthCheckNodePort, .Status.LoadBalancer.Ingress")
 		return nil
 	}
 
-	klog.V(5).Infof("Updating service from: %v to: %v", oldSvc, newSvc)
+	logger.V(5).Info("Updating service", "from", oldSvc, "to", newSvc)
+
+	// NodePort and external VIPs do not depend on the ClusterIPs, so only the
+	// cluster VIPs need to be rebuilt when nothing else changed
+	if reflect.DeepEqual(newSvc.Spec.Ports, oldSvc.Spec.Ports) &&
+		reflect.DeepEqual(newSvc.Spec.ExternalIPs, oldSvc.Spec.ExternalIPs) &&
+		reflect.DeepEqual(newSvc.Spec.IPFamilies, oldSvc.Spec.IPFamilies) &&
+		reflect.DeepEqual(newSvc.Spec.Type, oldSvc.Spec.Type) &&
+		util.ServiceTypeHasNodePort(newSvc) == util.ServiceTypeHasNodePort(oldSvc) &&
+		reflect.DeepEqual(newSvc.Spec.HealthCheckNodePort, oldSvc.Spec.HealthCheckNodePort) &&
+		reflect.DeepEqual(newSvc.Status.LoadBalancer.Ingress, oldSvc.Status.LoadBalancer.Ingress) {
+		return ovn.updateServiceClusterIP(ctx, oldSvc, newSvc)
+	}
+
+	// Likewise, external IPs have VIPs of their own on the gateways, so only the external IPs
+	// added or removed need to be programmed when nothing else changed
+	if reflect.DeepEqual(newSvc.Spec.Ports, oldSvc.Spec.Ports) &&
+		sameClusterIPs &&
+		reflect.DeepEqual(newSvc.Spec.Type, oldSvc.Spec.Type) &&
+		util.ServiceTypeHasNodePort(newSvc) == util.ServiceTypeHasNodePort(oldSvc) &&
+		reflect.DeepEqual(newSvc.Spec.HealthCheckNodePort, oldSvc.Spec.HealthCheckNodePort) &&
+		reflect.DeepEqual(newSvc.Status.LoadBalancer.Ingress, oldSvc.Status.LoadBalancer.Ingress) {
+		return ovn.updateServiceExternalIPs(ctx, oldSvc, newSvc)
+	}
 
-	ovn.deleteService(oldSvc)
-	return ovn.createService(newSvc)
+	ovn.deleteServiceContext(ctx, oldSvc)
+	return ovn.createServiceWithGateways(ctx, newSvc, newGatewayCache(ovn.lbOps))
 }
 
-func (ovn *Controller) deleteService(service *kapi.Service) {
-	klog.Infof("Deleting service %s", service.Name)
-	if !util.IsClusterIPSet(service) {
-		return
+// updateServiceExternalIPs removes the VIPs, and their reject ACLs, of the external IPs of oldSvc
+// that newSvc does not have any more and creates those of the external IPs newSvc added, leaving
+// the cluster and NodePort VIPs of the service alone
+func (ovn *Controller) updateServiceExternalIPs(ctx context.Context, oldSvc, newSvc *kapi.Service) error {
+	gateways := newGatewayCache(ovn.lbOps)
+	oldIPs := sets.NewString(oldSvc.Spec.ExternalIPs...)
+	newIPs := sets.NewString(newSvc.Spec.ExternalIPs...)
+	removed := oldIPs.Difference(newIPs).List()
+	added := newIPs.Difference(oldIPs).Intersection(sets.NewString(ovn.svcExternalIPs(newSvc, gateways)...)).List()
+	logger := newServiceLogger(newSvc)
+	logger.V(5).Info("Updating the external IPs of service", "added", added, "removed", removed)
+
+	var protoPortMap map[kapi.Protocol]map[string]lbEndpoints
+	ep, err := ovn.watchFactory.GetEndpoint(newSvc.Namespace, newSvc.Name)
+	if err == nil && serviceHasReadyEndpoints(ep, newSvc) {
+		protoPortMap = getLbEndpoints(ep, newSvc, ovn.endpointTerminating)
 	}
-	for _, svcPort := range service.Spec.Ports {
-		var port int32
-		if util.ServiceTypeHasNodePort(service) {
-			port = svcPort.NodePort
-		} else {
-			port = svcPort.Port
-		}
 
-		if err := util.ValidatePort(svcPort.Protocol, port); err != nil {
-			klog.Errorf("Skipping delete for service port %s: %v", svcPort.Name, err)
+	for _, extIP := range removed {
+		ovn.deleteExternalIPVIPs(ctx, newSvc, extIP)
+	}
+	if len(added) == 0 {
+		return nil
+	}
+	for _, svcPort := range newSvc.Spec.Ports {
+		if err := util.ValidatePort(svcPort.Protocol, svcPort.Port); err != nil {
+			logger.Error(err, "Error validating port", "port", svcPort.Name, "protocol", svcPort.Protocol)
 			continue
 		}
-
-		if util.ServiceTypeHasNodePort(service) {
-			// Delete the 'NodePort' service from a load balancer instantiated in gateways.
-			ovn.deleteNodeVIPs(nil, svcPort.Protocol, port)
+		if !ovn.serviceProtocolSupported(svcPort.Protocol) {
+			continue
 		}
-		if util.ServiceTypeHasClusterIP(service) {
-			loadBalancer, err := ovn.getLoadBalancer(svcPort.Protocol)
-			if err != nil {
-				klog.Errorf("Failed to get load balancer for %s (%v)", svcPort.Protocol, err)
-				continue
-			}
-			vip := util.JoinHostPortInt32(service.Spec.ClusterIP, svcPort.Port)
-			if err := ovn.deleteLoadBalancerVIP(loadBalancer, vip); err != nil {
-				klog.Error(err)
+		if lbEps, ok := protoPortMap[svcPort.Protocol][svcPort.Name]; ok {
+			if err := ovn.createPerNodeVIPs(ctx, added, svcPort.Protocol, svcPort.Port, lbEps.IPs, lbEps.Port); err != nil {
+				return fmt.Errorf("error in creating %s ExternalIP for svc %s, target port: %d - %v",
+					svcPort.Protocol, svcKey(newSvc), lbEps.Port, err)
 			}
-			ovn.deleteNodeVIPs([]string{service.Spec.ClusterIP}, svcPort.Protocol, svcPort.Port)
-			// Cloud load balancers
-			if err := ovn.deleteIngressVIPs(service, svcPort); err != nil {
-				klog.Error(err)
+		} else if svcEmptyServiceACLAction(newSvc) != "" {
+			gatewayRouters, _, err := gateways.GetOvnGateways()
+			if err != nil {
+				return err
 			}
-			if err := ovn.deleteExternalVIPs(service, svcPort); err != nil {
-				klog.Error(err)
+			if err := ovn.createExternalIPRejectACLs(ctx, newSvc, svcPort, added, gateways, gatewayRouters); err != nil {
+				return err
 			}
 		}
 	}
+	return nil
 }
 
-// svcQualifiesForReject determines if a service should have a reject ACL on it when it has no endpoints
-// The reject ACL is only applied to terminate incoming connections immediately when idling is not used
-// or OVNEmptyLbEvents are not enabled. When idilng or empty LB events are enabled, we want to ensure we
-// receive these packets and not reject them.
-func svcQualifiesForReject(service *kapi.Service) bool {
-	_, ok := service.Annotations[OvnServiceIdledAt]
-	return !(config.Kubernetes.OVNEmptyLbEvents && ok)
+// deleteIngressVIPs removes the VIPs, and their reject ACLs, of the load balancer ingress IPs of
+// oldSvc that newSvc does not have any more, like when the cloud provider deleted its load balancer
+// and cleared the status of the service. The IPs newSvc still uses for other VIPs are kept.
+func (ovn *Controller) deleteIngressVIPs(ctx context.Context, oldSvc, newSvc *kapi.Service) {
+	kept := sets.NewString(svcIngressIPs(newSvc)...)
+	kept.Insert(newSvc.Spec.ExternalIPs...)
+	kept.Insert(util.GetClusterIPs(newSvc)...)
+	removed := sets.NewString(svcIngressIPs(oldSvc)...).Difference(kept)
+	if removed.Len() == 0 {
+		return
+	}
+	newServiceLogger(newSvc).Info("Removing the VIPs of ingress IPs", "ingressIPs", removed.List())
+	ovn.deleteIngressIPVIPs(ctx, oldSvc, removed.List())
 }
 
-// SVC can be of types 1. clusterIP, 2. NodePort, 3. LoadBalancer,
-// or 4.ExternalIP
-// TODO adjust for upstream patch when it lands:
-// https://bugzilla.redhat.com/show_bug.cgi?id=1908540
-func getSvcVips(service *kapi.Service) []net.IP {
-	ips := make([]net.IP, 0)
-
-	if util.ServiceTypeHasNodePort(service) {
-		gatewayRouters, _, err := gateway.GetOvnGateways()
+// svcExternalIPs returns the external IPs of service of its IP families, except for the physical
+// IPs of the gateway routers. The VIPs of such an external IP would collide with the NodePort VIPs
+// on the gateway load balancers, so it is skipped with a warning event. When the gateway routers
+// cannot be looked up, every external IP is returned.
+func (ovn *Controller) svcExternalIPs(service *kapi.Service, gateways *gatewayCache) []string {
+	extIPs := svcFamilyIPs(service, service.Spec.ExternalIPs)
+	if len(extIPs) == 0 {
+		return nil
+	}
+	logger := newServiceLogger(service)
+	gatewayRouters, _, err := gateways.GetOvnGateways()
+	if err != nil {
+		logger.V(5).Info("Cannot check the external IPs of service against the node IPs", "err", err)
+		return extIPs
+	}
+	nodeIPs := make(map[string]string)
+	for _, gatewayRouter := range gatewayRouters {
+		physicalIPs, err := gateways.GetGatewayPhysicalIPs(gatewayRouter)
 		if err != nil {
-			klog.Errorf("Cannot get gateways: %s", err)
+			logger.V(5).Info("Cannot check the external IPs of service against the physical IPs of gateway router",
+				"gatewayRouter", gatewayRouter, "err", err)
+			continue
 		}
-		for _, gatewayRouter := range gatewayRouters {
-			// VIPs would be the physical IPS of the GRs(IPs of the node) in this case
-			physicalIPs, err := gateway.GetGatewayPhysicalIPs(gatewayRouter)
-			if err != nil {
-				klog.Errorf("Unable to get gateway router %s physical ip, error: %v", gatewayRouter, err)
+		for _, physicalIP := range filterPhysicalIPsByFamily(gatewayRouter, physicalIPs, nil) {
+			nodeIPs[net.ParseIP(physicalIP).String()] = gatewayRouter
+		}
+	}
+	filtered := make([]string, 0, len(extIPs))
+	for _, extIP := range extIPs {
+		if ip := net.ParseIP(extIP); ip != nil {
+			if gatewayRouter, ok := nodeIPs[ip.String()]; ok {
+				logger.Warning("Skipping external IP: it is a physical IP of gateway router", "externalIP", extIP,
+					"gatewayRouter", gatewayRouter)
+				ovn.recordServiceEvent(service, kapi.EventTypeWarning, "ExternalIPIsNodeIP",
+					fmt.Sprintf("External IP %s is a node IP and is not configured", extIP))
 				continue
 			}
+		}
+		filtered = append(filtered, extIP)
+	}
+	return filtered
+}
 
-			for _, physicalIP := range physicalIPs {
-				ip := net.ParseIP(physicalIP)
-				if ip == nil {
-					klog.Errorf("Failed to parse pod IP %q", physicalIP)
-					continue
+// deleteExternalIPVIPs removes the VIPs of the external IP extIP for every port of service from
+// the gateway and worker load balancers, which also removes their reject ACLs. The VIPs of the
+// other external IPs of the service are left alone.
+func (ovn *Controller) deleteExternalIPVIPs(ctx context.Context, service *kapi.Service, extIP string) {
+	newServiceLogger(service).V(5).Info("Removing the VIPs of external IP", "externalIP", extIP)
+	for _, svcPort := range service.Spec.Ports {
+		if err := util.ValidatePort(svcPort.Protocol, svcPort.Port); err != nil {
+			continue
+		}
+		if !ovn.serviceProtocolSupported(svcPort.Protocol) {
+			continue
+		}
+		ovn.deleteNodeVIPs(ctx, []string{extIP}, svcPort.Protocol, svcPort.Port)
+	}
+}
+
+// createExternalIPRejectACLs creates the reject ACLs of the external IPs extIPs of svcPort on the
+// load balancer of every gateway router, except for the VIPs that already have targets. The load
+// balancer of a gateway router is looked up once and all its ACLs are created in one transaction.
+func (ovn *Controller) createExternalIPRejectACLs(ctx context.Context, service *kapi.Service, svcPort kapi.ServicePort, extIPs []string,
+	gateways *gatewayCache, gatewayRouters []string) error {
+	logger := newServiceLogger(service).WithValues("port", svcPort.Name, "protocol", svcPort.Protocol)
+	aclDenyLogging, aclMeter := ovn.getRejectACLLogging(service.Namespace)
+	for _, gatewayRouter := range gatewayRouters {
+		loadBalancer, err := gateways.GetGatewayLoadBalancer(gatewayRouter, svcPort.Protocol)
+		if err != nil {
+			logger.Error(err, "Gateway router does not have a load balancer", "gatewayRouter", gatewayRouter)
+			ovn.recordGatewayLBLookupFailure(service, gatewayRouter, svcPort.Protocol, err)
+			continue
+		}
+		var rejectVIPs []rejectACLVIP
+		for _, extIP := range extIPs {
+			vip := util.JoinHostPortInt32(extIP, svcPort.Port)
+			// Skip creating LB if endpoints watcher already did it
+			if _, hasEps := ovn.getServiceLBInfo(loadBalancer, vip); hasEps {
+				logger.V(5).Info("Load balancer already configured for VIP", "vip", vip, "lb", loadBalancer)
+				continue
+			}
+			rejectVIPs = append(rejectVIPs, rejectACLVIP{ip: extIP, port: svcPort.Port})
+		}
+		if len(rejectVIPs) == 0 {
+			continue
+		}
+		aclUUIDs, err := ovn.lbOps.EnsureRejectACLs(ctx, loadBalancer, rejectVIPs,
+			svcPort.Protocol, aclDenyLogging, aclMeter, svcEmptyServiceACLAction(service))
+		if err != nil {
+			for _, vip := range rejectVIPs {
+				ovn.recordVIPConfigurationFailure(service, svcPort.Protocol, vip.String(), err)
+			}
+			return fmt.Errorf("failed to create the reject ACLs of %s external IP VIPs %v of service %s on gateway router %s: %v",
+				svcPort.Protocol, rejectVIPs, svcKey(service), gatewayRouter, err)
+		}
+		for i, vip := range rejectVIPs {
+			logger.Info("Service reject ACL created for external IP VIP", "vip", vip, "lb", loadBalancer,
+				"acl", aclUUIDs[i])
+		}
+	}
+	return nil
+}
+
+// updateServiceClusterIP moves the cluster VIPs of a service, and their reject ACLs,
+// from the ClusterIPs of oldSvc to the ClusterIPs of newSvc
+func (ovn *Controller) updateServiceClusterIP(ctx context.Context, oldSvc, newSvc *kapi.Service) error {
+	var protoPortMap map[kapi.Protocol]map[string]lbEndpoints
+	ep, err := ovn.watchFactory.GetEndpoint(newSvc.Namespace, newSvc.Name)
+	if err == nil && serviceHasReadyEndpoints(ep, newSvc) {
+		protoPortMap = getLbEndpoints(ep, newSvc, ovn.endpointTerminating)
+	}
+	var oldClusterIPs, newClusterIPs []string
+	if util.IsClusterIPSet(oldSvc) {
+		oldClusterIPs = svcClusterIPs(oldSvc)
+	}
+	if util.IsClusterIPSet(newSvc) {
+		newClusterIPs = svcClusterIPs(newSvc)
+	}
+
+	logger := newServiceLogger(newSvc)
+	for _, svcPort := range newSvc.Spec.Ports {
+		portLogger := logger.WithValues("port", svcPort.Name, "protocol", svcPort.Protocol)
+		if err := util.ValidatePort(svcPort.Protocol, svcPort.Port); err != nil {
+			portLogger.Error(err, "Error validating port")
+			continue
+		}
+		if !ovn.serviceProtocolSupported(svcPort.Protocol) {
+			continue
+		}
+		loadBalancer, err := ovn.lbOps.GetLoadBalancer(svcPort.Protocol)
+		if err != nil {
+			return fmt.Errorf("failed to get load balancer for %s (%v)", svcPort.Protocol, err)
+		}
+
+		// Remove the old VIPs first, which also removes their reject ACLs
+		if len(oldClusterIPs) > 0 {
+			if err := ovn.deleteServiceVIPs(ctx, oldClusterIPs, svcPort.Protocol, svcPort.Port); err != nil {
+				portLogger.Error(err, "Failed to remove the old ClusterIP VIPs", "lb", loadBalancer)
+			}
+		}
+		for _, clusterIP := range oldClusterIPs {
+			vip := util.JoinHostPortInt32(clusterIP, svcPort.Port)
+			vipLogger := portLogger.WithValues("vip", vip, "lb", loadBalancer)
+			if svcPort.AppProtocol != nil {
+				if err := ovn.lbOps.SetVIPAppProtocol(ctx, loadBalancer, vip, ""); err != nil {
+					vipLogger.Error(err, "Failed to remove the app protocol of the old ClusterIP VIP")
+				}
+			}
+			if enabled, _ := svcProxyProtocol(oldSvc); enabled {
+				if err := ovn.lbOps.SetVIPProxyProtocol(ctx, loadBalancer, vip, false); err != nil {
+					vipLogger.Error(err, "Failed to remove the proxy protocol of the old ClusterIP VIP")
+				}
+			}
+		}
+
+		if len(newClusterIPs) == 0 {
+			continue
+		}
+		for _, clusterIP := range newClusterIPs {
+			vip := util.JoinHostPortInt32(clusterIP, svcPort.Port)
+			vipLogger := portLogger.WithValues("vip", vip, "lb", loadBalancer)
+			if svcPort.AppProtocol != nil {
+				if err := ovn.lbOps.SetVIPAppProtocol(ctx, loadBalancer, vip, *svcPort.AppProtocol); err != nil {
+					vipLogger.Error(err, "Failed to set the app protocol of the ClusterIP VIP")
+				}
+			}
+			if enabled, _ := svcProxyProtocol(newSvc); enabled {
+				if err := ovn.lbOps.SetVIPProxyProtocol(ctx, loadBalancer, vip, true); err != nil {
+					vipLogger.Error(err, "Failed to set the proxy protocol of the ClusterIP VIP")
 				}
-				ips = append(ips, ip)
 			}
 		}
+		if lbEps, ok := protoPortMap[svcPort.Protocol][svcPort.Name]; ok {
+			if hasHostEndpoints(lbEps.IPs) && config.Gateway.Mode == config.GatewayModeShared {
+				err = ovn.createPerNodeVIPs(ctx, newClusterIPs, svcPort.Protocol, svcPort.Port,
+					lbEps.IPs, lbEps.Port)
+			} else {
+				err = ovn.lbOps.EnsureVIP(ctx, loadBalancer, newClusterIPs, svcPort.Port,
+					lbEps.IPs, lbEps.Port)
+			}
+			if err != nil {
+				return fmt.Errorf("error in creating %s Cluster IP for svc %s, target port: %d - %v",
+					svcPort.Protocol, svcKey(newSvc), lbEps.Port, err)
+			}
+		} else if action := svcEmptyServiceACLAction(newSvc); action != "" {
+			aclDenyLogging, aclMeter := ovn.getRejectACLLogging(newSvc.Namespace)
+			for _, clusterIP := range newClusterIPs {
+				aclUUID, err := ovn.lbOps.EnsureRejectACL(ctx, loadBalancer, clusterIP,
+					svcPort.Port, svcPort.Protocol, aclDenyLogging, aclMeter, action)
+				if err != nil {
+					return fmt.Errorf("failed to create service ACL: %v", err)
+				}
+				portLogger.Info("Service reject ACL created for ClusterIP VIP", "acl", aclUUID,
+					"vip", util.JoinHostPortInt32(clusterIP, svcPort.Port), "lb", loadBalancer)
+			}
+		}
+	}
+	return nil
+}
+
+func (ovn *Controller) deleteService(service *kapi.Service) {
+	// stop removing the VIPs once the controller is stopped
+	ctx, cancel := ovn.stopContext()
+	defer cancel()
+	ovn.deleteServiceContext(ctx, service)
+}
+
+// deleteServiceContext is deleteService stopping once ctx is cancelled
+func (ovn *Controller) deleteServiceContext(ctx context.Context, service *kapi.Service) {
+	logger := newServiceLogger(service)
+	if service.Spec.Type == kapi.ServiceTypeExternalName {
+		logger.V(5).Info("Skipping service delete: the service is of type ExternalName")
+		return
+	}
+	if svcSkipsLoadBalancing(service) {
+		logger.V(5).Info("Skipping service delete: the service opted out of load balancing")
+		return
 	}
+	logger.Info("Deleting service")
+	if !svcHasVIPs(service) {
+		logger.V(5).Info("Skipping service delete: the service has no load balancer VIPs")
+		return
+	}
+	// VIPs removed for the service, reported in an event once done
+	var removed []string
+	gateways := newGatewayCache(ovn.lbOps)
+	proxyProtocol, _ := svcProxyProtocol(service)
+	for _, svcPort := range service.Spec.Ports {
+		if svcPort.AppProtocol != nil {
+			ovn.setServicePortAppProtocol(ctx, service, svcPort, gateways, "")
+		}
+		if proxyProtocol {
+			ovn.setServicePortProxyProtocol(ctx, service, svcPort, gateways, false)
+		}
+		var port int32
+		if util.ServicePortHasNodePort(service, &svcPort) {
+			port = svcPort.NodePort
+		} else {
+			port = svcPort.Port
+		}
+
+		if err := util.ValidatePort(svcPort.Protocol, port); err != nil {
+			logger.Error(err, "Skipping delete for port", "port", svcPort.Name, "protocol", svcPort.Protocol)
+			continue
+		}
+
+		if util.ServicePortHasNodePort(service, &svcPort) {
+			removed = append(removed, fmt.Sprintf("%s NodePort %d", svcPort.Protocol, port))
+		}
+		if util.ServiceTypeHasClusterIP(service) {
+			for _, clusterIP := range util.GetClusterIPs(service) {
+				removed = append(removed, fmt.Sprintf("%s %s", svcPort.Protocol,
+					util.JoinHostPortInt32(clusterIP, svcPort.Port)))
+			}
+		}
+	}
+	if err := ovn.deleteAllVIPsForServiceWithGateways(ctx, service, gateways); err != nil {
+		logger.Error(err, "Failed to delete the VIPs of service")
+	}
+
+	if len(removed) > 0 {
+		ovn.recordServiceEvent(service, kapi.EventTypeNormal, "LoadBalancerRemoved",
+			fmt.Sprintf("Removed load balancer VIPs: %s", strings.Join(removed, ", ")))
+	}
+}
+
+// DeleteAllVIPsForService removes every VIP service could own from the load balancers, along with
+// their reject ACLs, without relying on what was programmed for it. The VIPs of its ClusterIPs,
+// external IPs and ingress IPs are removed from the cluster, gateway and worker load balancers,
+// and the VIPs of its NodePorts and health check NodePort from the physical IPs of every gateway.
+// VIPs that are not there are ignored, so it also purges a service whose VIPs diverged from it.
+// The NodePorts of the service are released, except those programmed for another service whose
+// VIPs are kept. The VIPs of each load balancer are removed in a single transaction, and a failure
+// does not stop the removal from the other load balancers.
+func (ovn *Controller) DeleteAllVIPsForService(service *kapi.Service) error {
+	ctx, cancel := ovn.stopContext()
+	defer cancel()
+	return ovn.deleteAllVIPsForServiceWithGateways(ctx, service, newGatewayCache(ovn.lbOps))
+}
+
+// deleteAllVIPsForServiceWithGateways is DeleteAllVIPsForService looking the gateway routers, their
+// load balancers and their physical IPs up in gateways, until ctx is cancelled
+func (ovn *Controller) deleteAllVIPsForServiceWithGateways(ctx context.Context, service *kapi.Service, gateways *gatewayCache) error {
+	logger := newServiceLogger(service)
+	logger.Info("Deleting all the VIPs of service")
+	vips, errs := ovn.svcVIPsByLoadBalancer(service, gateways)
+	for _, loadBalancer := range sets.StringKeySet(vips).List() {
+		logger.V(5).Info("Removing VIPs of service from load balancer", "vips", vips[loadBalancer].List(),
+			"lb", loadBalancer)
+		if err := ovn.lbOps.RemoveVIPs(ctx, loadBalancer, vips[loadBalancer].List()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	ovn.releaseNodePorts(service)
+	return utilerrors.NewAggregate(errs)
+}
+
+// svcVIPsByLoadBalancer returns every VIP service could own, by load balancer, as removed by
+// DeleteAllVIPsForService. The VIPs of a NodePort programmed for another service are left out.
+// Load balancers that cannot be looked up are logged and skipped, and only the failure to look
+// up a cluster load balancer is returned as an error.
+func (ovn *Controller) svcVIPsByLoadBalancer(service *kapi.Service, gateways *gatewayCache) (map[string]sets.String, []error) {
+	logger := newServiceLogger(service)
+	vips := make(map[string]sets.String)
+	add := func(loadBalancer string, ips []string, port int32) {
+		if vips[loadBalancer] == nil {
+			vips[loadBalancer] = sets.NewString()
+		}
+		for _, ip := range ips {
+			vips[loadBalancer].Insert(util.JoinHostPortInt32(ip, port))
+		}
+	}
+	var ips []string
+	if util.IsClusterIPSet(service) {
+		ips = append(ips, util.GetClusterIPs(service)...)
+	}
+	ips = append(ips, service.Spec.ExternalIPs...)
+	ips = append(ips, svcIngressIPs(service)...)
+
+	gatewayRouters, _, err := gateways.GetOvnGateways()
+	if err != nil {
+		logger.Error(err, "Error while searching for gateways")
+	}
+	// nodeLoadBalancers returns the gateway load balancer of protocol of gatewayRouter, along with
+	// the worker one in shared gateway mode
+	nodeLoadBalancers := func(gatewayRouter string, protocol kapi.Protocol) []string {
+		gatewayLB, err := gateways.GetGatewayLoadBalancer(gatewayRouter, protocol)
+		if err != nil {
+			logger.Error(err, "Gateway router does not have a load balancer", "gatewayRouter", gatewayRouter,
+				"protocol", protocol)
+			return nil
+		}
+		loadBalancers := []string{gatewayLB}
+		if config.Gateway.Mode == config.GatewayModeShared {
+			workerNode := util.GetWorkerFromGatewayRouter(gatewayRouter)
+			workerLB, err := ovn.lbOps.GetWorkerLoadBalancer(workerNode, protocol)
+			if err != nil {
+				// still clean up the gateway load balancer, along with its reject ACLs
+				logger.Error(err, "Worker switch does not have a load balancer", "node", workerNode,
+					"protocol", protocol)
+			} else {
+				loadBalancers = append(loadBalancers, workerLB)
+			}
+		}
+		return loadBalancers
+	}
+	// addNodePort adds the VIPs of port on the physical IPs of every gateway router
+	addNodePort := func(protocol kapi.Protocol, port int32) {
+		for _, gatewayRouter := range gatewayRouters {
+			physicalIPs, err := gateways.GetGatewayPhysicalIPs(gatewayRouter)
+			if err != nil {
+				logger.Error(err, "Gateway router does not have a physical IP", "gatewayRouter", gatewayRouter)
+				continue
+			}
+			for _, loadBalancer := range nodeLoadBalancers(gatewayRouter, protocol) {
+				add(loadBalancer, physicalIPs, port)
+			}
+		}
+	}
+
+	var errs []error
+	for _, svcPort := range service.Spec.Ports {
+		if err := util.ValidatePort(svcPort.Protocol, svcPort.Port); err != nil {
+			logger.Error(err, "Skipping delete for port", "port", svcPort.Name, "protocol", svcPort.Protocol)
+			continue
+		}
+		// the VIPs of a NodePort programmed for another service are left alone
+		if svcPort.NodePort != 0 && ovn.ownsNodePort(service, svcPort.Protocol, svcPort.NodePort) {
+			addNodePort(svcPort.Protocol, svcPort.NodePort)
+		}
+		if len(ips) == 0 {
+			continue
+		}
+		for _, gatewayRouter := range gatewayRouters {
+			for _, loadBalancer := range nodeLoadBalancers(gatewayRouter, svcPort.Protocol) {
+				add(loadBalancer, ips, svcPort.Port)
+			}
+		}
+		loadBalancer, err := ovn.lbOps.GetLoadBalancer(svcPort.Protocol)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to get load balancer for %s (%v)", svcPort.Protocol, err))
+			continue
+		}
+		add(loadBalancer, ips, svcPort.Port)
+	}
+	if service.Spec.HealthCheckNodePort != 0 {
+		addNodePort(kapi.ProtocolTCP, service.Spec.HealthCheckNodePort)
+	}
+	return vips, errs
+}
+
+// deleteServiceVIPs removes the ip:port VIP of every ip from the cluster load balancer of protocol
+// and from the gateway and worker load balancers of protocol, along with their reject ACLs. The
+// load balancers of the other protocols are left alone, even when they have a VIP on the same IP
+// and port.
+func (ovn *Controller) deleteServiceVIPs(ctx context.Context, ips []string, protocol kapi.Protocol, port int32) error {
+	// the node VIPs are removed even when the cluster ones cannot be
+	defer ovn.deleteNodeVIPs(ctx, ips, protocol, port)
+	loadBalancer, err := ovn.lbOps.GetLoadBalancer(protocol)
+	if err != nil {
+		return fmt.Errorf("failed to get load balancer for %s (%v)", protocol, err)
+	}
+	var errs []error
+	for _, ip := range ips {
+		if err := ovn.lbOps.RemoveVIP(ctx, loadBalancer, util.JoinHostPortInt32(ip, port)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// setServicePortAppProtocol records appProtocol, like kubernetes.io/h2c, on the cluster VIPs of
+// svcPort and on its NodePort VIPs on the load balancers of gateways, or removes the record when
+// appProtocol is empty. OVN does not act on it: it is only there for whoever inspects the load
+// balancers. Failures are logged, as the VIPs work without it.
+func (ovn *Controller) setServicePortAppProtocol(ctx context.Context, service *kapi.Service, svcPort kapi.ServicePort,
+	gateways *gatewayCache, appProtocol string) {
+	ovn.setServicePortVIPs(service, svcPort, gateways, func(loadBalancer, vip string) error {
+		return ovn.lbOps.SetVIPAppProtocol(ctx, loadBalancer, vip, appProtocol)
+	})
+}
+
+// setServicePortProxyProtocol records that the backends of svcPort expect the PROXY protocol on its
+// cluster VIPs and on its NodePort VIPs on the load balancers of gateways, or removes the record
+// when enabled is false. OVN does not act on it. Failures are logged, as the VIPs work without it.
+func (ovn *Controller) setServicePortProxyProtocol(ctx context.Context, service *kapi.Service, svcPort kapi.ServicePort,
+	gateways *gatewayCache, enabled bool) {
+	ovn.setServicePortVIPs(service, svcPort, gateways, func(loadBalancer, vip string) error {
+		return ovn.lbOps.SetVIPProxyProtocol(ctx, loadBalancer, vip, enabled)
+	})
+}
+
+// setServicePortVIPs calls set for the cluster VIPs of svcPort and for its NodePort VIPs on the
+// load balancers of gateways, logging its failures
+func (ovn *Controller) setServicePortVIPs(service *kapi.Service, svcPort kapi.ServicePort,
+	gateways *gatewayCache, set func(loadBalancer, vip string) error) {
 	if util.ServiceTypeHasClusterIP(service) {
-		if util.IsClusterIPSet(service) {
-			ip := net.ParseIP(service.Spec.ClusterIP)
-			if ip == nil {
-				klog.Errorf("Failed to parse pod IP %q", service.Spec.ClusterIP)
+		loadBalancer, err := ovn.lbOps.GetLoadBalancer(svcPort.Protocol)
+		if err != nil {
+			klog.Errorf("Failed to get load balancer for %s (%v)", svcPort.Protocol, err)
+		} else {
+			for _, clusterIP := range svcFamilyIPs(service, util.GetClusterIPs(service)) {
+				vip := util.JoinHostPortInt32(clusterIP, svcPort.Port)
+				if err := set(loadBalancer, vip); err != nil {
+					klog.Error(err)
+				}
+			}
+		}
+	}
+	if !util.ServicePortHasNodePort(service, &svcPort) {
+		return
+	}
+	gatewayRouters, _, err := gateways.GetOvnGateways()
+	if err != nil {
+		klog.Errorf("Failed to get the gateway routers (%v)", err)
+		return
+	}
+	for _, gatewayRouter := range gatewayRouters {
+		loadBalancer, err := gateways.GetGatewayLoadBalancer(gatewayRouter, svcPort.Protocol)
+		if err != nil {
+			klog.Errorf("Gateway router %s does not have load balancer (%v)", gatewayRouter, err)
+			continue
+		}
+		physicalIPs, err := gateways.GetGatewayPhysicalIPs(gatewayRouter)
+		if err != nil {
+			klog.Errorf("Gateway router %s does not have physical ip (%v)", gatewayRouter, err)
+			continue
+		}
+		for _, physicalIP := range filterPhysicalIPsByFamily(gatewayRouter, physicalIPs, svcFamilyIPs(service, util.GetClusterIPs(service))) {
+			vip := util.JoinHostPortInt32(physicalIP, svcPort.NodePort)
+			if err := set(loadBalancer, vip); err != nil {
+				klog.Error(err)
 			}
-			ips = append(ips, ip)
 		}
+	}
+}
 
-		for _, ing := range service.Status.LoadBalancer.Ingress {
-			ip := net.ParseIP(ing.IP)
+// ReconcileService reprograms the load balancers of a single service from the service and
+// endpoints known to the watch factory, regardless of what is cached about them. VIPs of the
+// service that no longer match its ports are removed first. It is safe to call concurrently
+// with the service and endpoints handlers.
+func (ovn *Controller) ReconcileService(namespace, name string) error {
+	service, err := ovn.watchFactory.GetService(namespace, name)
+	if err != nil {
+		return fmt.Errorf("failed to get service %s/%s: %v", namespace, name, err)
+	}
+	if !svcHasVIPs(service) || svcSkipsLoadBalancing(service) {
+		klog.V(5).Infof("Skipping service reconcile: %s/%s has no load balancer VIPs", namespace, name)
+		return nil
+	}
+	klog.Infof("Reconciling service %s/%s", namespace, name)
+
+	ovn.serviceReconcileLock.Lock()
+	defer ovn.serviceReconcileLock.Unlock()
+
+	// stop reconciling the service once the controller is stopped
+	ctx, cancel := ovn.stopContext()
+	defer cancel()
+
+	// the stale VIPs and the service share the gateway lookups of the reconcile
+	gateways := newGatewayCache(ovn.lbOps)
+	if err := ovn.deleteStaleServiceVIPs(ctx, service, gateways); err != nil {
+		return err
+	}
+	// the VIPs may be cached as configured while missing from the database, so program
+	// the endpoints directly instead of relying on createService to do it
+	ep, err := ovn.watchFactory.GetEndpoint(namespace, name)
+	if err == nil && serviceHasReadyEndpoints(ep, service) {
+		if err := ovn.addEndpointsContext(ctx, ep, true); err != nil {
+			return err
+		}
+	}
+	return ovn.createServiceWithGateways(ctx, service, gateways)
+}
+
+// deleteStaleServiceVIPs removes the VIPs of the service IPs whose port is not a port of the
+// service any more, from the cluster load balancers and, for external and ingress IPs, from
+// the load balancers of the gateways, looked up in gateways, until ctx is cancelled
+func (ovn *Controller) deleteStaleServiceVIPs(ctx context.Context, service *kapi.Service, gateways *gatewayCache) error {
+	protocols := ovn.supportedServiceProtocols()
+	ports := make(map[kapi.Protocol]sets.String)
+	for _, protocol := range protocols {
+		ports[protocol] = sets.NewString()
+	}
+	for _, svcPort := range service.Spec.Ports {
+		if _, ok := ports[svcPort.Protocol]; ok {
+			ports[svcPort.Protocol].Insert(fmt.Sprintf("%d", svcPort.Port))
+		}
+	}
+	isValid := func(ips sets.String, protocol kapi.Protocol) func(vip string) bool {
+		return func(vip string) bool {
+			ip, port, err := net.SplitHostPort(vip)
+			if err != nil {
+				return true
+			}
+			return !ips.Has(ip) || ports[protocol].Has(port)
+		}
+	}
+
+	gatewayIPs := sets.NewString(service.Spec.ExternalIPs...)
+	gatewayIPs.Insert(svcIngressIPs(service)...)
+	var gatewayRouters []string
+	if gatewayIPs.Len() > 0 {
+		var err error
+		gatewayRouters, _, err = gateways.GetOvnGateways()
+		if err != nil {
+			return err
+		}
+	}
+
+	var errs []error
+	for _, protocol := range protocols {
+		loadBalancer, err := ovn.lbOps.GetLoadBalancer(protocol)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to get load balancer for %s (%v)", protocol, err))
+			continue
+		}
+		if err := ovn.deleteStaleLoadBalancerVIPs(ctx, loadBalancer,
+			isValid(sets.NewString(svcClusterIPs(service)...), protocol)); err != nil {
+			errs = append(errs, err)
+		}
+		for _, gatewayRouter := range gatewayRouters {
+			loadBalancer, err := gateways.GetGatewayLoadBalancer(gatewayRouter, protocol)
+			if err != nil {
+				if err != gateway.OVNGatewayLBIsEmpty {
+					errs = append(errs, fmt.Errorf("failed to get load balancer of gateway router %s for %s (%v)",
+						gatewayRouter, protocol, err))
+				}
+				continue
+			}
+			if err := ovn.deleteStaleLoadBalancerVIPs(ctx, loadBalancer, isValid(gatewayIPs, protocol)); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// recordServiceEvent records an event on a service, unless the very same event was
+// already recorded for it less than serviceEventInterval ago, so that flapping
+// services and endpoints do not flood the service with events
+func (ovn *Controller) recordServiceEvent(service *kapi.Service, eventType, reason, message string) {
+	key := strings.Join([]string{service.Namespace, service.Name, eventType, reason, message}, "/")
+	now := time.Now()
+	ovn.serviceEventsLock.Lock()
+	for k, last := range ovn.serviceEvents {
+		if now.Sub(last) >= serviceEventInterval {
+			delete(ovn.serviceEvents, k)
+		}
+	}
+	_, recent := ovn.serviceEvents[key]
+	if !recent {
+		ovn.serviceEvents[key] = now
+	}
+	ovn.serviceEventsLock.Unlock()
+	if recent {
+		klog.V(5).Infof("Skipping duplicate %s event %s for service %s", eventType, reason, svcKey(service))
+		return
+	}
+
+	ref, err := reference.GetReference(scheme.Scheme, service)
+	if err != nil {
+		klog.Errorf("Could not get reference for service %s: %v", svcKey(service), err)
+		return
+	}
+	ovn.recorder.Event(ref, eventType, reason, message)
+}
+
+// recordGatewayLBLookupFailure records a warning event on a service when the load balancer
+// of a gateway router cannot be found. Gateway routers without NodePort support have no
+// load balancer, which is expected and not reported.
+func (ovn *Controller) recordGatewayLBLookupFailure(service *kapi.Service, gatewayRouter string, protocol kapi.Protocol, err error) {
+	if err == gateway.OVNGatewayLBIsEmpty {
+		return
+	}
+	ovn.recordServiceEvent(service, kapi.EventTypeWarning, "LoadBalancerLookupFailed",
+		fmt.Sprintf("Failed to get %s load balancer of gateway router %s: %v", protocol, gatewayRouter, err))
+}
+
+// recordVIPConfigurationFailure records a warning event on a service when one of its VIPs
+// cannot be configured
+func (ovn *Controller) recordVIPConfigurationFailure(service *kapi.Service, protocol kapi.Protocol, vip string, err error) {
+	ovn.recordServiceEvent(service, kapi.EventTypeWarning, "LoadBalancerConfigurationFailed",
+		fmt.Sprintf("Failed to configure %s load balancer VIP %s: %v", protocol, vip, err))
+}
+
+// svcEmptyServiceACLAction returns the action of the reject ACL a service gets on its VIPs when it
+// has no endpoints: "drop" when the service asks for it, so that the VIPs do not answer with a TCP
+// RST or ICMP unreachable, "reject" otherwise, or "" when the service gets no reject ACL.
+// The reject ACL is only applied to terminate incoming connections immediately when idling is not used
+// or OVNEmptyLbEvents are not enabled. When idilng or empty LB events are enabled, we want to ensure we
+// receive these packets and not reject them. No service gets one when reject ACLs are disabled.
+func svcEmptyServiceACLAction(service *kapi.Service) string {
+	if config.Kubernetes.DisableServiceRejectACLs {
+		return ""
+	}
+	if _, ok := service.Annotations[OvnServiceIdledAt]; ok && config.Kubernetes.OVNEmptyLbEvents {
+		return ""
+	}
+	if service.Annotations[OvnServiceEmptyServiceAction] == "drop" {
+		return "drop"
+	}
+	return "reject"
+}
+
+// svcSkipsLoadBalancing determines if a service opted out of OVN load balancing, in which case
+// neither VIPs nor reject ACLs are programmed for it
+func svcSkipsLoadBalancing(service *kapi.Service) bool {
+	return service.Annotations[OvnServiceSkipLoadBalancing] == "true"
+}
+
+// svcProtocols returns the protocols of the ports of service
+func svcProtocols(service *kapi.Service) []kapi.Protocol {
+	var protocols []kapi.Protocol
+	for _, svcPort := range service.Spec.Ports {
+		protocols = append(protocols, svcPort.Protocol)
+	}
+	return protocols
+}
+
+// svcProxyProtocol returns whether the service asks in its annotation for its VIPs to be recorded
+// as expecting the PROXY protocol
+func svcProxyProtocol(service *kapi.Service) (bool, error) {
+	value, ok := service.Annotations[OvnServiceProxyProtocol]
+	if !ok {
+		return false, nil
+	}
+	enabled, err := strconv.ParseBool(strings.TrimSpace(value))
+	if err != nil {
+		return false, fmt.Errorf("invalid proxy protocol %q, must be \"true\" or \"false\"", value)
+	}
+	return enabled, nil
+}
+
+// maxEndpointWeight is the highest weight of an endpoint, which is repeated as many times as its
+// weight in the targets of the VIPs of its service
+const maxEndpointWeight = 100
+
+// svcEndpointWeights returns the weights of the endpoint IPs asked for by service in its
+// annotation, or nil when it asks for none
+func svcEndpointWeights(service *kapi.Service) (map[string]int, error) {
+	value, ok := service.Annotations[OvnServiceEndpointWeights]
+	if !ok {
+		return nil, nil
+	}
+	weights := make(map[string]int)
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		ip := net.ParseIP(strings.TrimSpace(parts[0]))
+		if len(parts) != 2 || ip == nil {
+			return nil, fmt.Errorf("invalid endpoint weight %q, must be an endpoint IP and a weight like 10.128.0.5=3", entry)
+		}
+		weight, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil || weight < 1 || weight > maxEndpointWeight {
+			return nil, fmt.Errorf("invalid endpoint weight %q, must be a number from 1 to %d", entry, maxEndpointWeight)
+		}
+		weights[ip.String()] = weight
+	}
+	return weights, nil
+}
+
+// svcHasVIPs tells whether service can have any load balancer VIP: headless and ExternalName
+// services, and services without ports, have none, so they need no OVN lookups at all
+func svcHasVIPs(service *kapi.Service) bool {
+	return service.Spec.Type != kapi.ServiceTypeExternalName && util.IsClusterIPSet(service) &&
+		len(service.Spec.Ports) > 0
+}
+
+// svcFamilyIPs returns the IPs of ips of the IP families of service: a VIP of another family would
+// have neither a ClusterIP nor endpoints to go with it
+func svcFamilyIPs(service *kapi.Service, ips []string) []string {
+	filtered := util.FilterIPsByServiceFamilies(service, ips)
+	if len(filtered) < len(ips) {
+		klog.V(5).Infof("Skipping IPs %v of service %s: not of its IP families %v",
+			sets.NewString(ips...).Difference(sets.NewString(filtered...)).List(), svcKey(service),
+			util.GetServiceIPFamilies(service))
+	}
+	return filtered
+}
+
+// svcClusterIPs returns the ClusterIPs of service that have a VIP: one per IP family of the
+// service. A PreferDualStack service may only have got the ClusterIP of one family, the other
+// family then has nothing to program, which is only logged at debug level.
+func svcClusterIPs(service *kapi.Service) []string {
+	clusterIPs := svcFamilyIPs(service, util.GetClusterIPs(service))
+	if families := util.GetServiceIPFamilies(service); len(service.Spec.IPFamilies) > len(families) {
+		klog.V(5).Infof("Service %s has ClusterIPs %v for IP families %v only, out of %v", svcKey(service),
+			clusterIPs, families, service.Spec.IPFamilies)
+	}
+	return clusterIPs
+}
+
+// svcIngressIPs returns the IPs of the cloud load balancer ingresses of service. An ingress with a
+// hostname instead of an IP gets the IPs the hostname resolves to, and one with neither is skipped.
+func svcIngressIPs(service *kapi.Service) []string {
+	var ips []string
+	for _, ing := range service.Status.LoadBalancer.Ingress {
+		if ing.IP != "" {
+			ips = append(ips, ing.IP)
+		} else if ing.Hostname != "" {
+			ips = append(ips, ingressHostnames.IPs(ing.Hostname)...)
+		}
+	}
+	return ips
+}
+
+// svcKey returns the namespace/name key of service that its log lines are tagged with, so that
+// services of the same name in different namespaces can be told apart
+func svcKey(service *kapi.Service) string {
+	return service.Namespace + "/" + service.Name
+}
+
+// nodePortKey identifies the protocol NodePort port in nodePortOwners
+func nodePortKey(protocol kapi.Protocol, port int32) string {
+	return fmt.Sprintf("%s/%d", protocol, port)
+}
+
+// claimNodePort records service as the owner of the protocol NodePort port, unless the NodePort
+// is already programmed for another service. It returns the owner of the NodePort and whether it
+// is service.
+func (ovn *Controller) claimNodePort(service *kapi.Service, protocol kapi.Protocol, port int32) (string, bool) {
+	ovn.nodePortOwnersLock.Lock()
+	defer ovn.nodePortOwnersLock.Unlock()
+	key := nodePortKey(protocol, port)
+	if owner, ok := ovn.nodePortOwners[key]; ok && owner != svcKey(service) {
+		return owner, false
+	}
+	ovn.nodePortOwners[key] = svcKey(service)
+	return svcKey(service), true
+}
+
+// ownsNodePort tells whether the protocol NodePort port is not programmed for another service
+// than service
+func (ovn *Controller) ownsNodePort(service *kapi.Service, protocol kapi.Protocol, port int32) bool {
+	ovn.nodePortOwnersLock.Lock()
+	defer ovn.nodePortOwnersLock.Unlock()
+	owner, ok := ovn.nodePortOwners[nodePortKey(protocol, port)]
+	return !ok || owner == svcKey(service)
+}
+
+// releaseNodePorts forgets service as the owner of its NodePorts
+func (ovn *Controller) releaseNodePorts(service *kapi.Service) {
+	ovn.nodePortOwnersLock.Lock()
+	defer ovn.nodePortOwnersLock.Unlock()
+	for key, owner := range ovn.nodePortOwners {
+		if owner == svcKey(service) {
+			delete(ovn.nodePortOwners, key)
+		}
+	}
+}
+
+// syncNodePortOwners claims the NodePorts of services from the oldest service to the newest, and
+// reports the services with a NodePort already allocated to an older one. Only the owner of a
+// NodePort gets its VIPs.
+func (ovn *Controller) syncNodePortOwners(services []interface{}) {
+	var nodePortServices []*kapi.Service
+	for _, serviceInterface := range services {
+		service, ok := serviceInterface.(*kapi.Service)
+		if !ok || !util.IsClusterIPSet(service) || svcSkipsLoadBalancing(service) || !svcHasNodePorts(service) {
+			continue
+		}
+		nodePortServices = append(nodePortServices, service)
+	}
+	sortServicesByAge(nodePortServices)
+	for _, service := range nodePortServices {
+		for _, svcPort := range service.Spec.Ports {
+			if !util.ServicePortHasNodePort(service, &svcPort) {
+				continue
+			}
+			if owner, ok := ovn.claimNodePort(service, svcPort.Protocol, svcPort.NodePort); !ok {
+				klog.Errorf("Service Sync: %s NodePort %d of service %s is already allocated to service %s",
+					svcPort.Protocol, svcPort.NodePort, svcKey(service), owner)
+				ovn.recordServiceEvent(service, kapi.EventTypeWarning, "DuplicateNodePort",
+					fmt.Sprintf("%s NodePort %d is already allocated to service %s and is not configured",
+						svcPort.Protocol, svcPort.NodePort, owner))
+			}
+		}
+	}
+}
+
+// sortServicesByAge sorts services from the oldest to the newest, and by namespace/name when
+// they were created at the same time
+func sortServicesByAge(services []*kapi.Service) {
+	sort.SliceStable(services, func(i, j int) bool {
+		a, b := services[i].CreationTimestamp, services[j].CreationTimestamp
+		if !a.Equal(&b) {
+			return a.Before(&b)
+		}
+		return svcKey(services[i]) < svcKey(services[j])
+	})
+}
+
+// svcHasNodePorts tells whether any port of service has a NodePort
+func svcHasNodePorts(service *kapi.Service) bool {
+	for i := range service.Spec.Ports {
+		if util.ServicePortHasNodePort(service, &service.Spec.Ports[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// VIPRole tells why an IP is a VIP of a service
+type VIPRole string
+
+const (
+	// VIPRoleClusterIP is the role of a ClusterIP of a service
+	VIPRoleClusterIP VIPRole = "ClusterIP"
+	// VIPRoleNodePort is the role of a physical IP of a gateway router, the node IP a NodePort of a
+	// service is reached on
+	VIPRoleNodePort VIPRole = "NodePort"
+	// VIPRoleExternalIP is the role of an external IP of a service
+	VIPRoleExternalIP VIPRole = "ExternalIP"
+	// VIPRoleIngress is the role of an IP of a cloud load balancer ingress of a service
+	VIPRoleIngress VIPRole = "Ingress"
+)
+
+// VIPInfo is a VIP of a service: an IP of one of its IP families, why it is a VIP of the service
+// and the protocol and port of the service port it is a VIP of
+type VIPInfo struct {
+	IP       net.IP
+	Role     VIPRole
+	Protocol kapi.Protocol
+	// Port is the NodePort of the service port for a NodePort VIP, and its port otherwise
+	Port int32
+}
+
+// GetServiceVIPInfo returns the VIPs of every port of service, with their role: the physical IPs
+// of the gateway routers for the ports with a NodePort, then the ClusterIPs, the ingress IPs and
+// the external IPs. Only the IPs of the IP families of the service are returned, and the IPs that
+// cannot be parsed are left out.
+// TODO adjust for upstream patch when it lands:
+// https://bugzilla.redhat.com/show_bug.cgi?id=1908540
+func GetServiceVIPInfo(service *kapi.Service) []VIPInfo {
+	if !svcHasVIPs(service) {
+		klog.V(5).Infof("Service %s has no VIPs", svcKey(service))
+		return nil
+	}
+	var vips []VIPInfo
+	add := func(ips []string, role VIPRole, protocol kapi.Protocol, port int32) {
+		for _, vipIP := range ips {
+			ip := net.ParseIP(vipIP)
 			if ip == nil {
-				klog.Errorf("Failed to parse pod IP %q", ing)
+				klog.Errorf("Failed to parse %s IP %q of service %s", role, vipIP, svcKey(service))
 				continue
 			}
-			klog.V(5).Infof("Adding ingress IPs from Service: %s to VIP set", service.Name)
-			ips = append(ips, ip)
+			vips = append(vips, VIPInfo{IP: ip, Role: role, Protocol: protocol, Port: port})
 		}
+	}
 
-		if len(service.Spec.ExternalIPs) > 0 {
-			for _, extIP := range service.Spec.ExternalIPs {
-				ip := net.ParseIP(extIP)
-				if ip == nil {
-					klog.Errorf("Failed to parse pod IP %q", extIP)
-					continue
-				}
-				klog.V(5).Infof("Adding external IPs from Service: %s to VIP set", service.Name)
-				ips = append(ips, ip)
+	if svcHasNodePorts(service) {
+		gatewayRouters, _, err := gateway.GetOvnGateways()
+		if err != nil {
+			klog.Errorf("Cannot get gateways: %s", err)
+		}
+		// VIPs would be the physical IPS of the GRs(IPs of the node) in this case
+		var physicalIPs []string
+		for _, gatewayRouter := range gatewayRouters {
+			ips, err := gateway.GetGatewayPhysicalIPs(gatewayRouter)
+			if err != nil {
+				klog.Errorf("Unable to get gateway router %s physical ip, error: %v", gatewayRouter, err)
+				continue
+			}
+			physicalIPs = append(physicalIPs,
+				filterPhysicalIPsByFamily(gatewayRouter, ips, svcFamilyIPs(service, util.GetClusterIPs(service)))...)
+		}
+		for i := range service.Spec.Ports {
+			svcPort := &service.Spec.Ports[i]
+			if util.ServicePortHasNodePort(service, svcPort) {
+				add(physicalIPs, VIPRoleNodePort, svcPort.Protocol, svcPort.NodePort)
+			}
+		}
+	}
+	if util.ServiceTypeHasClusterIP(service) {
+		for _, svcPort := range service.Spec.Ports {
+			if util.IsClusterIPSet(service) {
+				add(svcClusterIPs(service), VIPRoleClusterIP, svcPort.Protocol, svcPort.Port)
 			}
 		}
+		for _, svcPort := range service.Spec.Ports {
+			add(svcFamilyIPs(service, svcIngressIPs(service)), VIPRoleIngress, svcPort.Protocol, svcPort.Port)
+		}
+		for _, svcPort := range service.Spec.Ports {
+			add(svcFamilyIPs(service, service.Spec.ExternalIPs), VIPRoleExternalIP, svcPort.Protocol, svcPort.Port)
+		}
 	}
-	if len(ips) == 0 {
-		klog.V(5).Infof("Service has no VIPs")
+	if len(vips) == 0 {
+		klog.V(5).Infof("Service %s has no VIPs", svcKey(service))
 		return nil
 	}
+	return vips
+}
+
+// getSvcVips returns the IPs of the VIPs of service, as returned by GetServiceVIPInfo, each once
+func getSvcVips(service *kapi.Service) []net.IP {
+	var ips []net.IP
+	seen := sets.NewString()
+	for _, vip := range GetServiceVIPInfo(service) {
+		if !seen.Has(vip.IP.String()) {
+			seen.Insert(vip.IP.String())
+			ips = append(ips, vip.IP)
+		}
+	}
 	return ips
 }
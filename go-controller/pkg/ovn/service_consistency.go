@@ -0,0 +1,210 @@
+package ovn
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/loadbalancer"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	kapi "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// DiscrepancyKind is the kind of a difference between the services and OVN
+type DiscrepancyKind string
+
+const (
+	// DiscrepancyMissingVIP is a VIP a service asks for that is not on its load balancer
+	DiscrepancyMissingVIP DiscrepancyKind = "MissingVIP"
+	// DiscrepancyOrphanVIP is a VIP of a load balancer that no service asks for
+	DiscrepancyOrphanVIP DiscrepancyKind = "OrphanVIP"
+	// DiscrepancyMissingRejectACL is a VIP of a service without endpoints that has no reject ACL
+	DiscrepancyMissingRejectACL DiscrepancyKind = "MissingRejectACL"
+)
+
+// Discrepancy is a difference between the services and OVN found by CheckServiceConsistency
+type Discrepancy struct {
+	Kind DiscrepancyKind
+	// Service is the namespace/name of the service the VIP belongs to, empty for orphan VIPs
+	Service string
+	// LoadBalancer is the UUID of the load balancer
+	LoadBalancer string
+	// VIP is the IP:port of the VIP
+	VIP string
+}
+
+func (d Discrepancy) String() string {
+	if d.Service == "" {
+		return fmt.Sprintf("%s %s on load balancer %s", d.Kind, d.VIP, d.LoadBalancer)
+	}
+	return fmt.Sprintf("%s %s on load balancer %s for service %s", d.Kind, d.VIP, d.LoadBalancer, d.Service)
+}
+
+// DiffServiceConsistency returns the discrepancies between services and the current load balancers
+// (as listed by loadbalancer.ListLoadBalancerVIPs). endpoints are keyed by namespace/name,
+// physicalIPs by gateway router, and rejectACLs holds the names of the reject and drop ACLs in OVN.
+//
+// The VIPs are compared the way DiffServiceVIPs plans them, but only VIPs missing altogether are
+// reported, not VIPs with other targets, so terminating endpoints are not told apart. A service
+// without endpoints that qualifies for reject ACLs must have one for its ClusterIPs and ingress IPs
+// on the cluster load balancer, and for its external IPs and NodePorts on the gateway load
+// balancers. The discrepancies are sorted by kind, load balancer and VIP.
+func DiffServiceConsistency(services []*kapi.Service, endpoints map[string]*kapi.Endpoints,
+	current map[string]*loadbalancer.LoadBalancerVIPs, physicalIPs map[string][]string,
+	rejectACLs sets.String) ([]Discrepancy, error) {
+	toAdd, toRemove, err := DiffServiceVIPs(services, endpoints, current, physicalIPs, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// services owning each VIP, and each NodePort or health check NodePort by protocol
+	vipOwners := make(map[string]string)
+	nodePortOwners := make(map[string]string)
+	for _, service := range services {
+		key := svcKey(service)
+		for _, svcPort := range service.Spec.Ports {
+			for _, ip := range svcVIPIPs(service) {
+				vipOwners[util.JoinHostPortInt32(ip, svcPort.Port)] = key
+			}
+			// a NodePort of several services belongs to the first of them, as in DiffServiceVIPs
+			if _, ok := nodePortOwners[nodePortKey(svcPort.Protocol, svcPort.NodePort)]; svcPort.NodePort != 0 && !ok {
+				nodePortOwners[nodePortKey(svcPort.Protocol, svcPort.NodePort)] = key
+			}
+		}
+		if port := service.Spec.HealthCheckNodePort; port != 0 {
+			nodePortOwners[nodePortKey(kapi.ProtocolTCP, port)] = key
+		}
+	}
+	owner := func(lb, vip string) string {
+		if key, ok := vipOwners[vip]; ok {
+			return key
+		}
+		if _, port, err := util.SplitHostPortInt32(vip); err == nil {
+			return nodePortOwners[nodePortKey(current[lb].Protocol, port)]
+		}
+		return ""
+	}
+
+	var discrepancies []Discrepancy
+	for _, op := range toAdd {
+		if _, ok := current[op.LoadBalancer].VIPs[op.VIP]; !ok {
+			discrepancies = append(discrepancies, Discrepancy{Kind: DiscrepancyMissingVIP,
+				Service: owner(op.LoadBalancer, op.VIP), LoadBalancer: op.LoadBalancer, VIP: op.VIP})
+		}
+	}
+	for _, op := range toRemove {
+		discrepancies = append(discrepancies, Discrepancy{Kind: DiscrepancyOrphanVIP,
+			LoadBalancer: op.LoadBalancer, VIP: op.VIP})
+	}
+
+	lbs := make(map[lbKey]string)
+	for lb, info := range current {
+		lbs[lbKey{info.Role, info.Protocol, info.Owner}] = lb
+	}
+	for _, service := range services {
+		if !util.ServiceTypeHasClusterIP(service) || svcSkipsLoadBalancing(service) || !util.IsClusterIPSet(service) ||
+			svcEmptyServiceACLAction(service) == "" {
+			continue
+		}
+		key := svcKey(service)
+		if ep, ok := endpoints[key]; ok && serviceHasReadyEndpoints(ep, service) {
+			continue
+		}
+		checkACL := func(lb, ip string, port int32) {
+			if lb == "" || ip == "" || rejectACLs.Has(generateACLName(lb, ip, port)) {
+				return
+			}
+			discrepancies = append(discrepancies, Discrepancy{Kind: DiscrepancyMissingRejectACL,
+				Service: key, LoadBalancer: lb, VIP: util.JoinHostPortInt32(ip, port)})
+		}
+		for _, svcPort := range service.Spec.Ports {
+			if err := util.ValidatePort(svcPort.Protocol, svcPort.Port); err != nil {
+				continue
+			}
+			clusterLB := lbs[lbKey{loadbalancer.LoadBalancerRoleCluster, svcPort.Protocol, ""}]
+			for _, clusterIP := range svcClusterIPs(service) {
+				checkACL(clusterLB, clusterIP, svcPort.Port)
+			}
+			for _, ingIP := range svcIngressIPs(service) {
+				checkACL(clusterLB, ingIP, svcPort.Port)
+			}
+			for _, gatewayRouter := range sets.StringKeySet(physicalIPs).List() {
+				gatewayLB := lbs[lbKey{loadbalancer.LoadBalancerRoleGateway, svcPort.Protocol, gatewayRouter}]
+				for _, extIP := range service.Spec.ExternalIPs {
+					checkACL(gatewayLB, extIP, svcPort.Port)
+				}
+				if util.ServicePortHasNodePort(service, &svcPort) {
+					for _, physicalIP := range physicalIPs[gatewayRouter] {
+						checkACL(gatewayLB, physicalIP, svcPort.NodePort)
+					}
+				}
+			}
+		}
+	}
+
+	sort.Slice(discrepancies, func(i, j int) bool {
+		a, b := discrepancies[i], discrepancies[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.LoadBalancer != b.LoadBalancer {
+			return a.LoadBalancer < b.LoadBalancer
+		}
+		return a.VIP < b.VIP
+	})
+	return discrepancies, nil
+}
+
+// svcVIPIPs returns the ClusterIPs, external IPs and ingress IPs of service
+func svcVIPIPs(service *kapi.Service) []string {
+	ips := append([]string{}, svcClusterIPs(service)...)
+	ips = append(ips, service.Spec.ExternalIPs...)
+	return append(ips, svcIngressIPs(service)...)
+}
+
+// CheckServiceConsistency audits OVN against the services in the informer cache, and returns the
+// VIPs missing from the load balancers, the VIPs no service asks for and the reject ACLs missing
+// for services without endpoints, as found by DiffServiceConsistency. Unlike syncServices it only
+// reads from OVN and changes nothing, so it is safe to run at any time.
+func (ovn *Controller) CheckServiceConsistency() ([]Discrepancy, error) {
+	services, err := ovn.watchFactory.GetServices()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the services: %v", err)
+	}
+	sortServicesByAge(services)
+	endpoints := make(map[string]*kapi.Endpoints)
+	for _, service := range services {
+		if ep, err := ovn.watchFactory.GetEndpoint(service.Namespace, service.Name); err == nil {
+			endpoints[service.Namespace+"/"+service.Name] = ep
+		}
+	}
+
+	gateways := newGatewayCache(ovn.lbOps)
+	lbs, err := loadbalancer.ListLoadBalancerVIPs(gateways.GetOvnGateways)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the load balancers: %v", err)
+	}
+	physicalIPs := gatewayPhysicalIPs(gateways, lbs)
+
+	rejectACLs := sets.NewString()
+	if !config.Kubernetes.DisableServiceRejectACLs {
+		for _, action := range []string{"reject", "drop"} {
+			names, stderr, err := util.RunOVNNbctl("--data=bare", "--no-heading", "--columns=name",
+				"find", "acl", "action="+action)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list the ACLs with %s action, stderr: %q, error: %v",
+					action, stderr, err)
+			}
+			for _, name := range strings.Split(names, "\n") {
+				if name = strings.TrimSpace(name); name != "" {
+					rejectACLs.Insert(name)
+				}
+			}
+		}
+	}
+
+	return DiffServiceConsistency(services, endpoints, lbs, physicalIPs, rejectACLs)
+}
@@ -0,0 +1,125 @@
+package ovn
+
+import (
+	"testing"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/loadbalancer"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestDiffServiceConsistency(t *testing.T) {
+	clusterLB := func(vips map[string]string) *loadbalancer.LoadBalancerVIPs {
+		return &loadbalancer.LoadBalancerVIPs{Role: loadbalancer.LoadBalancerRoleCluster, Protocol: v1.ProtocolTCP, VIPs: vips}
+	}
+	gatewayLB := func(owner string, vips map[string]string) *loadbalancer.LoadBalancerVIPs {
+		return &loadbalancer.LoadBalancerVIPs{Role: loadbalancer.LoadBalancerRoleGateway, Protocol: v1.ProtocolTCP, Owner: owner, VIPs: vips}
+	}
+	clusterIPService := newService("service1", "namespace1", "10.96.0.10",
+		[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}}, v1.ServiceTypeClusterIP, nil)
+	nodePortService := newService("service2", "namespace1", "10.96.0.20",
+		[]v1.ServicePort{{Port: 80, NodePort: 30080, Protocol: v1.ProtocolTCP}}, v1.ServiceTypeNodePort, nil)
+	invalidService := newService("service1", "namespace1", "10.96.0.10",
+		[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}}, v1.ServiceTypeClusterIP, []string{"1.1.1"})
+	endpoints := map[string]*v1.Endpoints{
+		"namespace1/service1": newEndpoints("service1", "namespace1",
+			[]v1.EndpointAddress{{IP: "10.128.0.5"}}, []v1.EndpointPort{{Port: 8080, Protocol: v1.ProtocolTCP}}),
+		"namespace1/service2": newEndpoints("service2", "namespace1",
+			[]v1.EndpointAddress{{IP: "10.128.0.6"}}, []v1.EndpointPort{{Port: 8080, Protocol: v1.ProtocolTCP}}),
+	}
+	physicalIPs := map[string][]string{"GR_node1": {"192.168.0.1"}}
+
+	testcases := []struct {
+		desc                string
+		disableRejectACLs   bool
+		services            []*v1.Service
+		endpoints           map[string]*v1.Endpoints
+		current             map[string]*loadbalancer.LoadBalancerVIPs
+		rejectACLs          sets.String
+		expectDiscrepancies []Discrepancy
+		expectErr           bool
+	}{
+		{
+			desc:      "reports nothing when OVN matches the services",
+			services:  []*v1.Service{clusterIPService},
+			endpoints: endpoints,
+			current: map[string]*loadbalancer.LoadBalancerVIPs{
+				"cluster-tcp": clusterLB(map[string]string{"10.96.0.10:80": "10.128.0.5:8080"}),
+			},
+		},
+		{
+			desc:      "reports a missing and an orphan VIP",
+			services:  []*v1.Service{clusterIPService, nodePortService},
+			endpoints: endpoints,
+			current: map[string]*loadbalancer.LoadBalancerVIPs{
+				"cluster-tcp": clusterLB(map[string]string{
+					"10.96.0.10:80": "10.128.0.5:8080",
+					"10.96.0.20:80": "10.128.0.6:8080",
+					"10.96.0.99:80": "10.128.0.9:8080",
+				}),
+				"gr-tcp": gatewayLB("GR_node1", map[string]string{}),
+			},
+			expectDiscrepancies: []Discrepancy{
+				{Kind: DiscrepancyMissingVIP, Service: "namespace1/service2", LoadBalancer: "gr-tcp", VIP: "192.168.0.1:30080"},
+				{Kind: DiscrepancyOrphanVIP, LoadBalancer: "cluster-tcp", VIP: "10.96.0.99:80"},
+			},
+		},
+		{
+			desc:      "does not report a VIP with other targets",
+			services:  []*v1.Service{clusterIPService},
+			endpoints: endpoints,
+			current: map[string]*loadbalancer.LoadBalancerVIPs{
+				"cluster-tcp": clusterLB(map[string]string{"10.96.0.10:80": "10.128.0.7:8080"}),
+			},
+		},
+		{
+			desc:     "reports the missing reject ACLs of a service without endpoints",
+			services: []*v1.Service{clusterIPService, nodePortService},
+			current: map[string]*loadbalancer.LoadBalancerVIPs{
+				"cluster-tcp": clusterLB(map[string]string{}),
+				"gr-tcp":      gatewayLB("GR_node1", map[string]string{}),
+			},
+			rejectACLs: sets.NewString("cluster-tcp-10.96.0.10:80"),
+			expectDiscrepancies: []Discrepancy{
+				{Kind: DiscrepancyMissingRejectACL, Service: "namespace1/service2", LoadBalancer: "cluster-tcp", VIP: "10.96.0.20:80"},
+				{Kind: DiscrepancyMissingRejectACL, Service: "namespace1/service2", LoadBalancer: "gr-tcp", VIP: "192.168.0.1:30080"},
+			},
+		},
+		{
+			desc:              "does not expect reject ACLs when they are disabled",
+			disableRejectACLs: true,
+			services:          []*v1.Service{clusterIPService},
+			current: map[string]*loadbalancer.LoadBalancerVIPs{
+				"cluster-tcp": clusterLB(map[string]string{}),
+			},
+		},
+		{
+			desc:      "fails on a service with an invalid IP",
+			services:  []*v1.Service{invalidService},
+			endpoints: endpoints,
+			current: map[string]*loadbalancer.LoadBalancerVIPs{
+				"cluster-tcp": clusterLB(map[string]string{}),
+			},
+			expectErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.desc, func(t *testing.T) {
+			config.PrepareTestConfig()
+			config.Kubernetes.DisableServiceRejectACLs = tc.disableRejectACLs
+			rejectACLs := tc.rejectACLs
+			if rejectACLs == nil {
+				rejectACLs = sets.NewString()
+			}
+			discrepancies, err := DiffServiceConsistency(tc.services, tc.endpoints, tc.current, physicalIPs, rejectACLs)
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectDiscrepancies, discrepancies)
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package ovn
+
+import (
+	"fmt"
+	"strings"
+
+	kapi "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+// serviceLogger writes log lines through klog, in the format of the structured klog lines, with the
+// key-value pairs it was given, so that every line about a service can be found by the namespace
+// and name of the service, and where relevant by VIP, protocol or load balancer. Unlike a
+// logr.Logger, it keeps the severity of the klog lines it replaced.
+type serviceLogger struct {
+	// level is the klog verbosity of the info lines, which are always logged at 0
+	level         klog.Level
+	keysAndValues []interface{}
+}
+
+// newServiceLogger returns the logger of the lines about service
+func newServiceLogger(service *kapi.Service) serviceLogger {
+	return serviceLogger{}.WithValues("namespace", service.Namespace, "name", service.Name)
+}
+
+// WithValues returns a logger adding keysAndValues to the key-value pairs of every line
+func (l serviceLogger) WithValues(keysAndValues ...interface{}) serviceLogger {
+	values := make([]interface{}, 0, len(l.keysAndValues)+len(keysAndValues))
+	l.keysAndValues = append(append(values, l.keysAndValues...), keysAndValues...)
+	return l
+}
+
+// V returns a logger writing its info lines at the klog verbosity level
+func (l serviceLogger) V(level klog.Level) serviceLogger {
+	l.level = level
+	return l
+}
+
+// Info logs msg at the info severity, when the klog verbosity of the logger is enabled
+func (l serviceLogger) Info(msg string, keysAndValues ...interface{}) {
+	if l.level > 0 && !klog.V(l.level).Enabled() {
+		return
+	}
+	klog.InfoDepth(1, l.line(msg, nil, keysAndValues))
+}
+
+// Warning logs msg at the warning severity
+func (l serviceLogger) Warning(msg string, keysAndValues ...interface{}) {
+	klog.WarningDepth(1, l.line(msg, nil, keysAndValues))
+}
+
+// Error logs msg and err at the error severity
+func (l serviceLogger) Error(err error, msg string, keysAndValues ...interface{}) {
+	klog.ErrorDepth(1, l.line(msg, err, keysAndValues))
+}
+
+// line formats msg, err and the key-value pairs of the logger followed by keysAndValues the way
+// klog.InfoS and klog.ErrorS do
+func (l serviceLogger) line(msg string, err error, keysAndValues []interface{}) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%q", msg)
+	if err != nil {
+		fmt.Fprintf(&b, " err=%q", err.Error())
+	}
+	values := append(append([]interface{}{}, l.keysAndValues...), keysAndValues...)
+	for i := 0; i < len(values); i += 2 {
+		var v interface{} = "(MISSING)"
+		if i+1 < len(values) {
+			v = values[i+1]
+		}
+		switch v := v.(type) {
+		case string:
+			fmt.Fprintf(&b, " %s=%q", values[i], v)
+		case error:
+			fmt.Fprintf(&b, " %s=%q", values[i], v.Error())
+		case fmt.Stringer:
+			fmt.Fprintf(&b, " %s=%q", values[i], v.String())
+		default:
+			fmt.Fprintf(&b, " %s=%+v", values[i], v)
+		}
+	}
+	return b.String()
+}
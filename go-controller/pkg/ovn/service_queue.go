@@ -0,0 +1,219 @@
+package ovn
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+
+	kapi "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	utilwait "k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// serviceQueueName is the name of the queue of the services to reconcile
+	serviceQueueName = "ovn-services"
+
+	// maxServiceRetries is the number of times a service is retried before it is dropped out of
+	// the queue. With the rate limiter in use, the delays between the retries grow exponentially
+	// from 5ms to 82s.
+	maxServiceRetries = 15
+
+	// serviceResyncJitter is the fraction of the resync interval the full syncs of the services
+	// are delayed by at most, so that the controllers do not all resync at the same time
+	serviceResyncJitter = 0.1
+)
+
+// newServiceQueue returns the rate limited queue of the keys of the services to reconcile. A
+// service changing several times before it is reconciled is only queued once, so bursts of
+// updates collapse into a single reconcile of the latest state.
+func newServiceQueue() workqueue.RateLimitingInterface {
+	return workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), serviceQueueName)
+}
+
+// enqueueService queues the key of an added or updated service for reconciliation
+func (oc *Controller) enqueueService(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("couldn't get key for service %+v: %v", obj, err))
+		return
+	}
+	oc.serviceQueue.Add(key)
+}
+
+// enqueueDeletedService queues a deleted service. If it was never programmed, it is remembered
+// as programmed so that the reconcile still removes whatever an earlier failed attempt left.
+func (oc *Controller) enqueueDeletedService(obj interface{}) {
+	service, ok := obj.(*kapi.Service)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if ok {
+			service, ok = tombstone.Obj.(*kapi.Service)
+		}
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("couldn't get service from deleted object %+v", obj))
+			return
+		}
+	}
+	key, err := cache.MetaNamespaceKeyFunc(service)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("couldn't get key for service %+v: %v", service, err))
+		return
+	}
+	oc.programmedServicesLock.Lock()
+	if _, ok := oc.programmedServices[key]; !ok {
+		oc.programmedServices[key] = service
+	}
+	oc.programmedServicesLock.Unlock()
+	oc.serviceQueue.Add(key)
+}
+
+// runServiceWorker reconciles the queued services until the queue is shut down. The queue
+// never hands the same service to two workers at once.
+func (oc *Controller) runServiceWorker() {
+	for oc.processNextService() {
+	}
+}
+
+// startServiceWorker reconciles the queued services in the background until the controller is
+// stopped, which shuts the queue down. The queue still hands out the services queued before, so
+// the worker reconciles them before it returns, and no service is queued after. A reconcile cut
+// short, like by the process exiting, is not rolled back, but a VIP getting targets loses its
+// reject ACL in the same transaction (see setLoadBalancerVIPs).
+func (oc *Controller) startServiceWorker() {
+	go utilwait.Until(oc.runServiceWorker, time.Second, oc.stopChan)
+	go func() {
+		<-oc.stopChan
+		oc.serviceQueue.ShutDown()
+	}()
+}
+
+// startServiceResync runs the full sync of the services again every service resync interval,
+// jittered, until the controller is stopped. No resync is run when the interval is 0.
+func (oc *Controller) startServiceResync() {
+	interval := time.Duration(config.Kubernetes.ServiceResyncInterval) * time.Second
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		for {
+			select {
+			case <-time.After(utilwait.Jitter(interval, serviceResyncJitter)):
+				oc.resyncServices()
+			case <-oc.stopChan:
+				return
+			}
+		}
+	}()
+}
+
+// resyncServices runs the full sync of the services done at startup again, over the services
+// known to the watch factory. It corrects the load balancers that drifted from the services
+// because of a missed event or of a change made to the OVN database behind the controller.
+func (oc *Controller) resyncServices() {
+	services, err := oc.watchFactory.GetServices()
+	if err != nil {
+		klog.Errorf("Failed to list the services to resync them: %v", err)
+		return
+	}
+	objs := make([]interface{}, 0, len(services))
+	for _, service := range services {
+		objs = append(objs, service)
+	}
+
+	oc.serviceReconcileLock.Lock()
+	defer oc.serviceReconcileLock.Unlock()
+	klog.Infof("Resyncing %d services", len(objs))
+	oc.syncServices(objs)
+}
+
+// processNextService reconciles the next queued service, and returns false once the queue is
+// shut down
+func (oc *Controller) processNextService() bool {
+	key, quit := oc.serviceQueue.Get()
+	if quit {
+		return false
+	}
+	defer oc.serviceQueue.Done(key)
+
+	err := oc.syncService(key.(string))
+	if err == nil {
+		oc.serviceQueue.Forget(key)
+		return true
+	}
+	if oc.serviceQueue.NumRequeues(key) < maxServiceRetries {
+		klog.Warningf("Error reconciling service %s, retrying: %v", key, err)
+		oc.serviceQueue.AddRateLimited(key)
+		return true
+	}
+	klog.Warningf("Dropping service %s out of the queue: %v", key, err)
+	oc.serviceQueue.Forget(key)
+	utilruntime.HandleError(err)
+	oc.recordServiceDropped(key.(string), err)
+	return true
+}
+
+// recordServiceDropped records a warning event on a service dropped out of the queue, unless the
+// service is gone
+func (oc *Controller) recordServiceDropped(key string, err error) {
+	namespace, name, keyErr := cache.SplitMetaNamespaceKey(key)
+	if keyErr != nil {
+		return
+	}
+	service, getErr := oc.watchFactory.GetService(namespace, name)
+	if getErr != nil {
+		return
+	}
+	oc.recordServiceEvent(service, kapi.EventTypeWarning, "ReconcileFailed",
+		fmt.Sprintf("Gave up configuring the load balancers after %d retries: %v", maxServiceRetries, err))
+}
+
+// syncService brings OVN from the last programmed state of the service to its current state,
+// creating, updating or deleting it as needed. The programmed state is only moved forward when
+// the reconcile succeeds, so a retry starts from the same place.
+func (oc *Controller) syncService(key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return fmt.Errorf("invalid service key %q: %v", key, err)
+	}
+	service, err := oc.watchFactory.GetService(namespace, name)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to get service %s: %v", key, err)
+	}
+
+	oc.serviceReconcileLock.Lock()
+	defer oc.serviceReconcileLock.Unlock()
+
+	oc.programmedServicesLock.Lock()
+	programmed := oc.programmedServices[key]
+	oc.programmedServicesLock.Unlock()
+
+	switch {
+	case service == nil:
+		if programmed != nil {
+			oc.deleteService(programmed)
+		}
+	case programmed == nil:
+		if err := oc.createService(service); err != nil {
+			return err
+		}
+	default:
+		if err := oc.updateService(programmed, service); err != nil {
+			return err
+		}
+	}
+
+	oc.programmedServicesLock.Lock()
+	defer oc.programmedServicesLock.Unlock()
+	if service == nil {
+		delete(oc.programmedServices, key)
+	} else {
+		oc.programmedServices[key] = service
+	}
+	return nil
+}
@@ -1,19 +1,29 @@
 package ovn
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"net"
+	"strings"
 
 	"github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
 	"github.com/onsi/gomega"
 
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/metrics"
 	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/urfave/cli/v2"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	apimachinerytypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	utilnet "k8s.io/utils/net"
 )
 
 type service struct{}
@@ -41,13 +51,30 @@ func newService(name, namespace, ip string, ports []v1.ServicePort, serviceType
 	}
 }
 
+// listRejectACLCmd returns the command reading the fields of the existing reject ACL aclUUID, which
+// has the given priority, match, action, severity and meter and logs when log is set. An empty
+// severity or meter is not set.
+func listRejectACLCmd(aclUUID string, priority int, match, action string, log bool, severity, meter string) *ovntest.ExpectedCmd {
+	optional := func(value string) string {
+		if value == "" {
+			return `["set",[]]`
+		}
+		return `"` + value + `"`
+	}
+	return &ovntest.ExpectedCmd{
+		Cmd: "ovn-nbctl --timeout=15 --columns=priority,match,action,log,severity,meter --format=json list acl " + aclUUID,
+		Output: fmt.Sprintf(`{"data":[[%d,"%s","%s",%t,%s,%s]],"headings":["priority","match","action","log","severity","meter"]}`,
+			priority, match, action, log, optional(severity), optional(meter)),
+	}
+}
+
 func (s service) baseCmds(fexec *ovntest.FakeExec, service v1.Service) {
 	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
 		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
 		Output: k8sTCPLoadBalancerIP,
 	})
 	fexec.AddFakeCmdsNoOutputNoError([]string{
-		"ovn-nbctl --timeout=15 --columns=name,_uuid --format=json find acl action=reject",
+		"ovn-nbctl --timeout=15 --columns=name,_uuid,priority,severity,log,meter --format=json find acl action=reject",
 	})
 	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
 		Cmd:    fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer %s vips", k8sTCPLoadBalancerIP),
@@ -131,14 +158,36 @@ func (s service) baseCmds(fexec *ovntest.FakeExec, service v1.Service) {
 	})
 }
 
+// listLoadBalancersCmds adds the commands the sync runs to list the load balancers when it
+// reconciles the service VIPs, finding none of them. The gateway routers come from the
+// earlier phases of the sync.
+func (s service) listLoadBalancersCmds(fexec *ovntest.FakeExec, gatewayRouters ...string) {
+	fexec.AddFakeCmdsNoOutputNoError([]string{
+		"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
+		"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-udp=yes",
+		"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-sctp=yes",
+	})
+	for _, gatewayRouter := range gatewayRouters {
+		for _, protocol := range []string{"TCP", "UDP", "SCTP"} {
+			fexec.AddFakeCmdsNoOutputNoError([]string{
+				fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:%s_lb_gateway_router=%s", protocol, gatewayRouter),
+			})
+		}
+	}
+	fexec.AddFakeCmdsNoOutputNoError([]string{
+		"ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=external_ids find load_balancer",
+	})
+}
+
 func (s service) addCmds(fexec *ovntest.FakeExec, service v1.Service) {
 	s.baseCmds(fexec, service)
+	s.listLoadBalancersCmds(fexec, "gateway1")
 	for _, port := range service.Spec.Ports {
 		fexec.AddFakeCmdsNoOutputNoError([]string{
 			fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=%s-%s\\:%v",
 				k8sTCPLoadBalancerIP, service.Spec.ClusterIP, port.Port),
 			fmt.Sprintf("ovn-nbctl --timeout=15 --id=@reject-acl create acl direction="+types.DirectionFromLPort+" priority="+types.DefaultDenyPriority+" match=\"ip4.dst==%s && tcp "+
-				"&& tcp.dst==%v\" action=reject log=false severity=info meter=acl-logging name=%s-%s\\:%v -- add port_group %s acls @reject-acl", service.Spec.ClusterIP, port.Port,
+				"&& tcp.dst==%v\" action=reject log=false severity=info name=%s-%s\\:%v -- add port_group %s acls @reject-acl", service.Spec.ClusterIP, port.Port,
 				k8sTCPLoadBalancerIP, service.Spec.ClusterIP, port.Port, ovnClusterPortGroupUUID),
 		})
 	}
@@ -150,11 +199,214 @@ func (s service) delCmds(fexec *ovntest.FakeExec, service v1.Service) {
 			fmt.Sprintf("ovn-nbctl --timeout=15 --if-exists remove load_balancer %s vips \"%s:%v\"", k8sTCPLoadBalancerIP, service.Spec.ClusterIP, port.Port),
 			fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=%s-%s\\:%v", k8sTCPLoadBalancerIP, service.Spec.ClusterIP, port.Port),
 			"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
-			"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
 		})
 	}
 }
 
+// fakeServiceLBBackend is a ServiceLBBackend that records the VIPs and reject ACLs it is
+// asked to configure instead of running any OVN command
+type fakeServiceLBBackend struct {
+	gateways    []string
+	physicalIPs map[string][]string
+	rejectACLs  []string
+	// liveRejectACLs holds the "<load balancer> <vip>" whose reject ACL was created and not removed
+	// since, along with the VIP or once the VIP got targets
+	liveRejectACLs sets.String
+	// vips maps "<load balancer> <vip>" to the targets of the VIP
+	vips map[string][]string
+	// removedVIPs holds the "<load balancer> <vip>" removed, in order
+	removedVIPs []string
+	// gatewayLookups counts the lookups of the gateway routers
+	gatewayLookups int
+	// gatewayLBLookups counts the lookups of the load balancers of each gateway router
+	gatewayLBLookups map[string]int
+	// removedVIPBatches holds the "<load balancer> <vip>" removed by each RemoveVIPs, in order
+	removedVIPBatches [][]string
+	// rejectACLBatches holds the reject ACLs created by each EnsureRejectACLs, in order
+	rejectACLBatches [][]string
+	// appProtocols maps "<load balancer> <vip>" to the app protocol recorded for the VIP
+	appProtocols map[string]string
+	// proxyProtocols holds the "<load balancer> <vip>" recorded as expecting the PROXY protocol
+	proxyProtocols sets.String
+	// removedRejectACLs holds the "<load balancer> <acl>" removed by RemoveRejectACL, in order
+	removedRejectACLs []string
+	// gatewaysErr fails the lookups of the gateway routers
+	gatewaysErr error
+	// gatewayLBErrs fails the lookups of the load balancers of the gateway routers it holds
+	gatewayLBErrs map[string]error
+}
+
+var _ ServiceLBBackend = &fakeServiceLBBackend{}
+
+// countingEndpointsLister is an endpointsLister counting the lookups and the lists of endpoints
+type countingEndpointsLister struct {
+	endpointsLister
+	gets  int
+	lists int
+}
+
+func (l *countingEndpointsLister) GetEndpoint(namespace, name string) (*v1.Endpoints, error) {
+	l.gets++
+	return l.endpointsLister.GetEndpoint(namespace, name)
+}
+
+func (l *countingEndpointsLister) GetEndpoints(namespace string) ([]*v1.Endpoints, error) {
+	l.lists++
+	return l.endpointsLister.GetEndpoints(namespace)
+}
+
+func (f *fakeServiceLBBackend) GetOvnGateways() ([]string, string, error) {
+	f.gatewayLookups++
+	if f.gatewaysErr != nil {
+		return nil, "", f.gatewaysErr
+	}
+	return f.gateways, "", nil
+}
+
+func (f *fakeServiceLBBackend) GetLoadBalancer(protocol v1.Protocol) (string, error) {
+	return fmt.Sprintf("cluster-%s", protocol), nil
+}
+
+func (f *fakeServiceLBBackend) GetGatewayLoadBalancer(gatewayRouter string, protocol v1.Protocol) (string, error) {
+	if f.gatewayLBLookups == nil {
+		f.gatewayLBLookups = make(map[string]int)
+	}
+	f.gatewayLBLookups[gatewayRouter]++
+	if err := f.gatewayLBErrs[gatewayRouter]; err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s-%s", gatewayRouter, protocol), nil
+}
+
+func (f *fakeServiceLBBackend) GetGatewayPhysicalIPs(gatewayRouter string) ([]string, error) {
+	return f.physicalIPs[gatewayRouter], nil
+}
+
+func (f *fakeServiceLBBackend) GetWorkerLoadBalancer(node string, protocol v1.Protocol) (string, error) {
+	return fmt.Sprintf("%s-worker-%s", node, protocol), nil
+}
+
+func (f *fakeServiceLBBackend) EnsureVIP(ctx context.Context, lb string, sourceIPs []string, sourcePort int32, targetIPs []string, targetPort int32) error {
+	if f.vips == nil {
+		f.vips = make(map[string][]string)
+	}
+	for _, sourceIP := range sourceIPs {
+		var targets []string
+		for _, targetIP := range targetIPs {
+			if utilnet.IsIPv6String(targetIP) == utilnet.IsIPv6String(sourceIP) {
+				targets = append(targets, util.JoinHostPortInt32(targetIP, targetPort))
+			}
+		}
+		key := fmt.Sprintf("%s %s", lb, util.JoinHostPortInt32(sourceIP, sourcePort))
+		f.vips[key] = targets
+		if len(targets) > 0 {
+			f.liveRejectACLs.Delete(key)
+		}
+	}
+	return nil
+}
+
+func (f *fakeServiceLBBackend) RemoveVIP(ctx context.Context, lb, vip string) error {
+	key := fmt.Sprintf("%s %s", lb, vip)
+	delete(f.vips, key)
+	f.liveRejectACLs.Delete(key)
+	f.removedVIPs = append(f.removedVIPs, key)
+	return nil
+}
+
+func (f *fakeServiceLBBackend) RemoveVIPs(ctx context.Context, lb string, vips []string) error {
+	var batch []string
+	for _, vip := range vips {
+		key := fmt.Sprintf("%s %s", lb, vip)
+		delete(f.vips, key)
+		f.liveRejectACLs.Delete(key)
+		batch = append(batch, key)
+	}
+	f.removedVIPs = append(f.removedVIPs, batch...)
+	f.removedVIPBatches = append(f.removedVIPBatches, batch)
+	return nil
+}
+
+func (f *fakeServiceLBBackend) SetVIPAppProtocol(ctx context.Context, lb, vip, appProtocol string) error {
+	if f.appProtocols == nil {
+		f.appProtocols = make(map[string]string)
+	}
+	key := fmt.Sprintf("%s %s", lb, vip)
+	if appProtocol == "" {
+		delete(f.appProtocols, key)
+	} else {
+		f.appProtocols[key] = appProtocol
+	}
+	return nil
+}
+
+func (f *fakeServiceLBBackend) SetVIPProxyProtocol(ctx context.Context, lb, vip string, enabled bool) error {
+	if f.proxyProtocols == nil {
+		f.proxyProtocols = sets.NewString()
+	}
+	key := fmt.Sprintf("%s %s", lb, vip)
+	if enabled {
+		f.proxyProtocols.Insert(key)
+	} else {
+		f.proxyProtocols.Delete(key)
+	}
+	return nil
+}
+
+func (f *fakeServiceLBBackend) EnsureRejectACL(ctx context.Context, lb, sourceIP string, sourcePort int32, proto v1.Protocol, aclLogging, meter, action string) (string, error) {
+	key := fmt.Sprintf("%s %s", lb, util.JoinHostPortInt32(sourceIP, sourcePort))
+	f.rejectACLs = append(f.rejectACLs, key)
+	if f.liveRejectACLs == nil {
+		f.liveRejectACLs = sets.NewString()
+	}
+	f.liveRejectACLs.Insert(key)
+	return fakeUUID, nil
+}
+
+func (f *fakeServiceLBBackend) EnsureRejectACLs(ctx context.Context, lb string, vips []rejectACLVIP, proto v1.Protocol, aclLogging, meter, action string) ([]string, error) {
+	aclUUIDs, err := f.EnsureRejectACLsOfLoadBalancers(ctx, []lbRejectACLVIPs{{lb: lb, protocol: proto, vips: vips}},
+		aclLogging, meter, action)
+	if err != nil {
+		return nil, err
+	}
+	return aclUUIDs[0], nil
+}
+
+func (f *fakeServiceLBBackend) EnsureRejectACLsOfLoadBalancers(ctx context.Context, lbs []lbRejectACLVIPs, aclLogging, meter, action string) ([][]string, error) {
+	var batch []string
+	aclUUIDs := make([][]string, len(lbs))
+	for l, lbVIPs := range lbs {
+		for _, vip := range lbVIPs.vips {
+			batch = append(batch, fmt.Sprintf("%s %s", lbVIPs.lb, vip))
+			aclUUIDs[l] = append(aclUUIDs[l], fakeUUID)
+		}
+	}
+	f.rejectACLs = append(f.rejectACLs, batch...)
+	if f.liveRejectACLs == nil {
+		f.liveRejectACLs = sets.NewString()
+	}
+	f.liveRejectACLs.Insert(batch...)
+	f.rejectACLBatches = append(f.rejectACLBatches, batch)
+	return aclUUIDs, nil
+}
+
+func (f *fakeServiceLBBackend) RemoveRejectACL(ctx context.Context, lb, aclUUID string) {
+	f.removedRejectACLs = append(f.removedRejectACLs, fmt.Sprintf("%s %s", lb, aclUUID))
+}
+
+func (f *fakeServiceLBBackend) ClearVIP(ctx context.Context, lb, sourceIP string, sourcePort int32, proto v1.Protocol, aclLogging, meter, action string) (string, error) {
+	if f.vips == nil {
+		f.vips = make(map[string][]string)
+	}
+	key := fmt.Sprintf("%s %s", lb, util.JoinHostPortInt32(sourceIP, sourcePort))
+	f.vips[key] = nil
+	if action == "" {
+		f.liveRejectACLs.Delete(key)
+		return "", nil
+	}
+	return f.EnsureRejectACL(ctx, lb, sourceIP, sourcePort, proto, aclLogging, meter, action)
+}
+
 var _ = ginkgo.Describe("OVN Namespace Operations", func() {
 	var (
 		app     *cli.App
@@ -170,7 +422,9 @@ var _ = ginkgo.Describe("OVN Namespace Operations", func() {
 		app.Name = "test"
 		app.Flags = config.Flags
 
-		fExec = ovntest.NewFakeExec()
+		// stale load balancer VIPs are removed in parallel on startup, so
+		// the order of the executed commands is not deterministic
+		fExec = ovntest.NewLooseCompareFakeExec()
 		fakeOvn = NewFakeOVN(fExec)
 	})
 
@@ -180,6 +434,11 @@ var _ = ginkgo.Describe("OVN Namespace Operations", func() {
 
 	ginkgo.Context("on startup", func() {
 
+		ginkgo.BeforeEach(func() {
+			// the ClusterIPs of the stale VIPs come from the service CIDR
+			config.Kubernetes.RawServiceCIDRs = "172.30.0.0/16"
+		})
+
 		ginkgo.It("reconciles an existing service", func() {
 			app.Action = func(ctx *cli.Context) error {
 
@@ -205,16 +464,433 @@ var _ = ginkgo.Describe("OVN Namespace Operations", func() {
 						},
 					},
 				)
+				// the load balancers of the commands include the SCTP ones
+				fakeOvn.controller.SCTPSupport = true
 				fakeOvn.controller.clusterPortGroupUUID = ovnClusterPortGroupUUID
 				fakeOvn.controller.WatchServices()
 
 				_, err := fakeOvn.fakeClient.KubeClient.CoreV1().Services(service.Namespace).Get(context.TODO(), service.Name, metav1.GetOptions{})
 				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Eventually(fExec.CalledMatchesExpected).Should(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("removes stale VIPs from the load balancers of every gateway", func() {
+			app.Action = func(ctx *cli.Context) error {
+				const staleVIP = "172.30.0.10:53"
+				staleVIPs := fmt.Sprintf("{\"%s\"=\"10.128.0.18:5353,10.129.0.3:5353\"}", staleVIP)
+
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --columns=name,_uuid,priority,severity,log,meter --format=json find acl action=reject",
+				})
+				for _, protocol := range []string{"tcp", "udp", "sctp"} {
+					lb := protocol + "_cluster_load_balancer"
+					fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+						Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-" + protocol + "=yes",
+						Output: lb,
+					})
+					fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+						Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer " + lb + " vips",
+						Output: staleVIPs,
+					})
+					fExec.AddFakeCmdsNoOutputNoError([]string{
+						fmt.Sprintf("ovn-nbctl --timeout=15 --if-exists remove load_balancer %s vips \"%s\"", lb, staleVIP),
+						fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=%s-172.30.0.10\\:53", lb),
+					})
+				}
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+					Output: "gateway1\ngateway2\ngateway3",
+				})
+				for _, gateway := range []string{"gateway1", "gateway2", "gateway3"} {
+					for _, protocol := range []string{"TCP", "UDP", "SCTP"} {
+						lb := fmt.Sprintf("%s_load_balancer_%s", protocol, gateway)
+						fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+							Cmd:    fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:%s_lb_gateway_router=%s", protocol, gateway),
+							Output: lb,
+						})
+						fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+							Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer " + lb + " vips",
+							Output: staleVIPs,
+						})
+						fExec.AddFakeCmdsNoOutputNoError([]string{
+							fmt.Sprintf("ovn-nbctl --timeout=15 --if-exists remove load_balancer %s vips \"%s\"", lb, staleVIP),
+							fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=%s-172.30.0.10\\:53", lb),
+						})
+					}
+				}
+
+				// the stale VIPs are gone by the time the load balancers of the gateways are
+				// listed to reconcile the service VIPs
+				service{}.listLoadBalancersCmds(fExec)
+				for _, gateway := range []string{"gateway1", "gateway2", "gateway3"} {
+					for _, protocol := range []string{"TCP", "UDP", "SCTP"} {
+						lb := fmt.Sprintf("%s_load_balancer_%s", protocol, gateway)
+						fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+							Cmd:    fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:%s_lb_gateway_router=%s", protocol, gateway),
+							Output: lb,
+						})
+						fExec.AddFakeCmdsNoOutputNoError([]string{
+							"ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer " + lb + " vips",
+						})
+					}
+					fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+						Cmd:    "ovn-nbctl --timeout=15 get logical_router " + gateway + " external_ids:physical_ips",
+						Output: "192.168.0.1",
+					})
+				}
+
+				fakeOvn.start(ctx, &v1.ServiceList{})
+				// the load balancers of the commands include the SCTP ones
+				fakeOvn.controller.SCTPSupport = true
+				fakeOvn.controller.clusterPortGroupUUID = ovnClusterPortGroupUUID
+				fakeOvn.controller.WatchServices()
+
+				gomega.Eventually(fExec.CalledMatchesExpected).Should(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("leaves alone the VIPs that no service could have added", func() {
+			app.Action = func(ctx *cli.Context) error {
+				const staleVIP = "172.30.0.10:53"
+				// added by another component, outside of the service CIDR and on no service port
+				const foreignVIP = "192.0.2.10:9999"
+				vips := fmt.Sprintf("{\"%s\"=\"10.128.0.18:5353\", \"%s\"=\"10.128.0.20:9999\"}", staleVIP, foreignVIP)
+
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --columns=name,_uuid,priority,severity,log,meter --format=json find acl action=reject",
+				})
+				for _, protocol := range []string{"tcp", "udp", "sctp"} {
+					lb := protocol + "_cluster_load_balancer"
+					fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+						Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-" + protocol + "=yes",
+						Output: lb,
+					})
+					fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+						Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer " + lb + " vips",
+						Output: vips,
+					})
+					fExec.AddFakeCmdsNoOutputNoError([]string{
+						fmt.Sprintf("ovn-nbctl --timeout=15 --if-exists remove load_balancer %s vips \"%s\"", lb, staleVIP),
+						fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=%s-172.30.0.10\\:53", lb),
+					})
+				}
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+				})
+				service{}.listLoadBalancersCmds(fExec)
+
+				fakeOvn.start(ctx, &v1.ServiceList{})
+				// the load balancers of the commands include the SCTP ones
+				fakeOvn.controller.SCTPSupport = true
+				fakeOvn.controller.clusterPortGroupUUID = ovnClusterPortGroupUUID
+				fakeOvn.controller.WatchServices()
+
+				// removing the foreign VIP would be an unexpected command
+				gomega.Eventually(fExec.CalledMatchesExpected).Should(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("looks up the gateway routers only once per sync", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{
+						{Name: "http", Port: 80, NodePort: 30080, Protocol: v1.ProtocolTCP},
+						{Name: "https", Port: 443, NodePort: 30443, Protocol: v1.ProtocolTCP},
+					},
+					v1.ServiceTypeNodePort,
+					[]string{"1.1.1.1"},
+				)
+				fakeOps := &fakeServiceLBBackend{
+					gateways:    []string{"GR_node1"},
+					physicalIPs: map[string][]string{"GR_node1": {"192.168.0.1"}},
+				}
+
+				// there is no cluster load balancer, so only the gateway load balancers are synced
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --columns=name,_uuid,priority,severity,log,meter --format=json find acl action=reject",
+				})
+				for i := 0; i < 4; i++ {
+					fExec.AddFakeCmdsNoOutputNoError([]string{
+						"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
+					})
+				}
+				for i := 0; i < 2; i++ {
+					fExec.AddFakeCmdsNoOutputNoError([]string{
+						"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-udp=yes",
+					})
+				}
+				// SCTP is not supported, so only the listing of the load balancers looks it up
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-sctp=yes",
+				})
+				for _, protocol := range []v1.Protocol{v1.ProtocolTCP, v1.ProtocolUDP} {
+					fExec.AddFakeCmdsNoOutputNoError([]string{
+						fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer GR_node1-%s vips", protocol),
+					})
+				}
+				for _, protocol := range []v1.Protocol{v1.ProtocolTCP, v1.ProtocolUDP, v1.ProtocolSCTP} {
+					fExec.AddFakeCmdsNoOutputNoError([]string{
+						fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:%s_lb_gateway_router=GR_node1", protocol),
+					})
+				}
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=external_ids find load_balancer",
+				})
+
+				fakeOvn.start(ctx)
+				fakeOvn.controller.lbOps = fakeOps
+				fakeOvn.controller.syncServices([]interface{}{service})
+
+				gomega.Expect(fakeOps.gatewayLookups).To(gomega.Equal(1))
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("lists the endpoints of all the services once per sync", func() {
+			app.Action = func(ctx *cli.Context) error {
+				var services []interface{}
+				var items []v1.Service
+				var endpoints []v1.Endpoints
+				for i := 0; i < 10; i++ {
+					service := newService(fmt.Sprintf("service%d", i), fmt.Sprintf("namespace%d", i%3),
+						fmt.Sprintf("172.30.0.%d", i+10), nil, v1.ServiceTypeClusterIP, nil)
+					services = append(services, service)
+					items = append(items, *service)
+					endpoints = append(endpoints, *newEndpoints(service.Name, service.Namespace,
+						[]v1.EndpointAddress{{IP: fmt.Sprintf("10.128.0.%d", i+10)}},
+						[]v1.EndpointPort{{Port: 8080, Protocol: v1.ProtocolTCP}}))
+				}
+
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --columns=name,_uuid,priority,severity,log,meter --format=json find acl action=reject",
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
+				})
+				for i := 0; i < 2; i++ {
+					fExec.AddFakeCmdsNoOutputNoError([]string{
+						"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-udp=yes",
+					})
+				}
+				// SCTP is not supported, so only the listing of the load balancers looks it up
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-sctp=yes",
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=external_ids find load_balancer",
+				})
+
+				fakeOvn.start(ctx, &v1.ServiceList{Items: items}, &v1.EndpointsList{Items: endpoints})
+				fakeOvn.controller.lbOps = &fakeServiceLBBackend{}
+				lister := &countingEndpointsLister{endpointsLister: fakeOvn.controller.endpoints}
+				fakeOvn.controller.endpoints = lister
+				fakeOvn.controller.syncServices(services)
+
+				gomega.Expect(lister.lists).To(gomega.Equal(1))
+				gomega.Expect(lister.gets).To(gomega.Equal(0))
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("removes a reject ACL named the way of the releases escaping its colons", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{
+						{
+							Port:     80,
+							Protocol: v1.ProtocolTCP,
+						},
+					},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+
+				// the service has no endpoints, so only the name of its ACL makes it stale
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd: "ovn-nbctl --timeout=15 --columns=name,_uuid,priority,severity,log,meter --format=json find acl action=reject",
+					Output: fmt.Sprintf(`{"data":[["%s-172.30.0.10\\:80",["uuid","%s"],1000,"info",false,["set",[]]]],"headings":["name","_uuid","priority","severity","log","meter"]}`,
+						k8sTCPLoadBalancerIP, fakeUUID),
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --columns=name,_uuid,priority,severity,log,meter --format=json find acl action=drop",
+					Output: `{"data":[],"headings":["name","_uuid","priority","severity","log","meter"]}`,
+				})
+				for i := 0; i < 2; i++ {
+					fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+						Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
+						Output: k8sTCPLoadBalancerIP,
+					})
+					fExec.AddFakeCmdsNoOutputNoError([]string{
+						"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-udp=yes",
+					})
+				}
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 -- --if-exists remove port_group " + ovnClusterPortGroupUUID + " acls " + fakeUUID,
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_switch load_balancer{>=}" + k8sTCPLoadBalancerIP,
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}" + k8sTCPLoadBalancerIP,
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-sctp=yes",
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+					"ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=external_ids find load_balancer",
+				})
+				for i := 0; i < 2; i++ {
+					fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+						Cmd:    fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer %s vips", k8sTCPLoadBalancerIP),
+						Output: `{"172.30.0.10:80"=""}`,
+					})
+				}
+
+				fakeOvn.start(ctx, &v1.ServiceList{Items: []v1.Service{*service}})
+				fakeOvn.controller.clusterPortGroupUUID = ovnClusterPortGroupUUID
+				fakeOvn.controller.syncServices([]interface{}{service})
+
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		table.DescribeTable("only syncs the load balancers of the protocols OVN supports", func(sctpSupport bool, protocols []string) {
+			app.Action = func(ctx *cli.Context) error {
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --columns=name,_uuid,priority,severity,log,meter --format=json find acl action=reject",
+				})
+				for _, protocol := range protocols {
+					lb := strings.ToLower(protocol) + "_cluster_load_balancer"
+					fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+						Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-" + strings.ToLower(protocol) + "=yes",
+						Output: lb,
+					})
+					fExec.AddFakeCmdsNoOutputNoError([]string{
+						"ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer " + lb + " vips",
+					})
+				}
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+					Output: "GR_node1",
+				})
+				for _, protocol := range protocols {
+					lb := "GR_node1_" + strings.ToLower(protocol) + "_load_balancer"
+					fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+						Cmd:    fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:%s_lb_gateway_router=GR_node1", protocol),
+						Output: lb,
+					})
+					fExec.AddFakeCmdsNoOutputNoError([]string{
+						"ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer " + lb + " vips",
+					})
+				}
+				// the VIPs of the services are reconciled on every load balancer found in OVN
+				service{}.listLoadBalancersCmds(fExec, "GR_node1")
+
+				fakeOvn.start(ctx)
+				fakeOvn.controller.SCTPSupport = sctpSupport
+				fakeOvn.controller.syncServices(nil)
+
 				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
 
 				return nil
 			}
 
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		},
+			table.Entry("with SCTP support", true, []string{"TCP", "UDP", "SCTP"}),
+			table.Entry("without SCTP support", false, []string{"TCP", "UDP"}),
+		)
+
+		ginkgo.It("counts the errors of the gateway phase of the sync", func() {
+			app.Action = func(ctx *cli.Context) error {
+				syncErrors := func(phase string) float64 {
+					metric := &dto.Metric{}
+					err := metrics.MetricServiceSyncErrors.WithLabelValues(phase).Write(metric)
+					gomega.Expect(err).NotTo(gomega.HaveOccurred())
+					return metric.GetCounter().GetValue()
+				}
+				clusterErrors := syncErrors(metrics.ServiceSyncPhaseClusterVIP)
+				gatewayErrors := syncErrors(metrics.ServiceSyncPhaseGatewayVIP)
+				rejectACLErrors := syncErrors(metrics.ServiceSyncPhaseRejectACL)
+
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --columns=name,_uuid,priority,severity,log,meter --format=json find acl action=reject",
+					Output: `{"data":[["acl1",["uuid","` + fakeUUID + `"]],["acl2",["uuid","` + fakeUUIDv6 + `"]]],"headings":["name","_uuid"]}`,
+				})
+				// drop ACLs of network policies are not counted
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --columns=name,_uuid,priority,severity,log,meter --format=json find acl action=drop",
+					Output: `{"data":[["namespace1_deny",["uuid","` + fakeUUID + `"]]],"headings":["name","_uuid"]}`,
+				})
+				for _, protocol := range []string{"tcp", "udp", "sctp"} {
+					lb := protocol + "_cluster_load_balancer"
+					fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+						Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-" + protocol + "=yes",
+						Output: lb,
+					})
+					fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+						Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer " + lb + " vips",
+						Output: "{}",
+					})
+				}
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd: "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+					Err: fmt.Errorf("connection failed"),
+				})
+
+				// listing the load balancers to reconcile the service VIPs skips the gateway routers
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-udp=yes",
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-sctp=yes",
+					"ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=external_ids find load_balancer",
+				})
+
+				fakeOvn.start(ctx, &v1.ServiceList{})
+				// the load balancers of the commands include the SCTP ones
+				fakeOvn.controller.SCTPSupport = true
+				fakeOvn.controller.clusterPortGroupUUID = ovnClusterPortGroupUUID
+				fakeOvn.controller.WatchServices()
+
+				gomega.Eventually(fExec.CalledMatchesExpected).Should(gomega.BeTrue(), fExec.ErrorDesc)
+				gomega.Expect(syncErrors(metrics.ServiceSyncPhaseGatewayVIP)).To(gomega.Equal(gatewayErrors + 1))
+				gomega.Expect(syncErrors(metrics.ServiceSyncPhaseClusterVIP)).To(gomega.Equal(clusterErrors))
+				gomega.Expect(syncErrors(metrics.ServiceSyncPhaseRejectACL)).To(gomega.Equal(rejectACLErrors))
+
+				rejectACLs := &dto.Metric{}
+				err := metrics.MetricRejectACLCount.Write(rejectACLs)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(rejectACLs.GetGauge().GetValue()).To(gomega.Equal(float64(2)))
+
+				return nil
+			}
+
 			err := app.Run([]string{app.Name})
 			gomega.Expect(err).NotTo(gomega.HaveOccurred())
 		})
@@ -244,12 +920,14 @@ var _ = ginkgo.Describe("OVN Namespace Operations", func() {
 						},
 					},
 				)
+				// the load balancers of the commands include the SCTP ones
+				fakeOvn.controller.SCTPSupport = true
 				fakeOvn.controller.clusterPortGroupUUID = ovnClusterPortGroupUUID
 				fakeOvn.controller.WatchServices()
 
 				_, err := fakeOvn.fakeClient.KubeClient.CoreV1().Services(service.Namespace).Get(context.TODO(), service.Name, metav1.GetOptions{})
 				gomega.Expect(err).NotTo(gomega.HaveOccurred())
-				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+				gomega.Eventually(fExec.CalledMatchesExpected).Should(gomega.BeTrue(), fExec.ErrorDesc)
 
 				test.delCmds(fExec, service)
 				err = fakeOvn.fakeClient.KubeClient.CoreV1().Services(service.Namespace).Delete(context.TODO(), service.Name, *metav1.NewDeleteOptions(0))
@@ -263,6 +941,3886 @@ var _ = ginkgo.Describe("OVN Namespace Operations", func() {
 				return nil
 			}
 
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+		ginkgo.It("updates existing reject ACLs only when their settings differ from the configured ones", func() {
+			app.Action = func(ctx *cli.Context) error {
+				config.OVNKubernetesFeature.RejectACLPriority = 1500
+
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 set acl " + fakeUUID + " priority=1500",
+					"ovn-nbctl --timeout=15 set acl " + fakeUUIDv6 + " priority=1500 severity=info",
+				})
+
+				fakeOvn.start(ctx)
+				// the severity of a logged ACL comes from its namespace and is kept
+				updateRejectACLSettings(context.Background(), "acl1", fakeUUID, []interface{}{float64(1000), "alert", true, "acl-logging"})
+				updateRejectACLSettings(context.Background(), "acl2", fakeUUIDv6, []interface{}{float64(1000), []interface{}{"set", []interface{}{}}, false,
+					[]interface{}{"set", []interface{}{}}})
+				updateRejectACLSettings(context.Background(), "acl3", "acl3-uuid", []interface{}{float64(1500), "info", false, []interface{}{"set", []interface{}{}}})
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+		ginkgo.It("updates the meter of existing reject ACLs to the configured one, unless it is the one of their namespace", func() {
+			app.Action = func(ctx *cli.Context) error {
+				defer func() {
+					config.OVNKubernetesFeature.RejectACLMeter = types.OvnACLLoggingMeter
+				}()
+
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 set acl " + fakeUUID + " meter=reject-meter",
+					"ovn-nbctl --timeout=15 set acl " + fakeUUIDv6 + " meter=[]",
+				})
+
+				fakeOvn.start(ctx)
+				config.OVNKubernetesFeature.RejectACLMeter = "reject-meter"
+				updateRejectACLSettings(context.Background(), "acl1", fakeUUID, []interface{}{float64(1000), "alert", true, "acl-logging"})
+				updateRejectACLSettings(context.Background(), "acl2", fakeUUIDv6, []interface{}{float64(1000), "info", false, "acl-logging"})
+				updateRejectACLSettings(context.Background(), "acl3", "acl3-uuid", []interface{}{float64(1000), "alert", true, "acl-logging-namespace1"})
+				updateRejectACLSettings(context.Background(), "acl4", "acl4-uuid", []interface{}{float64(1000), "alert", true, "reject-meter"})
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+		ginkgo.It("moves a reject ACL still attached to the node switches to the cluster port group", func() {
+			app.Action = func(ctx *cli.Context) error {
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_switch acls{>=}" + fakeUUID,
+					Output: "node1\nnode2",
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}" + k8sTCPLoadBalancerIP,
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_switch load_balancer{>=}" + k8sTCPLoadBalancerIP,
+					Output: "node1-uuid\nnode2-uuid",
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 -- add port_group " + ovnClusterPortGroupUUID + " acls " + fakeUUID +
+						" -- --if-exists remove logical_switch node1 acls " + fakeUUID +
+						" -- --if-exists remove logical_switch node2 acls " + fakeUUID,
+					// the ACL is on no switch anymore, so the next sync leaves it alone
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_switch acls{>=}" + fakeUUID,
+				})
+
+				fakeOvn.start(ctx)
+				fakeOvn.controller.clusterPortGroupUUID = ovnClusterPortGroupUUID
+				fakeOvn.controller.migrateRejectACLToPortGroup(context.Background(), serviceLogger{}, k8sTCPLoadBalancerIP, fakeUUID)
+				fakeOvn.controller.migrateRejectACLToPortGroup(context.Background(), serviceLogger{}, k8sTCPLoadBalancerIP, fakeUUID)
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("reconciles the VIPs of a service, removing an ExternalIP VIP from the cluster load balancer only", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "10.129.0.2",
+					[]v1.ServicePort{
+						{
+							Port:     80,
+							Protocol: v1.ProtocolTCP,
+						},
+					},
+					v1.ServiceTypeClusterIP,
+					[]string{"1.1.1.1"},
+				)
+				endpoints := newEndpoints("service1", "namespace1",
+					[]v1.EndpointAddress{{IP: "10.128.0.18"}},
+					[]v1.EndpointPort{{Port: 8080, Protocol: v1.ProtocolTCP}},
+				)
+
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
+					Output: k8sTCPLoadBalancerIP,
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-udp=yes",
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-sctp=yes",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+					Output: "GR_node1",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:TCP_lb_gateway_router=GR_node1",
+					Output: "gateway_tcp_load_balancer",
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:UDP_lb_gateway_router=GR_node1",
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:SCTP_lb_gateway_router=GR_node1",
+					"ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=external_ids find load_balancer",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer %s vips", k8sTCPLoadBalancerIP),
+					Output: "{\"10.129.0.2:80\"=\"10.128.0.18:8080\", \"1.1.1.1:80\"=\"10.128.0.18:8080\"}",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer gateway_tcp_load_balancer vips",
+					Output: "{\"1.1.1.1:80\"=\"10.128.0.18:8080\"}",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 get logical_router GR_node1 external_ids:physical_ips",
+					Output: "192.168.0.1",
+				})
+				// only the copy of the ExternalIP VIP on the cluster load balancer goes away
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					fmt.Sprintf("ovn-nbctl --timeout=15 --if-exists remove load_balancer %s vips \"1.1.1.1:80\"", k8sTCPLoadBalancerIP),
+					fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=%s-1.1.1.1\\:80", k8sTCPLoadBalancerIP),
+				})
+
+				fakeOvn.start(ctx)
+				err := fakeOvn.controller.reconcileServiceVIPs(context.Background(), newGatewayCache(fakeOvn.controller.lbOps), []*v1.Service{service},
+					map[string]*v1.Endpoints{"namespace1/service1": endpoints})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("does not set the VIP of an ExternalIP that is a node IP again on resync", func() {
+			app.Action = func(ctx *cli.Context) error {
+				// 192.168.0.1 is the physical IP of GR_node1
+				service := newService("service1", "namespace1", "10.129.0.2",
+					[]v1.ServicePort{
+						{
+							Port:     80,
+							Protocol: v1.ProtocolTCP,
+						},
+					},
+					v1.ServiceTypeClusterIP,
+					[]string{"1.1.1.1", "192.168.0.1"},
+				)
+				endpoints := newEndpoints("service1", "namespace1",
+					[]v1.EndpointAddress{{IP: "10.128.0.18"}},
+					[]v1.EndpointPort{{Port: 8080, Protocol: v1.ProtocolTCP}},
+				)
+
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
+					Output: k8sTCPLoadBalancerIP,
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-udp=yes",
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-sctp=yes",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+					Output: "GR_node1",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:TCP_lb_gateway_router=GR_node1",
+					Output: "gateway_tcp_load_balancer",
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:UDP_lb_gateway_router=GR_node1",
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:SCTP_lb_gateway_router=GR_node1",
+					"ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=external_ids find load_balancer",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer %s vips", k8sTCPLoadBalancerIP),
+					Output: "{\"10.129.0.2:80\"=\"10.128.0.18:8080\"}",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer gateway_tcp_load_balancer vips",
+					Output: "{\"1.1.1.1:80\"=\"10.128.0.18:8080\"}",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 get logical_router GR_node1 external_ids:physical_ips",
+					Output: "192.168.0.1",
+				})
+				// the VIPs are already as the service was programmed, without the node IP
+
+				fakeOvn.start(ctx)
+				err := fakeOvn.controller.reconcileServiceVIPs(context.Background(), newGatewayCache(fakeOvn.controller.lbOps), []*v1.Service{service},
+					map[string]*v1.Endpoints{"namespace1/service1": endpoints})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+	})
+
+	ginkgo.Context("on resync", func() {
+
+		ginkgo.It("sets a VIP of a service missing from its load balancer again", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "10.129.0.2",
+					[]v1.ServicePort{
+						{
+							Port:     80,
+							Protocol: v1.ProtocolTCP,
+						},
+					},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+				endpoints := newEndpoints("service1", "namespace1",
+					[]v1.EndpointAddress{{IP: "10.128.0.18"}},
+					[]v1.EndpointPort{{Port: 8080, Protocol: v1.ProtocolTCP}},
+				)
+
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --columns=name,_uuid,priority,severity,log,meter --format=json find acl action=reject",
+				})
+				for i := 0; i < 2; i++ {
+					fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+						Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
+						Output: k8sTCPLoadBalancerIP,
+					})
+					fExec.AddFakeCmdsNoOutputNoError([]string{
+						"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-udp=yes",
+					})
+				}
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-sctp=yes",
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+					"ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=external_ids find load_balancer",
+				})
+				// the ClusterIP VIP was removed from the cluster load balancer behind the controller
+				for i := 0; i < 2; i++ {
+					fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+						Cmd:    fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer %s vips", k8sTCPLoadBalancerIP),
+						Output: "{}",
+					})
+				}
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=%s-10.129.0.2\\:80", k8sTCPLoadBalancerIP),
+					fmt.Sprintf("ovn-nbctl --timeout=15 set load_balancer %s vips:\"10.129.0.2:80\"=\"10.128.0.18:8080\"", k8sTCPLoadBalancerIP),
+				})
+
+				fakeOvn.start(ctx, &v1.ServiceList{Items: []v1.Service{*service}},
+					&v1.EndpointsList{Items: []v1.Endpoints{*endpoints}})
+				fakeOvn.controller.resyncServices()
+
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+	})
+
+	ginkgo.Context("on service VIP info", func() {
+
+		ginkgo.It("attributes the role and port of every VIP of a LoadBalancer service with an external IP", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, NodePort: 30080, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeLoadBalancer,
+					[]string{"1.1.1.1"},
+				)
+				service.Status.LoadBalancer.Ingress = []v1.LoadBalancerIngress{{IP: "5.5.5.5"}}
+
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+					Output: "GR_node1",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 get logical_router GR_node1 external_ids:physical_ips",
+					Output: "192.168.0.1",
+				})
+
+				fakeOvn.start(ctx)
+
+				gomega.Expect(GetServiceVIPInfo(service)).To(gomega.Equal([]VIPInfo{
+					{IP: net.ParseIP("192.168.0.1"), Role: VIPRoleNodePort, Protocol: v1.ProtocolTCP, Port: 30080},
+					{IP: net.ParseIP("172.30.0.10"), Role: VIPRoleClusterIP, Protocol: v1.ProtocolTCP, Port: 80},
+					{IP: net.ParseIP("5.5.5.5"), Role: VIPRoleIngress, Protocol: v1.ProtocolTCP, Port: 80},
+					{IP: net.ParseIP("1.1.1.1"), Role: VIPRoleExternalIP, Protocol: v1.ProtocolTCP, Port: 80},
+				}))
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+	})
+
+	ginkgo.Context("on services without VIPs", func() {
+
+		ginkgo.It("does not program OVN for a headless service or a NodePort service without ports", func() {
+			app.Action = func(ctx *cli.Context) error {
+				headless := newService("service1", "namespace1", v1.ClusterIPNone,
+					[]v1.ServicePort{
+						{
+							Port:     8032,
+							Protocol: v1.ProtocolTCP,
+						},
+					},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+				updated := headless.DeepCopy()
+				updated.Spec.Ports[0].Port = 8033
+				noPorts := newService("service2", "namespace1", "10.129.0.2", nil, v1.ServiceTypeNodePort, nil)
+
+				fakeOvn.start(ctx)
+
+				// any nbctl call would fail the fake exec, which expects none
+				err := fakeOvn.controller.createService(headless)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				err = fakeOvn.controller.updateService(headless, updated)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				fakeOvn.controller.deleteService(updated)
+				gomega.Expect(getSvcVips(updated)).To(gomega.BeEmpty())
+				gomega.Expect(getSvcVips(noPorts)).To(gomega.BeEmpty())
+				fakeOvn.controller.deleteService(noPorts)
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+	})
+
+	ginkgo.Context("on ExternalName services", func() {
+
+		ginkgo.It("does not program OVN when the service is created, updated and deleted", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "", nil, v1.ServiceTypeExternalName, nil)
+				service.Spec.ExternalName = "foo.example.com"
+				updated := service.DeepCopy()
+				updated.Spec.ExternalName = "bar.example.com"
+
+				fakeOvn.start(ctx)
+
+				// any nbctl call would fail the fake exec, which expects none
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				err = fakeOvn.controller.updateService(service, updated)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				fakeOvn.controller.deleteService(updated)
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("removes the VIPs of a ClusterIP service converted to ExternalName", func() {
+			app.Action = func(ctx *cli.Context) error {
+				test := service{}
+
+				service := newService("service1", "namespace1", "10.129.0.2",
+					[]v1.ServicePort{
+						{
+							Port:     8032,
+							Protocol: v1.ProtocolTCP,
+						},
+					},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+				updated := newService("service1", "namespace1", "", nil, v1.ServiceTypeExternalName, nil)
+				updated.Spec.ExternalName = "foo.example.com"
+
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
+					Output: k8sTCPLoadBalancerIP,
+				})
+				test.delCmds(fExec, *service)
+
+				fakeOvn.start(ctx)
+
+				err := fakeOvn.controller.updateService(service, updated)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+	})
+
+	ginkgo.Context("on services opted out of load balancing", func() {
+
+		ginkgo.It("removes the VIPs of a service once it opts out and leaves it alone afterwards", func() {
+			app.Action = func(ctx *cli.Context) error {
+				test := service{}
+
+				service := newService("service1", "namespace1", "10.129.0.2",
+					[]v1.ServicePort{
+						{
+							Port:     8032,
+							Protocol: v1.ProtocolTCP,
+						},
+					},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+				optedOut := service.DeepCopy()
+				optedOut.Annotations = map[string]string{OvnServiceSkipLoadBalancing: "true"}
+				changed := optedOut.DeepCopy()
+				changed.Spec.Ports[0].Port = 8080
+
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
+					Output: k8sTCPLoadBalancerIP,
+				})
+				test.delCmds(fExec, *service)
+
+				fakeOvn.start(ctx)
+
+				err := fakeOvn.controller.updateService(service, optedOut)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				// any further nbctl call would fail the fake exec, which expects no more
+				err = fakeOvn.controller.updateService(optedOut, changed)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				err = fakeOvn.controller.createService(changed)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				fakeOvn.controller.deleteService(changed)
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+	})
+
+	ginkgo.Context("on gateway physical IP changes", func() {
+		var fakeOps *fakeServiceLBBackend
+
+		ginkgo.BeforeEach(func() {
+			fakeOps = &fakeServiceLBBackend{
+				gateways: []string{"GR_node1"},
+				physicalIPs: map[string][]string{
+					"GR_node1": {"192.168.0.20"},
+				},
+			}
+		})
+
+		ginkgo.It("moves the NodePort VIPs to the new physical IP", func() {
+			app.Action = func(ctx *cli.Context) error {
+				endpointsT := *newEndpoints("service1", "namespace1",
+					[]v1.EndpointAddress{
+						{
+							IP: "10.128.0.5",
+						},
+					},
+					[]v1.EndpointPort{
+						{
+							Name:     "http",
+							Port:     8080,
+							Protocol: v1.ProtocolTCP,
+						},
+					})
+				serviceT := *newService("service1", "namespace1", "172.30.0.20",
+					[]v1.ServicePort{
+						{
+							Name:     "http",
+							Port:     80,
+							NodePort: 30080,
+							Protocol: v1.ProtocolTCP,
+						},
+					},
+					v1.ServiceTypeNodePort,
+					nil,
+				)
+
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer GR_node1-TCP vips",
+					Output: "{\"192.168.0.10:30080\"=\"10.128.0.5:8080\", \"172.30.0.20:80\"=\"10.128.0.5:8080\"}",
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer GR_node1-UDP vips",
+				})
+
+				fakeOvn.start(ctx,
+					&v1.EndpointsList{
+						Items: []v1.Endpoints{
+							endpointsT,
+						},
+					},
+					&v1.ServiceList{
+						Items: []v1.Service{
+							serviceT,
+						},
+					},
+				)
+
+				fakeOvn.controller.lbOps = fakeOps
+
+				err := fakeOvn.controller.reconcileGatewayNodePortVIPs("GR_node1")
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.vips).To(gomega.Equal(map[string][]string{
+					"GR_node1-TCP 192.168.0.20:30080": {"10.128.0.5:8080"},
+				}))
+				gomega.Expect(fakeOps.removedVIPs).To(gomega.Equal([]string{"GR_node1-TCP 192.168.0.10:30080"}))
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("adds the physical IP VIPs the services were created with only", func() {
+			app.Action = func(ctx *cli.Context) error {
+				fakeOps.physicalIPs["GR_node1"] = []string{"192.168.0.20", "fd00::20"}
+				endpoints := newEndpoints("service1", "namespace1",
+					[]v1.EndpointAddress{{IP: "10.128.0.5"}},
+					[]v1.EndpointPort{{Name: "http", Port: 8080, Protocol: v1.ProtocolTCP}})
+				owner := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Name: "http", Port: 80, NodePort: 30080, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeNodePort,
+					nil,
+				)
+				duplicate := newService("service2", "namespace1", "172.30.0.20",
+					[]v1.ServicePort{{Name: "http", Port: 80, NodePort: 30080, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeNodePort,
+					nil,
+				)
+				outOfRange := newService("service3", "namespace1", "172.30.0.30",
+					[]v1.ServicePort{{Name: "http", Port: 80, NodePort: 20000, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeNodePort,
+					nil,
+				)
+				withoutEndpoints := newService("service4", "namespace1", "172.30.0.40",
+					[]v1.ServicePort{{Name: "http", Port: 80, NodePort: 30090, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeNodePort,
+					nil,
+				)
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer GR_node1-TCP vips",
+					"ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer GR_node1-UDP vips",
+				})
+
+				fakeOvn.start(ctx,
+					&v1.EndpointsList{Items: []v1.Endpoints{*endpoints}},
+					&v1.ServiceList{Items: []v1.Service{*owner, *duplicate, *outOfRange, *withoutEndpoints}},
+				)
+				fakeOvn.controller.lbOps = fakeOps
+				fakeOvn.controller.claimNodePort(owner, v1.ProtocolTCP, 30080)
+
+				err := fakeOvn.controller.reconcileGatewayNodePortVIPs("GR_node1")
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				// the IPv4 services get no VIP of the IPv6 physical IP, the NodePort of service2 is
+				// allocated to service1 and the one of service3 is outside of the node port range
+				gomega.Expect(fakeOps.vips).To(gomega.Equal(map[string][]string{
+					"GR_node1-TCP 192.168.0.20:30080": {"10.128.0.5:8080"},
+				}))
+				gomega.Expect(fakeOps.rejectACLs).To(gomega.Equal([]string{"GR_node1-TCP 192.168.0.20:30090"}))
+				gomega.Expect(fakeOps.removedVIPs).To(gomega.BeEmpty())
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+	})
+
+	ginkgo.Context("on service creation without endpoints", func() {
+		var fakeOps *fakeServiceLBBackend
+
+		ginkgo.BeforeEach(func() {
+			fakeOps = &fakeServiceLBBackend{
+				gateways: []string{"GR_node1", "GR_node2"},
+				physicalIPs: map[string][]string{
+					"GR_node1": {"192.168.0.1"},
+					"GR_node2": {"192.168.0.2"},
+				},
+			}
+		})
+
+		ginkgo.It("rejects traffic to the ClusterIP", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+
+				fakeOvn.start(ctx)
+				fakeOvn.controller.lbOps = fakeOps
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.rejectACLs).To(gomega.Equal([]string{
+					"cluster-TCP 172.30.0.10:80",
+				}))
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("records an event once the load balancer is configured", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+
+				fakeOvn.start(ctx)
+				fakeOvn.controller.lbOps = fakeOps
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOvn.fakeRecorder.Events).To(gomega.HaveLen(1))
+				gomega.Expect(<-fakeOvn.fakeRecorder.Events).To(gomega.Equal(
+					"Normal LoadBalancerConfigured Configured load balancer VIPs: TCP 172.30.0.10:80"))
+
+				// the same event is not recorded again right away
+				err = fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOvn.fakeRecorder.Events).To(gomega.BeEmpty())
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("skips a NodePort outside of the node port range with a warning event", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, NodePort: 80, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeNodePort,
+					nil,
+				)
+
+				fakeOvn.start(ctx)
+				fakeOvn.controller.lbOps = fakeOps
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				// only the ClusterIP is programmed
+				gomega.Expect(fakeOps.rejectACLs).To(gomega.Equal([]string{
+					"cluster-TCP 172.30.0.10:80",
+				}))
+				gomega.Expect(fakeOvn.fakeRecorder.Events).To(gomega.HaveLen(2))
+				gomega.Expect(<-fakeOvn.fakeRecorder.Events).To(gomega.Equal(
+					"Warning InvalidNodePort NodePort 80 is outside of the node port range 30000-32767 and is not configured"))
+				gomega.Expect(<-fakeOvn.fakeRecorder.Events).To(gomega.Equal(
+					"Normal LoadBalancerConfigured Configured load balancer VIPs: TCP 172.30.0.10:80"))
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("rejects traffic to a service whose endpoints are all not ready", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+				endpoint := newEndpoints("service1", "namespace1", nil,
+					[]v1.EndpointPort{{Port: 8080, Protocol: v1.ProtocolTCP}},
+				)
+				endpoint.Subsets[0].NotReadyAddresses = []v1.EndpointAddress{{IP: "10.128.0.5"}}
+
+				fakeOvn.start(ctx, &v1.EndpointsList{Items: []v1.Endpoints{*endpoint}})
+				fakeOvn.controller.lbOps = fakeOps
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.rejectACLs).To(gomega.Equal([]string{
+					"cluster-TCP 172.30.0.10:80",
+				}))
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("does not reject traffic to a service publishing its not ready endpoints", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+				service.Spec.PublishNotReadyAddresses = true
+				endpoint := newEndpoints("service1", "namespace1", nil,
+					[]v1.EndpointPort{{Port: 8080, Protocol: v1.ProtocolTCP}},
+				)
+				endpoint.Subsets[0].NotReadyAddresses = []v1.EndpointAddress{{IP: "10.128.0.5"}}
+
+				fakeOvn.start(ctx, &v1.EndpointsList{Items: []v1.Endpoints{*endpoint}})
+				fakeOvn.controller.lbOps = fakeOps
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.rejectACLs).To(gomega.BeEmpty())
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("rejects traffic to the NodePort on every gateway", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, NodePort: 30080, Protocol: v1.ProtocolUDP}},
+					v1.ServiceTypeNodePort,
+					nil,
+				)
+
+				fakeOvn.start(ctx)
+				fakeOvn.controller.lbOps = fakeOps
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.rejectACLs).To(gomega.Equal([]string{
+					"GR_node1-UDP 192.168.0.1:30080",
+					"GR_node2-UDP 192.168.0.2:30080",
+					"cluster-UDP 172.30.0.10:80",
+				}))
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("rejects traffic to the external IPs with one batch of reject ACLs per gateway", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeClusterIP,
+					[]string{"1.1.1.1", "1.1.1.2", "1.1.1.3"},
+				)
+
+				fakeOvn.start(ctx)
+				fakeOvn.controller.lbOps = fakeOps
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.gatewayLBLookups).To(gomega.Equal(map[string]int{
+					"GR_node1": 1,
+					"GR_node2": 1,
+				}))
+				gomega.Expect(fakeOps.rejectACLBatches).To(gomega.Equal([][]string{
+					{"GR_node1-TCP 1.1.1.1:80", "GR_node1-TCP 1.1.1.2:80", "GR_node1-TCP 1.1.1.3:80"},
+					{"GR_node2-TCP 1.1.1.1:80", "GR_node2-TCP 1.1.1.2:80", "GR_node2-TCP 1.1.1.3:80"},
+					{"cluster-TCP 172.30.0.10:80"},
+				}))
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("creates reject ACLs with the configured priority and severity", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+				config.OVNKubernetesFeature.RejectACLPriority = 1500
+				config.OVNKubernetesFeature.RejectACLSeverity = "warning"
+
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
+					Output: k8sTCPLoadBalancerIP,
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_switch load_balancer{>=}%s", k8sTCPLoadBalancerIP),
+					Output: "62c672a4-1132-44ab-9202-e47d18784138",
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}%s", k8sTCPLoadBalancerIP),
+					fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=%s-172.30.0.10\\:80", k8sTCPLoadBalancerIP),
+					fmt.Sprintf("ovn-nbctl --timeout=15 --id=@reject-acl create acl direction="+types.DirectionFromLPort+" priority=1500 match=\"ip4.dst==172.30.0.10 && tcp "+
+						"&& tcp.dst==80\" action=reject log=false severity=warning name=%s-172.30.0.10\\:80 -- add port_group %s acls @reject-acl",
+						k8sTCPLoadBalancerIP, ovnClusterPortGroupUUID),
+				})
+
+				fakeOvn.start(ctx)
+				fakeOvn.controller.clusterPortGroupUUID = ovnClusterPortGroupUUID
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("creates reject ACLs matching on the IPv6 destination of an IPv6 service", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "fd00:10:96::10",
+					[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
+					Output: k8sTCPLoadBalancerIP,
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_switch load_balancer{>=}%s", k8sTCPLoadBalancerIP),
+					Output: "62c672a4-1132-44ab-9202-e47d18784138",
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}%s", k8sTCPLoadBalancerIP),
+					fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=%s-fd00\\:10\\:96\\:\\:10\\:80", k8sTCPLoadBalancerIP),
+					fmt.Sprintf("ovn-nbctl --timeout=15 --id=@reject-acl create acl direction="+types.DirectionFromLPort+" priority="+types.DefaultDenyPriority+" match=\"ip6.dst==fd00:10:96::10 && tcp "+
+						"&& tcp.dst==80\" action=reject log=false severity=info name=%s-fd00\\:10\\:96\\:\\:10\\:80 -- add port_group %s acls @reject-acl",
+						k8sTCPLoadBalancerIP, ovnClusterPortGroupUUID),
+				})
+
+				fakeOvn.start(ctx)
+				fakeOvn.controller.clusterPortGroupUUID = ovnClusterPortGroupUUID
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		table.DescribeTable("creates the reject ACL of a service without endpoints on the cluster load balancer of its protocol",
+			func(protocol v1.Protocol, loadBalancer string) {
+				app.Action = func(ctx *cli.Context) error {
+					service := newService("service1", "namespace1", "172.30.0.10",
+						[]v1.ServicePort{{Port: 53, Protocol: protocol}},
+						v1.ServiceTypeClusterIP,
+						nil,
+					)
+					proto := strings.ToLower(string(protocol))
+
+					fExec.AddFakeCmdsNoOutputNoError([]string{
+						"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+					})
+					fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+						Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-" + proto + "=yes",
+						Output: loadBalancer,
+					})
+					fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+						Cmd:    fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_switch load_balancer{>=}%s", loadBalancer),
+						Output: "62c672a4-1132-44ab-9202-e47d18784138",
+					})
+					fExec.AddFakeCmdsNoOutputNoError([]string{
+						fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}%s", loadBalancer),
+						fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=%s-172.30.0.10\\:53", loadBalancer),
+						fmt.Sprintf("ovn-nbctl --timeout=15 --id=@reject-acl create acl direction="+types.DirectionFromLPort+" priority="+types.DefaultDenyPriority+" match=\"ip4.dst==172.30.0.10 && %s "+
+							"&& %s.dst==53\" action=reject log=false severity=info name=%s-172.30.0.10\\:53 -- add port_group %s acls @reject-acl",
+							proto, proto, loadBalancer, ovnClusterPortGroupUUID),
+					})
+
+					fakeOvn.start(ctx)
+					fakeOvn.controller.clusterPortGroupUUID = ovnClusterPortGroupUUID
+					fakeOvn.controller.SCTPSupport = true
+
+					err := fakeOvn.controller.createService(service)
+					gomega.Expect(err).NotTo(gomega.HaveOccurred())
+					gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+					return nil
+				}
+
+				err := app.Run([]string{app.Name})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			},
+			table.Entry("UDP", v1.ProtocolUDP, k8sUDPLoadBalancerIP),
+			table.Entry("SCTP", v1.ProtocolSCTP, k8sSCTPLoadBalancerIP),
+		)
+
+		ginkgo.It("creates the reject ACLs of a TCP and UDP service without endpoints in one transaction", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{
+						{Name: "dns-tcp", Port: 53, Protocol: v1.ProtocolTCP},
+						{Name: "dns", Port: 53, Protocol: v1.ProtocolUDP},
+					},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+				})
+				for _, lb := range []string{k8sTCPLoadBalancerIP, k8sUDPLoadBalancerIP} {
+					proto := "tcp"
+					if lb == k8sUDPLoadBalancerIP {
+						proto = "udp"
+					}
+					fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+						Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-" + proto + "=yes",
+						Output: lb,
+					})
+				}
+				for _, lb := range []string{k8sTCPLoadBalancerIP, k8sUDPLoadBalancerIP} {
+					fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+						Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_switch load_balancer{>=}" + lb,
+						Output: "62c672a4-1132-44ab-9202-e47d18784138",
+					})
+					fExec.AddFakeCmdsNoOutputNoError([]string{
+						"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}" + lb,
+						"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=" + lb + "-172.30.0.10\\:53",
+					})
+				}
+				// a single transaction creates the ACLs of both protocols and adds them to the port group
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd: "ovn-nbctl --timeout=15 --id=@reject-acl-0 create acl direction=" + types.DirectionFromLPort + " priority=" + types.DefaultDenyPriority +
+						" match=\"ip4.dst==172.30.0.10 && tcp && tcp.dst==53\" action=reject log=false severity=info name=" + k8sTCPLoadBalancerIP + "-172.30.0.10\\:53 " +
+						"-- --id=@reject-acl-1 create acl direction=" + types.DirectionFromLPort + " priority=" + types.DefaultDenyPriority +
+						" match=\"ip4.dst==172.30.0.10 && udp && udp.dst==53\" action=reject log=false severity=info name=" + k8sUDPLoadBalancerIP + "-172.30.0.10\\:53 " +
+						"-- add port_group " + ovnClusterPortGroupUUID + " acls @reject-acl-0 @reject-acl-1",
+					Output: fakeUUID + "\n" + fakeUUIDv6,
+				})
+
+				fakeOvn.start(ctx)
+				fakeOvn.controller.clusterPortGroupUUID = ovnClusterPortGroupUUID
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+				aclUUID, _ := fakeOvn.controller.getServiceLBInfo(k8sUDPLoadBalancerIP, "172.30.0.10:53")
+				gomega.Expect(aclUUID).To(gomega.Equal(fakeUUIDv6))
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("creates drop ACLs for a service asking for its traffic to be dropped", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+				service.Annotations = map[string]string{OvnServiceEmptyServiceAction: "drop"}
+
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
+					Output: k8sTCPLoadBalancerIP,
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_switch load_balancer{>=}%s", k8sTCPLoadBalancerIP),
+					Output: "62c672a4-1132-44ab-9202-e47d18784138",
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}%s", k8sTCPLoadBalancerIP),
+					fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=%s-172.30.0.10\\:80", k8sTCPLoadBalancerIP),
+					fmt.Sprintf("ovn-nbctl --timeout=15 --id=@reject-acl create acl direction="+types.DirectionFromLPort+" priority=1000 match=\"ip4.dst==172.30.0.10 && tcp "+
+						"&& tcp.dst==80\" action=drop log=false severity=info name=%s-172.30.0.10\\:80 -- add port_group %s acls @reject-acl",
+						k8sTCPLoadBalancerIP, ovnClusterPortGroupUUID),
+				})
+
+				fakeOvn.start(ctx)
+				fakeOvn.controller.clusterPortGroupUUID = ovnClusterPortGroupUUID
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("creates the reject ACLs of every port of a service in one transaction", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{
+						{Name: "http", Port: 80, Protocol: v1.ProtocolTCP},
+						{Name: "https", Port: 443, Protocol: v1.ProtocolTCP},
+						{Name: "alt", Port: 8080, Protocol: v1.ProtocolTCP},
+					},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
+					Output: k8sTCPLoadBalancerIP,
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_switch load_balancer{>=}%s", k8sTCPLoadBalancerIP),
+					Output: "62c672a4-1132-44ab-9202-e47d18784138",
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}%s", k8sTCPLoadBalancerIP),
+					fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=%s-172.30.0.10\\:80", k8sTCPLoadBalancerIP),
+					fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=%s-172.30.0.10\\:443", k8sTCPLoadBalancerIP),
+					fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=%s-172.30.0.10\\:8080", k8sTCPLoadBalancerIP),
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd: fmt.Sprintf("ovn-nbctl --timeout=15 --id=@reject-acl-0 create acl direction="+types.DirectionFromLPort+" priority=1000 match=\"ip4.dst==172.30.0.10 && tcp "+
+						"&& tcp.dst==80\" action=reject log=false severity=info name=%[1]s-172.30.0.10\\:80 "+
+						"-- --id=@reject-acl-1 create acl direction="+types.DirectionFromLPort+" priority=1000 match=\"ip4.dst==172.30.0.10 && tcp "+
+						"&& tcp.dst==443\" action=reject log=false severity=info name=%[1]s-172.30.0.10\\:443 "+
+						"-- --id=@reject-acl-2 create acl direction="+types.DirectionFromLPort+" priority=1000 match=\"ip4.dst==172.30.0.10 && tcp "+
+						"&& tcp.dst==8080\" action=reject log=false severity=info name=%[1]s-172.30.0.10\\:8080 "+
+						"-- add port_group %[2]s acls @reject-acl-0 @reject-acl-1 @reject-acl-2",
+						k8sTCPLoadBalancerIP, ovnClusterPortGroupUUID),
+					Output: "acl-uuid-80\nacl-uuid-443\nacl-uuid-8080",
+				})
+
+				fakeOvn.start(ctx)
+				fakeOvn.controller.clusterPortGroupUUID = ovnClusterPortGroupUUID
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+				aclUUID, _ := fakeOvn.controller.getServiceLBInfo(k8sTCPLoadBalancerIP, "172.30.0.10:443")
+				gomega.Expect(aclUUID).To(gomega.Equal("acl-uuid-443"))
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("logs the reject ACLs of a namespace with a logging rate on a meter of the namespace", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=bands find meter name=acl-logging-namespace1",
+					"ovn-nbctl --timeout=15 meter-add acl-logging-namespace1 drop 5 pktps",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
+					Output: k8sTCPLoadBalancerIP,
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_switch load_balancer{>=}%s", k8sTCPLoadBalancerIP),
+					Output: "62c672a4-1132-44ab-9202-e47d18784138",
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}%s", k8sTCPLoadBalancerIP),
+					fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=%s-172.30.0.10\\:80", k8sTCPLoadBalancerIP),
+					fmt.Sprintf("ovn-nbctl --timeout=15 --id=@reject-acl create acl direction="+types.DirectionFromLPort+" priority=1000 match=\"ip4.dst==172.30.0.10 && tcp "+
+						"&& tcp.dst==80\" action=reject log=true severity=alert meter=acl-logging-namespace1 name=%s-172.30.0.10\\:80 -- add port_group %s acls @reject-acl",
+						k8sTCPLoadBalancerIP, ovnClusterPortGroupUUID),
+				})
+
+				fakeOvn.start(ctx)
+				fakeOvn.controller.clusterPortGroupUUID = ovnClusterPortGroupUUID
+				nsInfo := fakeOvn.controller.createNamespaceLocked("namespace1")
+				gomega.Expect(fakeOvn.controller.aclLoggingCanEnable(`{"deny": "alert", "rate": 5}`, nsInfo)).To(gomega.BeTrue())
+				nsInfo.Unlock()
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("sets the rate of the meter of a namespace when it changes, and deletes the meter with the namespace", func() {
+			app.Action = func(ctx *cli.Context) error {
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=bands find meter name=acl-logging-namespace1",
+					Output: "band-uuid",
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 set meter_band band-uuid rate=5",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=bands find meter name=acl-logging-namespace1",
+					Output: "band-uuid",
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 set meter_band band-uuid rate=10",
+					"ovn-nbctl --timeout=15 meter-del acl-logging-namespace1",
+				})
+
+				fakeOvn.start(ctx)
+				namespace := newNamespace("namespace1")
+				nsInfo := fakeOvn.controller.createNamespaceLocked(namespace.Name)
+				gomega.Expect(fakeOvn.controller.aclLoggingCanEnable(`{"deny": "alert", "rate": 5}`, nsInfo)).To(gomega.BeTrue())
+				nsInfo.Unlock()
+
+				// the meter of an earlier run had another rate
+				_, meter := fakeOvn.controller.getRejectACLLogging(namespace.Name)
+				gomega.Expect(meter).To(gomega.Equal("acl-logging-namespace1"))
+				// the rate is unchanged, the meter is not looked up again
+				_, meter = fakeOvn.controller.getRejectACLLogging(namespace.Name)
+				gomega.Expect(meter).To(gomega.Equal("acl-logging-namespace1"))
+
+				nsInfo = fakeOvn.controller.getNamespaceLocked(namespace.Name)
+				gomega.Expect(fakeOvn.controller.aclLoggingCanEnable(`{"deny": "alert", "rate": 10}`, nsInfo)).To(gomega.BeTrue())
+				nsInfo.Unlock()
+				_, meter = fakeOvn.controller.getRejectACLLogging(namespace.Name)
+				gomega.Expect(meter).To(gomega.Equal("acl-logging-namespace1"))
+
+				fakeOvn.controller.deleteNamespace(namespace)
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("removes the reject ACL found in OVN once the endpoints of the service arrive", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+				endpoint := newEndpoints("service1", "namespace1",
+					[]v1.EndpointAddress{{IP: "10.128.0.5"}},
+					[]v1.EndpointPort{{Port: 8080, Protocol: v1.ProtocolTCP}},
+				)
+				clusterACL := "a0b2b1c8-7e45-4b6e-9e0d-6b0b6c6f1d2e"
+
+				// the service is created without endpoints, so its ClusterIP gets a reject ACL
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
+					Output: k8sTCPLoadBalancerIP,
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_switch load_balancer{>=}%s", k8sTCPLoadBalancerIP),
+					Output: "62c672a4-1132-44ab-9202-e47d18784138",
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}%s", k8sTCPLoadBalancerIP),
+					fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=%s-172.30.0.10\\:80", k8sTCPLoadBalancerIP),
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd: fmt.Sprintf("ovn-nbctl --timeout=15 --id=@reject-acl create acl direction="+types.DirectionFromLPort+" priority="+types.DefaultDenyPriority+" match=\"ip4.dst==172.30.0.10 && tcp "+
+						"&& tcp.dst==80\" action=reject log=false severity=info name=%s-172.30.0.10\\:80 -- add port_group %s acls @reject-acl",
+						k8sTCPLoadBalancerIP, ovnClusterPortGroupUUID),
+					Output: clusterACL,
+				})
+				// the endpoints arrive while the cache does not know of the reject ACL, which is
+				// found by name and removed as the VIP gets its targets
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=%s-172.30.0.10\\:80", k8sTCPLoadBalancerIP),
+					Output: clusterACL,
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}%s", k8sTCPLoadBalancerIP),
+					fmt.Sprintf("ovn-nbctl --timeout=15 set load_balancer %s vips:\"172.30.0.10:80\"=\"10.128.0.5:8080\" -- --if-exists remove port_group %s acls %s",
+						k8sTCPLoadBalancerIP, ovnClusterPortGroupUUID, clusterACL),
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+				})
+
+				fakeOvn.start(ctx, &v1.ServiceList{Items: []v1.Service{*service}})
+				fakeOvn.controller.clusterPortGroupUUID = ovnClusterPortGroupUUID
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				aclUUID, _ := fakeOvn.controller.getServiceLBInfo(k8sTCPLoadBalancerIP, "172.30.0.10:80")
+				gomega.Expect(aclUUID).To(gomega.Equal(clusterACL))
+
+				// lose the cache entry, like a restart in between would
+				fakeOvn.controller.removeServiceLB(k8sTCPLoadBalancerIP, "172.30.0.10:80")
+				err = fakeOvn.controller.AddEndpoints(endpoint, true)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+				aclUUID, hasEndpoints := fakeOvn.controller.getServiceLBInfo(k8sTCPLoadBalancerIP, "172.30.0.10:80")
+				gomega.Expect(aclUUID).To(gomega.BeEmpty())
+				gomega.Expect(hasEndpoints).To(gomega.BeTrue())
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("keeps the reject ACL when the VIP fails to get its targets", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+				endpoint := newEndpoints("service1", "namespace1",
+					[]v1.EndpointAddress{{IP: "10.128.0.5"}},
+					[]v1.EndpointPort{{Port: 8080, Protocol: v1.ProtocolTCP}},
+				)
+				clusterACL := "a0b2b1c8-7e45-4b6e-9e0d-6b0b6c6f1d2e"
+				setVIPRemoveACL := fmt.Sprintf("ovn-nbctl --timeout=15 set load_balancer %s vips:\"172.30.0.10:80\"=\"10.128.0.5:8080\" "+
+					"-- --if-exists remove port_group %s acls %s", k8sTCPLoadBalancerIP, ovnClusterPortGroupUUID, clusterACL)
+
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
+					Output: k8sTCPLoadBalancerIP,
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_switch load_balancer{>=}%s", k8sTCPLoadBalancerIP),
+					Output: "62c672a4-1132-44ab-9202-e47d18784138",
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}%s", k8sTCPLoadBalancerIP),
+					fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=%s-172.30.0.10\\:80", k8sTCPLoadBalancerIP),
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd: fmt.Sprintf("ovn-nbctl --timeout=15 --id=@reject-acl create acl direction="+types.DirectionFromLPort+" priority="+types.DefaultDenyPriority+" match=\"ip4.dst==172.30.0.10 && tcp "+
+						"&& tcp.dst==80\" action=reject log=false severity=info name=%s-172.30.0.10\\:80 -- add port_group %s acls @reject-acl",
+						k8sTCPLoadBalancerIP, ovnClusterPortGroupUUID),
+					Output: clusterACL,
+				})
+				// the transaction setting the targets and removing the reject ACL fails, as when ovn-nbctl is
+				// killed on shutdown
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}%s", k8sTCPLoadBalancerIP),
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd: setVIPRemoveACL,
+					Err: fmt.Errorf("connection closed"),
+				})
+				// and then retried
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}%s", k8sTCPLoadBalancerIP),
+					setVIPRemoveACL,
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+				})
+
+				fakeOvn.start(ctx, &v1.ServiceList{Items: []v1.Service{*service}})
+				fakeOvn.controller.clusterPortGroupUUID = ovnClusterPortGroupUUID
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+				err = fakeOvn.controller.AddEndpoints(endpoint, true)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				aclUUID, hasEndpoints := fakeOvn.controller.getServiceLBInfo(k8sTCPLoadBalancerIP, "172.30.0.10:80")
+				gomega.Expect(aclUUID).To(gomega.Equal(clusterACL))
+				gomega.Expect(hasEndpoints).To(gomega.BeFalse())
+
+				err = fakeOvn.controller.AddEndpoints(endpoint, true)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+				aclUUID, hasEndpoints = fakeOvn.controller.getServiceLBInfo(k8sTCPLoadBalancerIP, "172.30.0.10:80")
+				gomega.Expect(aclUUID).To(gomega.BeEmpty())
+				gomega.Expect(hasEndpoints).To(gomega.BeTrue())
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("removes the reject ACL of a gateway load balancer VIP getting targets from the external switch", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, NodePort: 30080, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeNodePort,
+					nil,
+				)
+				endpoint := newEndpoints("service1", "namespace1",
+					[]v1.EndpointAddress{{IP: "10.128.0.5"}},
+					[]v1.EndpointPort{{Port: 8080, Protocol: v1.ProtocolTCP}},
+				)
+				gatewayACL := "gateway-reject-acl-uuid"
+				clusterACL := "cluster-reject-acl-uuid"
+
+				// createService makes a reject ACL on the gateway load balancer for the NodePort
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+					Output: "GR_node1",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:TCP_lb_gateway_router=GR_node1",
+					Output: "tcp_load_balancer_id_1",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 get logical_router GR_node1 external_ids:physical_ips",
+					Output: "192.168.0.1",
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_switch load_balancer{>=}tcp_load_balancer_id_1",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}tcp_load_balancer_id_1",
+					Output: "GR_node1",
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=tcp_load_balancer_id_1-192.168.0.1\\:30080",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd: "ovn-nbctl --timeout=15 --id=@reject-acl create acl direction=" + types.DirectionFromLPort + " priority=" + types.DefaultDenyPriority + " match=\"ip4.dst==192.168.0.1 && tcp " +
+						"&& tcp.dst==30080\" action=reject log=false severity=info name=tcp_load_balancer_id_1-192.168.0.1\\:30080 -- add logical_switch ext_node1 acls @reject-acl",
+					Output: gatewayACL,
+				})
+				// and one on the cluster load balancer for the ClusterIP
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
+					Output: k8sTCPLoadBalancerIP,
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_switch load_balancer{>=}%s", k8sTCPLoadBalancerIP),
+					Output: "62c672a4-1132-44ab-9202-e47d18784138",
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}%s", k8sTCPLoadBalancerIP),
+					fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=%s-172.30.0.10\\:80", k8sTCPLoadBalancerIP),
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd: fmt.Sprintf("ovn-nbctl --timeout=15 --id=@reject-acl create acl direction="+types.DirectionFromLPort+" priority="+types.DefaultDenyPriority+" match=\"ip4.dst==172.30.0.10 && tcp "+
+						"&& tcp.dst==80\" action=reject log=false severity=info name=%s-172.30.0.10\\:80 -- add port_group %s acls @reject-acl",
+						k8sTCPLoadBalancerIP, ovnClusterPortGroupUUID),
+					Output: clusterACL,
+				})
+
+				// AddEndpoints gives the NodePort VIP its targets, removing its reject ACL from the
+				// external switch of the gateway router in the same transaction
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+					Output: "GR_node1",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:TCP_lb_gateway_router=GR_node1",
+					Output: "tcp_load_balancer_id_1",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 get logical_router GR_node1 external_ids:physical_ips",
+					Output: "192.168.0.1",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}tcp_load_balancer_id_1",
+					Output: "GR_node1",
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 set load_balancer tcp_load_balancer_id_1 vips:\"192.168.0.1:30080\"=\"10.128.0.5:8080\" " +
+						"-- --if-exists remove logical_switch ext_node1 acls " + gatewayACL + " " +
+						fmt.Sprintf("-- --if-exists remove port_group %s acls %s", ovnClusterPortGroupUUID, gatewayACL),
+				})
+				// and does the same for the ClusterIP VIP on the cluster load balancer
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}%s", k8sTCPLoadBalancerIP),
+					fmt.Sprintf("ovn-nbctl --timeout=15 set load_balancer %s vips:\"172.30.0.10:80\"=\"10.128.0.5:8080\" "+
+						"-- --if-exists remove port_group %s acls %s", k8sTCPLoadBalancerIP, ovnClusterPortGroupUUID, clusterACL),
+				})
+				// the ClusterIP VIP is then removed from the gateway load balancer, where it never was
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+					Output: "GR_node1",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:TCP_lb_gateway_router=GR_node1",
+					Output: "tcp_load_balancer_id_1",
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --if-exists remove load_balancer tcp_load_balancer_id_1 vips \"172.30.0.10:80\"",
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=tcp_load_balancer_id_1-172.30.0.10\\:80",
+				})
+
+				fakeOvn.start(ctx, &v1.ServiceList{Items: []v1.Service{*service}})
+				fakeOvn.controller.clusterPortGroupUUID = ovnClusterPortGroupUUID
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				err = fakeOvn.controller.AddEndpoints(endpoint, true)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+				aclUUID, hasEndpoints := fakeOvn.controller.getServiceLBInfo("tcp_load_balancer_id_1", "192.168.0.1:30080")
+				gomega.Expect(aclUUID).To(gomega.BeEmpty())
+				gomega.Expect(hasEndpoints).To(gomega.BeTrue())
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("does not create reject ACLs when they are disabled", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, NodePort: 30080, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeNodePort,
+					nil,
+				)
+				config.Kubernetes.DisableServiceRejectACLs = true
+
+				// only the load balancers are looked up, the fake exec fails on any create acl command
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+					Output: "GR_node1",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:TCP_lb_gateway_router=GR_node1",
+					Output: "tcp_load_balancer_id_1",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 get logical_router GR_node1 external_ids:physical_ips",
+					Output: "192.168.0.1",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
+					Output: k8sTCPLoadBalancerIP,
+				})
+
+				fakeOvn.start(ctx)
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("looks up the gateway routers, their load balancers and physical IPs only once for a service with several NodePorts", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{
+						{Name: "http", Port: 80, NodePort: 30080, Protocol: v1.ProtocolTCP},
+						{Name: "https", Port: 443, NodePort: 30443, Protocol: v1.ProtocolTCP},
+						{Name: "metrics", Port: 9090, NodePort: 30090, Protocol: v1.ProtocolTCP},
+					},
+					v1.ServiceTypeNodePort,
+					nil,
+				)
+				// an idled service gets no reject ACLs, which keeps the expected commands to the lookups
+				service.Annotations = map[string]string{OvnServiceIdledAt: "2021-01-01T00:00:00Z"}
+				config.Kubernetes.OVNEmptyLbEvents = true
+
+				// the fake exec fails on any command run more often than it is expected
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+					Output: "GR_node1",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:TCP_lb_gateway_router=GR_node1",
+					Output: "tcp_load_balancer_id_1",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 get logical_router GR_node1 external_ids:physical_ips",
+					Output: "192.168.0.1",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
+					Output: k8sTCPLoadBalancerIP,
+				})
+
+				fakeOvn.start(ctx)
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("creates the reject ACLs of several VIPs of a load balancer in one transaction", func() {
+			app.Action = func(ctx *cli.Context) error {
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_switch load_balancer{>=}tcp_load_balancer_id_1",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}tcp_load_balancer_id_1",
+					Output: "GR_node1",
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=tcp_load_balancer_id_1-1.1.1.1\\:80",
+				})
+				// the reject ACL of 1.1.1.2 survived a restart and is reused
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=tcp_load_balancer_id_1-1.1.1.2\\:80",
+					Output: "existing-acl-uuid",
+				})
+				fExec.AddFakeCmd(listRejectACLCmd("existing-acl-uuid", 1000, "ip4.dst==1.1.1.2 && tcp && tcp.dst==80", "reject", false, "info", ""))
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=tcp_load_balancer_id_1-1.1.1.3\\:80",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd: "ovn-nbctl --timeout=15 --id=@reject-acl-0 create acl direction=" + types.DirectionFromLPort + " priority=" + types.DefaultDenyPriority + " match=\"ip4.dst==1.1.1.1 && tcp " +
+						"&& tcp.dst==80\" action=reject log=false severity=info name=tcp_load_balancer_id_1-1.1.1.1\\:80 " +
+						"-- --id=@reject-acl-2 create acl direction=" + types.DirectionFromLPort + " priority=" + types.DefaultDenyPriority + " match=\"ip4.dst==1.1.1.3 && tcp " +
+						"&& tcp.dst==80\" action=reject log=false severity=info name=tcp_load_balancer_id_1-1.1.1.3\\:80 " +
+						"-- add logical_switch ext_node1 acls @reject-acl-0 existing-acl-uuid @reject-acl-2",
+					Output: "new-acl-uuid-1\nnew-acl-uuid-3",
+				})
+
+				fakeOvn.start(ctx)
+
+				aclUUIDs, err := fakeOvn.controller.ensureLoadBalancerRejectACLs(context.Background(), "tcp_load_balancer_id_1",
+					[]rejectACLVIP{{"1.1.1.1", 80}, {"1.1.1.2", 80}, {"1.1.1.3", 80}}, v1.ProtocolTCP, "", "acl-logging", "reject")
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(aclUUIDs).To(gomega.Equal([]string{"new-acl-uuid-1", "existing-acl-uuid", "new-acl-uuid-3"}))
+				aclUUID, _ := fakeOvn.controller.getServiceLBInfo("tcp_load_balancer_id_1", "1.1.1.3:80")
+				gomega.Expect(aclUUID).To(gomega.Equal("new-acl-uuid-3"))
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		table.DescribeTable("ensures the reject ACL of a VIP, updating an existing one in place",
+			func(existing *ovntest.ExpectedCmd, expectedCmd, expectedUUID string) {
+				app.Action = func(ctx *cli.Context) error {
+					fExec.AddFakeCmdsNoOutputNoError([]string{
+						"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_switch load_balancer{>=}tcp_load_balancer_id_1",
+					})
+					fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+						Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}tcp_load_balancer_id_1",
+						Output: "GR_node1",
+					})
+					if existing == nil {
+						fExec.AddFakeCmdsNoOutputNoError([]string{
+							"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=tcp_load_balancer_id_1-1.1.1.1\\:80",
+						})
+					} else {
+						fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+							Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=tcp_load_balancer_id_1-1.1.1.1\\:80",
+							Output: "existing-acl-uuid",
+						})
+						fExec.AddFakeCmd(existing)
+					}
+					fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+						Cmd:    "ovn-nbctl --timeout=15 " + expectedCmd,
+						Output: expectedUUID,
+					})
+
+					fakeOvn.start(ctx)
+
+					aclUUID, err := fakeOvn.controller.ensureLoadBalancerRejectACL(context.Background(), "tcp_load_balancer_id_1", "1.1.1.1", 80,
+						v1.ProtocolTCP, "", "acl-logging", "reject")
+					gomega.Expect(err).NotTo(gomega.HaveOccurred())
+					gomega.Expect(aclUUID).To(gomega.Equal(expectedUUID))
+					gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+					return nil
+				}
+
+				err := app.Run([]string{app.Name})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			},
+			table.Entry("when it does not exist", nil,
+				"--id=@reject-acl create acl direction="+types.DirectionFromLPort+" priority=1000 match=\"ip4.dst==1.1.1.1 && tcp "+
+					"&& tcp.dst==80\" action=reject log=false severity=info name=tcp_load_balancer_id_1-1.1.1.1\\:80 "+
+					"-- add logical_switch ext_node1 acls @reject-acl",
+				"new-acl-uuid"),
+			table.Entry("when it exists with the same fields",
+				listRejectACLCmd("existing-acl-uuid", 1000, "ip4.dst==1.1.1.1 && tcp && tcp.dst==80", "reject", false, "info", ""),
+				"-- add logical_switch ext_node1 acls existing-acl-uuid",
+				"existing-acl-uuid"),
+			table.Entry("when it exists with other fields",
+				listRejectACLCmd("existing-acl-uuid", 1500, "ip4.dst==1.1.1.1 && tcp && tcp.dst==8080", "drop", true, "alert", "acl-logging"),
+				"-- set acl existing-acl-uuid priority=1000 match=\"ip4.dst==1.1.1.1 && tcp && tcp.dst==80\" action=reject "+
+					"log=false severity=info meter=[] -- add logical_switch ext_node1 acls existing-acl-uuid",
+				"existing-acl-uuid"),
+		)
+
+		ginkgo.It("creates the reject ACL of a VIP only once when it is ensured twice", func() {
+			app.Action = func(ctx *cli.Context) error {
+				for _, existing := range []string{"", "acl-uuid-1"} {
+					fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+						Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_switch load_balancer{>=}tcp_load_balancer_id_1",
+						Output: "node1-uuid",
+					})
+					fExec.AddFakeCmdsNoOutputNoError([]string{
+						"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}tcp_load_balancer_id_1",
+					})
+					fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+						Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=tcp_load_balancer_id_1-1.1.1.1\\:80",
+						Output: existing,
+					})
+				}
+				// the first attempt creates the ACL, the retry finds it instead of creating another one
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd: "ovn-nbctl --timeout=15 --id=@reject-acl create acl direction=" + types.DirectionFromLPort + " priority=1000 " +
+						"match=\"ip4.dst==1.1.1.1 && tcp && tcp.dst==80\" action=reject log=false severity=info " +
+						"name=tcp_load_balancer_id_1-1.1.1.1\\:80 " + fmt.Sprintf("-- add port_group %s acls @reject-acl", ovnClusterPortGroupUUID),
+					Output: "acl-uuid-1",
+				})
+				fExec.AddFakeCmd(listRejectACLCmd("acl-uuid-1", 1000, "ip4.dst==1.1.1.1 && tcp && tcp.dst==80", "reject", false, "info", ""))
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					fmt.Sprintf("ovn-nbctl --timeout=15 -- add port_group %s acls acl-uuid-1", ovnClusterPortGroupUUID),
+					"ovn-nbctl --timeout=15 -- --if-exists remove logical_switch node1-uuid acls acl-uuid-1",
+				})
+
+				fakeOvn.start(ctx)
+				fakeOvn.controller.clusterPortGroupUUID = ovnClusterPortGroupUUID
+				for i := 0; i < 2; i++ {
+					aclUUID, err := fakeOvn.controller.ensureLoadBalancerRejectACL(context.Background(), "tcp_load_balancer_id_1", "1.1.1.1", 80,
+						v1.ProtocolTCP, "", "acl-logging", "reject")
+					gomega.Expect(err).NotTo(gomega.HaveOccurred())
+					gomega.Expect(aclUUID).To(gomega.Equal("acl-uuid-1"))
+				}
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("does not create the reject ACL of a VIP when it cannot tell whether it exists", func() {
+			app.Action = func(ctx *cli.Context) error {
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_switch load_balancer{>=}tcp_load_balancer_id_1",
+					Output: "node1-uuid",
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}tcp_load_balancer_id_1",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd: "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=tcp_load_balancer_id_1-1.1.1.1\\:80",
+					Err: fmt.Errorf("database connection failed"),
+				})
+
+				fakeOvn.start(ctx)
+				_, err := fakeOvn.controller.ensureLoadBalancerRejectACL(context.Background(), "tcp_load_balancer_id_1", "1.1.1.1", 80,
+					v1.ProtocolTCP, "", "acl-logging", "reject")
+				gomega.Expect(err).To(gomega.HaveOccurred())
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("keeps a single reject ACL of a VIP created twice, removing the duplicate", func() {
+			app.Action = func(ctx *cli.Context) error {
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_switch load_balancer{>=}tcp_load_balancer_id_1",
+					Output: "node1-uuid",
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}tcp_load_balancer_id_1",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=tcp_load_balancer_id_1-1.1.1.1\\:80",
+					Output: "acl-uuid-1\nacl-uuid-2",
+				})
+				fExec.AddFakeCmd(listRejectACLCmd("acl-uuid-1", 1000, "ip4.dst==1.1.1.1 && tcp && tcp.dst==80", "reject", false, "info", ""))
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					fmt.Sprintf("ovn-nbctl --timeout=15 -- add port_group %s acls acl-uuid-1 ", ovnClusterPortGroupUUID) +
+						fmt.Sprintf("-- --if-exists remove port_group %s acls acl-uuid-2 ", ovnClusterPortGroupUUID) +
+						"-- --if-exists remove logical_switch node1-uuid acls acl-uuid-2",
+					"ovn-nbctl --timeout=15 -- --if-exists remove logical_switch node1-uuid acls acl-uuid-1",
+				})
+
+				fakeOvn.start(ctx)
+				fakeOvn.controller.clusterPortGroupUUID = ovnClusterPortGroupUUID
+				aclUUID, err := fakeOvn.controller.ensureLoadBalancerRejectACL(context.Background(), "tcp_load_balancer_id_1", "1.1.1.1", 80,
+					v1.ProtocolTCP, "", "acl-logging", "reject")
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(aclUUID).To(gomega.Equal("acl-uuid-1"))
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("removes several VIPs of a load balancer and their reject ACLs in one transaction", func() {
+			app.Action = func(ctx *cli.Context) error {
+				for i := 0; i < 2; i++ {
+					fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+						Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}tcp_load_balancer_id_1",
+						Output: "GR_node1",
+					})
+				}
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --if-exists remove load_balancer tcp_load_balancer_id_1 vips \"1.1.1.1:80\" \"192.168.0.1:30080\" " +
+						"-- --if-exists remove logical_switch ext_node1 acls acl-uuid-1 " +
+						fmt.Sprintf("-- --if-exists remove port_group %s acls acl-uuid-1 ", ovnClusterPortGroupUUID) +
+						"-- --if-exists remove logical_switch ext_node1 acls acl-uuid-2 " +
+						fmt.Sprintf("-- --if-exists remove port_group %s acls acl-uuid-2", ovnClusterPortGroupUUID),
+				})
+
+				fakeOvn.start(ctx)
+				fakeOvn.controller.clusterPortGroupUUID = ovnClusterPortGroupUUID
+				fakeOvn.controller.setServiceACLToLB("tcp_load_balancer_id_1", "1.1.1.1:80", "acl-uuid-1")
+				fakeOvn.controller.setServiceACLToLB("tcp_load_balancer_id_1", "192.168.0.1:30080", "acl-uuid-2")
+
+				err := fakeOvn.controller.deleteLoadBalancerVIPs(context.Background(), "tcp_load_balancer_id_1", []string{"1.1.1.1:80", "192.168.0.1:30080"})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+				for _, vip := range []string{"1.1.1.1:80", "192.168.0.1:30080"} {
+					aclUUID, _ := fakeOvn.controller.getServiceLBInfo("tcp_load_balancer_id_1", vip)
+					gomega.Expect(aclUUID).To(gomega.BeEmpty())
+				}
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("only reads from OVN in service dry run", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+
+				// the fake exec fails on any command it does not expect, so only the reads may run
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
+					Output: k8sTCPLoadBalancerIP,
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_switch load_balancer{>=}" + k8sTCPLoadBalancerIP,
+					Output: "node1",
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}" + k8sTCPLoadBalancerIP,
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=" + k8sTCPLoadBalancerIP + "-172.30.0.10\\:80",
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=" + k8sTCPLoadBalancerIP + "-172.30.0.10\\:80",
+				})
+
+				fakeOvn.start(ctx)
+				fakeOvn.controller.clusterPortGroupUUID = ovnClusterPortGroupUUID
+				config.Kubernetes.ServiceDryRun = true
+				defer func() { config.Kubernetes.ServiceDryRun = false }()
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				// nothing was created, so nothing is cached
+				gomega.Expect(fakeOvn.controller.serviceLBMap).To(gomega.BeEmpty())
+				fakeOvn.controller.deleteService(service)
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("removes the reject ACLs of a service without endpoints once idled and brings them back once unidled", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+				idled := service.DeepCopy()
+				idled.Annotations = map[string]string{OvnServiceIdledAt: "2021-01-01T00:00:00Z"}
+
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
+					Output: k8sTCPLoadBalancerIP,
+				})
+				// idling removes the reject ACL along with the targets of the VIP, which is kept for OVN
+				// to report the traffic to it
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}" + k8sTCPLoadBalancerIP,
+					"ovn-nbctl --timeout=15 set load_balancer " + k8sTCPLoadBalancerIP + " vips:\"172.30.0.10:80\"=\"\" " +
+						"-- --if-exists remove port_group " + ovnClusterPortGroupUUID + " acls acl-uuid-1",
+				})
+				// unidling brings it back
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_switch load_balancer{>=}" + k8sTCPLoadBalancerIP,
+					Output: "node1",
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}" + k8sTCPLoadBalancerIP,
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=" + k8sTCPLoadBalancerIP + "-172.30.0.10\\:80",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd: "ovn-nbctl --timeout=15 --id=@reject-acl create acl direction=" + types.DirectionFromLPort + " priority=" + types.DefaultDenyPriority + " match=\"ip4.dst==172.30.0.10 && tcp " +
+						"&& tcp.dst==80\" action=reject log=false severity=info name=" + k8sTCPLoadBalancerIP + "-172.30.0.10\\:80 " +
+						"-- add port_group " + ovnClusterPortGroupUUID + " acls @reject-acl " +
+						"-- set load_balancer " + k8sTCPLoadBalancerIP + " vips:\"172.30.0.10:80\"=\"\"",
+					Output: "acl-uuid-2",
+				})
+
+				fakeOvn.start(ctx, &v1.ServiceList{Items: []v1.Service{*service}})
+				fakeOvn.controller.clusterPortGroupUUID = ovnClusterPortGroupUUID
+				config.Kubernetes.OVNEmptyLbEvents = true
+				fakeOvn.controller.setServiceACLToLB(k8sTCPLoadBalancerIP, "172.30.0.10:80", "acl-uuid-1")
+
+				// the VIP is kept without targets for OVN to report the traffic to it
+				err := fakeOvn.controller.updateService(service, idled)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				aclUUID, _ := fakeOvn.controller.getServiceLBInfo(k8sTCPLoadBalancerIP, "172.30.0.10:80")
+				gomega.Expect(aclUUID).To(gomega.BeEmpty())
+
+				err = fakeOvn.controller.updateService(idled, service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				aclUUID, _ = fakeOvn.controller.getServiceLBInfo(k8sTCPLoadBalancerIP, "172.30.0.10:80")
+				gomega.Expect(aclUUID).To(gomega.Equal("acl-uuid-2"))
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("updates the reject ACLs of a service without endpoints in place once it asks for its traffic to be dropped", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+				dropped := service.DeepCopy()
+				dropped.Annotations = map[string]string{OvnServiceEmptyServiceAction: "drop"}
+
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
+					Output: k8sTCPLoadBalancerIP,
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_switch load_balancer{>=}" + k8sTCPLoadBalancerIP,
+					Output: "node1",
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}" + k8sTCPLoadBalancerIP,
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=" + k8sTCPLoadBalancerIP + "-172.30.0.10\\:80",
+					Output: "acl-uuid-1",
+				})
+				fExec.AddFakeCmd(listRejectACLCmd("acl-uuid-1", 1000, "ip4.dst==172.30.0.10 && tcp && tcp.dst==80", "reject", false, "info", ""))
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 -- set acl acl-uuid-1 action=drop " +
+						"-- add port_group " + ovnClusterPortGroupUUID + " acls acl-uuid-1 " +
+						"-- set load_balancer " + k8sTCPLoadBalancerIP + " vips:\"172.30.0.10:80\"=\"\"",
+					"ovn-nbctl --timeout=15 -- --if-exists remove logical_switch node1 acls acl-uuid-1",
+				})
+
+				fakeOvn.start(ctx, &v1.ServiceList{Items: []v1.Service{*service}})
+				fakeOvn.controller.clusterPortGroupUUID = ovnClusterPortGroupUUID
+				fakeOvn.controller.setServiceACLToLB(k8sTCPLoadBalancerIP, "172.30.0.10:80", "acl-uuid-1")
+
+				err := fakeOvn.controller.updateService(service, dropped)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				aclUUID, _ := fakeOvn.controller.getServiceLBInfo(k8sTCPLoadBalancerIP, "172.30.0.10:80")
+				gomega.Expect(aclUUID).To(gomega.Equal("acl-uuid-1"))
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("creates a health check VIP on every gateway for a service with a health check NodePort", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, NodePort: 30080, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeLoadBalancer,
+					nil,
+				)
+				service.Spec.ExternalTrafficPolicy = v1.ServiceExternalTrafficPolicyTypeLocal
+				service.Spec.HealthCheckNodePort = 32000
+
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=GR_node1-TCP-192.168.0.1\\:32000",
+					"ovn-nbctl --timeout=15 set load_balancer GR_node1-TCP vips:\"192.168.0.1:32000\"=\"169.254.169.2:32000\"",
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=GR_node2-TCP-192.168.0.2\\:32000",
+					"ovn-nbctl --timeout=15 set load_balancer GR_node2-TCP vips:\"192.168.0.2:32000\"=\"169.254.169.2:32000\"",
+				})
+
+				fakeOvn.start(ctx)
+				fakeOvn.controller.lbOps = fakeOps
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.rejectACLs).To(gomega.Equal([]string{
+					"GR_node1-TCP 192.168.0.1:30080",
+					"GR_node2-TCP 192.168.0.2:30080",
+					"cluster-TCP 172.30.0.10:80",
+				}))
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("only rejects traffic to the ingress IPs of a LoadBalancer service without node ports", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeLoadBalancer,
+					nil,
+				)
+				allocateNodePorts := false
+				service.Spec.AllocateLoadBalancerNodePorts = &allocateNodePorts
+				service.Status.LoadBalancer.Ingress = []v1.LoadBalancerIngress{{IP: "5.5.5.5"}}
+
+				fakeOvn.start(ctx)
+				fakeOvn.controller.lbOps = fakeOps
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				// no VIP on port 0 of the physical IPs of the gateways
+				gomega.Expect(fakeOps.rejectACLs).To(gomega.Equal([]string{
+					"GR_node1-TCP 5.5.5.5:80",
+					"GR_node2-TCP 5.5.5.5:80",
+					"cluster-TCP 172.30.0.10:80",
+				}))
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("does not reject traffic to an idled service when empty LB events are enabled", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, NodePort: 30080, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeNodePort,
+					nil,
+				)
+				service.Annotations = map[string]string{OvnServiceIdledAt: "2021-01-01T00:00:00Z"}
+
+				fakeOvn.start(ctx)
+				fakeOvn.controller.lbOps = fakeOps
+				config.Kubernetes.OVNEmptyLbEvents = true
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.rejectACLs).To(gomega.BeEmpty())
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("clears the ClusterIP VIP without its reject ACL once idled and brings the reject ACL back once unidled", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+				idled := service.DeepCopy()
+				idled.Annotations = map[string]string{OvnServiceIdledAt: "2021-01-01T00:00:00Z"}
+
+				fakeOvn.start(ctx, &v1.ServiceList{Items: []v1.Service{*service}})
+				fakeOvn.controller.lbOps = fakeOps
+				config.Kubernetes.OVNEmptyLbEvents = true
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.liveRejectACLs.List()).To(gomega.Equal([]string{"cluster-TCP 172.30.0.10:80"}))
+
+				// the VIP is kept without targets for OVN to report the traffic to it
+				err = fakeOvn.controller.updateService(service, idled)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.vips).To(gomega.HaveKeyWithValue("cluster-TCP 172.30.0.10:80", gomega.BeEmpty()))
+				gomega.Expect(fakeOps.liveRejectACLs.List()).To(gomega.BeEmpty())
+
+				err = fakeOvn.controller.updateService(idled, service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.liveRejectACLs.List()).To(gomega.Equal([]string{"cluster-TCP 172.30.0.10:80"}))
+				gomega.Expect(fakeOps.rejectACLs).To(gomega.Equal([]string{
+					"cluster-TCP 172.30.0.10:80",
+					"cluster-TCP 172.30.0.10:80",
+				}))
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+	})
+
+	ginkgo.Context("on service deletion", func() {
+
+		ginkgo.It("removes the NodePort reject ACLs of an endpoint-less service from every gateway", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, NodePort: 30080, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeNodePort,
+					nil,
+				)
+				gatewayACL := "gateway-reject-acl-uuid"
+				clusterACL := "cluster-reject-acl-uuid"
+
+				// createService makes a reject ACL on the gateway load balancer for the NodePort
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+					Output: "GR_node1",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:TCP_lb_gateway_router=GR_node1",
+					Output: "tcp_load_balancer_id_1",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 get logical_router GR_node1 external_ids:physical_ips",
+					Output: "192.168.0.1",
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_switch load_balancer{>=}tcp_load_balancer_id_1",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}tcp_load_balancer_id_1",
+					Output: "GR_node1",
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=tcp_load_balancer_id_1-192.168.0.1\\:30080",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd: "ovn-nbctl --timeout=15 --id=@reject-acl create acl direction=" + types.DirectionFromLPort + " priority=" + types.DefaultDenyPriority + " match=\"ip4.dst==192.168.0.1 && tcp " +
+						"&& tcp.dst==30080\" action=reject log=false severity=info name=tcp_load_balancer_id_1-192.168.0.1\\:30080 -- add logical_switch ext_node1 acls @reject-acl",
+					Output: gatewayACL,
+				})
+				// and one on the cluster load balancer for the ClusterIP
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
+					Output: k8sTCPLoadBalancerIP,
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_switch load_balancer{>=}%s", k8sTCPLoadBalancerIP),
+					Output: "62c672a4-1132-44ab-9202-e47d18784138",
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}%s", k8sTCPLoadBalancerIP),
+					fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=%s-172.30.0.10\\:80", k8sTCPLoadBalancerIP),
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd: fmt.Sprintf("ovn-nbctl --timeout=15 --id=@reject-acl create acl direction="+types.DirectionFromLPort+" priority="+types.DefaultDenyPriority+" match=\"ip4.dst==172.30.0.10 && tcp "+
+						"&& tcp.dst==80\" action=reject log=false severity=info name=%s-172.30.0.10\\:80 -- add port_group %s acls @reject-acl",
+						k8sTCPLoadBalancerIP, ovnClusterPortGroupUUID),
+					Output: clusterACL,
+				})
+
+				// deleteService looks up every VIP the service could own
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+					Output: "GR_node1",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 get logical_router GR_node1 external_ids:physical_ips",
+					Output: "192.168.0.1",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:TCP_lb_gateway_router=GR_node1",
+					Output: "tcp_load_balancer_id_1",
+				})
+				// then removes the ClusterIP VIP and its reject ACL from the cluster load balancer
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}%s", k8sTCPLoadBalancerIP),
+					fmt.Sprintf("ovn-nbctl --timeout=15 --if-exists remove load_balancer %s vips \"172.30.0.10:80\" "+
+						"-- --if-exists remove port_group %s acls %s", k8sTCPLoadBalancerIP, ovnClusterPortGroupUUID, clusterACL),
+				})
+				// and the ClusterIP VIP, which never had a reject ACL, along with the NodePort VIP and
+				// its reject ACL from the gateway load balancer in a single transaction
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=tcp_load_balancer_id_1-172.30.0.10\\:80",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}tcp_load_balancer_id_1",
+					Output: "GR_node1",
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --if-exists remove load_balancer tcp_load_balancer_id_1 vips \"172.30.0.10:80\" \"192.168.0.1:30080\" " +
+						"-- --if-exists remove logical_switch ext_node1 acls " + gatewayACL + " " +
+						fmt.Sprintf("-- --if-exists remove port_group %s acls %s", ovnClusterPortGroupUUID, gatewayACL),
+				})
+
+				fakeOvn.start(ctx)
+				fakeOvn.controller.clusterPortGroupUUID = ovnClusterPortGroupUUID
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				fakeOvn.controller.deleteService(service)
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("removes the VIPs of both IP families of a dual-stack service", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+				service.Spec.ClusterIPs = []string{"172.30.0.10", "fd00:10:96::10"}
+
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+					Output: "GR_node1",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:TCP_lb_gateway_router=GR_node1",
+					Output: "tcp_load_balancer_id_1",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
+					Output: k8sTCPLoadBalancerIP,
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=%s-172.30.0.10\\:80", k8sTCPLoadBalancerIP),
+					fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=%s-fd00\\:10\\:96\\:\\:10\\:80", k8sTCPLoadBalancerIP),
+					fmt.Sprintf("ovn-nbctl --timeout=15 --if-exists remove load_balancer %s vips \"172.30.0.10:80\" \"[fd00:10:96::10]:80\"", k8sTCPLoadBalancerIP),
+				})
+				// both cluster IPs are removed from the gateway load balancer too
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=tcp_load_balancer_id_1-172.30.0.10\\:80",
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=tcp_load_balancer_id_1-fd00\\:10\\:96\\:\\:10\\:80",
+					"ovn-nbctl --timeout=15 --if-exists remove load_balancer tcp_load_balancer_id_1 vips \"172.30.0.10:80\" \"[fd00:10:96::10]:80\"",
+				})
+
+				fakeOvn.start(ctx)
+				fakeOvn.controller.deleteService(service)
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+	})
+
+	ginkgo.Context("with a fake load balancer backend", func() {
+		var fakeOps *fakeServiceLBBackend
+
+		ginkgo.BeforeEach(func() {
+			fakeOps = &fakeServiceLBBackend{
+				gateways: []string{"GR_node1", "GR_node2"},
+				physicalIPs: map[string][]string{
+					"GR_node1": {"192.168.0.1"},
+					"GR_node2": {"192.168.0.2"},
+				},
+			}
+		})
+
+		// newTypedService returns service1 of type serviceType, with the NodePort and the ingress IP
+		// the type gets allocated
+		newTypedService := func(serviceType v1.ServiceType) *v1.Service {
+			svcPort := v1.ServicePort{Port: 80, Protocol: v1.ProtocolTCP}
+			if serviceType != v1.ServiceTypeClusterIP {
+				svcPort.NodePort = 30080
+			}
+			service := newService("service1", "namespace1", "172.30.0.10", []v1.ServicePort{svcPort}, serviceType, nil)
+			if serviceType == v1.ServiceTypeLoadBalancer {
+				service.Status.LoadBalancer.Ingress = []v1.LoadBalancerIngress{{IP: "5.5.5.5"}}
+			}
+			return service
+		}
+
+		table.DescribeTable("leaves the VIPs and reject ACLs of a service changing type as if it was created with the new type",
+			func(oldType, newType v1.ServiceType) {
+				app.Action = func(ctx *cli.Context) error {
+					oldSvc := newTypedService(oldType)
+					newSvc := newTypedService(newType)
+
+					fakeOvn.start(ctx, &v1.ServiceList{Items: []v1.Service{*newSvc}})
+
+					// the VIPs and reject ACLs of the service created with the new type right away
+					created := &fakeServiceLBBackend{gateways: fakeOps.gateways, physicalIPs: fakeOps.physicalIPs}
+					fakeOvn.controller.lbOps = created
+					err := fakeOvn.controller.createService(newSvc)
+					gomega.Expect(err).NotTo(gomega.HaveOccurred())
+					gomega.Expect(created.liveRejectACLs).NotTo(gomega.BeEmpty())
+					fakeOvn.controller.releaseNodePorts(newSvc)
+
+					fakeOvn.controller.lbOps = fakeOps
+					err = fakeOvn.controller.createService(oldSvc)
+					gomega.Expect(err).NotTo(gomega.HaveOccurred())
+					err = fakeOvn.controller.updateService(oldSvc, newSvc)
+					gomega.Expect(err).NotTo(gomega.HaveOccurred())
+					gomega.Expect(fakeOps.vips).To(gomega.Equal(created.vips))
+					gomega.Expect(fakeOps.liveRejectACLs).To(gomega.Equal(created.liveRejectACLs))
+
+					// the NodePort is only kept by a service still allocated one
+					fakeOvn.controller.nodePortOwnersLock.Lock()
+					defer fakeOvn.controller.nodePortOwnersLock.Unlock()
+					if newType == v1.ServiceTypeClusterIP {
+						gomega.Expect(fakeOvn.controller.nodePortOwners).To(gomega.BeEmpty())
+					} else {
+						gomega.Expect(fakeOvn.controller.nodePortOwners).To(gomega.HaveLen(1))
+					}
+
+					return nil
+				}
+
+				err := app.Run([]string{app.Name})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			},
+			table.Entry("ClusterIP to NodePort", v1.ServiceTypeClusterIP, v1.ServiceTypeNodePort),
+			table.Entry("NodePort to ClusterIP", v1.ServiceTypeNodePort, v1.ServiceTypeClusterIP),
+			table.Entry("NodePort to LoadBalancer", v1.ServiceTypeNodePort, v1.ServiceTypeLoadBalancer),
+			table.Entry("LoadBalancer to NodePort", v1.ServiceTypeLoadBalancer, v1.ServiceTypeNodePort),
+			table.Entry("ClusterIP to LoadBalancer", v1.ServiceTypeClusterIP, v1.ServiceTypeLoadBalancer),
+			table.Entry("LoadBalancer to ClusterIP", v1.ServiceTypeLoadBalancer, v1.ServiceTypeClusterIP),
+		)
+
+		ginkgo.It("points the ClusterIP VIP at the endpoints", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+				endpoint := newEndpoints("service1", "namespace1",
+					[]v1.EndpointAddress{{IP: "10.128.0.5"}, {IP: "10.129.0.3"}},
+					[]v1.EndpointPort{{Port: 8080, Protocol: v1.ProtocolTCP}},
+				)
+
+				fakeOvn.start(ctx, &v1.EndpointsList{Items: []v1.Endpoints{*endpoint}},
+					&v1.ServiceList{Items: []v1.Service{*service}})
+				fakeOvn.controller.lbOps = fakeOps
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.vips).To(gomega.Equal(map[string][]string{
+					"cluster-TCP 172.30.0.10:80": {"10.128.0.5:8080", "10.129.0.3:8080"},
+				}))
+				gomega.Expect(fakeOps.rejectACLs).To(gomega.BeEmpty())
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		// newTerminatingPod returns the pod behind an endpoint address, which is terminating
+		newTerminatingPod := func(name, podIP string) *v1.Pod {
+			pod := newPod("namespace1", name, "node1", podIP)
+			now := metav1.Now()
+			pod.DeletionTimestamp = &now
+			return pod
+		}
+
+		ginkgo.It("points the ClusterIP VIP at the ready endpoints only, leaving out the terminating ones", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+				readyPod := newPod("namespace1", "pod1", "node1", "10.128.0.5")
+				terminatingPod := newTerminatingPod("pod2", "10.129.0.3")
+				endpoint := newEndpoints("service1", "namespace1",
+					[]v1.EndpointAddress{
+						{IP: "10.128.0.5", TargetRef: &v1.ObjectReference{Kind: "Pod", Namespace: "namespace1", Name: "pod1"}},
+						{IP: "10.129.0.3", TargetRef: &v1.ObjectReference{Kind: "Pod", Namespace: "namespace1", Name: "pod2"}},
+					},
+					[]v1.EndpointPort{{Port: 8080, Protocol: v1.ProtocolTCP}},
+				)
+
+				fakeOvn.start(ctx, &v1.EndpointsList{Items: []v1.Endpoints{*endpoint}},
+					&v1.ServiceList{Items: []v1.Service{*service}},
+					&v1.PodList{Items: []v1.Pod{*readyPod, *terminatingPod}})
+				fakeOvn.controller.lbOps = fakeOps
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.vips).To(gomega.Equal(map[string][]string{
+					"cluster-TCP 172.30.0.10:80": {"10.128.0.5:8080"},
+				}))
+				gomega.Expect(fakeOps.rejectACLs).To(gomega.BeEmpty())
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("points the ClusterIP VIP at the terminating endpoints when no ready one is left", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+				terminatingPod := newTerminatingPod("pod2", "10.129.0.3")
+				endpoint := newEndpoints("service1", "namespace1",
+					[]v1.EndpointAddress{
+						{IP: "10.129.0.3", TargetRef: &v1.ObjectReference{Kind: "Pod", Namespace: "namespace1", Name: "pod2"}},
+					},
+					[]v1.EndpointPort{{Port: 8080, Protocol: v1.ProtocolTCP}},
+				)
+
+				fakeOvn.start(ctx, &v1.EndpointsList{Items: []v1.Endpoints{*endpoint}},
+					&v1.ServiceList{Items: []v1.Service{*service}},
+					&v1.PodList{Items: []v1.Pod{*terminatingPod}})
+				fakeOvn.controller.lbOps = fakeOps
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.vips).To(gomega.Equal(map[string][]string{
+					"cluster-TCP 172.30.0.10:80": {"10.129.0.3:8080"},
+				}))
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("points the NodePort VIP of every gateway at the endpoints", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, NodePort: 30080, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeNodePort,
+					nil,
+				)
+				endpoint := newEndpoints("service1", "namespace1",
+					[]v1.EndpointAddress{{IP: "10.128.0.5"}},
+					[]v1.EndpointPort{{Port: 8080, Protocol: v1.ProtocolTCP}},
+				)
+
+				fakeOvn.start(ctx, &v1.EndpointsList{Items: []v1.Endpoints{*endpoint}},
+					&v1.ServiceList{Items: []v1.Service{*service}})
+				fakeOvn.controller.lbOps = fakeOps
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.vips).To(gomega.Equal(map[string][]string{
+					"GR_node1-TCP 192.168.0.1:30080": {"10.128.0.5:8080"},
+					"GR_node2-TCP 192.168.0.2:30080": {"10.128.0.5:8080"},
+					"cluster-TCP 172.30.0.10:80":     {"10.128.0.5:8080"},
+				}))
+				gomega.Expect(fakeOps.rejectACLs).To(gomega.BeEmpty())
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("programs the NodePort on the other gateways when one of them fails", func() {
+			app.Action = func(ctx *cli.Context) error {
+				fakeOps.gatewayLBErrs = map[string]error{"GR_node2": fmt.Errorf("load balancer not found")}
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, NodePort: 30080, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeNodePort,
+					nil,
+				)
+				endpoint := newEndpoints("service1", "namespace1",
+					[]v1.EndpointAddress{{IP: "10.128.0.5"}},
+					[]v1.EndpointPort{{Port: 8080, Protocol: v1.ProtocolTCP}},
+				)
+				rejectedService := newService("service2", "namespace1", "172.30.0.20",
+					[]v1.ServicePort{{Port: 80, NodePort: 30081, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeNodePort,
+					nil,
+				)
+
+				fakeOvn.start(ctx, &v1.EndpointsList{Items: []v1.Endpoints{*endpoint}},
+					&v1.ServiceList{Items: []v1.Service{*service, *rejectedService}})
+				fakeOvn.controller.lbOps = fakeOps
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.vips).To(gomega.Equal(map[string][]string{
+					"GR_node1-TCP 192.168.0.1:30080": {"10.128.0.5:8080"},
+					"cluster-TCP 172.30.0.10:80":     {"10.128.0.5:8080"},
+				}))
+
+				err = fakeOvn.controller.createService(rejectedService)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.rejectACLs).To(gomega.Equal([]string{
+					"GR_node1-TCP 192.168.0.1:30081",
+					"cluster-TCP 172.30.0.20:80",
+				}))
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("still rejects traffic to the ClusterIP when the gateways cannot be looked up", func() {
+			app.Action = func(ctx *cli.Context) error {
+				fakeOps.gatewaysErr = fmt.Errorf("connection refused")
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, NodePort: 30080, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeNodePort,
+					nil,
+				)
+
+				fakeOvn.start(ctx, &v1.ServiceList{Items: []v1.Service{*service}})
+				fakeOvn.controller.lbOps = fakeOps
+
+				// the NodePort fails, so the service is retried, but its ClusterIP is configured
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).To(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.rejectACLs).To(gomega.Equal([]string{
+					"cluster-TCP 172.30.0.10:80",
+				}))
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("does not create the NodePort VIPs of an IPv4 service on an IPv6 only gateway", func() {
+			app.Action = func(ctx *cli.Context) error {
+				fakeOps.physicalIPs["GR_node2"] = []string{"fd00:192:168::2"}
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, NodePort: 30080, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeNodePort,
+					nil,
+				)
+				endpoint := newEndpoints("service1", "namespace1",
+					[]v1.EndpointAddress{{IP: "10.128.0.5"}},
+					[]v1.EndpointPort{{Port: 8080, Protocol: v1.ProtocolTCP}},
+				)
+				rejectedService := newService("service2", "namespace1", "172.30.0.20",
+					[]v1.ServicePort{{Port: 80, NodePort: 30081, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeNodePort,
+					nil,
+				)
+
+				fakeOvn.start(ctx, &v1.EndpointsList{Items: []v1.Endpoints{*endpoint}},
+					&v1.ServiceList{Items: []v1.Service{*service, *rejectedService}})
+				fakeOvn.controller.lbOps = fakeOps
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.vips).To(gomega.Equal(map[string][]string{
+					"GR_node1-TCP 192.168.0.1:30080": {"10.128.0.5:8080"},
+					"cluster-TCP 172.30.0.10:80":     {"10.128.0.5:8080"},
+				}))
+
+				err = fakeOvn.controller.createService(rejectedService)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.rejectACLs).To(gomega.Equal([]string{
+					"GR_node1-TCP 192.168.0.1:30081",
+					"cluster-TCP 172.30.0.20:80",
+				}))
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("only programs the IP family of an IPv6 SingleStack service", func() {
+			app.Action = func(ctx *cli.Context) error {
+				fakeOps.gateways = []string{"GR_node1"}
+				fakeOps.physicalIPs["GR_node1"] = []string{"192.168.0.1", "fd00:192:168::1"}
+				singleStack := v1.IPFamilyPolicySingleStack
+				service := newService("service1", "namespace1", "fd00:10:96::10",
+					[]v1.ServicePort{{Port: 80, NodePort: 30080, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeLoadBalancer,
+					[]string{"1.1.1.1", "2001:db8::1"},
+				)
+				service.Spec.ClusterIPs = []string{"fd00:10:96::10"}
+				service.Spec.IPFamilies = []v1.IPFamily{v1.IPv6Protocol}
+				service.Spec.IPFamilyPolicy = &singleStack
+				service.Status.LoadBalancer.Ingress = []v1.LoadBalancerIngress{{IP: "1.1.1.2"}}
+
+				fakeOvn.start(ctx, &v1.ServiceList{Items: []v1.Service{*service}})
+				fakeOvn.controller.lbOps = fakeOps
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.vips).To(gomega.BeEmpty())
+				gomega.Expect(fakeOps.rejectACLs).To(gomega.Equal([]string{
+					"GR_node1-TCP [fd00:192:168::1]:30080",
+					"GR_node1-TCP [2001:db8::1]:80",
+					"cluster-TCP [fd00:10:96::10]:80",
+				}))
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("does not program the NodePort of a LoadBalancer service without allocated NodePorts", func() {
+			app.Action = func(ctx *cli.Context) error {
+				fakeOps.gateways = []string{"GR_node1"}
+				allocateNodePorts := false
+				// the NodePort was allocated before allocateLoadBalancerNodePorts was disabled
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, NodePort: 30080, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeLoadBalancer,
+					nil,
+				)
+				service.Spec.AllocateLoadBalancerNodePorts = &allocateNodePorts
+				service.Status.LoadBalancer.Ingress = []v1.LoadBalancerIngress{{IP: "5.5.5.5"}}
+				endpoint := newEndpoints("service1", "namespace1",
+					[]v1.EndpointAddress{{IP: "10.128.0.5"}},
+					[]v1.EndpointPort{{Port: 8080, Protocol: v1.ProtocolTCP}},
+				)
+
+				fakeOvn.start(ctx, &v1.EndpointsList{Items: []v1.Endpoints{*endpoint}},
+					&v1.ServiceList{Items: []v1.Service{*service}})
+				fakeOvn.controller.lbOps = fakeOps
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				err = fakeOvn.controller.AddEndpoints(endpoint, true)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.vips).To(gomega.Equal(map[string][]string{
+					"cluster-TCP 172.30.0.10:80": {"10.128.0.5:8080"},
+					"GR_node1-TCP 5.5.5.5:80":    {"10.128.0.5:8080"},
+				}))
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("removes the ingress VIPs of a LoadBalancer service once its ingress is cleared", func() {
+			app.Action = func(ctx *cli.Context) error {
+				fakeOps.gateways = []string{"GR_node1"}
+				oldSvc := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeLoadBalancer,
+					nil,
+				)
+				oldSvc.Status.LoadBalancer.Ingress = []v1.LoadBalancerIngress{{IP: "5.5.5.5"}}
+				newSvc := oldSvc.DeepCopy()
+				newSvc.Status.LoadBalancer.Ingress = nil
+				endpoint := newEndpoints("service1", "namespace1",
+					[]v1.EndpointAddress{{IP: "10.128.0.5"}},
+					[]v1.EndpointPort{{Port: 8080, Protocol: v1.ProtocolTCP}},
+				)
+
+				fakeOvn.start(ctx, &v1.EndpointsList{Items: []v1.Endpoints{*endpoint}},
+					&v1.ServiceList{Items: []v1.Service{*oldSvc}})
+				fakeOvn.controller.lbOps = fakeOps
+
+				err := fakeOvn.controller.createService(oldSvc)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				err = fakeOvn.controller.AddEndpoints(endpoint, true)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.vips).To(gomega.HaveKey("GR_node1-TCP 5.5.5.5:80"))
+				fakeOps.removedVIPs = nil
+
+				// the cloud provider deleted the load balancer of the service
+				_, err = fakeOvn.fakeClient.KubeClient.CoreV1().Services(newSvc.Namespace).UpdateStatus(
+					context.TODO(), newSvc, metav1.UpdateOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Eventually(func() []v1.LoadBalancerIngress {
+					s, err := fakeOvn.watcher.GetService(newSvc.Namespace, newSvc.Name)
+					if err != nil {
+						return []v1.LoadBalancerIngress{{}}
+					}
+					return s.Status.LoadBalancer.Ingress
+				}).Should(gomega.BeEmpty())
+
+				err = fakeOvn.controller.updateService(oldSvc, newSvc)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				// the ingress VIPs are removed before the service is rebuilt
+				gomega.Expect(len(fakeOps.removedVIPs)).To(gomega.BeNumerically(">=", 2))
+				gomega.Expect(fakeOps.removedVIPs[:2]).To(gomega.Equal([]string{"cluster-TCP 5.5.5.5:80", "GR_node1-TCP 5.5.5.5:80"}))
+				gomega.Expect(fakeOps.vips).NotTo(gomega.HaveKey("GR_node1-TCP 5.5.5.5:80"))
+				gomega.Expect(fakeOps.vips).To(gomega.HaveKey("cluster-TCP 172.30.0.10:80"))
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("programs the VIPs of the IPs the ingress hostname of a LoadBalancer service resolves to", func() {
+			app.Action = func(ctx *cli.Context) error {
+				fakeOps.gateways = []string{"GR_node1"}
+				resolver := ingressHostnames
+				defer func() { ingressHostnames = resolver }()
+				ingressHostnames = newIngressHostnameResolver(func(hostname string) ([]string, error) {
+					gomega.Expect(hostname).To(gomega.Equal("lb.example.com"))
+					return []string{"5.5.5.6", "5.5.5.5"}, nil
+				})
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeLoadBalancer,
+					nil,
+				)
+				service.Status.LoadBalancer.Ingress = []v1.LoadBalancerIngress{{Hostname: "lb.example.com"}, {}}
+				endpoint := newEndpoints("service1", "namespace1",
+					[]v1.EndpointAddress{{IP: "10.128.0.5"}},
+					[]v1.EndpointPort{{Port: 8080, Protocol: v1.ProtocolTCP}},
+				)
+
+				fakeOvn.start(ctx, &v1.EndpointsList{Items: []v1.Endpoints{*endpoint}},
+					&v1.ServiceList{Items: []v1.Service{*service}})
+				fakeOvn.controller.lbOps = fakeOps
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.vips).To(gomega.Equal(map[string][]string{
+					"cluster-TCP 172.30.0.10:80": {"10.128.0.5:8080"},
+					"GR_node1-TCP 5.5.5.5:80":    {"10.128.0.5:8080"},
+					"GR_node1-TCP 5.5.5.6:80":    {"10.128.0.5:8080"},
+				}))
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("logs the namespace, name and VIPs of the services it creates as key-value pairs", func() {
+			app.Action = func(ctx *cli.Context) error {
+				fakeOps.gateways = []string{"GR_node1"}
+				service1 := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+				service2 := newService("service1", "namespace2", "172.30.0.20",
+					[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+
+				fakeOvn.start(ctx, &v1.ServiceList{Items: []v1.Service{*service1, *service2}})
+				fakeOvn.controller.lbOps = fakeOps
+
+				var logs bytes.Buffer
+				klog.LogToStderr(false)
+				klog.SetOutput(&logs)
+				defer klog.LogToStderr(true)
+
+				err := fakeOvn.controller.createService(service1)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				err = fakeOvn.controller.createService(service2)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				klog.Flush()
+				gomega.Expect(logs.String()).To(gomega.ContainSubstring(
+					`"Creating service" namespace="namespace1" name="service1"`))
+				gomega.Expect(logs.String()).To(gomega.ContainSubstring(
+					`"Service reject ACL created for ClusterIP VIP" namespace="namespace1" name="service1" ` +
+						`protocol=TCP vip="172.30.0.10:80" lb="cluster-TCP"`))
+				gomega.Expect(logs.String()).To(gomega.ContainSubstring(
+					`"Creating service" namespace="namespace2" name="service1"`))
+				gomega.Expect(logs.String()).To(gomega.ContainSubstring(
+					`"Service reject ACL created for ClusterIP VIP" namespace="namespace2" name="service1" ` +
+						`protocol=TCP vip="172.30.0.20:80" lb="cluster-TCP"`))
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("never leaves the ClusterIP VIP without targets while the service has endpoints", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+				newEndpointsOf := func(ips ...string) *v1.Endpoints {
+					var addresses []v1.EndpointAddress
+					for _, ip := range ips {
+						addresses = append(addresses, v1.EndpointAddress{IP: ip})
+					}
+					return newEndpoints("service1", "namespace1", addresses,
+						[]v1.EndpointPort{{Port: 8080, Protocol: v1.ProtocolTCP}})
+				}
+				// updateEndpoints stores ep in the informer cache, as it is before the handlers run
+				updateEndpoints := func(ep *v1.Endpoints) {
+					_, err := fakeOvn.fakeClient.KubeClient.CoreV1().Endpoints(ep.Namespace).Update(
+						context.TODO(), ep, metav1.UpdateOptions{})
+					gomega.Expect(err).NotTo(gomega.HaveOccurred())
+					gomega.Eventually(func() []v1.EndpointSubset {
+						latest, err := fakeOvn.watcher.GetEndpoint(ep.Namespace, ep.Name)
+						if err != nil {
+							return nil
+						}
+						return latest.Subsets
+					}).Should(gomega.Equal(ep.Subsets))
+				}
+				vip := "cluster-TCP 172.30.0.10:80"
+
+				fakeOvn.start(ctx, &v1.EndpointsList{Items: []v1.Endpoints{
+					*newEndpointsOf("10.128.0.5", "10.128.0.6", "10.128.0.7")}},
+					&v1.ServiceList{Items: []v1.Service{*service}})
+				fakeOvn.controller.lbOps = fakeOps
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.vips[vip]).To(gomega.HaveLen(3))
+
+				// the endpoints go away one at a time
+				for _, ips := range [][]string{{"10.128.0.5", "10.128.0.6"}, {"10.128.0.5"}} {
+					ep := newEndpointsOf(ips...)
+					updateEndpoints(ep)
+					err = fakeOvn.controller.AddEndpoints(ep, true)
+					gomega.Expect(err).NotTo(gomega.HaveOccurred())
+					gomega.Expect(fakeOps.vips[vip]).To(gomega.HaveLen(len(ips)))
+				}
+
+				// the last endpoint is replaced before the event of its removal is handled
+				updateEndpoints(newEndpointsOf("10.128.0.8"))
+				err = fakeOvn.controller.deleteEndpoints(newEndpointsOf())
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.vips[vip]).To(gomega.Equal([]string{"10.128.0.8:8080"}))
+				gomega.Expect(fakeOps.rejectACLs).To(gomega.BeEmpty())
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("repeats an endpoint in the targets of the VIPs as many times as its weight", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+				service.Annotations = map[string]string{OvnServiceEndpointWeights: "10.129.0.3=2"}
+				endpoint := newEndpoints("service1", "namespace1",
+					[]v1.EndpointAddress{{IP: "10.128.0.5"}, {IP: "10.129.0.3"}},
+					[]v1.EndpointPort{{Port: 8080, Protocol: v1.ProtocolTCP}},
+				)
+
+				fakeOvn.start(ctx, &v1.EndpointsList{Items: []v1.Endpoints{*endpoint}},
+					&v1.ServiceList{Items: []v1.Service{*service}})
+				fakeOvn.controller.lbOps = fakeOps
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.vips).To(gomega.Equal(map[string][]string{
+					"cluster-TCP 172.30.0.10:80": {"10.128.0.5:8080", "10.129.0.3:8080", "10.129.0.3:8080"},
+				}))
+
+				// once the annotation is removed, every endpoint appears once
+				updated := service.DeepCopy()
+				updated.Annotations = nil
+				_, err = fakeOvn.fakeClient.KubeClient.CoreV1().Services(service.Namespace).Update(
+					context.TODO(), updated, metav1.UpdateOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Eventually(func() map[string]string {
+					svc, _ := fakeOvn.watcher.GetService(service.Namespace, service.Name)
+					return svc.Annotations
+				}).Should(gomega.BeEmpty())
+				err = fakeOvn.controller.updateService(service, updated)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.vips).To(gomega.Equal(map[string][]string{
+					"cluster-TCP 172.30.0.10:80": {"10.128.0.5:8080", "10.129.0.3:8080"},
+				}))
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("deletes the cluster, NodePort and external IP VIPs of a service at once", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, NodePort: 30080, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeNodePort,
+					[]string{"5.5.5.5"},
+				)
+				endpoint := newEndpoints("service1", "namespace1",
+					[]v1.EndpointAddress{{IP: "10.128.0.5"}},
+					[]v1.EndpointPort{{Port: 8080, Protocol: v1.ProtocolTCP}},
+				)
+
+				fakeOvn.start(ctx, &v1.EndpointsList{Items: []v1.Endpoints{*endpoint}},
+					&v1.ServiceList{Items: []v1.Service{*service}})
+				fakeOvn.controller.lbOps = fakeOps
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.vips).To(gomega.HaveKey("cluster-TCP 172.30.0.10:80"))
+				gomega.Expect(fakeOps.vips).To(gomega.HaveKey("GR_node1-TCP 192.168.0.1:30080"))
+				gomega.Expect(fakeOps.vips).To(gomega.HaveKey("GR_node1-TCP 5.5.5.5:80"))
+
+				err = fakeOvn.controller.DeleteAllVIPsForService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.vips).To(gomega.BeEmpty())
+				gomega.Expect(fakeOps.removedVIPs).To(gomega.ContainElements(
+					"cluster-TCP 172.30.0.10:80",
+					"GR_node1-TCP 192.168.0.1:30080",
+					"GR_node2-TCP 192.168.0.2:30080",
+					"GR_node1-TCP 5.5.5.5:80",
+					"GR_node2-TCP 5.5.5.5:80",
+				))
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("deletes every VIP of a LoadBalancer service with one batch per load balancer", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, NodePort: 30080, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeLoadBalancer,
+					[]string{"5.5.5.5"},
+				)
+				service.Status.LoadBalancer.Ingress = []v1.LoadBalancerIngress{{IP: "6.6.6.6"}}
+				endpoint := newEndpoints("service1", "namespace1",
+					[]v1.EndpointAddress{{IP: "10.128.0.5"}},
+					[]v1.EndpointPort{{Port: 8080, Protocol: v1.ProtocolTCP}},
+				)
+
+				fakeOvn.start(ctx, &v1.EndpointsList{Items: []v1.Endpoints{*endpoint}},
+					&v1.ServiceList{Items: []v1.Service{*service}})
+				fakeOvn.controller.lbOps = fakeOps
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.vips).To(gomega.HaveKey("cluster-TCP 172.30.0.10:80"))
+				gomega.Expect(fakeOps.vips).To(gomega.HaveKey("GR_node2-TCP 192.168.0.2:30080"))
+				gomega.Expect(fakeOps.vips).To(gomega.HaveKey("GR_node1-TCP 6.6.6.6:80"))
+
+				fakeOvn.controller.deleteService(service)
+				gomega.Expect(fakeOps.vips).To(gomega.BeEmpty())
+				gomega.Expect(fakeOps.removedVIPBatches).To(gomega.Equal([][]string{
+					{"GR_node1-TCP 172.30.0.10:80", "GR_node1-TCP 192.168.0.1:30080", "GR_node1-TCP 5.5.5.5:80", "GR_node1-TCP 6.6.6.6:80"},
+					{"GR_node2-TCP 172.30.0.10:80", "GR_node2-TCP 192.168.0.2:30080", "GR_node2-TCP 5.5.5.5:80", "GR_node2-TCP 6.6.6.6:80"},
+					{"cluster-TCP 172.30.0.10:80", "cluster-TCP 5.5.5.5:80", "cluster-TCP 6.6.6.6:80"},
+				}))
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("only programs the IPv4 VIP of a PreferDualStack service that only got an IPv4 ClusterIP", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+				preferDualStack := v1.IPFamilyPolicyPreferDualStack
+				service.Spec.IPFamilyPolicy = &preferDualStack
+				service.Spec.IPFamilies = []v1.IPFamily{v1.IPv4Protocol, v1.IPv6Protocol}
+				service.Spec.ClusterIPs = []string{"172.30.0.10"}
+				endpoint := newEndpoints("service1", "namespace1",
+					[]v1.EndpointAddress{{IP: "10.128.0.5"}, {IP: "fd00:10:128::5"}},
+					[]v1.EndpointPort{{Port: 8080, Protocol: v1.ProtocolTCP}},
+				)
+
+				fakeOvn.start(ctx, &v1.EndpointsList{Items: []v1.Endpoints{*endpoint}},
+					&v1.ServiceList{Items: []v1.Service{*service}})
+				fakeOvn.controller.lbOps = fakeOps
+
+				var logs bytes.Buffer
+				klog.LogToStderr(false)
+				klog.SetOutput(&logs)
+				defer klog.LogToStderr(true)
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.vips).To(gomega.Equal(map[string][]string{
+					"cluster-TCP 172.30.0.10:80": {"10.128.0.5:8080"},
+				}))
+				klog.Flush()
+				gomega.Expect(logs.String()).To(gomega.ContainSubstring(
+					"Service namespace1/service1 has ClusterIPs [172.30.0.10] for IP families [IPv4] only"))
+				for _, line := range strings.Split(logs.String(), "\n") {
+					if strings.HasPrefix(line, "E") {
+						gomega.Expect(line).NotTo(gomega.ContainSubstring("namespace1/service1"))
+						gomega.Expect(line).NotTo(gomega.ContainSubstring(`namespace="namespace1" name="service1"`))
+					}
+				}
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("programs the IPv6 VIP of a service upgraded to dual-stack", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+				singleStack := v1.IPFamilyPolicySingleStack
+				service.Spec.IPFamilyPolicy = &singleStack
+				service.Spec.IPFamilies = []v1.IPFamily{v1.IPv4Protocol}
+				service.Spec.ClusterIPs = []string{"172.30.0.10"}
+				endpoint := newEndpoints("service1", "namespace1",
+					[]v1.EndpointAddress{{IP: "10.128.0.5"}, {IP: "fd00:10:128::5"}},
+					[]v1.EndpointPort{{Port: 8080, Protocol: v1.ProtocolTCP}},
+				)
+
+				fakeOvn.start(ctx, &v1.EndpointsList{Items: []v1.Endpoints{*endpoint}},
+					&v1.ServiceList{Items: []v1.Service{*service}})
+				fakeOvn.controller.lbOps = fakeOps
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.vips).To(gomega.Equal(map[string][]string{
+					"cluster-TCP 172.30.0.10:80": {"10.128.0.5:8080"},
+				}))
+
+				// the ClusterIP stays the same, only the ClusterIPs and the IP families change
+				dualStack := service.DeepCopy()
+				requireDualStack := v1.IPFamilyPolicyRequireDualStack
+				dualStack.Spec.IPFamilyPolicy = &requireDualStack
+				dualStack.Spec.IPFamilies = []v1.IPFamily{v1.IPv4Protocol, v1.IPv6Protocol}
+				dualStack.Spec.ClusterIPs = []string{"172.30.0.10", "fd00:10:96::10"}
+				_, err = fakeOvn.fakeClient.KubeClient.CoreV1().Services(service.Namespace).Update(
+					context.TODO(), dualStack, metav1.UpdateOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Eventually(func() []v1.IPFamily {
+					svc, _ := fakeOvn.watcher.GetService(service.Namespace, service.Name)
+					return svc.Spec.IPFamilies
+				}).Should(gomega.HaveLen(2))
+				err = fakeOvn.controller.updateService(service, dualStack)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.vips).To(gomega.Equal(map[string][]string{
+					"cluster-TCP 172.30.0.10:80":      {"10.128.0.5:8080"},
+					"cluster-TCP [fd00:10:96::10]:80": {"[fd00:10:128::5]:8080"},
+				}))
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("rebuilds a service once for each new resync generation", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, NodePort: 30080, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeNodePort,
+					nil,
+				)
+
+				fakeOvn.start(ctx, &v1.ServiceList{Items: []v1.Service{*service}})
+				fakeOvn.controller.lbOps = fakeOps
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.rejectACLs).To(gomega.HaveLen(3))
+
+				// only the annotation changes, the service is deleted and created again
+				resynced := service.DeepCopy()
+				resynced.Annotations = map[string]string{OvnServiceResyncGeneration: "1"}
+				err = fakeOvn.controller.updateService(service, resynced)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.removedVIPs).To(gomega.ConsistOf(
+					"cluster-TCP 172.30.0.10:80",
+					"GR_node1-TCP 172.30.0.10:80",
+					"GR_node2-TCP 172.30.0.10:80",
+					"GR_node1-TCP 192.168.0.1:30080",
+					"GR_node2-TCP 192.168.0.2:30080",
+				))
+				gomega.Expect(fakeOps.rejectACLs).To(gomega.HaveLen(6))
+
+				// the same generation does not rebuild the service again
+				fakeOps.removedVIPs = nil
+				relabeled := resynced.DeepCopy()
+				relabeled.Labels = map[string]string{"app": "web"}
+				err = fakeOvn.controller.updateService(resynced, relabeled)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.removedVIPs).To(gomega.BeEmpty())
+				gomega.Expect(fakeOps.rejectACLs).To(gomega.HaveLen(6))
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("keeps the VIPs of a NodePort for the service programmed first when another service claims it", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service1 := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, NodePort: 30080, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeNodePort,
+					nil,
+				)
+				service2 := newService("service2", "namespace1", "172.30.0.20",
+					[]v1.ServicePort{{Port: 80, NodePort: 30080, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeNodePort,
+					nil,
+				)
+				endpoint1 := newEndpoints("service1", "namespace1",
+					[]v1.EndpointAddress{{IP: "10.128.0.5"}},
+					[]v1.EndpointPort{{Port: 8080, Protocol: v1.ProtocolTCP}},
+				)
+				endpoint2 := newEndpoints("service2", "namespace1",
+					[]v1.EndpointAddress{{IP: "10.128.0.6"}},
+					[]v1.EndpointPort{{Port: 8080, Protocol: v1.ProtocolTCP}},
+				)
+				vip := "GR_node1-TCP 192.168.0.1:30080"
+
+				fakeOvn.start(ctx, &v1.EndpointsList{Items: []v1.Endpoints{*endpoint1, *endpoint2}},
+					&v1.ServiceList{Items: []v1.Service{*service1, *service2}})
+				fakeOvn.controller.lbOps = fakeOps
+
+				err := fakeOvn.controller.createService(service1)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.vips[vip]).To(gomega.Equal([]string{"10.128.0.5:8080"}))
+
+				// the second service gets its ClusterIP but not the NodePort
+				err = fakeOvn.controller.createService(service2)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				err = fakeOvn.controller.AddEndpoints(endpoint2, true)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.vips[vip]).To(gomega.Equal([]string{"10.128.0.5:8080"}))
+				gomega.Expect(fakeOps.vips["cluster-TCP 172.30.0.20:80"]).To(gomega.Equal([]string{"10.128.0.6:8080"}))
+				var events []string
+				for len(fakeOvn.fakeRecorder.Events) > 0 {
+					events = append(events, <-fakeOvn.fakeRecorder.Events)
+				}
+				gomega.Expect(events).To(gomega.ContainElement("Warning DuplicateNodePort TCP NodePort 30080 " +
+					"is already allocated to service namespace1/service1 and is not configured"))
+
+				// deleting the second service leaves the NodePort alone
+				fakeOvn.controller.deleteService(service2)
+				gomega.Expect(fakeOps.removedVIPs).NotTo(gomega.ContainElement(vip))
+				gomega.Expect(fakeOps.vips[vip]).To(gomega.Equal([]string{"10.128.0.5:8080"}))
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("skips an external IP that is a node IP with a warning event", func() {
+			app.Action = func(ctx *cli.Context) error {
+				// 192.168.0.1 is the physical IP of GR_node1
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, NodePort: 30080, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeNodePort,
+					[]string{"192.0.2.10", "192.168.0.1"},
+				)
+				endpoint := newEndpoints("service1", "namespace1",
+					[]v1.EndpointAddress{{IP: "10.128.0.5"}},
+					[]v1.EndpointPort{{Port: 8080, Protocol: v1.ProtocolTCP}},
+				)
+
+				fakeOvn.start(ctx, &v1.EndpointsList{Items: []v1.Endpoints{*endpoint}},
+					&v1.ServiceList{Items: []v1.Service{*service}})
+				fakeOvn.controller.lbOps = fakeOps
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				// the rest of the service is programmed
+				for _, vip := range []string{
+					"cluster-TCP 172.30.0.10:80",
+					"GR_node1-TCP 192.0.2.10:80",
+					"GR_node2-TCP 192.0.2.10:80",
+					"GR_node1-TCP 192.168.0.1:30080",
+					"GR_node2-TCP 192.168.0.2:30080",
+				} {
+					gomega.Expect(fakeOps.vips[vip]).To(gomega.Equal([]string{"10.128.0.5:8080"}), vip)
+				}
+				gomega.Expect(fakeOps.vips).NotTo(gomega.HaveKey("GR_node1-TCP 192.168.0.1:80"))
+				gomega.Expect(fakeOps.vips).NotTo(gomega.HaveKey("GR_node2-TCP 192.168.0.1:80"))
+				var events []string
+				for len(fakeOvn.fakeRecorder.Events) > 0 {
+					events = append(events, <-fakeOvn.fakeRecorder.Events)
+				}
+				gomega.Expect(events).To(gomega.ContainElement(
+					"Warning ExternalIPIsNodeIP External IP 192.168.0.1 is a node IP and is not configured"))
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("records the app protocol of a port on its VIPs and removes it with the service", func() {
+			app.Action = func(ctx *cli.Context) error {
+				appProtocol := "kubernetes.io/h2c"
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, NodePort: 30080, Protocol: v1.ProtocolTCP, AppProtocol: &appProtocol}},
+					v1.ServiceTypeNodePort,
+					nil,
+				)
+				endpoint := newEndpoints("service1", "namespace1",
+					[]v1.EndpointAddress{{IP: "10.128.0.5"}},
+					[]v1.EndpointPort{{Port: 8080, Protocol: v1.ProtocolTCP}},
+				)
+
+				fakeOvn.start(ctx, &v1.EndpointsList{Items: []v1.Endpoints{*endpoint}},
+					&v1.ServiceList{Items: []v1.Service{*service}})
+				fakeOvn.controller.lbOps = fakeOps
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.appProtocols).To(gomega.Equal(map[string]string{
+					"cluster-TCP 172.30.0.10:80":     appProtocol,
+					"GR_node1-TCP 192.168.0.1:30080": appProtocol,
+					"GR_node2-TCP 192.168.0.2:30080": appProtocol,
+				}))
+
+				fakeOvn.controller.deleteService(service)
+				gomega.Expect(fakeOps.appProtocols).To(gomega.BeEmpty())
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("records the proxy protocol of the VIPs of a service until the annotation is removed", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, NodePort: 30080, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeNodePort,
+					nil,
+				)
+				service.Annotations = map[string]string{OvnServiceProxyProtocol: "true"}
+				endpoint := newEndpoints("service1", "namespace1",
+					[]v1.EndpointAddress{{IP: "10.128.0.5"}},
+					[]v1.EndpointPort{{Port: 8080, Protocol: v1.ProtocolTCP}},
+				)
+
+				fakeOvn.start(ctx, &v1.EndpointsList{Items: []v1.Endpoints{*endpoint}},
+					&v1.ServiceList{Items: []v1.Service{*service}})
+				fakeOvn.controller.lbOps = fakeOps
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				proxied := sets.NewString(
+					"cluster-TCP 172.30.0.10:80",
+					"GR_node1-TCP 192.168.0.1:30080",
+					"GR_node2-TCP 192.168.0.2:30080",
+				)
+				gomega.Expect(fakeOps.proxyProtocols).To(gomega.Equal(proxied))
+				targets := fakeOps.vips["cluster-TCP 172.30.0.10:80"]
+
+				unannotated := service.DeepCopy()
+				delete(unannotated.Annotations, OvnServiceProxyProtocol)
+				err = fakeOvn.controller.updateService(service, unannotated)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.proxyProtocols).To(gomega.BeEmpty())
+
+				err = fakeOvn.controller.updateService(unannotated, service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.proxyProtocols).To(gomega.Equal(proxied))
+				// the record is metadata only, the VIPs keep their targets
+				gomega.Expect(fakeOps.vips["cluster-TCP 172.30.0.10:80"]).To(gomega.Equal(targets))
+
+				fakeOvn.controller.deleteService(service)
+				gomega.Expect(fakeOps.proxyProtocols).To(gomega.BeEmpty())
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("moves the cluster VIPs of both IP families of a dual-stack service on ClusterIP changes", func() {
+			app.Action = func(ctx *cli.Context) error {
+				oldSvc := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+				oldSvc.Spec.IPFamilies = []v1.IPFamily{v1.IPv4Protocol, v1.IPv6Protocol}
+				oldSvc.Spec.ClusterIPs = []string{"172.30.0.10", "fd00:10:96::10"}
+				newSvc := oldSvc.DeepCopy()
+				newSvc.Spec.ClusterIP = "172.30.0.20"
+				newSvc.Spec.ClusterIPs = []string{"172.30.0.20", "fd00:10:96::20"}
+
+				fakeOvn.start(ctx)
+				fakeOvn.controller.lbOps = fakeOps
+
+				err := fakeOvn.controller.updateService(oldSvc, newSvc)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.removedVIPs).To(gomega.ConsistOf(
+					"cluster-TCP 172.30.0.10:80",
+					"cluster-TCP [fd00:10:96::10]:80",
+					"GR_node1-TCP 172.30.0.10:80",
+					"GR_node1-TCP [fd00:10:96::10]:80",
+					"GR_node2-TCP 172.30.0.10:80",
+					"GR_node2-TCP [fd00:10:96::10]:80",
+				))
+				gomega.Expect(fakeOps.rejectACLs).To(gomega.Equal([]string{
+					"cluster-TCP 172.30.0.20:80",
+					"cluster-TCP [fd00:10:96::20]:80",
+				}))
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("removes the VIP of a single protocol, leaving the other protocols alone", func() {
+			app.Action = func(ctx *cli.Context) error {
+				fakeOvn.start(ctx)
+				fakeOvn.controller.lbOps = fakeOps
+				fakeOps.vips = map[string][]string{
+					"cluster-TCP 172.30.0.10:53":  {"10.128.0.5:5353"},
+					"cluster-UDP 172.30.0.10:53":  {"10.128.0.5:5353"},
+					"GR_node1-TCP 172.30.0.10:53": {"10.128.0.5:5353"},
+					"GR_node1-UDP 172.30.0.10:53": {"10.128.0.5:5353"},
+				}
+
+				err := fakeOvn.controller.deleteServiceVIPs(context.Background(), []string{"172.30.0.10"}, v1.ProtocolUDP, 53)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.removedVIPs).To(gomega.Equal([]string{
+					"cluster-UDP 172.30.0.10:53",
+					"GR_node1-UDP 172.30.0.10:53",
+					"GR_node2-UDP 172.30.0.10:53",
+				}))
+				gomega.Expect(fakeOps.vips).To(gomega.Equal(map[string][]string{
+					"cluster-TCP 172.30.0.10:53":  {"10.128.0.5:5353"},
+					"GR_node1-TCP 172.30.0.10:53": {"10.128.0.5:5353"},
+				}))
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("only programs the external IPs added to or removed from a service", func() {
+			app.Action = func(ctx *cli.Context) error {
+				oldSvc := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeClusterIP,
+					[]string{"1.1.1.1"},
+				)
+				newSvc := oldSvc.DeepCopy()
+				newSvc.Spec.ExternalIPs = []string{"1.1.1.1", "2.2.2.2"}
+
+				fakeOvn.start(ctx)
+				fakeOvn.controller.lbOps = fakeOps
+
+				err := fakeOvn.controller.updateService(oldSvc, newSvc)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.removedVIPs).To(gomega.BeEmpty())
+				gomega.Expect(fakeOps.rejectACLs).To(gomega.Equal([]string{
+					"GR_node1-TCP 2.2.2.2:80",
+					"GR_node2-TCP 2.2.2.2:80",
+				}))
+
+				err = fakeOvn.controller.updateService(newSvc, oldSvc)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.removedVIPs).To(gomega.Equal([]string{
+					"GR_node1-TCP 2.2.2.2:80",
+					"GR_node2-TCP 2.2.2.2:80",
+				}))
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("removes only the VIPs of the external IP removed from a service with endpoints", func() {
+			app.Action = func(ctx *cli.Context) error {
+				oldSvc := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}, {Name: "dns", Port: 53, Protocol: v1.ProtocolUDP}},
+					v1.ServiceTypeClusterIP,
+					[]string{"1.1.1.1", "2.2.2.2"},
+				)
+				endpoint := newEndpoints("service1", "namespace1",
+					[]v1.EndpointAddress{{IP: "10.128.0.5"}},
+					[]v1.EndpointPort{{Port: 8080, Protocol: v1.ProtocolTCP}, {Name: "dns", Port: 5353, Protocol: v1.ProtocolUDP}},
+				)
+				newSvc := oldSvc.DeepCopy()
+				newSvc.Spec.ExternalIPs = []string{"1.1.1.1"}
+
+				fakeOvn.start(ctx, &v1.EndpointsList{Items: []v1.Endpoints{*endpoint}},
+					&v1.ServiceList{Items: []v1.Service{*oldSvc}})
+				fakeOvn.controller.lbOps = fakeOps
+
+				err := fakeOvn.controller.createService(oldSvc)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.vips).To(gomega.HaveKey("GR_node1-TCP 2.2.2.2:80"))
+				fakeOps.removedVIPs = nil
+
+				err = fakeOvn.controller.updateService(oldSvc, newSvc)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.removedVIPs).To(gomega.ConsistOf(
+					"GR_node1-TCP 2.2.2.2:80",
+					"GR_node2-TCP 2.2.2.2:80",
+					"GR_node1-UDP 2.2.2.2:53",
+					"GR_node2-UDP 2.2.2.2:53",
+				))
+				for _, vip := range []string{"GR_node1-TCP 1.1.1.1:80", "GR_node2-UDP 1.1.1.1:53", "cluster-TCP 172.30.0.10:80"} {
+					gomega.Expect(fakeOps.vips).To(gomega.HaveKey(vip))
+				}
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+	})
+
+	ginkgo.Context("on namespace ACL logging changes", func() {
+
+		table.DescribeTable("updates the logging of the reject ACLs of the services of the namespace",
+			func(oldAnnotation, newAnnotation, expectedLogging string) {
+				app.Action = func(ctx *cli.Context) error {
+					service := newService("service1", "namespace1", "172.30.0.10",
+						[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}},
+						v1.ServiceTypeClusterIP,
+						nil,
+					)
+					other := newService("service2", "namespace2", "172.30.0.20",
+						[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}},
+						v1.ServiceTypeClusterIP,
+						nil,
+					)
+					oldNs := newNamespace("namespace1")
+					oldNs.Annotations = map[string]string{aclLoggingAnnotation: oldAnnotation}
+					newNs := oldNs.DeepCopy()
+					newNs.Annotations = map[string]string{aclLoggingAnnotation: newAnnotation}
+
+					// only the reject ACL of the service of the namespace is updated
+					fExec.AddFakeCmdsNoOutputNoError([]string{
+						"ovn-nbctl --timeout=15 -- set acl " + fakeUUID + " " + expectedLogging,
+					})
+
+					fakeOvn.start(ctx, &v1.ServiceList{Items: []v1.Service{*service, *other}})
+					fakeOvn.controller.setServiceACLToLB(k8sTCPLoadBalancerIP, "172.30.0.10:80", fakeUUID)
+					fakeOvn.controller.setServiceACLToLB(k8sTCPLoadBalancerIP, "172.30.0.20:80", fakeUUIDv6)
+					nsInfo := fakeOvn.controller.createNamespaceLocked("namespace1")
+					fakeOvn.controller.aclLoggingCanEnable(oldAnnotation, nsInfo)
+					nsInfo.Unlock()
+
+					fakeOvn.controller.updateNamespace(oldNs, newNs)
+					gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+					return nil
+				}
+
+				err := app.Run([]string{app.Name})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			},
+			table.Entry("when deny logging is set to alert", "", `{"deny": "alert"}`,
+				"log=true severity=alert meter=acl-logging"),
+			table.Entry("when deny logging is disabled", `{"deny": "alert"}`, "",
+				"log=false severity=info meter=[]"),
+		)
+	})
+
+	ginkgo.Context("on ClusterIP changes", func() {
+
+		ginkgo.It("only rebuilds the cluster VIPs of a NodePort service", func() {
+			app.Action = func(ctx *cli.Context) error {
+				oldSvc := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, NodePort: 30080, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeNodePort,
+					nil,
+				)
+				newSvc := oldSvc.DeepCopy()
+				newSvc.Spec.ClusterIP = "172.30.0.20"
+
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
+					Output: k8sTCPLoadBalancerIP,
+				})
+				// the old VIP and its reject ACL are removed
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					fmt.Sprintf("ovn-nbctl --timeout=15 --if-exists remove load_balancer %s vips \"172.30.0.10:80\"", k8sTCPLoadBalancerIP),
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=%s-172.30.0.10\\:80", k8sTCPLoadBalancerIP),
+					Output: fakeUUID,
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}%s", k8sTCPLoadBalancerIP),
+					fmt.Sprintf("ovn-nbctl --timeout=15 -- --if-exists remove port_group %s acls %s", ovnClusterPortGroupUUID, fakeUUID),
+				})
+				// the old ClusterIP may have been on the gateway load balancer
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+					Output: "GR_node1",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:TCP_lb_gateway_router=GR_node1",
+					Output: "tcp_load_balancer_id_1",
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --if-exists remove load_balancer tcp_load_balancer_id_1 vips \"172.30.0.10:80\"",
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=tcp_load_balancer_id_1-172.30.0.10\\:80",
+				})
+				// a reject ACL is created for the new VIP
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_switch load_balancer{>=}%s", k8sTCPLoadBalancerIP),
+					Output: "62c672a4-1132-44ab-9202-e47d18784138",
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}%s", k8sTCPLoadBalancerIP),
+					fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=%s-172.30.0.20\\:80", k8sTCPLoadBalancerIP),
+					fmt.Sprintf("ovn-nbctl --timeout=15 --id=@reject-acl create acl direction="+types.DirectionFromLPort+" priority="+types.DefaultDenyPriority+" match=\"ip4.dst==172.30.0.20 && tcp "+
+						"&& tcp.dst==80\" action=reject log=false severity=info name=%s-172.30.0.20\\:80 -- add port_group %s acls @reject-acl",
+						k8sTCPLoadBalancerIP, ovnClusterPortGroupUUID),
+				})
+
+				fakeOvn.start(ctx)
+				fakeOvn.controller.clusterPortGroupUUID = ovnClusterPortGroupUUID
+
+				// no NodePort VIP command is expected, any would fail the fake exec
+				err := fakeOvn.controller.updateService(oldSvc, newSvc)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+	})
+
+	ginkgo.Context("in the service queue", func() {
+
+		ginkgo.It("coalesces a burst of updates into a single reconcile of the latest state", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeLoadBalancer,
+					nil,
+				)
+				endpoint := newEndpoints("service1", "namespace1",
+					[]v1.EndpointAddress{{IP: "10.128.0.5"}},
+					[]v1.EndpointPort{{Port: 8080, Protocol: v1.ProtocolTCP}},
+				)
+				fakeOps := &fakeServiceLBBackend{
+					gateways:    []string{"GR_node1"},
+					physicalIPs: map[string][]string{"GR_node1": {"192.168.0.1"}},
+				}
+
+				fakeOvn.start(ctx, &v1.EndpointsList{Items: []v1.Endpoints{*endpoint}},
+					&v1.ServiceList{Items: []v1.Service{*service}})
+				fakeOvn.controller.lbOps = fakeOps
+
+				// the ingress IP flips five times before the worker gets to the service
+				for i := 1; i <= 5; i++ {
+					service = service.DeepCopy()
+					service.Status.LoadBalancer.Ingress = []v1.LoadBalancerIngress{{IP: fmt.Sprintf("5.5.5.%d", i)}}
+					_, err := fakeOvn.fakeClient.KubeClient.CoreV1().Services(service.Namespace).UpdateStatus(
+						context.TODO(), service, metav1.UpdateOptions{})
+					gomega.Expect(err).NotTo(gomega.HaveOccurred())
+					fakeOvn.controller.enqueueService(service)
+				}
+				gomega.Eventually(func() []v1.LoadBalancerIngress {
+					s, err := fakeOvn.watcher.GetService(service.Namespace, service.Name)
+					if err != nil {
+						return nil
+					}
+					return s.Status.LoadBalancer.Ingress
+				}).Should(gomega.Equal(service.Status.LoadBalancer.Ingress))
+				gomega.Expect(fakeOvn.controller.serviceQueue.Len()).To(gomega.Equal(1))
+
+				gomega.Expect(fakeOvn.controller.processNextService()).To(gomega.BeTrue())
+				gomega.Expect(fakeOvn.controller.serviceQueue.Len()).To(gomega.Equal(0))
+				gomega.Expect(fakeOps.vips).To(gomega.Equal(map[string][]string{
+					"cluster-TCP 172.30.0.10:80": {"10.128.0.5:8080"},
+					"GR_node1-TCP 5.5.5.5:80":    {"10.128.0.5:8080"},
+				}))
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("reconciles the services queued before a shutdown and none after", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+				endpoint := newEndpoints("service1", "namespace1",
+					[]v1.EndpointAddress{{IP: "10.128.0.5"}},
+					[]v1.EndpointPort{{Port: 8080, Protocol: v1.ProtocolTCP}},
+				)
+				late := newService("service2", "namespace1", "172.30.0.20",
+					[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+				fakeOps := &fakeServiceLBBackend{}
+
+				fakeOvn.start(ctx, &v1.EndpointsList{Items: []v1.Endpoints{*endpoint}},
+					&v1.ServiceList{Items: []v1.Service{*service, *late}})
+				fakeOvn.controller.lbOps = fakeOps
+
+				fakeOvn.controller.enqueueService(service)
+				// what startServiceWorker does once the controller is stopped
+				fakeOvn.controller.serviceQueue.ShutDown()
+				fakeOvn.controller.enqueueService(late)
+
+				// the worker returns once the service queued before the shutdown is reconciled
+				fakeOvn.controller.runServiceWorker()
+				gomega.Expect(fakeOvn.controller.serviceQueue.Len()).To(gomega.Equal(0))
+				gomega.Expect(fakeOps.vips).To(gomega.Equal(map[string][]string{
+					"cluster-TCP 172.30.0.10:80": {"10.128.0.5:8080"},
+				}))
+				// service2, queued after the shutdown, did not get its reject ACL
+				gomega.Expect(fakeOps.rejectACLs).To(gomega.BeEmpty())
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("requeues a service whose first nbctl call fails until it is configured", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+				// the first attempt fails to look up the cluster load balancer
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd: "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
+					Err: fmt.Errorf("connection refused"),
+				})
+				// the retry creates the reject ACL of the endpoint-less service
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
+					Output: k8sTCPLoadBalancerIP,
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_switch load_balancer{>=}%s", k8sTCPLoadBalancerIP),
+					Output: "62c672a4-1132-44ab-9202-e47d18784138",
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}%s", k8sTCPLoadBalancerIP),
+					fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=%s-172.30.0.10\\:80", k8sTCPLoadBalancerIP),
+					fmt.Sprintf("ovn-nbctl --timeout=15 --id=@reject-acl create acl direction="+types.DirectionFromLPort+" priority="+types.DefaultDenyPriority+" match=\"ip4.dst==172.30.0.10 && tcp "+
+						"&& tcp.dst==80\" action=reject log=false severity=info name=%s-172.30.0.10\\:80 -- add port_group %s acls @reject-acl",
+						k8sTCPLoadBalancerIP, ovnClusterPortGroupUUID),
+				})
+
+				fakeOvn.start(ctx, &v1.ServiceList{Items: []v1.Service{*service}})
+				fakeOvn.controller.clusterPortGroupUUID = ovnClusterPortGroupUUID
+				key := service.Namespace + "/" + service.Name
+
+				fakeOvn.controller.enqueueService(service)
+				gomega.Expect(fakeOvn.controller.processNextService()).To(gomega.BeTrue())
+				gomega.Expect(fakeOvn.controller.serviceQueue.NumRequeues(key)).To(gomega.Equal(1))
+				gomega.Expect(fakeOvn.controller.programmedServices).NotTo(gomega.HaveKey(key))
+
+				// the retry is handed out once its backoff expires
+				gomega.Expect(fakeOvn.controller.processNextService()).To(gomega.BeTrue())
+				gomega.Expect(fakeOvn.controller.serviceQueue.NumRequeues(key)).To(gomega.Equal(0))
+				gomega.Expect(fakeOvn.controller.programmedServices).To(gomega.HaveKey(key))
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("retries a failed reconcile with backoff", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolSCTP}},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+
+				fakeOvn.start(ctx, &v1.ServiceList{Items: []v1.Service{*service}})
+				fakeOvn.controller.lbOps = &fakeServiceLBBackend{}
+				fakeOvn.controller.SCTPSupport = false
+
+				// creating an SCTP service fails while OVN does not support SCTP
+				fakeOvn.controller.enqueueService(service)
+				gomega.Expect(fakeOvn.controller.processNextService()).To(gomega.BeTrue())
+				key := service.Namespace + "/" + service.Name
+				gomega.Expect(fakeOvn.controller.serviceQueue.NumRequeues(key)).To(gomega.Equal(1))
+				gomega.Expect(fakeOvn.controller.programmedServices).NotTo(gomega.HaveKey(key))
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+	})
+
+	ginkgo.Context("on SCTP support changes", func() {
+
+		ginkgo.It("programs SCTP services once OVN starts supporting SCTP", func() {
+			app.Action = func(ctx *cli.Context) error {
+				const listColumnsCmd = "ovsdb-client list-columns  --data=bare --no-heading --format=json OVN_Northbound Load_Balancer"
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolSCTP}},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+				fakeOps := &fakeServiceLBBackend{}
+
+				fakeOvn.start(ctx)
+				fakeOvn.controller.lbOps = fakeOps
+				fakeOvn.controller.SCTPSupport = false
+
+				err := fakeOvn.controller.createService(service)
+				gomega.Expect(err).To(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.rejectACLs).To(gomega.BeEmpty())
+
+				// a failed query keeps the previous value
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd: listColumnsCmd,
+					Err: fmt.Errorf("connection failed"),
+				})
+				fakeOvn.controller.refreshSCTPSupport()
+				gomega.Expect(fakeOvn.controller.sctpSupported()).To(gomega.BeFalse())
+
+				// OVN is upgraded in place and now supports SCTP
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    listColumnsCmd,
+					Output: `{"data":[["_version","uuid"],["name","string"],["protocol",{"key":{"enum":["set",["sctp","tcp","udp"]],"type":"string"},"min":0}]],"headings":["Column","Type"]}`,
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-sctp=yes",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 -- create load_balancer external_ids:k8s-cluster-lb-sctp=yes protocol=sctp",
+					Output: "sctp-lb-uuid",
+				})
+				fakeOvn.controller.refreshSCTPSupport()
+				gomega.Expect(fakeOvn.controller.sctpSupported()).To(gomega.BeTrue())
+				gomega.Expect(fakeOvn.controller.SCTPLoadBalancerUUID).To(gomega.Equal("sctp-lb-uuid"))
+
+				err = fakeOvn.controller.createService(service)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fakeOps.rejectACLs).To(gomega.Equal([]string{
+					"cluster-SCTP 172.30.0.10:80",
+				}))
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+	})
+
+	ginkgo.Context("on demand reconciliation", func() {
+
+		ginkgo.It("restores a VIP removed from the database out of band", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+				endpoint := newEndpoints("service1", "namespace1",
+					[]v1.EndpointAddress{{IP: "10.128.0.5"}},
+					[]v1.EndpointPort{{Port: 8080, Protocol: v1.ProtocolTCP}},
+				)
+
+				// the VIP of the service was removed from the database while a VIP of a
+				// port that the service does not have any more was left behind
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer " + k8sTCPLoadBalancerIP + " vips",
+					Output: `{"172.30.0.10:81"="10.128.0.5:8081", "172.30.0.20:80"="10.128.0.6:8080"}`,
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer " + k8sUDPLoadBalancerIP + " vips",
+					Output: `{}`,
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --if-exists remove load_balancer " + k8sTCPLoadBalancerIP + " vips \"172.30.0.10:81\"",
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=" + k8sTCPLoadBalancerIP + "-172.30.0.10\\:81",
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=" + k8sTCPLoadBalancerIP + "-172.30.0.10\\:80",
+					"ovn-nbctl --timeout=15 set load_balancer " + k8sTCPLoadBalancerIP + " vips:\"172.30.0.10:80\"=\"10.128.0.5:8080\"",
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
+				})
+
+				fakeOvn.start(ctx,
+					&v1.ServiceList{Items: []v1.Service{*service}},
+					&v1.EndpointsList{Items: []v1.Endpoints{*endpoint}},
+				)
+				fakeOvn.controller.loadbalancerClusterCache[v1.ProtocolTCP] = k8sTCPLoadBalancerIP
+				fakeOvn.controller.loadbalancerClusterCache[v1.ProtocolUDP] = k8sUDPLoadBalancerIP
+				// the controller believes the VIP is still configured
+				fakeOvn.controller.setServiceEndpointsToLB(k8sTCPLoadBalancerIP, "172.30.0.10:80", []string{"10.128.0.5:8080"})
+
+				err := fakeOvn.controller.ReconcileService("namespace1", "service1")
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(fExec.CalledMatchesExpected()).To(gomega.BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("fails for a service that does not exist", func() {
+			app.Action = func(ctx *cli.Context) error {
+				fakeOvn.start(ctx)
+
+				err := fakeOvn.controller.ReconcileService("namespace1", "service1")
+				gomega.Expect(err).To(gomega.HaveOccurred())
+
+				return nil
+			}
+
 			err := app.Run([]string{app.Name})
 			gomega.Expect(err).NotTo(gomega.HaveOccurred())
 		})
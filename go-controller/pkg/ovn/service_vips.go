@@ -0,0 +1,343 @@
+package ovn
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/loadbalancer"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	kapi "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	utilnet "k8s.io/utils/net"
+)
+
+// VIPOp is an operation on a load balancer VIP planned by DiffServiceVIPs
+type VIPOp struct {
+	// LoadBalancer is the UUID of the load balancer
+	LoadBalancer string
+	// VIP is the IP:port of the VIP
+	VIP string
+	// Targets are the sorted IP:port targets of the VIP, empty when it is removed
+	Targets []string
+}
+
+// lbKey identifies a load balancer managed by ovn-kubernetes
+type lbKey struct {
+	role     loadbalancer.LoadBalancerRole
+	protocol kapi.Protocol
+	owner    string
+}
+
+// DiffServiceVIPs plans the operations that make the VIPs of the current load balancers (as
+// listed by loadbalancer.ListLoadBalancerVIPs) match services. endpoints are keyed by
+// namespace/name and physicalIPs by gateway router.
+//
+// A service port puts its ClusterIPs on the cluster load balancer, or on the gateway and, in
+// shared gateway mode, worker load balancers when it has host networked endpoints. Its external
+// and ingress IPs, its NodePort on every physical IP and its health check NodePort go on the
+// gateway and worker load balancers. An external IP that is a physical IP is skipped, as
+// svcExternalIPs does. A NodePort asked for by several services goes to the first of them. VIPs
+// without targets of their address family are left as they are, the reject ACLs of the service
+// take care of them. Load balancers of a gateway router missing from physicalIPs, VIPs that are
+// not IP:port and VIPs that no service could have added, as told by isServiceVIP, are left alone
+// too.
+//
+// toAdd creates or updates VIPs and toRemove removes VIPs no service asks for. Both are sorted by
+// load balancer and VIP. An error is returned, without any operation, when a service has an
+// invalid IP, as planning without the service would remove its VIPs. The targets leave out the
+// addresses terminating tells are of terminating pods, as getLbEndpoints does.
+func DiffServiceVIPs(services []*kapi.Service, endpoints map[string]*kapi.Endpoints,
+	current map[string]*loadbalancer.LoadBalancerVIPs, physicalIPs map[string][]string,
+	terminating endpointTerminatingFunc) (toAdd, toRemove []VIPOp, err error) {
+	sharedGateway := config.Gateway.Mode == config.GatewayModeShared
+	lbs := make(map[lbKey]string)
+	for lb, info := range current {
+		switch info.Role {
+		case loadbalancer.LoadBalancerRoleGateway:
+			if _, ok := physicalIPs[info.Owner]; !ok {
+				continue
+			}
+		case loadbalancer.LoadBalancerRoleWorker:
+			if _, ok := physicalIPs[util.GetGatewayRouterFromNode(info.Owner)]; !sharedGateway || !ok {
+				continue
+			}
+		}
+		lbs[lbKey{info.Role, info.Protocol, info.Owner}] = lb
+	}
+	gatewayRouters := sets.StringKeySet(physicalIPs).List()
+
+	// desired targets of each VIP of each load balancer, nil when the VIP is left as it is
+	desired := make(map[string]map[string][]string)
+	want := func(lb, ip string, port int32, targetIPs []string, targetPort int32) {
+		if lb == "" {
+			return
+		}
+		if desired[lb] == nil {
+			desired[lb] = make(map[string][]string)
+		}
+		vip := util.JoinHostPortInt32(ip, port)
+		var targets []string
+		for _, targetIP := range targetIPs {
+			if utilnet.IsIPv6String(targetIP) == utilnet.IsIPv6String(ip) {
+				targets = append(targets, util.JoinHostPortInt32(targetIP, targetPort))
+			}
+		}
+		if len(targets) == 0 {
+			if _, ok := desired[lb][vip]; !ok {
+				desired[lb][vip] = nil
+			}
+			return
+		}
+		sort.Strings(targets)
+		desired[lb][vip] = targets
+	}
+	masqueradeIPs := []string{types.V4HostMasqueradeIP, types.V6HostMasqueradeIP}
+	// the physical IPs of every gateway router, the external IPs that are node IPs
+	gatewayIPs := sets.NewString()
+	for _, ips := range physicalIPs {
+		for _, ip := range ips {
+			if parsed := net.ParseIP(ip); parsed != nil {
+				gatewayIPs.Insert(parsed.String())
+			}
+		}
+	}
+	// protocol/port of the NodePorts already given to a service
+	nodePorts := sets.NewString()
+
+	var errs []error
+	for _, service := range services {
+		if !util.ServiceTypeHasClusterIP(service) || svcSkipsLoadBalancing(service) || !util.IsClusterIPSet(service) {
+			continue
+		}
+		// external and ingress IPs of the service
+		var nodeIPs []string
+		for _, extIP := range service.Spec.ExternalIPs {
+			if ip := net.ParseIP(extIP); ip != nil && gatewayIPs.Has(ip.String()) {
+				continue
+			}
+			nodeIPs = append(nodeIPs, extIP)
+		}
+		nodeIPs = append(nodeIPs, svcIngressIPs(service)...)
+		clusterIPs := svcClusterIPs(service)
+		for _, ip := range append(append([]string{}, clusterIPs...), nodeIPs...) {
+			if net.ParseIP(ip) == nil {
+				errs = append(errs, fmt.Errorf("service %s/%s has invalid IP %q", service.Namespace, service.Name, ip))
+			}
+		}
+
+		var protoPortMap map[kapi.Protocol]map[string]lbEndpoints
+		if ep, ok := endpoints[service.Namespace+"/"+service.Name]; ok && serviceHasReadyEndpoints(ep, service) {
+			protoPortMap = getLbEndpoints(ep, service, terminating)
+		}
+		for _, svcPort := range service.Spec.Ports {
+			if err := util.ValidatePort(svcPort.Protocol, svcPort.Port); err != nil {
+				continue
+			}
+			lbEps := protoPortMap[svcPort.Protocol][svcPort.Name]
+			svcIPs := nodeIPs
+			if sharedGateway && hasHostEndpoints(lbEps.IPs) {
+				svcIPs = append(append([]string{}, clusterIPs...), nodeIPs...)
+			} else {
+				for _, clusterIP := range clusterIPs {
+					want(lbs[lbKey{loadbalancer.LoadBalancerRoleCluster, svcPort.Protocol, ""}],
+						clusterIP, svcPort.Port, lbEps.IPs, lbEps.Port)
+				}
+			}
+			hasNodePort := util.ServicePortHasNodePort(service, &svcPort) &&
+				config.Kubernetes.NodePortRange.Contains(int(svcPort.NodePort)) &&
+				!nodePorts.Has(nodePortKey(svcPort.Protocol, svcPort.NodePort))
+			if hasNodePort {
+				nodePorts.Insert(nodePortKey(svcPort.Protocol, svcPort.NodePort))
+			}
+
+			for _, gatewayRouter := range gatewayRouters {
+				gatewayLB := lbs[lbKey{loadbalancer.LoadBalancerRoleGateway, svcPort.Protocol, gatewayRouter}]
+				workerLB := lbs[lbKey{loadbalancer.LoadBalancerRoleWorker, svcPort.Protocol,
+					util.GetWorkerFromGatewayRouter(gatewayRouter)}]
+				// the gateway router reaches endpoints on its own node through the host masquerade IPs
+				gatewayTargets := util.UpdateIPsSlice(lbEps.IPs, physicalIPs[gatewayRouter], masqueradeIPs)
+				for _, ip := range svcIPs {
+					want(gatewayLB, ip, svcPort.Port, gatewayTargets, lbEps.Port)
+					want(workerLB, ip, svcPort.Port, lbEps.IPs, lbEps.Port)
+				}
+				if hasNodePort {
+					for _, ip := range physicalIPs[gatewayRouter] {
+						want(gatewayLB, ip, svcPort.NodePort, gatewayTargets, lbEps.Port)
+						want(workerLB, ip, svcPort.NodePort, lbEps.IPs, lbEps.Port)
+					}
+				}
+			}
+		}
+
+		if port := service.Spec.HealthCheckNodePort; port != 0 {
+			for _, gatewayRouter := range gatewayRouters {
+				gatewayLB := lbs[lbKey{loadbalancer.LoadBalancerRoleGateway, kapi.ProtocolTCP, gatewayRouter}]
+				for _, ip := range physicalIPs[gatewayRouter] {
+					want(gatewayLB, ip, port, masqueradeIPs, port)
+				}
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return nil, nil, utilerrors.NewAggregate(errs)
+	}
+
+	servicePorts := serviceVIPPorts(services)
+	for _, lb := range lbs {
+		for vip, targets := range current[lb].VIPs {
+			if _, _, err := util.SplitHostPortInt32(vip); err != nil {
+				continue
+			}
+			desiredTargets, ok := desired[lb][vip]
+			if !ok {
+				if !isServiceVIP(vip, current[lb].Protocol, servicePorts) {
+					klog.V(5).Infof("Leaving VIP %s of load balancer %s alone, no service could have added it", vip, lb)
+					continue
+				}
+				toRemove = append(toRemove, VIPOp{LoadBalancer: lb, VIP: vip})
+			} else if desiredTargets != nil && !sets.NewString(desiredTargets...).Equal(splitVIPTargets(targets)) {
+				toAdd = append(toAdd, VIPOp{LoadBalancer: lb, VIP: vip, Targets: desiredTargets})
+			}
+		}
+		for vip, desiredTargets := range desired[lb] {
+			if _, ok := current[lb].VIPs[vip]; !ok && desiredTargets != nil {
+				toAdd = append(toAdd, VIPOp{LoadBalancer: lb, VIP: vip, Targets: desiredTargets})
+			}
+		}
+	}
+	sortVIPOps(toAdd)
+	sortVIPOps(toRemove)
+	return toAdd, toRemove, nil
+}
+
+// serviceVIPPorts returns the ports, NodePorts and health check NodePorts of services by protocol
+func serviceVIPPorts(services []*kapi.Service) map[kapi.Protocol]sets.Int32 {
+	ports := make(map[kapi.Protocol]sets.Int32)
+	insert := func(protocol kapi.Protocol, port int32) {
+		if ports[protocol] == nil {
+			ports[protocol] = sets.NewInt32()
+		}
+		ports[protocol].Insert(port)
+	}
+	for _, service := range services {
+		for _, svcPort := range service.Spec.Ports {
+			insert(svcPort.Protocol, svcPort.Port)
+			if svcPort.NodePort != 0 {
+				insert(svcPort.Protocol, svcPort.NodePort)
+			}
+		}
+		if port := service.Spec.HealthCheckNodePort; port != 0 {
+			insert(kapi.ProtocolTCP, port)
+		}
+	}
+	return ports
+}
+
+// isServiceVIP returns whether vip, of a load balancer of protocol, may have been added for a
+// service: its IP is in a service CIDR, its port is in the NodePort range or it is one of the
+// servicePorts, as returned by serviceVIPPorts. The other VIPs were added by other components
+// sharing the load balancers, and are never removed as stale.
+func isServiceVIP(vip string, protocol kapi.Protocol, servicePorts map[kapi.Protocol]sets.Int32) bool {
+	ip, port, err := util.SplitHostPortInt32(vip)
+	if err != nil {
+		return false
+	}
+	if parsedIP := net.ParseIP(ip); parsedIP != nil {
+		for _, serviceCIDR := range config.Kubernetes.ServiceCIDRs {
+			if serviceCIDR.Contains(parsedIP) {
+				return true
+			}
+		}
+	}
+	return config.Kubernetes.NodePortRange.Contains(int(port)) || servicePorts[protocol].Has(port)
+}
+
+// splitVIPTargets returns the set of the comma separated targets of a VIP
+func splitVIPTargets(targets string) sets.String {
+	if targets == "" {
+		return sets.NewString()
+	}
+	return sets.NewString(strings.Split(targets, ",")...)
+}
+
+// sortVIPOps sorts ops by load balancer and VIP
+func sortVIPOps(ops []VIPOp) {
+	sort.Slice(ops, func(i, j int) bool {
+		if ops[i].LoadBalancer != ops[j].LoadBalancer {
+			return ops[i].LoadBalancer < ops[j].LoadBalancer
+		}
+		return ops[i].VIP < ops[j].VIP
+	})
+}
+
+// gatewayPhysicalIPs returns the physical IPs of the gateway routers owning the gateway load
+// balancers of lbs. Gateway routers whose physical IPs cannot be found are left out, so that their
+// VIPs are left as they are.
+func gatewayPhysicalIPs(gateways *gatewayCache, lbs map[string]*loadbalancer.LoadBalancerVIPs) map[string][]string {
+	physicalIPs := make(map[string][]string)
+	failed := sets.NewString()
+	for _, info := range lbs {
+		if info.Role != loadbalancer.LoadBalancerRoleGateway || failed.Has(info.Owner) {
+			continue
+		}
+		if _, ok := physicalIPs[info.Owner]; ok {
+			continue
+		}
+		ips, err := gateways.GetGatewayPhysicalIPs(info.Owner)
+		if err != nil {
+			klog.Warningf("Gateway router %s does not have physical ips, leaving its VIPs "+
+				"as they are: %v", info.Owner, err)
+			failed.Insert(info.Owner)
+			continue
+		}
+		physicalIPs[info.Owner] = ips
+	}
+	return physicalIPs
+}
+
+// reconcileServiceVIPs makes the VIPs of every load balancer match services, as planned by
+// DiffServiceVIPs. endpoints are keyed by namespace/name. The gateway routers and their physical
+// IPs come from the gateways of the reconcile. It stops once ctx is cancelled.
+func (ovn *Controller) reconcileServiceVIPs(ctx context.Context, gateways *gatewayCache, services []*kapi.Service,
+	endpoints map[string]*kapi.Endpoints) error {
+	lbs, err := loadbalancer.ListLoadBalancerVIPs(gateways.GetOvnGateways)
+	if err != nil {
+		return fmt.Errorf("failed to list the load balancers: %v", err)
+	}
+	toAdd, toRemove, err := DiffServiceVIPs(services, endpoints, lbs, gatewayPhysicalIPs(gateways, lbs),
+		ovn.endpointTerminating)
+	if err != nil {
+		return fmt.Errorf("failed to plan the service VIPs: %v", err)
+	}
+	var errs []error
+	for _, op := range toRemove {
+		info := lbs[op.LoadBalancer]
+		klog.Infof("Service Sync: Removing stale VIP %s from %s %s load balancer %s %s",
+			op.VIP, info.Role, info.Protocol, op.LoadBalancer, info.Owner)
+		if err := ovn.lbOps.RemoveVIP(ctx, op.LoadBalancer, op.VIP); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, op := range toAdd {
+		ip, port, err := util.SplitHostPortInt32(op.VIP)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		info := lbs[op.LoadBalancer]
+		klog.Infof("Service Sync: Setting VIP %s of %s %s load balancer %s %s to %s",
+			op.VIP, info.Role, info.Protocol, op.LoadBalancer, info.Owner, strings.Join(op.Targets, ","))
+		if err := ovn.setLoadBalancerVIP(ctx, op.LoadBalancer, ip, port, op.Targets); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
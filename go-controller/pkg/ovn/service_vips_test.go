@@ -0,0 +1,259 @@
+package ovn
+
+import (
+	"net"
+	"testing"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/loadbalancer"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestDiffServiceVIPs(t *testing.T) {
+	clusterLB := func(vips map[string]string) *loadbalancer.LoadBalancerVIPs {
+		return &loadbalancer.LoadBalancerVIPs{Role: loadbalancer.LoadBalancerRoleCluster, Protocol: v1.ProtocolTCP, VIPs: vips}
+	}
+	gatewayLB := func(owner string, vips map[string]string) *loadbalancer.LoadBalancerVIPs {
+		return &loadbalancer.LoadBalancerVIPs{Role: loadbalancer.LoadBalancerRoleGateway, Protocol: v1.ProtocolTCP, Owner: owner, VIPs: vips}
+	}
+	workerLB := func(owner string, vips map[string]string) *loadbalancer.LoadBalancerVIPs {
+		return &loadbalancer.LoadBalancerVIPs{Role: loadbalancer.LoadBalancerRoleWorker, Protocol: v1.ProtocolTCP, Owner: owner, VIPs: vips}
+	}
+	clusterIPService := newService("service1", "namespace1", "10.96.0.10",
+		[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}}, v1.ServiceTypeClusterIP, nil)
+	nodePortService := newService("service1", "namespace1", "10.96.0.10",
+		[]v1.ServicePort{{Port: 80, NodePort: 30080, Protocol: v1.ProtocolTCP}}, v1.ServiceTypeNodePort, nil)
+	outOfRangeService := newService("service1", "namespace1", "10.96.0.10",
+		[]v1.ServicePort{{Port: 80, NodePort: 8080, Protocol: v1.ProtocolTCP}}, v1.ServiceTypeNodePort, nil)
+	externalIPService := newService("service1", "namespace1", "10.96.0.10",
+		[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}}, v1.ServiceTypeClusterIP, []string{"1.1.1.1"})
+	nodeIPService := newService("service1", "namespace1", "10.96.0.10",
+		[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}}, v1.ServiceTypeClusterIP, []string{"1.1.1.1", "192.168.0.1"})
+	invalidService := newService("service1", "namespace1", "10.96.0.10",
+		[]v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}}, v1.ServiceTypeClusterIP, []string{"1.1.1"})
+	duplicateNodePortService := newService("service2", "namespace1", "10.96.0.20",
+		[]v1.ServicePort{{Port: 80, NodePort: 30080, Protocol: v1.ProtocolTCP}}, v1.ServiceTypeNodePort, nil)
+	podEndpoints := map[string]*v1.Endpoints{
+		"namespace1/service1": newEndpoints("service1", "namespace1",
+			[]v1.EndpointAddress{{IP: "10.128.0.5"}}, []v1.EndpointPort{{Port: 8080, Protocol: v1.ProtocolTCP}}),
+	}
+	hostEndpoints := map[string]*v1.Endpoints{
+		"namespace1/service1": newEndpoints("service1", "namespace1",
+			[]v1.EndpointAddress{{IP: "192.168.0.1"}}, []v1.EndpointPort{{Port: 8080, Protocol: v1.ProtocolTCP}}),
+	}
+	physicalIPs := map[string][]string{"GR_node1": {"192.168.0.1"}}
+
+	testcases := []struct {
+		desc         string
+		gatewayMode  config.GatewayMode
+		services     []*v1.Service
+		endpoints    map[string]*v1.Endpoints
+		current      map[string]*loadbalancer.LoadBalancerVIPs
+		physicalIPs  map[string][]string
+		expectAdd    []VIPOp
+		expectRemove []VIPOp
+		expectErr    bool
+	}{
+		{
+			desc:      "adds a missing ClusterIP VIP",
+			services:  []*v1.Service{clusterIPService},
+			endpoints: podEndpoints,
+			current:   map[string]*loadbalancer.LoadBalancerVIPs{"cluster-tcp": clusterLB(map[string]string{})},
+			expectAdd: []VIPOp{{LoadBalancer: "cluster-tcp", VIP: "10.96.0.10:80", Targets: []string{"10.128.0.5:8080"}}},
+		},
+		{
+			desc:      "leaves a ClusterIP VIP with the same targets alone",
+			services:  []*v1.Service{clusterIPService},
+			endpoints: podEndpoints,
+			current: map[string]*loadbalancer.LoadBalancerVIPs{
+				"cluster-tcp": clusterLB(map[string]string{"10.96.0.10:80": "10.128.0.5:8080"}),
+			},
+		},
+		{
+			desc:      "updates a ClusterIP VIP with stale targets",
+			services:  []*v1.Service{clusterIPService},
+			endpoints: podEndpoints,
+			current: map[string]*loadbalancer.LoadBalancerVIPs{
+				"cluster-tcp": clusterLB(map[string]string{"10.96.0.10:80": "10.128.0.4:8080,10.128.0.5:8080"}),
+			},
+			expectAdd: []VIPOp{{LoadBalancer: "cluster-tcp", VIP: "10.96.0.10:80", Targets: []string{"10.128.0.5:8080"}}},
+		},
+		{
+			desc:      "removes the ClusterIP VIP of a deleted service",
+			services:  []*v1.Service{clusterIPService},
+			endpoints: podEndpoints,
+			current: map[string]*loadbalancer.LoadBalancerVIPs{
+				"cluster-tcp": clusterLB(map[string]string{
+					"10.96.0.10:80": "10.128.0.5:8080",
+					"10.96.0.99:80": "10.128.0.9:8080",
+				}),
+			},
+			expectRemove: []VIPOp{{LoadBalancer: "cluster-tcp", VIP: "10.96.0.99:80"}},
+		},
+		{
+			desc:     "leaves the ClusterIP VIP of a service without endpoints alone",
+			services: []*v1.Service{clusterIPService},
+			current: map[string]*loadbalancer.LoadBalancerVIPs{
+				"cluster-tcp": clusterLB(map[string]string{"10.96.0.10:80": ""}),
+			},
+		},
+		{
+			desc:        "adds the NodePort VIPs of the gateway routers and removes a stale NodePort VIP",
+			services:    []*v1.Service{nodePortService},
+			endpoints:   podEndpoints,
+			physicalIPs: physicalIPs,
+			current: map[string]*loadbalancer.LoadBalancerVIPs{
+				"cluster-tcp": clusterLB(map[string]string{"10.96.0.10:80": "10.128.0.5:8080"}),
+				"gr-tcp":      gatewayLB("GR_node1", map[string]string{"192.168.0.1:30081": "10.128.0.5:8080"}),
+			},
+			expectAdd:    []VIPOp{{LoadBalancer: "gr-tcp", VIP: "192.168.0.1:30080", Targets: []string{"10.128.0.5:8080"}}},
+			expectRemove: []VIPOp{{LoadBalancer: "gr-tcp", VIP: "192.168.0.1:30081"}},
+		},
+		{
+			desc:        "targets the host masquerade IP from the gateway router for an endpoint on the node",
+			services:    []*v1.Service{nodePortService},
+			endpoints:   hostEndpoints,
+			physicalIPs: physicalIPs,
+			current: map[string]*loadbalancer.LoadBalancerVIPs{
+				"cluster-tcp": clusterLB(map[string]string{}),
+				"gr-tcp":      gatewayLB("GR_node1", map[string]string{}),
+			},
+			expectAdd: []VIPOp{
+				{LoadBalancer: "cluster-tcp", VIP: "10.96.0.10:80", Targets: []string{"192.168.0.1:8080"}},
+				{LoadBalancer: "gr-tcp", VIP: "192.168.0.1:30080", Targets: []string{types.V4HostMasqueradeIP + ":8080"}},
+			},
+		},
+		{
+			desc:     "keeps the NodePort VIPs of the first service asking for the NodePort",
+			services: []*v1.Service{nodePortService, duplicateNodePortService},
+			endpoints: map[string]*v1.Endpoints{
+				"namespace1/service1": podEndpoints["namespace1/service1"],
+				"namespace1/service2": newEndpoints("service2", "namespace1",
+					[]v1.EndpointAddress{{IP: "10.128.0.6"}}, []v1.EndpointPort{{Port: 8080, Protocol: v1.ProtocolTCP}}),
+			},
+			physicalIPs: physicalIPs,
+			current: map[string]*loadbalancer.LoadBalancerVIPs{
+				"cluster-tcp": clusterLB(map[string]string{"10.96.0.10:80": "10.128.0.5:8080"}),
+				"gr-tcp":      gatewayLB("GR_node1", map[string]string{"192.168.0.1:30080": "10.128.0.5:8080"}),
+			},
+			expectAdd: []VIPOp{{LoadBalancer: "cluster-tcp", VIP: "10.96.0.20:80", Targets: []string{"10.128.0.6:8080"}}},
+		},
+		{
+			desc:        "removes the VIP of a NodePort outside of the node port range",
+			services:    []*v1.Service{outOfRangeService},
+			endpoints:   podEndpoints,
+			physicalIPs: physicalIPs,
+			current: map[string]*loadbalancer.LoadBalancerVIPs{
+				"cluster-tcp": clusterLB(map[string]string{"10.96.0.10:80": "10.128.0.5:8080"}),
+				"gr-tcp":      gatewayLB("GR_node1", map[string]string{"192.168.0.1:8080": "10.128.0.5:8080"}),
+			},
+			expectRemove: []VIPOp{{LoadBalancer: "gr-tcp", VIP: "192.168.0.1:8080"}},
+		},
+		{
+			desc:        "leaves the load balancer of a gateway router without physical IPs alone",
+			services:    []*v1.Service{nodePortService},
+			endpoints:   podEndpoints,
+			physicalIPs: physicalIPs,
+			current: map[string]*loadbalancer.LoadBalancerVIPs{
+				"cluster-tcp": clusterLB(map[string]string{"10.96.0.10:80": "10.128.0.5:8080"}),
+				"gr-tcp":      gatewayLB("GR_node1", map[string]string{"192.168.0.1:30080": "10.128.0.5:8080"}),
+				"gr2-tcp":     gatewayLB("GR_node2", map[string]string{"192.168.0.2:30081": "10.128.0.5:8080"}),
+			},
+		},
+		{
+			desc:        "moves an ExternalIP VIP from the cluster load balancer to the gateway and worker ones",
+			gatewayMode: config.GatewayModeShared,
+			services:    []*v1.Service{externalIPService},
+			endpoints:   podEndpoints,
+			physicalIPs: physicalIPs,
+			current: map[string]*loadbalancer.LoadBalancerVIPs{
+				"cluster-tcp": clusterLB(map[string]string{
+					"10.96.0.10:80": "10.128.0.5:8080",
+					"1.1.1.1:80":    "10.128.0.5:8080",
+				}),
+				"gr-tcp":     gatewayLB("GR_node1", map[string]string{}),
+				"worker-tcp": workerLB("node1", map[string]string{}),
+			},
+			expectAdd: []VIPOp{
+				{LoadBalancer: "gr-tcp", VIP: "1.1.1.1:80", Targets: []string{"10.128.0.5:8080"}},
+				{LoadBalancer: "worker-tcp", VIP: "1.1.1.1:80", Targets: []string{"10.128.0.5:8080"}},
+			},
+			expectRemove: []VIPOp{{LoadBalancer: "cluster-tcp", VIP: "1.1.1.1:80"}},
+		},
+		{
+			desc:        "ignores the worker load balancers in local gateway mode",
+			gatewayMode: config.GatewayModeLocal,
+			services:    []*v1.Service{externalIPService},
+			endpoints:   podEndpoints,
+			physicalIPs: physicalIPs,
+			current: map[string]*loadbalancer.LoadBalancerVIPs{
+				"cluster-tcp": clusterLB(map[string]string{"10.96.0.10:80": "10.128.0.5:8080"}),
+				"gr-tcp":      gatewayLB("GR_node1", map[string]string{"1.1.1.1:80": "10.128.0.5:8080"}),
+				"worker-tcp":  workerLB("node1", map[string]string{"10.96.0.99:80": "10.128.0.5:8080"}),
+			},
+		},
+		{
+			desc:        "skips an ExternalIP that is a physical IP and removes its VIP",
+			gatewayMode: config.GatewayModeLocal,
+			services:    []*v1.Service{nodeIPService},
+			endpoints:   podEndpoints,
+			physicalIPs: physicalIPs,
+			current: map[string]*loadbalancer.LoadBalancerVIPs{
+				"cluster-tcp": clusterLB(map[string]string{"10.96.0.10:80": "10.128.0.5:8080"}),
+				"gr-tcp": gatewayLB("GR_node1", map[string]string{
+					"1.1.1.1:80":     "10.128.0.5:8080",
+					"192.168.0.1:80": "10.128.0.5:8080",
+				}),
+			},
+			expectRemove: []VIPOp{{LoadBalancer: "gr-tcp", VIP: "192.168.0.1:80"}},
+		},
+		{
+			desc:        "moves the ClusterIP VIP of host networked endpoints to the gateway and worker load balancers",
+			gatewayMode: config.GatewayModeShared,
+			services:    []*v1.Service{clusterIPService},
+			endpoints:   hostEndpoints,
+			physicalIPs: physicalIPs,
+			current: map[string]*loadbalancer.LoadBalancerVIPs{
+				"cluster-tcp": clusterLB(map[string]string{"10.96.0.10:80": "192.168.0.1:8080"}),
+				"gr-tcp":      gatewayLB("GR_node1", map[string]string{}),
+				"worker-tcp":  workerLB("node1", map[string]string{}),
+			},
+			expectAdd: []VIPOp{
+				{LoadBalancer: "gr-tcp", VIP: "10.96.0.10:80", Targets: []string{types.V4HostMasqueradeIP + ":8080"}},
+				{LoadBalancer: "worker-tcp", VIP: "10.96.0.10:80", Targets: []string{"192.168.0.1:8080"}},
+			},
+			expectRemove: []VIPOp{{LoadBalancer: "cluster-tcp", VIP: "10.96.0.10:80"}},
+		},
+		{
+			desc:      "fails on a service with an invalid ExternalIP",
+			services:  []*v1.Service{invalidService},
+			endpoints: podEndpoints,
+			current: map[string]*loadbalancer.LoadBalancerVIPs{
+				"cluster-tcp": clusterLB(map[string]string{"10.96.0.99:80": "10.128.0.9:8080"}),
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.desc, func(t *testing.T) {
+			config.PrepareTestConfig()
+			_, clusterSubnet, _ := net.ParseCIDR("10.128.0.0/14")
+			config.Default.ClusterSubnets = []config.CIDRNetworkEntry{{CIDR: clusterSubnet, HostSubnetLength: 24}}
+			config.Gateway.Mode = tc.gatewayMode
+
+			toAdd, toRemove, err := DiffServiceVIPs(tc.services, tc.endpoints, tc.current, tc.physicalIPs, nil)
+			if tc.expectErr {
+				assert.Error(t, err)
+				assert.Empty(t, toAdd)
+				assert.Empty(t, toRemove)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectAdd, toAdd)
+			assert.Equal(t, tc.expectRemove, toRemove)
+		})
+	}
+}
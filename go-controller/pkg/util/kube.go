@@ -3,6 +3,7 @@ package util
 import (
 	"encoding/json"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -18,6 +19,7 @@ import (
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/cert"
 	"k8s.io/klog/v2"
+	utilnet "k8s.io/utils/net"
 
 	egressfirewallclientset "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressfirewall/v1/apis/clientset/versioned"
 	egressipclientset "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1/apis/clientset/versioned"
@@ -159,6 +161,70 @@ func GetClusterIPs(service *kapi.Service) []string {
 	return []string{}
 }
 
+// GetServiceIPFamilies returns the IP families of service, in order: those of Spec.IPFamilies, only
+// the first of them for a SingleStack service, or the ones of the ClusterIPs for services created
+// before Spec.IPFamilies existed. Families without a ClusterIP are left out, as they have no VIP.
+func GetServiceIPFamilies(service *kapi.Service) []kapi.IPFamily {
+	clusterIPFamilies := make(map[kapi.IPFamily]bool)
+	var families []kapi.IPFamily
+	for _, clusterIP := range GetClusterIPs(service) {
+		ip := net.ParseIP(clusterIP)
+		if ip == nil {
+			continue
+		}
+		family := kapi.IPv4Protocol
+		if utilnet.IsIPv6(ip) {
+			family = kapi.IPv6Protocol
+		}
+		if !clusterIPFamilies[family] {
+			clusterIPFamilies[family] = true
+			families = append(families, family)
+		}
+	}
+	if len(service.Spec.IPFamilies) == 0 {
+		return families
+	}
+	families = nil
+	for _, family := range service.Spec.IPFamilies {
+		if clusterIPFamilies[family] {
+			families = append(families, family)
+		}
+	}
+	policy := service.Spec.IPFamilyPolicy
+	if policy != nil && *policy == kapi.IPFamilyPolicySingleStack && len(families) > 1 {
+		families = families[:1]
+	}
+	return families
+}
+
+// FilterIPsByServiceFamilies returns the IPs of ips of the IP families of service, as returned by
+// GetServiceIPFamilies. ips are returned as they are when service has no ClusterIP.
+func FilterIPsByServiceFamilies(service *kapi.Service, ips []string) []string {
+	families := GetServiceIPFamilies(service)
+	if len(families) == 0 {
+		return ips
+	}
+	var hasIPv4, hasIPv6 bool
+	for _, family := range families {
+		if family == kapi.IPv6Protocol {
+			hasIPv6 = true
+		} else {
+			hasIPv4 = true
+		}
+	}
+	filtered := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		if utilnet.IsIPv6String(ip) {
+			if hasIPv6 {
+				filtered = append(filtered, ip)
+			}
+		} else if hasIPv4 {
+			filtered = append(filtered, ip)
+		}
+	}
+	return filtered
+}
+
 // ValidatePort checks if the port is non-zero and port protocol is valid
 func ValidatePort(proto kapi.Protocol, port int32) error {
 	if port <= 0 || port > 65535 {
@@ -180,9 +246,19 @@ func ServiceTypeHasClusterIP(service *kapi.Service) bool {
 	return service.Spec.Type == kapi.ServiceTypeClusterIP || service.Spec.Type == kapi.ServiceTypeNodePort || service.Spec.Type == kapi.ServiceTypeLoadBalancer
 }
 
-// ServiceTypeHasNodePort checks if the service has an associated NodePort or not
+// ServiceTypeHasNodePort checks if the service has an associated NodePort or not. A LoadBalancer
+// service with allocateLoadBalancerNodePorts set to false has none, even when its ports kept the
+// NodePorts allocated before the field was changed.
 func ServiceTypeHasNodePort(service *kapi.Service) bool {
-	return service.Spec.Type == kapi.ServiceTypeNodePort || service.Spec.Type == kapi.ServiceTypeLoadBalancer
+	if service.Spec.Type == kapi.ServiceTypeLoadBalancer {
+		return service.Spec.AllocateLoadBalancerNodePorts == nil || *service.Spec.AllocateLoadBalancerNodePorts
+	}
+	return service.Spec.Type == kapi.ServiceTypeNodePort
+}
+
+// ServicePortHasNodePort checks if the service port has an allocated NodePort
+func ServicePortHasNodePort(service *kapi.Service, svcPort *kapi.ServicePort) bool {
+	return ServiceTypeHasNodePort(service) && svcPort.NodePort != 0
 }
 
 // GetNodePrimaryIP extracts the primary IP address from the node status in the  API
@@ -118,6 +118,103 @@ func TestIsClusterIPSet(t *testing.T) {
 	}
 }
 
+func TestGetServiceIPFamilies(t *testing.T) {
+	singleStack := v1.IPFamilyPolicySingleStack
+	preferDualStack := v1.IPFamilyPolicyPreferDualStack
+	tests := []struct {
+		desc   string
+		inp    v1.ServiceSpec
+		expOut []v1.IPFamily
+	}{
+		{
+			desc:   "families of the ClusterIP of a service without IPFamilies",
+			inp:    v1.ServiceSpec{ClusterIP: "fd00:10:96::1"},
+			expOut: []v1.IPFamily{v1.IPv6Protocol},
+		},
+		{
+			desc:   "no family for a headless service",
+			inp:    v1.ServiceSpec{ClusterIP: v1.ClusterIPNone, ClusterIPs: []string{v1.ClusterIPNone}},
+			expOut: nil,
+		},
+		{
+			desc: "IPFamilies in order for a dual stack service",
+			inp: v1.ServiceSpec{
+				ClusterIP:      "fd00:10:96::1",
+				ClusterIPs:     []string{"fd00:10:96::1", "10.96.0.1"},
+				IPFamilies:     []v1.IPFamily{v1.IPv6Protocol, v1.IPv4Protocol},
+				IPFamilyPolicy: &preferDualStack,
+			},
+			expOut: []v1.IPFamily{v1.IPv6Protocol, v1.IPv4Protocol},
+		},
+		{
+			desc: "only the first family for a SingleStack service",
+			inp: v1.ServiceSpec{
+				ClusterIP:      "fd00:10:96::1",
+				ClusterIPs:     []string{"fd00:10:96::1", "10.96.0.1"},
+				IPFamilies:     []v1.IPFamily{v1.IPv6Protocol, v1.IPv4Protocol},
+				IPFamilyPolicy: &singleStack,
+			},
+			expOut: []v1.IPFamily{v1.IPv6Protocol},
+		},
+		{
+			desc: "no family without a ClusterIP",
+			inp: v1.ServiceSpec{
+				ClusterIP:  "10.96.0.1",
+				ClusterIPs: []string{"10.96.0.1"},
+				IPFamilies: []v1.IPFamily{v1.IPv4Protocol, v1.IPv6Protocol},
+			},
+			expOut: []v1.IPFamily{v1.IPv4Protocol},
+		},
+	}
+	for i, tc := range tests {
+		t.Run(fmt.Sprintf("%d:%s", i, tc.desc), func(t *testing.T) {
+			res := GetServiceIPFamilies(&v1.Service{Spec: tc.inp})
+			assert.Equal(t, tc.expOut, res)
+		})
+	}
+}
+
+func TestFilterIPsByServiceFamilies(t *testing.T) {
+	singleStack := v1.IPFamilyPolicySingleStack
+	ips := []string{"1.1.1.1", "2001:db8::1"}
+	tests := []struct {
+		desc   string
+		inp    v1.ServiceSpec
+		expOut []string
+	}{
+		{
+			desc: "IPv6 IPs of an IPv6 SingleStack service",
+			inp: v1.ServiceSpec{
+				ClusterIP:      "fd00:10:96::1",
+				ClusterIPs:     []string{"fd00:10:96::1"},
+				IPFamilies:     []v1.IPFamily{v1.IPv6Protocol},
+				IPFamilyPolicy: &singleStack,
+			},
+			expOut: []string{"2001:db8::1"},
+		},
+		{
+			desc: "all the IPs of a dual stack service",
+			inp: v1.ServiceSpec{
+				ClusterIP:  "10.96.0.1",
+				ClusterIPs: []string{"10.96.0.1", "fd00:10:96::1"},
+				IPFamilies: []v1.IPFamily{v1.IPv4Protocol, v1.IPv6Protocol},
+			},
+			expOut: ips,
+		},
+		{
+			desc:   "all the IPs of a service without ClusterIP",
+			inp:    v1.ServiceSpec{ClusterIP: v1.ClusterIPNone},
+			expOut: ips,
+		},
+	}
+	for i, tc := range tests {
+		t.Run(fmt.Sprintf("%d:%s", i, tc.desc), func(t *testing.T) {
+			res := FilterIPsByServiceFamilies(&v1.Service{Spec: tc.inp}, ips)
+			assert.Equal(t, tc.expOut, res)
+		})
+	}
+}
+
 func TestValidateProtocol(t *testing.T) {
 	tests := []struct {
 		desc   string
@@ -200,6 +297,7 @@ func TestServiceTypeHasClusterIP(t *testing.T) {
 }
 
 func TestServiceTypeHasNodePort(t *testing.T) {
+	allocateNodePorts := false
 	tests := []struct {
 		desc   string
 		inp    v1.Service
@@ -232,6 +330,16 @@ func TestServiceTypeHasNodePort(t *testing.T) {
 			},
 			expOut: true,
 		},
+		{
+			desc: "false: test when Type set to `LoadBalancer` with allocateLoadBalancerNodePorts false",
+			inp: v1.Service{
+				Spec: v1.ServiceSpec{
+					Type:                          "LoadBalancer",
+					AllocateLoadBalancerNodePorts: &allocateNodePorts,
+				},
+			},
+			expOut: false,
+		},
 	}
 
 	for i, tc := range tests {
@@ -242,6 +350,65 @@ func TestServiceTypeHasNodePort(t *testing.T) {
 	}
 }
 
+func TestServicePortHasNodePort(t *testing.T) {
+	allocateNodePorts := false
+	tests := []struct {
+		desc    string
+		inp     v1.Service
+		inpPort v1.ServicePort
+		expOut  bool
+	}{
+		{
+			desc: "false: test when Type set to `ClusterIP`",
+			inp: v1.Service{
+				Spec: v1.ServiceSpec{
+					Type: "ClusterIP",
+				},
+			},
+			inpPort: v1.ServicePort{Port: 80},
+			expOut:  false,
+		},
+		{
+			desc: "true: test when Type set to `NodePort`",
+			inp: v1.Service{
+				Spec: v1.ServiceSpec{
+					Type: "NodePort",
+				},
+			},
+			inpPort: v1.ServicePort{Port: 80, NodePort: 30080},
+			expOut:  true,
+		},
+		{
+			desc: "false: test when Type set to `LoadBalancer` without node ports",
+			inp: v1.Service{
+				Spec: v1.ServiceSpec{
+					Type: "LoadBalancer",
+				},
+			},
+			inpPort: v1.ServicePort{Port: 80},
+			expOut:  false,
+		},
+		{
+			desc: "false: test when Type set to `LoadBalancer` keeping node ports with allocateLoadBalancerNodePorts false",
+			inp: v1.Service{
+				Spec: v1.ServiceSpec{
+					Type:                          "LoadBalancer",
+					AllocateLoadBalancerNodePorts: &allocateNodePorts,
+				},
+			},
+			inpPort: v1.ServicePort{Port: 80, NodePort: 30080},
+			expOut:  false,
+		},
+	}
+
+	for i, tc := range tests {
+		t.Run(fmt.Sprintf("%d:%s", i, tc.desc), func(t *testing.T) {
+			res := ServicePortHasNodePort(&tc.inp, &tc.inpPort)
+			assert.Equal(t, res, tc.expOut)
+		})
+	}
+}
+
 func TestGetNodePrimaryIP(t *testing.T) {
 	tests := []struct {
 		desc   string
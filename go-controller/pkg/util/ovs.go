@@ -2,6 +2,7 @@ package util
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"math"
@@ -82,6 +83,35 @@ func PrepareTestConfig() {
 // all the ovn-nbctl/ovn-sbctl calls occur on the master
 var MetricOvnCliLatency *prometheus.HistogramVec
 
+// MetricNbctlCommandDuration is the latency of ovn-nbctl commands by verb and status.
+// Like MetricOvnCliLatency it is set only for the ovnkube in master mode
+var MetricNbctlCommandDuration *prometheus.HistogramVec
+
+// nbctlMetricVerbs are the ovn-nbctl verbs reported by MetricNbctlCommandDuration,
+// any other verb is reported as "other" to keep the cardinality bounded
+var nbctlMetricVerbs = map[string]bool{
+	"find":   true,
+	"get":    true,
+	"set":    true,
+	"remove": true,
+	"create": true,
+}
+
+// nbctlCommandVerb returns the verb of the first ovn-nbctl command in args, skipping
+// options, as reported by MetricNbctlCommandDuration
+func nbctlCommandVerb(args []string) string {
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		if nbctlMetricVerbs[arg] {
+			return arg
+		}
+		break
+	}
+	return "other"
+}
+
 func runningPlatform() (string, error) {
 	if runtime.GOOS == windowsOS {
 		return windowsOS, nil
@@ -311,7 +341,18 @@ func run(cmdPath string, args ...string) (*bytes.Buffer, *bytes.Buffer, error) {
 }
 
 func runWithEnvVars(cmdPath string, envVars []string, args ...string) (*bytes.Buffer, *bytes.Buffer, error) {
-	cmd := runner.exec.Command(cmdPath, args...)
+	return runWithEnvVarsContext(context.Background(), cmdPath, envVars, args...)
+}
+
+// runWithEnvVarsContext runs a command that is killed once ctx is cancelled
+func runWithEnvVarsContext(ctx context.Context, cmdPath string, envVars []string, args ...string) (*bytes.Buffer, *bytes.Buffer, error) {
+	var cmd kexec.Cmd
+	if ctx.Done() == nil {
+		// the context can never be cancelled, so there is no child process to kill
+		cmd = runner.exec.Command(cmdPath, args...)
+	} else {
+		cmd = runner.exec.CommandContext(ctx, cmdPath, args...)
+	}
 	return runCmdExecRunner.RunCmd(cmd, cmdPath, envVars, args...)
 }
 
@@ -360,13 +401,22 @@ func RunOVNAppctlWithTimeout(timeout int, args ...string) (string, string, error
 // Run the ovn-ctl command and retry if "Connection refused"
 // poll waitng for service to become available
 func runOVNretry(cmdPath string, envVars []string, args ...string) (*bytes.Buffer, *bytes.Buffer, error) {
+	return runOVNretryContext(context.Background(), cmdPath, envVars, args...)
+}
+
+// runOVNretryContext is runOVNretry giving up, and killing the running command, once ctx
+// is cancelled
+func runOVNretryContext(ctx context.Context, cmdPath string, envVars []string, args ...string) (*bytes.Buffer, *bytes.Buffer, error) {
 
 	retriesLeft := ovnCmdRetryCount
 	for {
-		stdout, stderr, err := runWithEnvVars(cmdPath, envVars, args...)
+		stdout, stderr, err := runWithEnvVarsContext(ctx, cmdPath, envVars, args...)
 		if err == nil {
 			return stdout, stderr, err
 		}
+		if ctx.Err() != nil {
+			return stdout, stderr, fmt.Errorf("OVN command '%s %s' aborted: %w", cmdPath, strings.Join(args, " "), ctx.Err())
+		}
 
 		// Connection refused
 		// Master may not be up so keep trying
@@ -375,7 +425,11 @@ func runOVNretry(cmdPath string, envVars []string, args ...string) (*bytes.Buffe
 				return stdout, stderr, err
 			}
 			retriesLeft--
-			time.Sleep(2 * time.Second)
+			select {
+			case <-time.After(2 * time.Second):
+			case <-ctx.Done():
+				return stdout, stderr, fmt.Errorf("OVN command '%s %s' aborted: %w", cmdPath, strings.Join(args, " "), ctx.Err())
+			}
 		} else {
 			// Some other problem for caller to handle
 			return stdout, stderr, fmt.Errorf("OVN command '%s %s' failed: %s", cmdPath, strings.Join(args, " "), err)
@@ -474,18 +528,32 @@ func RunOVNNbctlUnix(args ...string) (string, string, error) {
 
 // RunOVNNbctlWithTimeout runs command via ovn-nbctl with a specific timeout
 func RunOVNNbctlWithTimeout(timeout int, args ...string) (string, string, error) {
+	return runOVNNbctlWithTimeoutContext(context.Background(), timeout, args...)
+}
+
+func runOVNNbctlWithTimeoutContext(ctx context.Context, timeout int, args ...string) (string, string, error) {
 	cmdArgs, envVars := getNbctlArgsAndEnv(timeout, args...)
 	start := time.Now()
-	stdout, stderr, err := runOVNretry(runner.nbctlPath, envVars, cmdArgs...)
+	stdout, stderr, err := runOVNretryContext(ctx, runner.nbctlPath, envVars, cmdArgs...)
+	duration := time.Since(start).Seconds()
 	if MetricOvnCliLatency != nil {
-		MetricOvnCliLatency.WithLabelValues("ovn-nbctl").Observe(time.Since(start).Seconds())
+		MetricOvnCliLatency.WithLabelValues("ovn-nbctl").Observe(duration)
+	}
+	if MetricNbctlCommandDuration != nil {
+		MetricNbctlCommandDuration.WithLabelValues(nbctlCommandVerb(args), strconv.FormatBool(err == nil)).Observe(duration)
 	}
 	return strings.Trim(strings.TrimSpace(stdout.String()), "\""), stderr.String(), err
 }
 
 // RunOVNNbctl runs a command via ovn-nbctl.
 func RunOVNNbctl(args ...string) (string, string, error) {
-	return RunOVNNbctlWithTimeout(ovsCommandTimeout, args...)
+	return RunOVNNbctlContext(context.Background(), args...)
+}
+
+// RunOVNNbctlContext runs a command via ovn-nbctl, killing it once ctx is cancelled. The
+// error then wraps the error of ctx.
+func RunOVNNbctlContext(ctx context.Context, args ...string) (string, string, error) {
+	return runOVNNbctlWithTimeoutContext(ctx, ovsCommandTimeout, args...)
 }
 
 // RunOVNSbctlUnix runs command via ovn-sbctl, with ovn-sbctl using the unix
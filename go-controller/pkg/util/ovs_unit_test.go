@@ -2,15 +2,22 @@ package util
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
 	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
 
 	mock_k8s_io_utils_exec "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing/mocks/k8s.io/utils/exec"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util/mocks"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 	kexec "k8s.io/utils/exec"
@@ -1338,6 +1345,99 @@ func TestRunOVNNbctl(t *testing.T) {
 	}
 }
 
+func TestRunOVNNbctlContextCancel(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ovn-nbctl")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	// an ovn-nbctl that hangs, like one waiting on an unreachable database
+	nbctlPath := filepath.Join(dir, "ovn-nbctl")
+	err = ioutil.WriteFile(nbctlPath, []byte("#!/bin/sh\nexec sleep 30\n"), 0755)
+	assert.NoError(t, err)
+	// below is defined in ovs.go
+	runCmdExecRunner = &defaultExecRunner{}
+	// note runner is defined in ovs.go file
+	runner = &execHelper{exec: kexec.New(), nbctlPath: nbctlPath}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	start := time.Now()
+	_, _, err = RunOVNNbctlContext(ctx, "show")
+
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, context.Canceled), "unexpected error: %v", err)
+	assert.Less(t, int64(time.Since(start)), int64(5*time.Second))
+}
+
+func TestNbctlCommandVerb(t *testing.T) {
+	tests := []struct {
+		desc   string
+		args   []string
+		expOut string
+	}{
+		{
+			desc:   "verb after options",
+			args:   []string{"--data=bare", "--no-heading", "--columns=_uuid", "find", "load_balancer"},
+			expOut: "find",
+		},
+		{
+			desc:   "verb with an option of its own",
+			args:   []string{"--if-exists", "remove", "load_balancer", "lb", "vips", "\"192.168.1.1:80\""},
+			expOut: "remove",
+		},
+		{
+			desc:   "verb outside of the reported ones",
+			args:   []string{"lr-list"},
+			expOut: "other",
+		},
+		{
+			desc:   "transaction of several commands",
+			args:   []string{"--", "add", "port_group", "pg", "acls", "acl"},
+			expOut: "other",
+		},
+		{
+			desc:   "no verb",
+			args:   []string{},
+			expOut: "other",
+		},
+	}
+	for i, tc := range tests {
+		t.Run(fmt.Sprintf("%d:%s", i, tc.desc), func(t *testing.T) {
+			assert.Equal(t, tc.expOut, nbctlCommandVerb(tc.args))
+		})
+	}
+}
+
+func TestRunOVNNbctlCommandDuration(t *testing.T) {
+	mockKexecIface := new(mock_k8s_io_utils_exec.Interface)
+	mockExecRunner := new(mocks.ExecRunner)
+	mockCmd := new(mock_k8s_io_utils_exec.Cmd)
+	// below is defined in ovs.go
+	runCmdExecRunner = mockExecRunner
+	// note runner is defined in ovs.go file
+	runner = &execHelper{exec: mockKexecIface}
+	MetricNbctlCommandDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "test_nbctl_command_duration_seconds",
+	}, []string{"verb", "ok"})
+	defer func() {
+		MetricNbctlCommandDuration = nil
+	}()
+
+	ovntest.ProcessMockFn(&mockExecRunner.Mock, ovntest.TestifyMockHelper{OnCallMethodName: "RunCmd", OnCallMethodArgType: []string{"*mocks.Cmd", "string", "[]string", "string", "string", "string"}, RetArgList: []interface{}{bytes.NewBuffer([]byte("testblah")), bytes.NewBuffer([]byte("")), nil}})
+	ovntest.ProcessMockFn(&mockKexecIface.Mock, ovntest.TestifyMockHelper{OnCallMethodName: "Command", OnCallMethodArgType: []string{"string", "string", "string", "string"}, RetArgList: []interface{}{mockCmd}})
+
+	_, _, err := RunOVNNbctl("find", "load_balancer")
+	assert.NoError(t, err)
+
+	metric := &dto.Metric{}
+	observer, err := MetricNbctlCommandDuration.GetMetricWithLabelValues("find", "true")
+	assert.NoError(t, err)
+	err = observer.(prometheus.Histogram).Write(metric)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), metric.GetHistogram().GetSampleCount())
+	mockExecRunner.AssertExpectations(t)
+	mockKexecIface.AssertExpectations(t)
+}
+
 func TestRunOVNSbctlUnix(t *testing.T) {
 	mockKexecIface := new(mock_k8s_io_utils_exec.Interface)
 	mockExecRunner := new(mocks.ExecRunner)